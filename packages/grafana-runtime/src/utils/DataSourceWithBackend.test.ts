@@ -77,6 +77,7 @@ describe('DataSourceWithBackend', () => {
             },
           ],
         },
+        "headers": Object {},
         "method": "POST",
         "requestId": undefined,
         "url": "/api/ds/query",
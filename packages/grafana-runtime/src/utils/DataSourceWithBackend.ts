@@ -102,12 +102,19 @@ export class DataSourceWithBackend<
       body.to = range.to.valueOf().toString();
     }
 
+    // lets the backend record the queries in the dashboard insights
+    const headers: Record<string, any> = {};
+    if (request.dashboardId) {
+      headers['X-Dashboard-Id'] = request.dashboardId;
+    }
+
     return getBackendSrv()
       .fetch({
         url: '/api/ds/query',
         method: 'POST',
         data: body,
         requestId,
+        headers,
       })
       .pipe(
         map((rsp: any) => {
@@ -246,6 +246,8 @@ func (hs *HTTPServer) registerRoutes() {
 			datasourceRoute.Delete("/name/:name", Wrap(DeleteDataSourceByName))
 			datasourceRoute.Get("/:id", Wrap(GetDataSourceById))
 			datasourceRoute.Get("/name/:name", Wrap(GetDataSourceByName))
+			datasourceRoute.Get("/unused", Wrap(GetUnusedDataSources))
+			datasourceRoute.Get("/uid/:uid/usage", Wrap(hs.GetDataSourceUsage))
 		}, reqOrgAdmin)
 
 		apiRoute.Get("/datasources/id/:name", Wrap(GetDataSourceIdByName), reqSignedIn)
@@ -269,6 +271,8 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Any("/datasources/:id/resources", hs.CallDatasourceResource)
 		apiRoute.Any("/datasources/:id/resources/*", hs.CallDatasourceResource)
 		apiRoute.Any("/datasources/:id/health", Wrap(hs.CheckDatasourceHealth))
+		apiRoute.Get("/datasources/uid/:uid/health", Wrap(hs.CheckDatasourceHealthByUID))
+		apiRoute.Get("/datasources/uid/:uid/health/history", Wrap(hs.GetDatasourceHealthHistory))
 
 		// Folders
 		apiRoute.Group("/folders", func(folderRoute routing.RouteRegister) {
@@ -280,6 +284,11 @@ func (hs *HTTPServer) registerRoutes() {
 				folderUidRoute.Get("/", Wrap(GetFolderByUID))
 				folderUidRoute.Put("/", bind(models.UpdateFolderCommand{}), Wrap(UpdateFolder))
 				folderUidRoute.Delete("/", Wrap(DeleteFolder))
+				folderUidRoute.Post("/move", bind(models.MoveFolderCommand{}), Wrap(MoveFolder))
+				folderUidRoute.Get("/children", Wrap(GetFolderChildren))
+				folderUidRoute.Get("/version-retention", Wrap(GetFolderVersionRetention))
+				folderUidRoute.Put("/version-retention", bind(models.SaveDashboardVersionRetentionCommand{}), Wrap(SaveFolderVersionRetention))
+				folderUidRoute.Delete("/version-retention", Wrap(DeleteFolderVersionRetention))
 
 				folderUidRoute.Group("/permissions", func(folderPermissionRoute routing.RouteRegister) {
 					folderPermissionRoute.Get("/", Wrap(GetFolderPermissionList))
@@ -292,6 +301,7 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Group("/dashboards", func(dashboardRoute routing.RouteRegister) {
 			dashboardRoute.Get("/uid/:uid", Wrap(hs.GetDashboard))
 			dashboardRoute.Delete("/uid/:uid", Wrap(DeleteDashboardByUID))
+			dashboardRoute.Get("/uid/:uid/insights", Wrap(GetDashboardInsights))
 
 			dashboardRoute.Get("/db/:slug", Wrap(hs.GetDashboard))
 			dashboardRoute.Delete("/db/:slug", Wrap(DeleteDashboardBySlug))
@@ -301,11 +311,18 @@ func (hs *HTTPServer) registerRoutes() {
 			dashboardRoute.Post("/db", bind(models.SaveDashboardCommand{}), Wrap(hs.PostDashboard))
 			dashboardRoute.Get("/home", Wrap(hs.GetHomeDashboard))
 			dashboardRoute.Get("/tags", GetDashboardTags)
+			dashboardRoute.Get("/stale", reqOrgAdmin, Wrap(GetStaleDashboards))
 			dashboardRoute.Post("/import", bind(dtos.ImportDashboardCommand{}), Wrap(ImportDashboard))
 
+			dashboardRoute.Get("/version-retention", Wrap(GetDashboardVersionRetentions))
+			dashboardRoute.Put("/version-retention/default", reqOrgAdmin, bind(models.SaveDashboardVersionRetentionCommand{}), Wrap(SaveDefaultDashboardVersionRetention))
+			dashboardRoute.Delete("/version-retention/default", reqOrgAdmin, Wrap(DeleteDefaultDashboardVersionRetention))
+
 			dashboardRoute.Group("/id/:dashboardId", func(dashIdRoute routing.RouteRegister) {
 				dashIdRoute.Get("/versions", Wrap(GetDashboardVersions))
 				dashIdRoute.Get("/versions/:id", Wrap(GetDashboardVersion))
+				dashIdRoute.Post("/versions/:id/pin", Wrap(PinDashboardVersion))
+				dashIdRoute.Delete("/versions/:id/pin", Wrap(UnpinDashboardVersion))
 				dashIdRoute.Post("/restore", bind(dtos.RestoreDashboardVersionCommand{}), Wrap(hs.RestoreDashboardVersion))
 
 				dashIdRoute.Group("/permissions", func(dashboardPermissionRoute routing.RouteRegister) {
@@ -315,6 +332,22 @@ func (hs *HTTPServer) registerRoutes() {
 			})
 		})
 
+		// Library panels
+		apiRoute.Group("/library-panels", func(libraryPanelRoute routing.RouteRegister) {
+			libraryPanelRoute.Get("/", Wrap(GetLibraryPanels))
+			libraryPanelRoute.Post("/", bind(models.CreateLibraryPanelCommand{}), Wrap(CreateLibraryPanel))
+			libraryPanelRoute.Get("/:uid", Wrap(GetLibraryPanelByUID))
+			libraryPanelRoute.Put("/:uid", bind(models.UpdateLibraryPanelCommand{}), Wrap(UpdateLibraryPanel))
+			libraryPanelRoute.Delete("/:uid", Wrap(DeleteLibraryPanel))
+		})
+
+		// Dashboard trash
+		apiRoute.Group("/trash", func(trashRoute routing.RouteRegister) {
+			trashRoute.Get("/", Wrap(GetDashboardTrash))
+			trashRoute.Post("/:id/restore", Wrap(RestoreDashboardFromTrash))
+			trashRoute.Delete("/:id", reqOrgAdmin, Wrap(DeleteDashboardFromTrash))
+		}, reqEditorRole)
+
 		// Dashboard snapshots
 		apiRoute.Group("/dashboard/snapshots", func(dashboardRoute routing.RouteRegister) {
 			dashboardRoute.Get("/", Wrap(SearchDashboardSnapshots))
@@ -343,6 +376,7 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// DataSource w/ expressions
 		apiRoute.Post("/ds/query", bind(dtos.MetricRequest{}), Wrap(hs.QueryMetricsV2))
+		apiRoute.Post("/ds/federated-query", bind(dtos.FederatedMetricRequest{}), Wrap(hs.QueryMetricsFederated))
 
 		apiRoute.Group("/alerts", func(alertsRoute routing.RouteRegister) {
 			alertsRoute.Post("/test", bind(dtos.AlertTestCommand{}), Wrap(AlertTest))
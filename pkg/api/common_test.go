@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/guardian"
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/macaron.v1"
@@ -73,6 +74,45 @@ func anonymousUserScenario(desc string, method string, url string, routePattern
 	})
 }
 
+// setupTestScenario clears the bus handlers registered by a test when it
+// ends, and replaces the dashboard guardian until then with the fake
+// returned for each dashboard.
+func setupTestScenario(t *testing.T, fakeGuardian func(dashId int64) *guardian.FakeDashboardGuardian) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	origNewGuardian := guardian.New
+	t.Cleanup(func() { guardian.New = origNewGuardian })
+	guardian.New = func(dashId int64, orgId int64, user *models.SignedInUser) guardian.DashboardGuardian {
+		return fakeGuardian(dashId)
+	}
+}
+
+// callAPI calls a handler on its route as a signed in user with the given
+// role, for tests not using goconvey.
+func callAPI(t *testing.T, role models.RoleType, method string, url string, routePattern string, handler handlerFunc) *scenarioContext {
+	sc := setupScenarioContext(url)
+	sc.t = t
+	sc.defaultHandler = Wrap(func(c *models.ReqContext) Response {
+		sc.context = c
+		sc.context.SignedInUser = &models.SignedInUser{OrgId: TestOrgID, UserId: TestUserID, OrgRole: role}
+		return handler(c)
+	})
+
+	switch method {
+	case "GET":
+		sc.m.Get(routePattern, sc.defaultHandler)
+	case "POST":
+		sc.m.Post(routePattern, sc.defaultHandler)
+	case "PUT":
+		sc.m.Put(routePattern, sc.defaultHandler)
+	case "DELETE":
+		sc.m.Delete(routePattern, sc.defaultHandler)
+	}
+
+	sc.fakeReqWithParams(method, url, map[string]string{}).exec()
+	return sc
+}
+
 func (sc *scenarioContext) fakeReq(method, url string) *scenarioContext {
 	sc.resp = httptest.NewRecorder()
 	req, err := http.NewRequest(method, url, nil)
@@ -132,11 +132,20 @@ func (hs *HTTPServer) GetDashboard(c *models.ReqContext) Response {
 	// make sure db version is in sync with json model version
 	dash.Data.Set("version", dash.Version)
 
+	if err := dashboards.LoadLibraryPanels(dash, c.SignedInUser); err != nil {
+		return Error(500, "Error while loading library panels", err)
+	}
+
 	dto := dtos.DashboardFullWithMeta{
 		Dashboard: dash.Data,
 		Meta:      meta,
 	}
 
+	viewCmd := models.RecordDashboardViewCommand{DashboardId: dash.Id, UserId: c.UserId}
+	if err := bus.Dispatch(&viewCmd); err != nil && err != bus.ErrHandlerNotFound {
+		hs.log.Warn("Failed to record dashboard view", "dashboardId", dash.Id, "err", err)
+	}
+
 	c.TimeRequest(metrics.MApiDashboardGet)
 	return JSON(200, dto)
 }
@@ -270,6 +279,7 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 		"id":      dashboard.Id,
 		"uid":     dashboard.Uid,
 		"url":     dashboard.GetUrl(),
+		"merged":  dashItem.Merged,
 	})
 }
 
@@ -294,6 +304,15 @@ func dashboardSaveErrorToApiResponse(err error) Response {
 		return Error(422, validationErr.Error(), nil)
 	}
 
+	var mergeErr models.DashboardMergeConflictError
+	if ok := errors.As(err, &mergeErr); ok {
+		return JSON(412, util.DynMap{
+			"status":    models.ErrDashboardVersionMismatch.Status,
+			"message":   mergeErr.Error(),
+			"conflicts": mergeErr.Conflicts,
+		})
+	}
+
 	var pluginErr models.UpdatePluginDashboardError
 	if ok := errors.As(err, &pluginErr); ok {
 		message := fmt.Sprintf("The dashboard belongs to plugin %s.", pluginErr.PluginId)
@@ -444,6 +463,7 @@ func GetDashboardVersion(c *models.ReqContext) Response {
 		Created:       query.Result.Created,
 		Message:       query.Result.Message,
 		CreatedBy:     creator,
+		Pinned:        query.Result.Pinned,
 	}
 
 	return JSON(200, dashVersionMeta)
@@ -486,7 +506,7 @@ func CalculateDashboardDiff(c *models.ReqContext, apiOptions dtos.CalculateDiffO
 		return Error(500, "Unable to compute diff", err)
 	}
 
-	if options.DiffType == dashdiffs.DiffDelta {
+	if options.DiffType == dashdiffs.DiffDelta || options.DiffType == dashdiffs.DiffSemantic {
 		return Respond(200, result.Delta).Header("Content-Type", "application/json")
 	}
 
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+)
+
+// defaultInsightsDays is the number of days of the insights and of the stale
+// dashboards report when not given
+const defaultInsightsDays = 30
+
+// GetDashboardInsights returns the views and queries of a dashboard during
+// the last days
+// GET /api/dashboards/uid/:uid/insights
+func GetDashboardInsights(c *models.ReqContext) Response {
+	dash, rsp := getDashboardHelper(c.OrgId, "", 0, c.Params(":uid"))
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(dash.Id, c.OrgId, c.SignedInUser)
+	if canView, err := guardian.CanView(); err != nil || !canView {
+		return dashboardGuardianResponse(err)
+	}
+
+	days := c.QueryInt("days")
+	if days == 0 {
+		days = defaultInsightsDays
+	}
+	if days < 1 || days > models.MostViewedDashboardsDays {
+		return Error(400, fmt.Sprintf("Days must be between 1 and %d", models.MostViewedDashboardsDays), nil)
+	}
+
+	query := models.GetDashboardInsightsQuery{DashboardId: dash.Id, Days: days}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get dashboard insights", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// GetStaleDashboards returns the dashboards of the organization that nobody
+// viewed in the last days
+// GET /api/dashboards/stale
+func GetStaleDashboards(c *models.ReqContext) Response {
+	days := c.QueryInt("days")
+	if days == 0 {
+		days = defaultInsightsDays
+	}
+	if days < 1 || days > models.MostViewedDashboardsDays {
+		return Error(400, fmt.Sprintf("Days must be between 1 and %d", models.MostViewedDashboardsDays), nil)
+	}
+
+	query := models.GetStaleDashboardsQuery{OrgId: c.OrgId, Days: days}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get stale dashboards", err)
+	}
+	for _, dash := range query.Result {
+		dash.Url = models.GetDashboardUrl(dash.Uid, dash.Slug)
+	}
+
+	return JSON(200, query.Result)
+}
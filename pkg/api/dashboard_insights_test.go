@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardInsightsAPIEndpoint(t *testing.T) {
+	// the user can view dashboard 1 only
+	setup := func(t *testing.T) {
+		setupTestScenario(t, func(dashId int64) *guardian.FakeDashboardGuardian {
+			return &guardian.FakeDashboardGuardian{CanViewValue: dashId == 1}
+		})
+
+		bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+			switch query.Uid {
+			case "viewable":
+				query.Result = &models.Dashboard{Id: 1, Uid: query.Uid}
+			case "hidden":
+				query.Result = &models.Dashboard{Id: 2, Uid: query.Uid}
+			default:
+				return models.ErrDashboardNotFound
+			}
+			return nil
+		})
+	}
+
+	t.Run("returns the insights of a dashboard", func(t *testing.T) {
+		setup(t)
+		bus.AddHandler("test", func(query *models.GetDashboardInsightsQuery) error {
+			assert.EqualValues(t, 1, query.DashboardId)
+			assert.Equal(t, 7, query.Days)
+			query.Result = &models.DashboardInsights{DashboardId: 1, Days: 7, Views: 12, Queries: 40, Errors: 3}
+			return nil
+		})
+
+		sc := callAPI(t, models.ROLE_VIEWER, "GET", "/api/dashboards/uid/viewable/insights?days=7", "/api/dashboards/uid/:uid/insights", GetDashboardInsights)
+		require.Equal(t, 200, sc.resp.Code)
+
+		var result models.DashboardInsights
+		require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&result))
+		assert.EqualValues(t, 12, result.Views)
+		assert.EqualValues(t, 40, result.Queries)
+		assert.EqualValues(t, 3, result.Errors)
+	})
+
+	t.Run("insights require permission to view the dashboard", func(t *testing.T) {
+		setup(t)
+		sc := callAPI(t, models.ROLE_VIEWER, "GET", "/api/dashboards/uid/hidden/insights", "/api/dashboards/uid/:uid/insights", GetDashboardInsights)
+		assert.Equal(t, 403, sc.resp.Code)
+
+		sc = callAPI(t, models.ROLE_VIEWER, "GET", "/api/dashboards/uid/unknown/insights", "/api/dashboards/uid/:uid/insights", GetDashboardInsights)
+		assert.Equal(t, 404, sc.resp.Code)
+	})
+
+	t.Run("insights are kept for 30 days", func(t *testing.T) {
+		setup(t)
+		sc := callAPI(t, models.ROLE_VIEWER, "GET", "/api/dashboards/uid/viewable/insights?days=90", "/api/dashboards/uid/:uid/insights", GetDashboardInsights)
+		assert.Equal(t, 400, sc.resp.Code)
+	})
+
+	t.Run("returns the stale dashboards with their url", func(t *testing.T) {
+		setup(t)
+		bus.AddHandler("test", func(query *models.GetStaleDashboardsQuery) error {
+			assert.EqualValues(t, TestOrgID, query.OrgId)
+			assert.Equal(t, 30, query.Days)
+			query.Result = []*models.StaleDashboard{{Id: 3, Uid: "old", Slug: "old-dashboard", Title: "Old dashboard"}}
+			return nil
+		})
+
+		sc := callAPI(t, models.ROLE_VIEWER, "GET", "/api/dashboards/stale", "/api/dashboards/stale", GetStaleDashboards)
+		require.Equal(t, 200, sc.resp.Code)
+
+		var result []models.StaleDashboard
+		require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&result))
+		require.Len(t, result, 1)
+		assert.Equal(t, "/d/old/old-dashboard", result[0].Url)
+	})
+
+	t.Run("stale dashboards are limited to the last 30 days of views", func(t *testing.T) {
+		setup(t)
+		sc := callAPI(t, models.ROLE_VIEWER, "GET", "/api/dashboards/stale?days=90", "/api/dashboards/stale", GetStaleDashboards)
+		assert.Equal(t, 400, sc.resp.Code)
+	})
+}
@@ -779,7 +779,8 @@ func TestDashboardApiEndpoint(t *testing.T) {
 				{SaveError: models.ErrDashboardWithSameNameInFolderExists, ExpectedStatusCode: 412},
 				{SaveError: models.ErrDashboardVersionMismatch, ExpectedStatusCode: 412},
 				{SaveError: models.ErrDashboardTitleEmpty, ExpectedStatusCode: 400},
-				{SaveError: models.ErrDashboardFolderCannotHaveParent, ExpectedStatusCode: 400},
+				{SaveError: models.ErrDashboardFolderCycle, ExpectedStatusCode: 400},
+				{SaveError: models.ErrDashboardFolderMaxDepthExceeded, ExpectedStatusCode: 400},
 				{SaveError: alerting.ValidationError{Reason: "Mu"}, ExpectedStatusCode: 422},
 				{SaveError: models.ErrDashboardFailedGenerateUniqueUid, ExpectedStatusCode: 500},
 				{SaveError: models.ErrDashboardTypeMismatch, ExpectedStatusCode: 400},
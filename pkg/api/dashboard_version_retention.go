@@ -0,0 +1,152 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GetDashboardVersionRetentions returns the version retention policies of
+// the org: its default policy and the policies of its folders.
+func GetDashboardVersionRetentions(c *models.ReqContext) Response {
+	query := models.GetDashboardVersionRetentionsQuery{OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get dashboard version retention policies", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+func SaveDefaultDashboardVersionRetention(c *models.ReqContext, cmd models.SaveDashboardVersionRetentionCommand) Response {
+	cmd.OrgId = c.OrgId
+	cmd.FolderId = 0
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toDashboardVersionRetentionError(err)
+	}
+
+	return JSON(200, cmd.Result)
+}
+
+func DeleteDefaultDashboardVersionRetention(c *models.ReqContext) Response {
+	cmd := models.DeleteDashboardVersionRetentionCommand{OrgId: c.OrgId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toDashboardVersionRetentionError(err)
+	}
+
+	return Success("Dashboard version retention policy deleted")
+}
+
+// GetFolderVersionRetention returns the version retention policy applied to
+// the dashboards of a folder, which may be inherited from a parent folder or
+// from the org default.
+func GetFolderVersionRetention(c *models.ReqContext) Response {
+	folder, err := dashboards.NewFolderService(c.OrgId, c.SignedInUser).GetFolderByUID(c.Params(":uid"))
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	query := models.GetDashboardVersionRetentionQuery{OrgId: c.OrgId, FolderId: folder.Id}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get dashboard version retention policy", err)
+	}
+
+	return JSON(200, util.DynMap{
+		"id":             query.Result.Id,
+		"folderId":       query.Result.FolderId,
+		"versionsToKeep": query.Result.VersionsToKeep,
+		"maxAge":         query.Result.MaxAge,
+		"inherited":      query.Result.Id == 0 || query.Result.FolderId != folder.Id,
+	})
+}
+
+func SaveFolderVersionRetention(c *models.ReqContext, cmd models.SaveDashboardVersionRetentionCommand) Response {
+	folder, rsp := getFolderForVersionRetentionUpdate(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	cmd.OrgId = c.OrgId
+	cmd.FolderId = folder.Id
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toDashboardVersionRetentionError(err)
+	}
+
+	return JSON(200, cmd.Result)
+}
+
+func DeleteFolderVersionRetention(c *models.ReqContext) Response {
+	folder, rsp := getFolderForVersionRetentionUpdate(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	cmd := models.DeleteDashboardVersionRetentionCommand{OrgId: c.OrgId, FolderId: folder.Id}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toDashboardVersionRetentionError(err)
+	}
+
+	return Success("Dashboard version retention policy deleted")
+}
+
+// getFolderForVersionRetentionUpdate returns the folder of the request if
+// the user can admin it.
+func getFolderForVersionRetentionUpdate(c *models.ReqContext) (*models.Folder, Response) {
+	folder, err := dashboards.NewFolderService(c.OrgId, c.SignedInUser).GetFolderByUID(c.Params(":uid"))
+	if err != nil {
+		return nil, toFolderError(err)
+	}
+
+	if canAdmin, err := guardian.New(folder.Id, c.OrgId, c.SignedInUser).CanAdmin(); err != nil || !canAdmin {
+		return nil, toFolderError(toAccessDeniedError(err))
+	}
+
+	return folder, nil
+}
+
+func PinDashboardVersion(c *models.ReqContext) Response {
+	return setDashboardVersionPinned(c, true, "Dashboard version pinned")
+}
+
+func UnpinDashboardVersion(c *models.ReqContext) Response {
+	return setDashboardVersionPinned(c, false, "Dashboard version unpinned")
+}
+
+func setDashboardVersionPinned(c *models.ReqContext, pinned bool, message string) Response {
+	dashID := c.ParamsInt64(":dashboardId")
+
+	guardian := guardian.New(dashID, c.OrgId, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	cmd := models.SetDashboardVersionPinnedCommand{
+		OrgId:       c.OrgId,
+		DashboardId: dashID,
+		Version:     c.ParamsInt(":id"),
+		Pinned:      pinned,
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrDashboardVersionNotFound) {
+			return Error(404, err.Error(), nil)
+		}
+		return Error(500, "Failed to update dashboard version", err)
+	}
+
+	return Success(message)
+}
+
+func toDashboardVersionRetentionError(err error) Response {
+	if errors.Is(err, models.ErrDashboardVersionRetentionInvalid) {
+		return Error(400, err.Error(), nil)
+	}
+
+	if errors.Is(err, models.ErrDashboardVersionRetentionNotFound) || errors.Is(err, models.ErrFolderNotFound) {
+		return Error(404, err.Error(), nil)
+	}
+
+	return Error(500, "Failed to update dashboard version retention policy", err)
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardVersionRetentionAPIEndpoint(t *testing.T) {
+	// the user can admin folder 2 and save dashboard 10
+	setup := func(t *testing.T) {
+		setupTestScenario(t, func(dashId int64) *guardian.FakeDashboardGuardian {
+			return &guardian.FakeDashboardGuardian{
+				CanViewValue:  true,
+				CanSaveValue:  dashId == 10,
+				CanAdminValue: dashId == 2,
+			}
+		})
+
+		origNewFolderService := dashboards.NewFolderService
+		t.Cleanup(func() { dashboards.NewFolderService = origNewFolderService })
+		mockFolderService(&fakeFolderService{GetFolderByUIDResult: &models.Folder{Id: 3, Uid: "audits"}})
+	}
+
+	t.Run("returns the policy inherited by a folder", func(t *testing.T) {
+		setup(t)
+		bus.AddHandler("test", func(query *models.GetDashboardVersionRetentionQuery) error {
+			assert.EqualValues(t, 3, query.FolderId)
+			query.Result = &models.DashboardVersionRetention{Id: 1, FolderId: 2, MaxAge: "1y"}
+			return nil
+		})
+
+		sc := callAPI(t, models.ROLE_EDITOR, "GET", "/api/folders/audits/version-retention", "/api/folders/:uid/version-retention", GetFolderVersionRetention)
+		require.Equal(t, 200, sc.resp.Code)
+
+		var result map[string]interface{}
+		require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&result))
+		assert.Equal(t, "1y", result["maxAge"])
+		assert.Equal(t, true, result["inherited"])
+	})
+
+	t.Run("folder policies can only be changed by folder admins", func(t *testing.T) {
+		setup(t)
+		saved := false
+		bus.AddHandler("test", func(cmd *models.SaveDashboardVersionRetentionCommand) error {
+			saved = true
+			return nil
+		})
+
+		save := func(c *models.ReqContext) Response {
+			return SaveFolderVersionRetention(c, models.SaveDashboardVersionRetentionCommand{VersionsToKeep: 5})
+		}
+		sc := callAPI(t, models.ROLE_EDITOR, "PUT", "/api/folders/audits/version-retention", "/api/folders/:uid/version-retention", save)
+		assert.Equal(t, 403, sc.resp.Code)
+		assert.False(t, saved)
+	})
+
+	t.Run("invalid policies return 400", func(t *testing.T) {
+		setup(t)
+		bus.AddHandler("test", func(cmd *models.SaveDashboardVersionRetentionCommand) error {
+			return models.ErrDashboardVersionRetentionInvalid
+		})
+
+		save := func(c *models.ReqContext) Response {
+			return SaveDefaultDashboardVersionRetention(c, models.SaveDashboardVersionRetentionCommand{MaxAge: "forever"})
+		}
+		sc := callAPI(t, models.ROLE_EDITOR, "PUT", "/api/dashboards/version-retention/default", "/api/dashboards/version-retention/default", save)
+		assert.Equal(t, 400, sc.resp.Code)
+	})
+
+	t.Run("pins a dashboard version", func(t *testing.T) {
+		setup(t)
+		var pinned *models.SetDashboardVersionPinnedCommand
+		bus.AddHandler("test", func(cmd *models.SetDashboardVersionPinnedCommand) error {
+			pinned = cmd
+			return nil
+		})
+
+		sc := callAPI(t, models.ROLE_EDITOR, "POST", "/api/dashboards/id/10/versions/4/pin", "/api/dashboards/id/:dashboardId/versions/:id/pin", PinDashboardVersion)
+		require.Equal(t, 200, sc.resp.Code)
+		require.NotNil(t, pinned)
+		assert.Equal(t, 4, pinned.Version)
+		assert.True(t, pinned.Pinned)
+
+		sc = callAPI(t, models.ROLE_EDITOR, "POST", "/api/dashboards/id/11/versions/4/pin", "/api/dashboards/id/:dashboardId/versions/:id/pin", PinDashboardVersion)
+		assert.Equal(t, 403, sc.resp.Code)
+	})
+}
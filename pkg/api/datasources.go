@@ -1,7 +1,7 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 
@@ -13,6 +13,9 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/datasource/wrapper"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/tsdb"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -348,7 +351,8 @@ func convertModelToDtos(ds *models.DataSource) dtos.DataSource {
 	return dto
 }
 
-// CheckDatasourceHealth sends a health check request to the plugin datasource
+// CheckDatasourceHealth sends a health check request to the plugin datasource,
+// or checks the health of the core datasource with its query endpoint
 // /api/datasource/:id/health
 func (hs *HTTPServer) CheckDatasourceHealth(c *models.ReqContext) Response {
 	datasourceID := c.ParamsInt64("id")
@@ -361,44 +365,140 @@ func (hs *HTTPServer) CheckDatasourceHealth(c *models.ReqContext) Response {
 		return Error(500, "Unable to load datasource metadata", err)
 	}
 
-	plugin, ok := hs.PluginManager.GetDatasource(ds.Type)
-	if !ok {
-		return Error(500, "Unable to find datasource plugin", err)
+	return hs.checkDatasourceHealth(c, ds)
+}
+
+// CheckDatasourceHealthByUID checks the health of a datasource by its uid
+// /api/datasources/uid/:uid/health
+func (hs *HTTPServer) CheckDatasourceHealthByUID(c *models.ReqContext) Response {
+	ds, errResp := hs.getDatasourceByUID(c)
+	if errResp != nil {
+		return errResp
 	}
 
-	dsInstanceSettings, err := wrapper.ModelToInstanceSettings(ds)
-	if err != nil {
-		return Error(500, "Unable to get datasource model", err)
+	return hs.checkDatasourceHealth(c, ds)
+}
+
+// GetDatasourceHealthHistory returns the results of the last health checks
+// of the datasource, recorded by the background health checker
+// /api/datasources/uid/:uid/health/history
+func (hs *HTTPServer) GetDatasourceHealthHistory(c *models.ReqContext) Response {
+	ds, errResp := hs.getDatasourceByUID(c)
+	if errResp != nil {
+		return errResp
 	}
-	pCtx := backend.PluginContext{
-		User:                       wrapper.BackendUserFromSignedInUser(c.SignedInUser),
-		OrgID:                      c.OrgId,
-		PluginID:                   plugin.Id,
-		DataSourceInstanceSettings: dsInstanceSettings,
+
+	query := models.GetDataSourceHealthHistoryQuery{
+		OrgId:        ds.OrgId,
+		DataSourceId: ds.Id,
+		Limit:        c.QueryInt("limit"),
 	}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get datasource health history", err)
+	}
+
+	return JSON(200, query.Result)
+}
 
-	resp, err := hs.BackendPluginManager.CheckHealth(c.Req.Context(), pCtx)
+// GetDataSourceUsage returns the dashboards and the alerts using the
+// datasource
+// /api/datasources/uid/:uid/usage
+func (hs *HTTPServer) GetDataSourceUsage(c *models.ReqContext) Response {
+	ds, errResp := hs.getDatasourceByUID(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	searchQuery := search.Query{
+		SignedInUser:  c.SignedInUser,
+		OrgId:         c.OrgId,
+		DatasourceUid: ds.Uid,
+		Limit:         5000,
+		Permission:    models.PERMISSION_VIEW,
+	}
+	if err := bus.Dispatch(&searchQuery); err != nil {
+		return Error(500, "Failed to search dashboards using the datasource", err)
+	}
+
+	alertsQuery := models.GetAlertsByDataSourceQuery{OrgId: c.OrgId, DataSourceId: ds.Id}
+	if err := bus.Dispatch(&alertsQuery); err != nil {
+		return Error(500, "Failed to get alerts using the datasource", err)
+	}
+	for _, alert := range alertsQuery.Result {
+		alert.Url = models.GetDashboardUrl(alert.DashboardUid, alert.DashboardSlug)
+	}
+
+	return JSON(200, util.DynMap{
+		"dashboards": searchQuery.Result,
+		"alerts":     alertsQuery.Result,
+	})
+}
+
+// GetUnusedDataSources returns the datasources used by no dashboard nor
+// alert
+// /api/datasources/unused
+func GetUnusedDataSources(c *models.ReqContext) Response {
+	query := models.GetUnusedDataSourcesQuery{OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to query unused datasources", err)
+	}
+
+	result := make([]util.DynMap, 0, len(query.Result))
+	for _, ds := range query.Result {
+		result = append(result, util.DynMap{
+			"id":        ds.Id,
+			"uid":       ds.Uid,
+			"name":      ds.Name,
+			"type":      ds.Type,
+			"isDefault": ds.IsDefault,
+		})
+	}
+
+	return JSON(200, result)
+}
+
+func (hs *HTTPServer) getDatasourceByUID(c *models.ReqContext) (*models.DataSource, Response) {
+	query := models.GetDataSourceByUidQuery{Uid: c.Params(":uid"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrDataSourceNotFound {
+			return nil, Error(404, "Data source not found", nil)
+		}
+		return nil, Error(500, "Failed to query datasources", err)
+	}
+
+	ds, err := hs.DatasourceCache.GetDatasource(query.Result.Id, c.SignedInUser, c.SkipCache)
 	if err != nil {
-		return translatePluginRequestErrorToAPIError(err)
+		if err == models.ErrDataSourceAccessDenied {
+			return nil, Error(403, "Access denied to datasource", err)
+		}
+		return nil, Error(500, "Unable to load datasource metadata", err)
 	}
 
-	payload := map[string]interface{}{
-		"status":  resp.Status.String(),
-		"message": resp.Message,
+	return ds, nil
+}
+
+func (hs *HTTPServer) checkDatasourceHealth(c *models.ReqContext, ds *models.DataSource) Response {
+	if _, ok := hs.PluginManager.GetDatasource(ds.Type); !ok {
+		return Error(500, "Unable to find datasource plugin", nil)
 	}
 
-	// Unmarshal JSONDetails if it's not empty.
-	if len(resp.JSONDetails) > 0 {
-		var jsonDetails map[string]interface{}
-		err = json.Unmarshal(resp.JSONDetails, &jsonDetails)
-		if err != nil {
-			return Error(500, "Failed to unmarshal detailed response from backend plugin", err)
+	result, err := datasources.CheckHealth(c.Req.Context(), hs.BackendPluginManager, ds, c.SignedInUser)
+	if err != nil {
+		if errors.Is(err, tsdb.ErrHealthCheckNotSupported) {
+			return Error(404, "Health check not supported by datasource", err)
 		}
+		return translatePluginRequestErrorToAPIError(err)
+	}
 
-		payload["details"] = jsonDetails
+	payload := map[string]interface{}{
+		"status":  result.Status,
+		"message": result.Message,
+	}
+	if len(result.Details) > 0 {
+		payload["details"] = result.Details
 	}
 
-	if resp.Status != backend.HealthStatusOk {
+	if result.Status != tsdb.HealthStatusOK {
 		return JSON(503, payload)
 	}
 
@@ -160,3 +160,22 @@ func TestUpdateDataSource_URLWithoutProtocol(t *testing.T) {
 
 	assert.Equal(t, 200, sc.resp.Code)
 }
+
+// Checking the health of a data source with an unknown uid should return 404.
+func TestCheckDatasourceHealthByUID_NotFound(t *testing.T) {
+	defer bus.ClearBusHandlers()
+
+	bus.AddHandler("test", func(query *models.GetDataSourceByUidQuery) error {
+		assert.Equal(t, "missing", query.Uid)
+		return models.ErrDataSourceNotFound
+	})
+
+	hs := &HTTPServer{}
+	sc := setupScenarioContext("/api/datasources/uid/missing/health")
+	sc.t = t
+	sc.m.Get("/api/datasources/uid/:uid/health", Wrap(hs.CheckDatasourceHealthByUID))
+
+	sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+	assert.Equal(t, 404, sc.resp.Code)
+}
@@ -0,0 +1,28 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+type LibraryPanel struct {
+	Id       int64            `json:"id"`
+	Uid      string           `json:"uid"`
+	FolderId int64            `json:"folderId"`
+	Name     string           `json:"name"`
+	Model    *simplejson.Json `json:"model"`
+	Version  int64            `json:"version"`
+	Created  time.Time        `json:"created"`
+	Updated  time.Time        `json:"updated"`
+	CanEdit  bool             `json:"canEdit"`
+
+	ConnectedDashboards []LibraryPanelDashboard `json:"connectedDashboards,omitempty"`
+}
+
+type LibraryPanelDashboard struct {
+	Id    int64  `json:"id"`
+	Uid   string `json:"uid"`
+	Title string `json:"title"`
+	Url   string `json:"url"`
+}
@@ -50,6 +50,18 @@ type MetricRequest struct {
 	Debug   bool               `json:"debug"`
 }
 
+// FederatedMetricRequest is a request for queries of several data sources.
+// Format is either "json" (default) or "arrow", and Stream asks for one
+// newline delimited JSON result per query, written as soon as it is ready.
+type FederatedMetricRequest struct {
+	From    string             `json:"from"`
+	To      string             `json:"to"`
+	Queries []*simplejson.Json `json:"queries"`
+	Format  string             `json:"format"`
+	Stream  bool               `json:"stream"`
+	Debug   bool               `json:"debug"`
+}
+
 type UserStars struct {
 	DashboardIds map[string]bool `json:"dashboardIds"`
 }
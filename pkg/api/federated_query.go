@@ -0,0 +1,419 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboardviews"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// federatedQueryConcurrency is the number of data sources queried at the same time
+const federatedQueryConcurrency = 10
+
+const (
+	federatedFormatJSON  = "json"
+	federatedFormatArrow = "arrow"
+)
+
+// federatedQueryResult is the result of a single query of a federated query.
+// Frames are Arrow IPC encoded frames, or JSON frames.
+type federatedQueryResult struct {
+	RefID        string        `json:"refId"`
+	DatasourceID int64         `json:"datasourceId"`
+	Error        string        `json:"error,omitempty"`
+	Frames       []interface{} `json:"frames"`
+}
+
+// federatedQueryGroup are the queries of a federated query sent to one data source
+type federatedQueryGroup struct {
+	datasourceID int64
+	queries      []*tsdb.Query
+}
+
+// federatedQuery runs the queries of several data sources concurrently.
+type federatedQuery struct {
+	timeRange     *tsdb.TimeRange
+	user          *models.SignedInUser
+	debug         bool
+	format        string
+	groups        []*federatedQueryGroup
+	getDatasource func(id int64) (*models.DataSource, error)
+	handleRequest tsdb.HandleRequestFunc
+}
+
+// QueryMetricsFederated runs queries for multiple data sources
+// POST /api/ds/federated-query
+func (hs *HTTPServer) QueryMetricsFederated(c *models.ReqContext, reqDto dtos.FederatedMetricRequest) Response {
+	query, err := newFederatedQuery(reqDto)
+	if err != nil {
+		return Error(400, err.Error(), nil)
+	}
+
+	query.user = c.SignedInUser
+	query.getDatasource = func(id int64) (*models.DataSource, error) {
+		return hs.DatasourceCache.GetDatasource(id, c.SignedInUser, c.SkipCache)
+	}
+	query.handleRequest = tsdb.HandleRequest
+
+	if reqDto.Stream {
+		return &federatedQueryStreamResponse{query: query}
+	}
+
+	results := make(map[string]*federatedQueryResult)
+	queries, failed := query.execute(c.Req.Context(), func(result *federatedQueryResult) {
+		results[result.RefID] = result
+	})
+	dashboardviews.RecordQueries(c.Req.Request, c.OrgId, queries, failed)
+
+	return JSON(200, map[string]interface{}{"results": results})
+}
+
+func newFederatedQuery(reqDto dtos.FederatedMetricRequest) (*federatedQuery, error) {
+	if len(reqDto.Queries) == 0 {
+		return nil, errors.New("No queries found in query")
+	}
+
+	query := &federatedQuery{
+		timeRange: tsdb.NewTimeRange(reqDto.From, reqDto.To),
+		debug:     reqDto.Debug,
+		format:    reqDto.Format,
+	}
+	if query.format == "" {
+		query.format = federatedFormatJSON
+	}
+	if query.format != federatedFormatJSON && query.format != federatedFormatArrow {
+		return nil, fmt.Errorf("Invalid format %q, should be either %q or %q", reqDto.Format, federatedFormatJSON, federatedFormatArrow)
+	}
+
+	refIDs := make(map[string]bool)
+	groups := make(map[int64]*federatedQueryGroup)
+	for _, model := range reqDto.Queries {
+		datasourceID, err := model.Get("datasourceId").Int64()
+		if err != nil {
+			return nil, errors.New("Query missing data source ID")
+		}
+
+		refID := model.Get("refId").MustString()
+		if refID == "" {
+			return nil, errors.New("Query missing refId")
+		}
+		if refIDs[refID] {
+			return nil, fmt.Errorf("Duplicate refId %q", refID)
+		}
+		refIDs[refID] = true
+
+		group, ok := groups[datasourceID]
+		if !ok {
+			group = &federatedQueryGroup{datasourceID: datasourceID}
+			groups[datasourceID] = group
+			query.groups = append(query.groups, group)
+		}
+		group.queries = append(group.queries, &tsdb.Query{
+			RefId:         refID,
+			MaxDataPoints: model.Get("maxDataPoints").MustInt64(100),
+			IntervalMs:    model.Get("intervalMs").MustInt64(1000),
+			QueryType:     model.Get("queryType").MustString(""),
+			Model:         model,
+		})
+	}
+
+	return query, nil
+}
+
+// execute queries every data source, at most federatedQueryConcurrency at a
+// time, and calls onResult with the result of each query as soon as it is
+// ready. Calls to onResult are serialized. It returns the number of queries
+// and of failed queries.
+func (q *federatedQuery) execute(ctx context.Context, onResult func(result *federatedQueryResult)) (queries int, failed int) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, federatedQueryConcurrency)
+
+	for _, group := range q.groups {
+		wg.Add(1)
+		go func(group *federatedQueryGroup) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results := q.executeGroup(ctx, group)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, result := range results {
+				queries++
+				if result.Error != "" {
+					failed++
+				}
+				onResult(result)
+			}
+		}(group)
+	}
+
+	wg.Wait()
+	return queries, failed
+}
+
+// executeGroup returns the results of the queries of a data source. Errors,
+// including panics of the data source, only fail the queries of the group.
+func (q *federatedQuery) executeGroup(ctx context.Context, group *federatedQueryGroup) (results []*federatedQueryResult) {
+	failAll := func(err error) []*federatedQueryResult {
+		results := make([]*federatedQueryResult, 0, len(group.queries))
+		for _, query := range group.queries {
+			results = append(results, &federatedQueryResult{
+				RefID:        query.RefId,
+				DatasourceID: group.datasourceID,
+				Error:        err.Error(),
+				Frames:       []interface{}{},
+			})
+		}
+		return results
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			results = failAll(fmt.Errorf("data source query failed: %v", r))
+		}
+	}()
+
+	ds, err := q.getDatasource(group.datasourceID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrDataSourceAccessDenied):
+			return failAll(errors.New("Access denied to data source"))
+		case errors.Is(err, models.ErrDataSourceNotFound):
+			return failAll(errors.New("Invalid data source ID"))
+		}
+		return failAll(errors.New("Unable to load data source metadata"))
+	}
+
+	for _, query := range group.queries {
+		query.DataSource = ds
+	}
+
+	resp, err := q.handleRequest(ctx, ds, &tsdb.TsdbQuery{
+		TimeRange: q.timeRange,
+		Queries:   group.queries,
+		Debug:     q.debug,
+		User:      q.user,
+	})
+	if err != nil {
+		return failAll(err)
+	}
+
+	for _, query := range group.queries {
+		result := &federatedQueryResult{
+			RefID:        query.RefId,
+			DatasourceID: group.datasourceID,
+			Frames:       []interface{}{},
+		}
+		if queryResult, ok := resp.Results[query.RefId]; ok {
+			if err := q.encodeQueryResult(queryResult, result); err != nil {
+				result.Error = err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// encodeQueryResult sets the error and frames of a query result, converting
+// legacy series and tables to frames.
+func (q *federatedQuery) encodeQueryResult(queryResult *tsdb.QueryResult, result *federatedQueryResult) error {
+	if queryResult.Error != nil {
+		result.Error = queryResult.Error.Error()
+	} else if queryResult.ErrorString != "" {
+		result.Error = queryResult.ErrorString
+	}
+
+	frames := data.Frames{}
+	if queryResult.Dataframes != nil {
+		decoded, err := queryResult.Dataframes.Decoded()
+		if err != nil {
+			return err
+		}
+		frames = append(frames, decoded...)
+	}
+	for _, series := range queryResult.Series {
+		frame, err := tsdb.SeriesToFrame(series)
+		if err != nil {
+			return err
+		}
+		frames = append(frames, frame)
+	}
+	for _, table := range queryResult.Tables {
+		frames = append(frames, tableToFrame(table))
+	}
+	for _, frame := range frames {
+		frame.RefID = result.RefID
+	}
+
+	if q.format == federatedFormatArrow {
+		encoded, err := tsdb.NewDecodedDataFrames(frames).Encoded()
+		if err != nil {
+			return err
+		}
+		for _, frame := range encoded {
+			result.Frames = append(result.Frames, frame)
+		}
+		return nil
+	}
+
+	for _, frame := range frames {
+		result.Frames = append(result.Frames, newFrameJSON(frame))
+	}
+	return nil
+}
+
+// federatedQueryStreamResponse writes one line of JSON per query result, as
+// soon as the data source of the query has answered.
+type federatedQueryStreamResponse struct {
+	query *federatedQuery
+}
+
+func (r *federatedQueryStreamResponse) WriteTo(ctx *models.ReqContext) {
+	ctx.Resp.Header().Set("Content-Type", "application/x-ndjson")
+	ctx.Resp.WriteHeader(200)
+
+	encoder := json.NewEncoder(ctx.Resp)
+	queries, failed := r.query.execute(ctx.Req.Context(), func(result *federatedQueryResult) {
+		if err := encoder.Encode(result); err != nil {
+			ctx.Logger.Error("Error writing to response", "err", err)
+			return
+		}
+		ctx.Resp.Flush()
+	})
+	dashboardviews.RecordQueries(ctx.Req.Request, ctx.OrgId, queries, failed)
+}
+
+// frameJSON is the JSON representation of a frame, with the values of each
+// field. Times are in epoch milliseconds.
+type frameJSON struct {
+	Name   string          `json:"name,omitempty"`
+	RefID  string          `json:"refId,omitempty"`
+	Meta   *data.FrameMeta `json:"meta,omitempty"`
+	Fields []fieldJSON     `json:"fields"`
+}
+
+type fieldJSON struct {
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Labels data.Labels       `json:"labels,omitempty"`
+	Config *data.FieldConfig `json:"config,omitempty"`
+	Values []interface{}     `json:"values"`
+}
+
+func newFrameJSON(frame *data.Frame) *frameJSON {
+	f := &frameJSON{
+		Name:   frame.Name,
+		RefID:  frame.RefID,
+		Meta:   frame.Meta,
+		Fields: make([]fieldJSON, 0, len(frame.Fields)),
+	}
+
+	for _, field := range frame.Fields {
+		values := make([]interface{}, field.Len())
+		for i := range values {
+			value, ok := field.ConcreteAt(i)
+			if !ok {
+				continue
+			}
+			switch v := value.(type) {
+			case time.Time:
+				values[i] = v.UnixNano() / int64(time.Millisecond)
+			case float64:
+				if !math.IsNaN(v) && !math.IsInf(v, 0) {
+					values[i] = v
+				}
+			case float32:
+				if !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0) {
+					values[i] = v
+				}
+			default:
+				values[i] = v
+			}
+		}
+
+		f.Fields = append(f.Fields, fieldJSON{
+			Name:   field.Name,
+			Type:   field.Type().ItemTypeString(),
+			Labels: field.Labels,
+			Config: field.Config,
+			Values: values,
+		})
+	}
+
+	return f
+}
+
+// tableToFrame converts a legacy table to a frame, with number columns as
+// float64 fields and the other columns as string fields.
+func tableToFrame(table *tsdb.Table) *data.Frame {
+	frame := data.NewFrame("")
+	for i, column := range table.Columns {
+		numeric := true
+		for _, row := range table.Rows {
+			if i >= len(row) || row[i] == nil {
+				continue
+			}
+			if _, ok := toFloat(row[i]); !ok {
+				numeric = false
+				break
+			}
+		}
+
+		if numeric {
+			values := make([]*float64, len(table.Rows))
+			for j, row := range table.Rows {
+				if i < len(row) {
+					if f, ok := toFloat(row[i]); ok {
+						values[j] = &f
+					}
+				}
+			}
+			frame.Fields = append(frame.Fields, data.NewField(column.Text, nil, values))
+			continue
+		}
+
+		values := make([]*string, len(table.Rows))
+		for j, row := range table.Rows {
+			if i < len(row) && row[i] != nil {
+				s := fmt.Sprint(row[i])
+				values[j] = &s
+			}
+		}
+		frame.Fields = append(frame.Fields, data.NewField(column.Text, nil, values))
+	}
+	return frame
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFederatedRequest(format string, queries ...map[string]interface{}) dtos.FederatedMetricRequest {
+	reqDto := dtos.FederatedMetricRequest{From: "5m", To: "now", Format: format}
+	for _, q := range queries {
+		reqDto.Queries = append(reqDto.Queries, simplejson.NewFromAny(q))
+	}
+	return reqDto
+}
+
+func newTestFederatedQuery(t *testing.T, reqDto dtos.FederatedMetricRequest) *federatedQuery {
+	query, err := newFederatedQuery(reqDto)
+	require.NoError(t, err)
+
+	query.getDatasource = func(id int64) (*models.DataSource, error) {
+		switch id {
+		case 4:
+			return nil, models.ErrDataSourceNotFound
+		case 5:
+			return nil, models.ErrDataSourceAccessDenied
+		}
+		return &models.DataSource{Id: id, Type: "test"}, nil
+	}
+	query.handleRequest = func(ctx context.Context, ds *models.DataSource, req *tsdb.TsdbQuery) (*tsdb.Response, error) {
+		resp := &tsdb.Response{Results: make(map[string]*tsdb.QueryResult)}
+		switch ds.Id {
+		case 1:
+			for _, q := range req.Queries {
+				frame := data.NewFrame("cpu",
+					data.NewField("time", nil, []time.Time{time.Unix(1, 0)}),
+					data.NewField("value", data.Labels{"host": "a"}, []*float64{nil}),
+				)
+				resp.Results[q.RefId] = &tsdb.QueryResult{RefId: q.RefId, Dataframes: tsdb.NewDecodedDataFrames(data.Frames{frame})}
+			}
+		case 2:
+			return nil, assert.AnError
+		case 3:
+			panic("data source panic")
+		case 6:
+			resp.Results["F"] = &tsdb.QueryResult{
+				Series: tsdb.TimeSeriesSlice{tsdb.NewTimeSeries("legacy", tsdb.NewTimeSeriesPointsFromArgs(1, 1000))},
+				Tables: []*tsdb.Table{{
+					Columns: []tsdb.TableColumn{{Text: "Value"}, {Text: "Info"}},
+					Rows:    []tsdb.RowValues{{1.5, "up"}, {nil, nil}},
+				}},
+			}
+		}
+		return resp, nil
+	}
+	return query
+}
+
+func executeFederatedQuery(query *federatedQuery) map[string]*federatedQueryResult {
+	results := make(map[string]*federatedQueryResult)
+	query.execute(context.Background(), func(result *federatedQueryResult) {
+		results[result.RefID] = result
+	})
+	return results
+}
+
+func TestNewFederatedQuery(t *testing.T) {
+	t.Run("groups queries by data source", func(t *testing.T) {
+		query, err := newFederatedQuery(newFederatedRequest("",
+			map[string]interface{}{"refId": "A", "datasourceId": 1},
+			map[string]interface{}{"refId": "B", "datasourceId": 2},
+			map[string]interface{}{"refId": "C", "datasourceId": 1},
+		))
+		require.NoError(t, err)
+
+		assert.Equal(t, federatedFormatJSON, query.format)
+		require.Len(t, query.groups, 2)
+		assert.Equal(t, int64(1), query.groups[0].datasourceID)
+		assert.Len(t, query.groups[0].queries, 2)
+		assert.Equal(t, "C", query.groups[0].queries[1].RefId)
+	})
+
+	for name, reqDto := range map[string]dtos.FederatedMetricRequest{
+		"no queries":     newFederatedRequest(""),
+		"invalid format": newFederatedRequest("csv", map[string]interface{}{"refId": "A", "datasourceId": 1}),
+		"no data source": newFederatedRequest("", map[string]interface{}{"refId": "A"}),
+		"no refId":       newFederatedRequest("", map[string]interface{}{"datasourceId": 1}),
+		"duplicate refId": newFederatedRequest("",
+			map[string]interface{}{"refId": "A", "datasourceId": 1},
+			map[string]interface{}{"refId": "A", "datasourceId": 2},
+		),
+	} {
+		t.Run("fails for "+name, func(t *testing.T) {
+			_, err := newFederatedQuery(reqDto)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFederatedQueryExecute(t *testing.T) {
+	reqDto := newFederatedRequest("",
+		map[string]interface{}{"refId": "A", "datasourceId": 1},
+		map[string]interface{}{"refId": "B", "datasourceId": 1},
+		map[string]interface{}{"refId": "C", "datasourceId": 2},
+		map[string]interface{}{"refId": "D", "datasourceId": 3},
+		map[string]interface{}{"refId": "E", "datasourceId": 4},
+		map[string]interface{}{"refId": "F", "datasourceId": 6},
+		map[string]interface{}{"refId": "G", "datasourceId": 5},
+	)
+
+	t.Run("isolates the errors of each data source", func(t *testing.T) {
+		results := executeFederatedQuery(newTestFederatedQuery(t, reqDto))
+		require.Len(t, results, 7)
+
+		assert.Empty(t, results["A"].Error)
+		assert.Empty(t, results["B"].Error)
+		assert.Equal(t, assert.AnError.Error(), results["C"].Error)
+		assert.Equal(t, "data source query failed: data source panic", results["D"].Error)
+		assert.Equal(t, "Invalid data source ID", results["E"].Error)
+		assert.Equal(t, "Access denied to data source", results["G"].Error)
+		assert.Empty(t, results["F"].Error)
+		assert.Equal(t, int64(3), results["D"].DatasourceID)
+	})
+
+	t.Run("returns JSON frames", func(t *testing.T) {
+		results := executeFederatedQuery(newTestFederatedQuery(t, reqDto))
+
+		require.Len(t, results["A"].Frames, 1)
+		body, err := json.Marshal(results["A"].Frames[0])
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"name": "cpu",
+			"refId": "A",
+			"fields": [
+				{"name": "time", "type": "time.Time", "values": [1000]},
+				{"name": "value", "type": "*float64", "labels": {"host": "a"}, "values": [null]}
+			]
+		}`, string(body))
+	})
+
+	t.Run("converts series and tables to frames", func(t *testing.T) {
+		results := executeFederatedQuery(newTestFederatedQuery(t, reqDto))
+
+		require.Len(t, results["F"].Frames, 2)
+		series := results["F"].Frames[0].(*frameJSON)
+		assert.Equal(t, "legacy", series.Name)
+		table := results["F"].Frames[1].(*frameJSON)
+		assert.Equal(t, []interface{}{1.5, nil}, table.Fields[0].Values)
+		assert.Equal(t, []interface{}{"up", nil}, table.Fields[1].Values)
+	})
+
+	t.Run("returns Arrow encoded frames", func(t *testing.T) {
+		reqDto.Format = federatedFormatArrow
+		results := executeFederatedQuery(newTestFederatedQuery(t, reqDto))
+
+		require.Len(t, results["A"].Frames, 1)
+		frame, err := data.UnmarshalArrowFrame(results["A"].Frames[0].([]byte))
+		require.NoError(t, err)
+		assert.Equal(t, "cpu", frame.Name)
+		assert.Equal(t, "A", frame.RefID)
+		assert.Equal(t, data.Labels{"host": "a"}, frame.Fields[1].Labels)
+	})
+}
+
+func TestFederatedQueryStreamResponse(t *testing.T) {
+	reqDto := newFederatedRequest("",
+		map[string]interface{}{"refId": "A", "datasourceId": 1},
+		map[string]interface{}{"refId": "C", "datasourceId": 2},
+	)
+
+	sc := setupScenarioContext("/api/ds/federated-query")
+	sc.m.Post(sc.url, Wrap(func(c *models.ReqContext) Response {
+		return &federatedQueryStreamResponse{query: newTestFederatedQuery(t, reqDto)}
+	}))
+	sc.fakeReqNoAssertions("POST", sc.url).exec()
+
+	assert.Equal(t, 200, sc.resp.Code)
+	assert.Equal(t, "application/x-ndjson", sc.resp.Header().Get("Content-Type"))
+
+	var refIDs []string
+	scanner := bufio.NewScanner(strings.NewReader(sc.resp.Body.String()))
+	for scanner.Scan() {
+		var result federatedQueryResult
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &result))
+		refIDs = append(refIDs, result.RefID)
+	}
+	sort.Strings(refIDs)
+	assert.Equal(t, []string{"A", "C"}, refIDs)
+}
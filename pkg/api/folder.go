@@ -23,9 +23,10 @@ func GetFolders(c *models.ReqContext) Response {
 
 	for _, f := range folders {
 		result = append(result, dtos.FolderSearchHit{
-			Id:    f.Id,
-			Uid:   f.Uid,
-			Title: f.Title,
+			Id:        f.Id,
+			Uid:       f.Uid,
+			Title:     f.Title,
+			ParentUid: f.ParentUid,
 		})
 	}
 
@@ -83,6 +84,27 @@ func UpdateFolder(c *models.ReqContext, cmd models.UpdateFolderCommand) Response
 	return JSON(200, toFolderDto(g, cmd.Result))
 }
 
+func MoveFolder(c *models.ReqContext, cmd models.MoveFolderCommand) Response {
+	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
+	err := s.MoveFolder(c.Params(":uid"), &cmd)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	g := guardian.New(cmd.Result.Id, c.OrgId, c.SignedInUser)
+	return JSON(200, toFolderDto(g, cmd.Result))
+}
+
+func GetFolderChildren(c *models.ReqContext) Response {
+	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
+	children, err := s.GetFolderChildren(c.Params(":uid"), c.QueryInt64("limit"))
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	return JSON(200, children)
+}
+
 func DeleteFolder(c *models.ReqContext) Response {
 	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
 	f, err := s.DeleteFolder(c.Params(":uid"))
@@ -125,6 +147,8 @@ func toFolderDto(g guardian.DashboardGuardian, folder *models.Folder) dtos.Folde
 		UpdatedBy: updater,
 		Updated:   folder.Updated,
 		Version:   folder.Version,
+		ParentId:  folder.ParentId,
+		ParentUid: folder.ParentUid,
 	}
 }
 
@@ -135,6 +159,7 @@ func toFolderError(err error) Response {
 	}
 
 	if err == models.ErrFolderTitleEmpty ||
+		err == models.ErrParentFolderNotFound ||
 		err == models.ErrFolderSameNameExists ||
 		err == models.ErrFolderWithSameUIDExists ||
 		err == models.ErrDashboardTypeMismatch ||
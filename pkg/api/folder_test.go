@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/search"
 	"github.com/grafana/grafana/pkg/setting"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -50,6 +51,8 @@ func TestFoldersApiEndpoint(t *testing.T) {
 				{Error: models.ErrFolderSameNameExists, ExpectedStatusCode: 400},
 				{Error: models.ErrDashboardInvalidUid, ExpectedStatusCode: 400},
 				{Error: models.ErrDashboardUidTooLong, ExpectedStatusCode: 400},
+				{Error: models.ErrParentFolderNotFound, ExpectedStatusCode: 400},
+				{Error: models.ErrDashboardFolderMaxDepthExceeded, ExpectedStatusCode: 400},
 				{Error: models.ErrFolderAccessDenied, ExpectedStatusCode: 403},
 				{Error: models.ErrFolderNotFound, ExpectedStatusCode: 404},
 				{Error: models.ErrFolderVersionMismatch, ExpectedStatusCode: 412},
@@ -134,6 +137,141 @@ func TestFoldersApiEndpoint(t *testing.T) {
 	})
 }
 
+func TestFoldersApiEndpoint_NestedFolders(t *testing.T) {
+	Convey("Move folder and folder children tests", t, func() {
+		Convey("Given a correct request for moving a folder", func() {
+			cmd := models.MoveFolderCommand{ParentUid: "parent"}
+
+			mock := &fakeFolderService{
+				MoveFolderResult: &models.Folder{Id: 2, Uid: "uid", Title: "Folder", ParentId: 1, ParentUid: "parent"},
+			}
+
+			moveFolderScenario("When calling POST on", "/api/folders/uid/move", "/api/folders/:uid/move", mock, cmd, func(sc *scenarioContext) {
+				sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+
+				Convey("It should return the folder with its new parent", func() {
+					folder := dtos.Folder{}
+					err := json.NewDecoder(sc.resp.Body).Decode(&folder)
+					So(err, ShouldBeNil)
+					So(folder.Uid, ShouldEqual, "uid")
+					So(folder.ParentId, ShouldEqual, 1)
+					So(folder.ParentUid, ShouldEqual, "parent")
+				})
+			})
+		})
+
+		Convey("Given incorrect requests for moving a folder", func() {
+			testCases := []struct {
+				Error              error
+				ExpectedStatusCode int
+			}{
+				{Error: models.ErrDashboardFolderCycle, ExpectedStatusCode: 400},
+				{Error: models.ErrDashboardFolderMaxDepthExceeded, ExpectedStatusCode: 400},
+				{Error: models.ErrParentFolderNotFound, ExpectedStatusCode: 400},
+				{Error: models.ErrFolderSameNameExists, ExpectedStatusCode: 400},
+				{Error: models.ErrFolderAccessDenied, ExpectedStatusCode: 403},
+				{Error: models.ErrFolderNotFound, ExpectedStatusCode: 404},
+				{Error: models.ErrFolderVersionMismatch, ExpectedStatusCode: 412},
+			}
+
+			for _, tc := range testCases {
+				mock := &fakeFolderService{
+					MoveFolderError: tc.Error,
+				}
+
+				moveFolderScenario(fmt.Sprintf("Expect '%s' error when calling POST on", tc.Error.Error()), "/api/folders/uid/move", "/api/folders/:uid/move", mock, models.MoveFolderCommand{}, func(sc *scenarioContext) {
+					sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+					if sc.resp.Code != tc.ExpectedStatusCode {
+						t.Errorf("For error '%s' expected status code %d, actual %d", tc.Error, tc.ExpectedStatusCode, sc.resp.Code)
+					}
+				})
+			}
+		})
+
+		Convey("Given a folder with a subfolder and a dashboard", func() {
+			mock := &fakeFolderService{
+				ChildrenResult: search.HitList{
+					{Id: 2, Uid: "sub", Title: "Subfolder", Type: search.DashHitFolder, FolderId: 1, FolderUid: "uid", FolderPath: []string{"Folder"}},
+					{Id: 3, Uid: "dash", Title: "Dashboard", Type: search.DashHitDB, FolderId: 1, FolderUid: "uid", FolderPath: []string{"Folder"}},
+				},
+			}
+
+			folderChildrenScenario("When calling GET on", "/api/folders/uid/children", "/api/folders/:uid/children", mock, func(sc *scenarioContext) {
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				Convey("It should return the children of the folder", func() {
+					So(sc.resp.Code, ShouldEqual, 200)
+					var hits []*search.Hit
+					err := json.NewDecoder(sc.resp.Body).Decode(&hits)
+					So(err, ShouldBeNil)
+					So(len(hits), ShouldEqual, 2)
+					So(hits[0].Type, ShouldEqual, search.DashHitFolder)
+					So(hits[1].FolderPath, ShouldResemble, []string{"Folder"})
+				})
+			})
+
+			mock = &fakeFolderService{ChildrenError: models.ErrFolderAccessDenied}
+			folderChildrenScenario("When calling GET without access on", "/api/folders/uid/children", "/api/folders/:uid/children", mock, func(sc *scenarioContext) {
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				Convey("It should return 403", func() {
+					So(sc.resp.Code, ShouldEqual, 403)
+				})
+			})
+		})
+	})
+}
+
+func moveFolderScenario(desc string, url string, routePattern string, mock *fakeFolderService, cmd models.MoveFolderCommand, fn scenarioFunc) {
+	Convey(desc+" "+url, func() {
+		defer bus.ClearBusHandlers()
+
+		sc := setupScenarioContext(url)
+		sc.defaultHandler = Wrap(func(c *models.ReqContext) Response {
+			sc.context = c
+			sc.context.SignedInUser = &models.SignedInUser{OrgId: TestOrgID, UserId: TestUserID}
+
+			return MoveFolder(c, cmd)
+		})
+
+		origNewFolderService := dashboards.NewFolderService
+		mockFolderService(mock)
+
+		sc.m.Post(routePattern, sc.defaultHandler)
+
+		defer func() {
+			dashboards.NewFolderService = origNewFolderService
+		}()
+
+		fn(sc)
+	})
+}
+
+func folderChildrenScenario(desc string, url string, routePattern string, mock *fakeFolderService, fn scenarioFunc) {
+	Convey(desc+" "+url, func() {
+		defer bus.ClearBusHandlers()
+
+		sc := setupScenarioContext(url)
+		sc.defaultHandler = Wrap(func(c *models.ReqContext) Response {
+			sc.context = c
+			sc.context.SignedInUser = &models.SignedInUser{OrgId: TestOrgID, UserId: TestUserID}
+
+			return GetFolderChildren(c)
+		})
+
+		origNewFolderService := dashboards.NewFolderService
+		mockFolderService(mock)
+
+		sc.m.Get(routePattern, sc.defaultHandler)
+
+		defer func() {
+			dashboards.NewFolderService = origNewFolderService
+		}()
+
+		fn(sc)
+	})
+}
+
 func callCreateFolder(sc *scenarioContext) {
 	sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
 }
@@ -211,6 +349,10 @@ type fakeFolderService struct {
 	DeleteFolderResult   *models.Folder
 	DeleteFolderError    error
 	DeletedFolderUids    []string
+	MoveFolderResult     *models.Folder
+	MoveFolderError      error
+	ChildrenResult       search.HitList
+	ChildrenError        error
 }
 
 func (s *fakeFolderService) GetFolders(limit int64) ([]*models.Folder, error) {
@@ -240,6 +382,15 @@ func (s *fakeFolderService) DeleteFolder(uid string) (*models.Folder, error) {
 	return s.DeleteFolderResult, s.DeleteFolderError
 }
 
+func (s *fakeFolderService) MoveFolder(uid string, cmd *models.MoveFolderCommand) error {
+	cmd.Result = s.MoveFolderResult
+	return s.MoveFolderError
+}
+
+func (s *fakeFolderService) GetFolderChildren(uid string, limit int64) (search.HitList, error) {
+	return s.ChildrenResult, s.ChildrenError
+}
+
 func mockFolderService(mock *fakeFolderService) {
 	dashboards.NewFolderService = func(orgId int64, user *models.SignedInUser) dashboards.FolderService {
 		return mock
@@ -15,6 +15,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 
+	"github.com/grafana/grafana/pkg/api/pluginproxy"
 	"github.com/grafana/grafana/pkg/api/routing"
 	httpstatic "github.com/grafana/grafana/pkg/api/static"
 	"github.com/grafana/grafana/pkg/bus"
@@ -71,6 +72,7 @@ type HTTPServer struct {
 	PluginManager        *plugins.PluginManager           `inject:""`
 	SearchService        *search.SearchService            `inject:""`
 	Live                 *live.GrafanaLive
+	DataProxyCache       *pluginproxy.ResponseCache
 	Listener             net.Listener
 }
 
@@ -90,6 +92,8 @@ func (hs *HTTPServer) Init() error {
 		go live.RunRandomCSV(hs.Live, "random-flakey-stream", 400, .6)
 	}
 
+	hs.DataProxyCache = pluginproxy.NewResponseCache(hs.Cfg, hs.CacheService, hs.RemoteCacheService)
+
 	hs.macaron = hs.newMacaron()
 	hs.registerRoutes()
 
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GetLibraryPanels returns the library panels in the folders the user can
+// view, optionally filtered by folder and by name.
+func GetLibraryPanels(c *models.ReqContext) Response {
+	folderIds := make([]int64, 0)
+	for _, id := range c.QueryStrings("folderId") {
+		folderId, err := strconv.ParseInt(id, 10, 64)
+		if err == nil {
+			folderIds = append(folderIds, folderId)
+		}
+	}
+
+	query := models.GetLibraryPanelsQuery{
+		OrgId:     c.OrgId,
+		FolderIds: folderIds,
+		Name:      c.Query("query"),
+		Limit:     c.QueryInt("limit"),
+	}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get library panels", err)
+	}
+
+	canViewFolder := make(map[int64]bool)
+	result := make([]dtos.LibraryPanel, 0, len(query.Result))
+	for _, panel := range query.Result {
+		canView, ok := canViewFolder[panel.FolderId]
+		if !ok {
+			var err error
+			if canView, err = guardian.New(panel.FolderId, c.OrgId, c.SignedInUser).CanView(); err != nil {
+				return Error(500, "Failed to check library panel permissions", err)
+			}
+			canViewFolder[panel.FolderId] = canView
+		}
+		if canView {
+			result = append(result, toLibraryPanelDto(panel, false))
+		}
+	}
+
+	return JSON(200, result)
+}
+
+// GetLibraryPanelByUID returns a library panel with the dashboards using it
+func GetLibraryPanelByUID(c *models.ReqContext) Response {
+	query := models.GetLibraryPanelQuery{OrgId: c.OrgId, Uid: c.Params(":uid")}
+	if err := bus.Dispatch(&query); err != nil {
+		return toLibraryPanelError(err)
+	}
+
+	g := guardian.New(query.Result.FolderId, c.OrgId, c.SignedInUser)
+	if canView, err := g.CanView(); err != nil || !canView {
+		return toLibraryPanelError(toAccessDeniedError(err))
+	}
+	canEdit, _ := g.CanSave()
+
+	return libraryPanelWithDashboardsResponse(c, query.Result, canEdit)
+}
+
+func CreateLibraryPanel(c *models.ReqContext, cmd models.CreateLibraryPanelCommand) Response {
+	if err := canSaveLibraryPanelIn(c, cmd.FolderId); err != nil {
+		return toLibraryPanelError(err)
+	}
+
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toLibraryPanelError(err)
+	}
+
+	return JSON(200, toLibraryPanelDto(cmd.Result, true))
+}
+
+// UpdateLibraryPanel saves a new version of a library panel, and returns it
+// with the dashboards using it.
+func UpdateLibraryPanel(c *models.ReqContext, cmd models.UpdateLibraryPanelCommand) Response {
+	query := models.GetLibraryPanelQuery{OrgId: c.OrgId, Uid: c.Params(":uid")}
+	if err := bus.Dispatch(&query); err != nil {
+		return toLibraryPanelError(err)
+	}
+
+	if err := canSaveLibraryPanelIn(c, query.Result.FolderId); err != nil {
+		return toLibraryPanelError(err)
+	}
+	if cmd.FolderId != query.Result.FolderId {
+		if err := canSaveLibraryPanelIn(c, cmd.FolderId); err != nil {
+			return toLibraryPanelError(err)
+		}
+	}
+
+	if cmd.Model != nil {
+		if err := dashboards.ValidateLibraryPanelAlerts(cmd.Model, c.OrgId, c.SignedInUser); err != nil {
+			return toLibraryPanelError(err)
+		}
+	}
+
+	cmd.Uid = query.Result.Uid
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toLibraryPanelError(err)
+	}
+
+	if err := dashboards.UpdateLibraryPanelAlerts(cmd.Result, c.SignedInUser); err != nil {
+		return toLibraryPanelError(err)
+	}
+
+	return libraryPanelWithDashboardsResponse(c, cmd.Result, true)
+}
+
+func DeleteLibraryPanel(c *models.ReqContext) Response {
+	query := models.GetLibraryPanelQuery{OrgId: c.OrgId, Uid: c.Params(":uid")}
+	if err := bus.Dispatch(&query); err != nil {
+		return toLibraryPanelError(err)
+	}
+
+	if err := canSaveLibraryPanelIn(c, query.Result.FolderId); err != nil {
+		return toLibraryPanelError(err)
+	}
+
+	cmd := models.DeleteLibraryPanelCommand{OrgId: c.OrgId, Uid: query.Result.Uid}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toLibraryPanelError(err)
+	}
+
+	return JSON(200, util.DynMap{
+		"id":      query.Result.Id,
+		"message": "Library panel deleted",
+	})
+}
+
+func libraryPanelWithDashboardsResponse(c *models.ReqContext, panel *models.LibraryPanel, canEdit bool) Response {
+	query := models.GetLibraryPanelDashboardsQuery{OrgId: c.OrgId, LibraryPanelId: panel.Id}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get the dashboards using the library panel", err)
+	}
+
+	dto := toLibraryPanelDto(panel, canEdit)
+	for _, dash := range query.Result {
+		if canView, err := guardian.New(dash.Id, c.OrgId, c.SignedInUser).CanView(); err != nil || !canView {
+			continue
+		}
+		dto.ConnectedDashboards = append(dto.ConnectedDashboards, dtos.LibraryPanelDashboard{
+			Id:    dash.Id,
+			Uid:   dash.Uid,
+			Title: dash.Title,
+			Url:   models.GetDashboardUrl(dash.Uid, dash.Slug),
+		})
+	}
+
+	return JSON(200, dto)
+}
+
+// canSaveLibraryPanelIn checks that the user can save library panels in a
+// folder, library panels having the permissions of their folder.
+func canSaveLibraryPanelIn(c *models.ReqContext, folderId int64) error {
+	canSave, err := guardian.New(folderId, c.OrgId, c.SignedInUser).CanSave()
+	if err != nil || !canSave {
+		return toAccessDeniedError(err)
+	}
+	return nil
+}
+
+func toAccessDeniedError(err error) error {
+	if err != nil {
+		return err
+	}
+	return models.ErrFolderAccessDenied
+}
+
+func toLibraryPanelDto(panel *models.LibraryPanel, canEdit bool) dtos.LibraryPanel {
+	return dtos.LibraryPanel{
+		Id:       panel.Id,
+		Uid:      panel.Uid,
+		FolderId: panel.FolderId,
+		Name:     panel.Name,
+		Model:    panel.Model,
+		Version:  panel.Version,
+		Created:  panel.Created,
+		Updated:  panel.Updated,
+		CanEdit:  canEdit,
+	}
+}
+
+func toLibraryPanelError(err error) Response {
+	if errors.Is(err, models.ErrLibraryPanelNameEmpty) ||
+		errors.Is(err, models.ErrLibraryPanelWithSameNameExists) ||
+		errors.Is(err, models.ErrLibraryPanelWithSameUidExists) ||
+		errors.Is(err, models.ErrLibraryPanelInvalidUid) ||
+		errors.Is(err, models.ErrLibraryPanelUidTooLong) ||
+		errors.Is(err, models.ErrFolderNotFound) {
+		return Error(400, err.Error(), nil)
+	}
+
+	if errors.Is(err, models.ErrFolderAccessDenied) {
+		return Error(403, "Access denied", err)
+	}
+
+	if errors.Is(err, models.ErrLibraryPanelInUse) {
+		return Error(403, err.Error(), nil)
+	}
+
+	if errors.Is(err, models.ErrLibraryPanelNotFound) {
+		return JSON(404, util.DynMap{"status": "not-found", "message": err.Error()})
+	}
+
+	if errors.Is(err, models.ErrLibraryPanelVersionMismatch) {
+		return JSON(412, util.DynMap{"status": "version-mismatch", "message": err.Error()})
+	}
+
+	var validationErr alerting.ValidationError
+	if ok := errors.As(err, &validationErr); ok {
+		return Error(422, validationErr.Error(), nil)
+	}
+
+	return Error(500, "Library panel API error", err)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryPanelsAPIEndpoint(t *testing.T) {
+	cpu := &models.LibraryPanel{
+		Id: 1, OrgId: TestOrgID, FolderId: 2, Uid: "cpu", Name: "CPU", Version: 3,
+		Model: simplejson.NewFromAny(map[string]interface{}{"type": "graph", "title": "CPU"}),
+	}
+
+	// the user can view folder 2 and dashboard 10, and edit folder 3
+	setup := func(t *testing.T) {
+		setupTestScenario(t, func(dashId int64) *guardian.FakeDashboardGuardian {
+			return &guardian.FakeDashboardGuardian{
+				CanViewValue: dashId == 2 || dashId == 3 || dashId == 10,
+				CanSaveValue: dashId == 3,
+			}
+		})
+
+		bus.AddHandler("test", func(query *models.GetLibraryPanelQuery) error {
+			if query.Uid != cpu.Uid {
+				return models.ErrLibraryPanelNotFound
+			}
+			query.Result = cpu
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetLibraryPanelDashboardsQuery) error {
+			query.Result = []*models.Dashboard{
+				{Id: 10, Uid: "hosts", Title: "Hosts", Slug: "hosts"},
+				{Id: 11, Uid: "secret", Title: "Secret", Slug: "secret"},
+			}
+			return nil
+		})
+	}
+
+	t.Run("returns a library panel with the dashboards using it the user can view", func(t *testing.T) {
+		setup(t)
+
+		sc := callAPI(t, models.ROLE_VIEWER, "GET", "/api/library-panels/cpu", "/api/library-panels/:uid", GetLibraryPanelByUID)
+		require.Equal(t, 200, sc.resp.Code)
+
+		var result dtos.LibraryPanel
+		require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&result))
+		assert.Equal(t, "CPU", result.Name)
+		assert.False(t, result.CanEdit)
+		assert.Equal(t, []dtos.LibraryPanelDashboard{{Id: 10, Uid: "hosts", Title: "Hosts", Url: "/d/hosts/hosts"}}, result.ConnectedDashboards)
+	})
+
+	t.Run("returns 404 for a library panel that doesn't exist", func(t *testing.T) {
+		setup(t)
+
+		sc := callAPI(t, models.ROLE_VIEWER, "GET", "/api/library-panels/missing", "/api/library-panels/:uid", GetLibraryPanelByUID)
+		assert.Equal(t, 404, sc.resp.Code)
+	})
+
+	t.Run("library panels can only be updated by users who can edit their folder", func(t *testing.T) {
+		setup(t)
+		updated := false
+		bus.AddHandler("test", func(cmd *models.UpdateLibraryPanelCommand) error {
+			updated = true
+			return nil
+		})
+
+		update := func(c *models.ReqContext) Response {
+			return UpdateLibraryPanel(c, models.UpdateLibraryPanelCommand{FolderId: 3, Name: "CPU", Version: 3})
+		}
+		sc := callAPI(t, models.ROLE_VIEWER, "PUT", "/api/library-panels/cpu", "/api/library-panels/:uid", update)
+		assert.Equal(t, 403, sc.resp.Code)
+		assert.False(t, updated)
+	})
+
+	t.Run("updating a library panel returns its new version and the dashboards using it", func(t *testing.T) {
+		setup(t)
+		cpu.FolderId = 3
+		t.Cleanup(func() { cpu.FolderId = 2 })
+		bus.AddHandler("test", func(cmd *models.UpdateLibraryPanelCommand) error {
+			assert.Equal(t, "cpu", cmd.Uid)
+			assert.EqualValues(t, TestUserID, cmd.UserId)
+			cmd.Result = &models.LibraryPanel{Id: 1, OrgId: TestOrgID, Uid: "cpu", FolderId: 3, Name: cmd.Name, Model: cmd.Model, Version: cmd.Version + 1}
+			return nil
+		})
+		bus.AddHandler("test", func(cmd *models.ValidateDashboardAlertsCommand) error {
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+			query.Result = &models.Dashboard{Id: query.Id, OrgId: query.OrgId, Data: simplejson.New()}
+			return nil
+		})
+		var alertsUpdated []int64
+		bus.AddHandler("test", func(cmd *models.UpdateDashboardAlertsCommand) error {
+			alertsUpdated = append(alertsUpdated, cmd.Dashboard.Id)
+			return nil
+		})
+
+		update := func(c *models.ReqContext) Response {
+			return UpdateLibraryPanel(c, models.UpdateLibraryPanelCommand{FolderId: 3, Name: "CPU usage", Version: 3, Model: simplejson.New()})
+		}
+		sc := callAPI(t, models.ROLE_VIEWER, "PUT", "/api/library-panels/cpu", "/api/library-panels/:uid", update)
+		require.Equal(t, 200, sc.resp.Code)
+
+		var result dtos.LibraryPanel
+		require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&result))
+		assert.EqualValues(t, 4, result.Version)
+		assert.True(t, result.CanEdit)
+		assert.Len(t, result.ConnectedDashboards, 1)
+		// the alerts of all the dashboards using it are updated, even the ones the user can't view
+		assert.Equal(t, []int64{10, 11}, alertsUpdated)
+	})
+
+	t.Run("library panels with an invalid alert aren't updated", func(t *testing.T) {
+		setup(t)
+		cpu.FolderId = 3
+		t.Cleanup(func() { cpu.FolderId = 2 })
+		bus.AddHandler("test", func(cmd *models.ValidateDashboardAlertsCommand) error {
+			assert.Equal(t, "graph", cmd.Dashboard.Data.Get("panels").GetIndex(0).Get("type").MustString())
+			return alerting.ValidationError{Reason: "Invalid frequency"}
+		})
+		updated := false
+		bus.AddHandler("test", func(cmd *models.UpdateLibraryPanelCommand) error {
+			updated = true
+			return nil
+		})
+
+		update := func(c *models.ReqContext) Response {
+			model := simplejson.NewFromAny(map[string]interface{}{"type": "graph", "alert": map[string]interface{}{"frequency": "never"}})
+			return UpdateLibraryPanel(c, models.UpdateLibraryPanelCommand{FolderId: 3, Name: "CPU", Version: 3, Model: model})
+		}
+		sc := callAPI(t, models.ROLE_VIEWER, "PUT", "/api/library-panels/cpu", "/api/library-panels/:uid", update)
+		assert.Equal(t, 422, sc.resp.Code)
+		assert.False(t, updated)
+	})
+
+	t.Run("library panels used by dashboards can't be deleted", func(t *testing.T) {
+		setup(t)
+		cpu.FolderId = 3
+		t.Cleanup(func() { cpu.FolderId = 2 })
+		bus.AddHandler("test", func(cmd *models.DeleteLibraryPanelCommand) error {
+			return models.ErrLibraryPanelInUse
+		})
+
+		sc := callAPI(t, models.ROLE_VIEWER, "DELETE", "/api/library-panels/cpu", "/api/library-panels/:uid", DeleteLibraryPanel)
+		assert.Equal(t, 403, sc.resp.Code)
+	})
+}
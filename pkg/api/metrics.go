@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboardviews"
 	"github.com/grafana/grafana/pkg/tsdb"
 	"github.com/grafana/grafana/pkg/tsdb/testdatasource"
 	"github.com/grafana/grafana/pkg/util"
@@ -73,6 +74,7 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDto dtos.MetricReq
 	if !expr {
 		resp, err = tsdb.HandleRequest(c.Req.Context(), ds, request)
 		if err != nil {
+			dashboardviews.RecordQueries(c.Req.Request, c.OrgId, len(request.Queries), len(request.Queries))
 			return Error(500, "Metric request error", err)
 		}
 	} else {
@@ -82,18 +84,22 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDto dtos.MetricReq
 
 		resp, err = plugins.Transform.Transform(c.Req.Context(), request)
 		if err != nil {
+			dashboardviews.RecordQueries(c.Req.Request, c.OrgId, len(request.Queries), len(request.Queries))
 			return Error(500, "Transform request error", err)
 		}
 	}
 
 	statusCode := 200
+	failed := 0
 	for _, res := range resp.Results {
 		if res.Error != nil {
 			res.ErrorString = res.Error.Error()
 			resp.Message = res.ErrorString
 			statusCode = 400
+			failed++
 		}
 	}
+	dashboardviews.RecordQueries(c.Req.Request, c.OrgId, len(request.Queries), failed)
 
 	return JSON(statusCode, &resp)
 }
@@ -138,17 +144,21 @@ func (hs *HTTPServer) QueryMetrics(c *models.ReqContext, reqDto dtos.MetricReque
 
 	resp, err := tsdb.HandleRequest(c.Req.Context(), ds, request)
 	if err != nil {
+		dashboardviews.RecordQueries(c.Req.Request, c.OrgId, len(request.Queries), len(request.Queries))
 		return Error(500, "Metric request error", err)
 	}
 
 	statusCode := 200
+	failed := 0
 	for _, res := range resp.Results {
 		if res.Error != nil {
 			res.ErrorString = res.Error.Error()
 			resp.Message = res.ErrorString
 			statusCode = 400
+			failed++
 		}
 	}
+	dashboardviews.RecordQueries(c.Req.Request, c.OrgId, len(request.Queries), failed)
 
 	return JSON(statusCode, &resp)
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
@@ -23,14 +24,16 @@ import (
 	"github.com/grafana/grafana/pkg/login/social"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/dashboardviews"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/proxyutil"
 )
 
 var (
-	logger = glog.New("data-proxy-log")
-	client = newHTTPClient()
+	logger      = glog.New("data-proxy-log")
+	auditLogger = glog.New("data-proxy-audit")
+	client      = newHTTPClient()
 )
 
 type DataSourceProxy struct {
@@ -41,6 +44,7 @@ type DataSourceProxy struct {
 	route     *plugins.AppPluginRoute
 	plugin    *plugins.DataSourcePlugin
 	cfg       *setting.Cfg
+	cache     *ResponseCache
 }
 
 type handleResponseTransport struct {
@@ -71,9 +75,10 @@ func (lw *logWrapper) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
-// NewDataSourceProxy creates a new Datasource proxy
+// NewDataSourceProxy creates a new Datasource proxy. Responses are cached
+// in cache when it is not nil and the matching route declares a cacheTTL.
 func NewDataSourceProxy(ds *models.DataSource, plugin *plugins.DataSourcePlugin, ctx *models.ReqContext,
-	proxyPath string, cfg *setting.Cfg) (*DataSourceProxy, error) {
+	proxyPath string, cfg *setting.Cfg, cache *ResponseCache) (*DataSourceProxy, error) {
 	targetURL, err := datasource.ValidateURL(ds.Type, ds.Url)
 	if err != nil {
 		return nil, err
@@ -86,6 +91,7 @@ func NewDataSourceProxy(ds *models.DataSource, plugin *plugins.DataSourcePlugin,
 		proxyPath: proxyPath,
 		targetUrl: targetURL,
 		cfg:       cfg,
+		cache:     cache,
 	}, nil
 }
 
@@ -97,11 +103,24 @@ func newHTTPClient() httpClient {
 }
 
 func (proxy *DataSourceProxy) HandleRequest() {
+	start := time.Now()
+	defer proxy.logAudit(start)
+
 	if err := proxy.validateRequest(); err != nil {
 		proxy.ctx.JsonApiErr(403, err.Error(), nil)
 		return
 	}
 
+	if rule, ok := getRateLimitRule(proxy.ds.JsonData, proxy.proxyPath); ok {
+		if allowed, wait := rateLimiters.allow(proxy.ctx.OrgId, proxy.ctx.UserId, proxy.ds.Id, rule); !allowed {
+			proxy.ctx.Resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			proxy.ctx.JsonApiErr(429, "Data source rate limit exceeded", nil)
+			return
+		}
+	}
+
+	defer proxy.recordDashboardQuery()
+
 	proxyErrorLogger := logger.New("userId", proxy.ctx.UserId, "orgId", proxy.ctx.OrgId, "uname", proxy.ctx.Login, "path", proxy.ctx.Req.URL.Path, "remote_addr", proxy.ctx.RemoteAddr(), "referer", proxy.ctx.Req.Referer())
 
 	reverseProxy := &httputil.ReverseProxy{
@@ -141,9 +160,26 @@ func (proxy *DataSourceProxy) HandleRequest() {
 		logger.Error("Failed to inject span context instance", "err", err)
 	}
 
+	if ttl := proxy.responseCacheTTL(); ttl > 0 {
+		proxy.cache.serve(proxy.ctx.Resp, proxy.responseCacheKey(), ttl, func(w http.ResponseWriter) {
+			reverseProxy.ServeHTTP(w, proxy.ctx.Req.Request)
+		})
+		return
+	}
+
 	reverseProxy.ServeHTTP(proxy.ctx.Resp, proxy.ctx.Req.Request)
 }
 
+// recordDashboardQuery records the proxied request in the insights of the
+// dashboard sending it, as a failed query when the response is an error.
+func (proxy *DataSourceProxy) recordDashboardQuery() {
+	failed := 0
+	if proxy.ctx.Resp.Status() >= 400 {
+		failed = 1
+	}
+	dashboardviews.RecordQueries(proxy.ctx.Req.Request, proxy.ctx.OrgId, 1, failed)
+}
+
 func (proxy *DataSourceProxy) addTraceFromHeaderValue(span opentracing.Span, headerName string, tagName string) {
 	panelId := proxy.ctx.Req.Header.Get(headerName)
 	dashId, err := strconv.Atoi(panelId)
@@ -294,6 +330,27 @@ func (proxy *DataSourceProxy) logRequest() {
 		"body", body)
 }
 
+// logAudit writes a structured record of the request to the audit logger,
+// including requests that were rejected.
+func (proxy *DataSourceProxy) logAudit(start time.Time) {
+	if !proxy.cfg.DataProxyAuditLogging {
+		return
+	}
+
+	auditLogger.Info("Data proxy request",
+		"userid", proxy.ctx.UserId,
+		"orgid", proxy.ctx.OrgId,
+		"username", proxy.ctx.Login,
+		"datasource_uid", proxy.ds.Uid,
+		"datasource", proxy.ds.Type,
+		"method", proxy.ctx.Req.Method,
+		"path", proxy.proxyPath,
+		"status", proxy.ctx.Resp.Status(),
+		"time_ms", time.Since(start).Milliseconds(),
+		"request_size", proxy.ctx.Req.ContentLength,
+		"size", proxy.ctx.Resp.Size())
+}
+
 func checkWhiteList(c *models.ReqContext, host string) bool {
 	if host != "" && len(setting.DataProxyWhiteList) > 0 {
 		if _, exists := setting.DataProxyWhiteList[host]; !exists {
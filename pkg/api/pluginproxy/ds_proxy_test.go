@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/datasource"
 	"github.com/grafana/grafana/pkg/components/securejsondata"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/inconshreveable/log15"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -93,7 +94,7 @@ func TestDSRouteRule(t *testing.T) {
 			}
 
 			Convey("When matching route path", func() {
-				proxy, err := NewDataSourceProxy(ds, plugin, ctx, "api/v4/some/method", &setting.Cfg{})
+				proxy, err := NewDataSourceProxy(ds, plugin, ctx, "api/v4/some/method", &setting.Cfg{}, nil)
 				So(err, ShouldBeNil)
 				proxy.route = plugin.Routes[0]
 				ApplyRoute(proxy.ctx.Req.Context(), req, proxy.proxyPath, proxy.route, proxy.ds)
@@ -105,7 +106,7 @@ func TestDSRouteRule(t *testing.T) {
 			})
 
 			Convey("When matching route path and has dynamic url", func() {
-				proxy, err := NewDataSourceProxy(ds, plugin, ctx, "api/common/some/method", &setting.Cfg{})
+				proxy, err := NewDataSourceProxy(ds, plugin, ctx, "api/common/some/method", &setting.Cfg{}, nil)
 				So(err, ShouldBeNil)
 				proxy.route = plugin.Routes[3]
 				ApplyRoute(proxy.ctx.Req.Context(), req, proxy.proxyPath, proxy.route, proxy.ds)
@@ -118,14 +119,14 @@ func TestDSRouteRule(t *testing.T) {
 
 			Convey("Validating request", func() {
 				Convey("plugin route with valid role", func() {
-					proxy, err := NewDataSourceProxy(ds, plugin, ctx, "api/v4/some/method", &setting.Cfg{})
+					proxy, err := NewDataSourceProxy(ds, plugin, ctx, "api/v4/some/method", &setting.Cfg{}, nil)
 					So(err, ShouldBeNil)
 					err = proxy.validateRequest()
 					So(err, ShouldBeNil)
 				})
 
 				Convey("plugin route with admin role and user is editor", func() {
-					proxy, err := NewDataSourceProxy(ds, plugin, ctx, "api/admin", &setting.Cfg{})
+					proxy, err := NewDataSourceProxy(ds, plugin, ctx, "api/admin", &setting.Cfg{}, nil)
 					So(err, ShouldBeNil)
 					err = proxy.validateRequest()
 					So(err, ShouldNotBeNil)
@@ -133,7 +134,7 @@ func TestDSRouteRule(t *testing.T) {
 
 				Convey("plugin route with admin role and user is admin", func() {
 					ctx.SignedInUser.OrgRole = models.ROLE_ADMIN
-					proxy, err := NewDataSourceProxy(ds, plugin, ctx, "api/admin", &setting.Cfg{})
+					proxy, err := NewDataSourceProxy(ds, plugin, ctx, "api/admin", &setting.Cfg{}, nil)
 					So(err, ShouldBeNil)
 					err = proxy.validateRequest()
 					So(err, ShouldBeNil)
@@ -203,7 +204,7 @@ func TestDSRouteRule(t *testing.T) {
 					So(err, ShouldBeNil)
 
 					client = newFakeHTTPClient(json)
-					proxy1, err := NewDataSourceProxy(ds, plugin, ctx, "pathwithtoken1", &setting.Cfg{})
+					proxy1, err := NewDataSourceProxy(ds, plugin, ctx, "pathwithtoken1", &setting.Cfg{}, nil)
 					So(err, ShouldBeNil)
 					proxy1.route = plugin.Routes[0]
 					ApplyRoute(proxy1.ctx.Req.Context(), req, proxy1.proxyPath, proxy1.route, proxy1.ds)
@@ -218,7 +219,7 @@ func TestDSRouteRule(t *testing.T) {
 
 						req, _ := http.NewRequest("GET", "http://localhost/asd", nil)
 						client = newFakeHTTPClient(json2)
-						proxy2, err := NewDataSourceProxy(ds, plugin, ctx, "pathwithtoken2", &setting.Cfg{})
+						proxy2, err := NewDataSourceProxy(ds, plugin, ctx, "pathwithtoken2", &setting.Cfg{}, nil)
 						So(err, ShouldBeNil)
 						proxy2.route = plugin.Routes[1]
 						ApplyRoute(proxy2.ctx.Req.Context(), req, proxy2.proxyPath, proxy2.route, proxy2.ds)
@@ -234,7 +235,7 @@ func TestDSRouteRule(t *testing.T) {
 							req, _ := http.NewRequest("GET", "http://localhost/asd", nil)
 
 							client = newFakeHTTPClient([]byte{})
-							proxy3, err := NewDataSourceProxy(ds, plugin, ctx, "pathwithtoken1", &setting.Cfg{})
+							proxy3, err := NewDataSourceProxy(ds, plugin, ctx, "pathwithtoken1", &setting.Cfg{}, nil)
 							So(err, ShouldBeNil)
 							proxy3.route = plugin.Routes[0]
 							ApplyRoute(proxy3.ctx.Req.Context(), req, proxy3.proxyPath, proxy3.route, proxy3.ds)
@@ -256,7 +257,7 @@ func TestDSRouteRule(t *testing.T) {
 			ds := &models.DataSource{Url: "htttp://graphite:8080", Type: models.DS_GRAPHITE}
 			ctx := &models.ReqContext{}
 
-			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "/render", &setting.Cfg{})
+			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "/render", &setting.Cfg{}, nil)
 			So(err, ShouldBeNil)
 			req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
 			So(err, ShouldBeNil)
@@ -282,7 +283,7 @@ func TestDSRouteRule(t *testing.T) {
 			}
 
 			ctx := &models.ReqContext{}
-			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "", &setting.Cfg{})
+			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "", &setting.Cfg{}, nil)
 			So(err, ShouldBeNil)
 
 			req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
@@ -307,7 +308,7 @@ func TestDSRouteRule(t *testing.T) {
 			}
 
 			ctx := &models.ReqContext{}
-			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "", &setting.Cfg{})
+			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "", &setting.Cfg{}, nil)
 			So(err, ShouldBeNil)
 
 			requestURL, _ := url.Parse("http://grafana.com/sub")
@@ -334,7 +335,7 @@ func TestDSRouteRule(t *testing.T) {
 			}
 
 			ctx := &models.ReqContext{}
-			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "", &setting.Cfg{})
+			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "", &setting.Cfg{}, nil)
 			So(err, ShouldBeNil)
 
 			requestURL, _ := url.Parse("http://grafana.com/sub")
@@ -356,7 +357,7 @@ func TestDSRouteRule(t *testing.T) {
 				Url:  "http://host/root/",
 			}
 			ctx := &models.ReqContext{}
-			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "/path/to/folder/", &setting.Cfg{})
+			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "/path/to/folder/", &setting.Cfg{}, nil)
 			So(err, ShouldBeNil)
 			req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
 			req.Header.Add("Origin", "grafana.com")
@@ -416,7 +417,7 @@ func TestDSRouteRule(t *testing.T) {
 					Req: macaron.Request{Request: req},
 				},
 			}
-			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "/path/to/folder/", &setting.Cfg{})
+			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "/path/to/folder/", &setting.Cfg{}, nil)
 			So(err, ShouldBeNil)
 			req, err = http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
 			So(err, ShouldBeNil)
@@ -528,7 +529,7 @@ func TestDSRouteRule(t *testing.T) {
 			Convey("When response header Set-Cookie is not set should remove proxied Set-Cookie header", func() {
 				writeErr = nil
 				ctx := setupCtx(nil)
-				proxy, err := NewDataSourceProxy(ds, plugin, ctx, "/render", &setting.Cfg{})
+				proxy, err := NewDataSourceProxy(ds, plugin, ctx, "/render", &setting.Cfg{}, nil)
 				So(err, ShouldBeNil)
 
 				proxy.HandleRequest()
@@ -542,7 +543,7 @@ func TestDSRouteRule(t *testing.T) {
 				ctx := setupCtx(func(w http.ResponseWriter) {
 					w.Header().Set("Set-Cookie", "important_cookie=important_value")
 				})
-				proxy, err := NewDataSourceProxy(ds, plugin, ctx, "/render", &setting.Cfg{})
+				proxy, err := NewDataSourceProxy(ds, plugin, ctx, "/render", &setting.Cfg{}, nil)
 				So(err, ShouldBeNil)
 
 				proxy.HandleRequest()
@@ -568,7 +569,7 @@ func TestNewDataSourceProxy_InvalidURL(t *testing.T) {
 	}
 	cfg := setting.Cfg{}
 	plugin := plugins.DataSourcePlugin{}
-	_, err := NewDataSourceProxy(&ds, &plugin, &ctx, "api/method", &cfg)
+	_, err := NewDataSourceProxy(&ds, &plugin, &ctx, "api/method", &cfg, nil)
 	require.Error(t, err)
 	assert.True(t, strings.HasPrefix(err.Error(), `Validation of data source URL "://host/root" failed`))
 }
@@ -587,7 +588,7 @@ func TestNewDataSourceProxy_ProtocolLessURL(t *testing.T) {
 	cfg := setting.Cfg{}
 	plugin := plugins.DataSourcePlugin{}
 
-	_, err := NewDataSourceProxy(&ds, &plugin, &ctx, "api/method", &cfg)
+	_, err := NewDataSourceProxy(&ds, &plugin, &ctx, "api/method", &cfg, nil)
 
 	require.NoError(t, err)
 }
@@ -627,7 +628,7 @@ func TestNewDataSourceProxy_MSSQL(t *testing.T) {
 				Url:  tc.url,
 			}
 
-			p, err := NewDataSourceProxy(&ds, &plugin, &ctx, "api/method", &cfg)
+			p, err := NewDataSourceProxy(&ds, &plugin, &ctx, "api/method", &cfg, nil)
 			if tc.err == nil {
 				require.NoError(t, err)
 				assert.Equal(t, &url.URL{
@@ -665,7 +666,7 @@ func getDatasourceProxiedRequest(ctx *models.ReqContext, cfg *setting.Cfg) *http
 		Url:  "http://host/root/",
 	}
 
-	proxy, err := NewDataSourceProxy(ds, plugin, ctx, "", cfg)
+	proxy, err := NewDataSourceProxy(ds, plugin, ctx, "", cfg, nil)
 	So(err, ShouldBeNil)
 	req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
 	So(err, ShouldBeNil)
@@ -775,7 +776,7 @@ func createAuthTest(dsType string, authType string, authCheck string, useSecureJ
 func runDatasourceAuthTest(test *Test) {
 	plugin := &plugins.DataSourcePlugin{}
 	ctx := &models.ReqContext{}
-	proxy, err := NewDataSourceProxy(test.datasource, plugin, ctx, "", &setting.Cfg{})
+	proxy, err := NewDataSourceProxy(test.datasource, plugin, ctx, "", &setting.Cfg{}, nil)
 	So(err, ShouldBeNil)
 
 	req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
@@ -785,3 +786,133 @@ func runDatasourceAuthTest(test *Test) {
 
 	test.checkReq(req)
 }
+
+func TestDataSourceProxy_AuditLog(t *testing.T) {
+	var records []*log15.Record
+	origHandler := auditLogger.GetHandler()
+	auditLogger.SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	t.Cleanup(func() { auditLogger.SetHandler(origHandler) })
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		_, err := w.Write([]byte("I am the backend"))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(backend.Close)
+
+	ds := &models.DataSource{Uid: "ds-uid", Url: backend.URL, Type: models.DS_GRAPHITE}
+	newCtx := func() *models.ReqContext {
+		responseRecorder := &CloseNotifierResponseRecorder{ResponseRecorder: httptest.NewRecorder()}
+		t.Cleanup(responseRecorder.Close)
+
+		return &models.ReqContext{
+			SignedInUser: &models.SignedInUser{UserId: 2, OrgId: 3, Login: "auditor"},
+			Context: &macaron.Context{
+				Req: macaron.Request{
+					Request: httptest.NewRequest("POST", "/api/datasources/proxy/1/render", strings.NewReader("target=a")),
+				},
+				Resp: macaron.NewResponseWriter("POST", responseRecorder),
+			},
+		}
+	}
+
+	t.Run("Logs nothing when audit logging is disabled", func(t *testing.T) {
+		records = nil
+		proxy, err := NewDataSourceProxy(ds, &plugins.DataSourcePlugin{}, newCtx(), "/render", &setting.Cfg{}, nil)
+		require.NoError(t, err)
+
+		proxy.HandleRequest()
+
+		assert.Empty(t, records)
+	})
+
+	t.Run("Logs the fields of the request", func(t *testing.T) {
+		records = nil
+		proxy, err := NewDataSourceProxy(ds, &plugins.DataSourcePlugin{}, newCtx(), "/render", &setting.Cfg{DataProxyAuditLogging: true}, nil)
+		require.NoError(t, err)
+
+		proxy.HandleRequest()
+
+		require.Len(t, records, 1)
+		assert.Equal(t, "Data proxy request", records[0].Msg)
+		fields := map[string]interface{}{}
+		for i := 0; i+1 < len(records[0].Ctx); i += 2 {
+			fields[records[0].Ctx[i].(string)] = records[0].Ctx[i+1]
+		}
+		assert.Equal(t, int64(2), fields["userid"])
+		assert.Equal(t, int64(3), fields["orgid"])
+		assert.Equal(t, "auditor", fields["username"])
+		assert.Equal(t, "ds-uid", fields["datasource_uid"])
+		assert.Equal(t, models.DS_GRAPHITE, fields["datasource"])
+		assert.Equal(t, "POST", fields["method"])
+		assert.Equal(t, "/render", fields["path"])
+		assert.Equal(t, 200, fields["status"])
+		assert.Equal(t, int64(8), fields["request_size"])
+		assert.Equal(t, len("I am the backend"), fields["size"])
+		assert.Contains(t, fields, "time_ms")
+	})
+}
+
+func TestDataSourceProxy_RecordDashboardQueries(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	var recorded []*models.RecordDashboardQueriesCommand
+	bus.AddHandler("test", func(cmd *models.RecordDashboardQueriesCommand) error {
+		recorded = append(recorded, cmd)
+		return nil
+	})
+
+	status := 200
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(backend.Close)
+
+	ds := &models.DataSource{Url: backend.URL, Type: models.DS_PROMETHEUS}
+	handle := func(t *testing.T, dashboardId string) {
+		t.Helper()
+		responseRecorder := &CloseNotifierResponseRecorder{ResponseRecorder: httptest.NewRecorder()}
+		t.Cleanup(responseRecorder.Close)
+
+		req := httptest.NewRequest("GET", "/api/datasources/proxy/1/api/v1/query", nil)
+		if dashboardId != "" {
+			req.Header.Set("X-Dashboard-Id", dashboardId)
+		}
+		ctx := &models.ReqContext{
+			SignedInUser: &models.SignedInUser{UserId: 2, OrgId: 3},
+			Context: &macaron.Context{
+				Req:  macaron.Request{Request: req},
+				Resp: macaron.NewResponseWriter("GET", responseRecorder),
+			},
+		}
+		proxy, err := NewDataSourceProxy(ds, &plugins.DataSourcePlugin{}, ctx, "/api/v1/query", &setting.Cfg{}, nil)
+		require.NoError(t, err)
+		proxy.HandleRequest()
+	}
+
+	t.Run("records the query of the dashboard in the organization of the user", func(t *testing.T) {
+		recorded, status = nil, 200
+		handle(t, "7")
+
+		require.Len(t, recorded, 1)
+		assert.Equal(t, models.RecordDashboardQueriesCommand{DashboardId: 7, OrgId: 3, Queries: 1}, *recorded[0])
+	})
+
+	t.Run("records the query as an error when the data source fails", func(t *testing.T) {
+		recorded, status = nil, 500
+		handle(t, "7")
+
+		require.Len(t, recorded, 1)
+		assert.EqualValues(t, 1, recorded[0].Errors)
+	})
+
+	t.Run("records nothing without the dashboard header", func(t *testing.T) {
+		recorded, status = nil, 200
+		handle(t, "")
+
+		assert.Empty(t, recorded)
+	})
+}
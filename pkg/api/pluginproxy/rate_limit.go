@@ -0,0 +1,126 @@
+package pluginproxy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// rateLimiterIdleTimeout is how long the bucket of a user is kept after its last request
+const rateLimiterIdleTimeout = 10 * time.Minute
+
+var rateLimiters = newRateLimiterRegistry(time.Now)
+
+// rateLimitRule limits the requests of each user to the paths of a data
+// source starting with Path. Rules are declared in the rateLimits array of
+// the data source jsonData.
+type rateLimitRule struct {
+	Path              string
+	RequestsPerSecond float64
+	Burst             int
+}
+
+// getRateLimitRule returns the rule with the longest path matching proxyPath.
+func getRateLimitRule(jsonData *simplejson.Json, proxyPath string) (*rateLimitRule, bool) {
+	if jsonData == nil {
+		return nil, false
+	}
+
+	var match *rateLimitRule
+	for _, item := range jsonData.Get("rateLimits").MustArray() {
+		rule := simplejson.NewFromAny(item)
+		path := strings.TrimPrefix(rule.Get("path").MustString(), "/")
+		rps := rule.Get("requestsPerSecond").MustFloat64(0)
+		if rps <= 0 || !strings.HasPrefix(strings.TrimPrefix(proxyPath, "/"), path) {
+			continue
+		}
+		if match != nil && len(match.Path) >= len(path) {
+			continue
+		}
+
+		burst := rule.Get("burst").MustInt(0)
+		if burst < 1 {
+			burst = int(math.Max(1, math.Ceil(rps)))
+		}
+		match = &rateLimitRule{Path: path, RequestsPerSecond: rps, Burst: burst}
+	}
+
+	return match, match != nil
+}
+
+// tokenBucket holds up to burst tokens, refilled at rate tokens per second.
+type tokenBucket struct {
+	tokens   float64
+	last     time.Time
+	lastSeen time.Time
+}
+
+// take removes a token from the bucket, or returns how long to wait for the
+// next token when the bucket is empty.
+func (b *tokenBucket) take(rule *rateLimitRule, now time.Time) (bool, time.Duration) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(rule.Burst), b.tokens+elapsed*rule.RequestsPerSecond)
+	b.last = now
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := (1 - b.tokens) / rule.RequestsPerSecond
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// rateLimiterRegistry holds a token bucket per user, data source and rule.
+type rateLimiterRegistry struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+func newRateLimiterRegistry(now func() time.Time) *rateLimiterRegistry {
+	return &rateLimiterRegistry{
+		now:     now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow returns true if the user can send a request to the data source, or
+// how long to wait before retrying.
+func (r *rateLimiterRegistry) allow(orgID, userID, datasourceID int64, rule *rateLimitRule) (bool, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.prune(now)
+
+	// A change of the rule gets a new bucket
+	key := fmt.Sprintf("%d-%d-%d-%s-%g-%d", orgID, userID, datasourceID, rule.Path, rule.RequestsPerSecond, rule.Burst)
+	bucket, ok := r.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(rule.Burst), last: now}
+		r.buckets[key] = bucket
+	}
+
+	return bucket.take(rule, now)
+}
+
+// prune removes the buckets of users who have been idle, at most once a minute.
+func (r *rateLimiterRegistry) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < time.Minute {
+		return
+	}
+	r.lastPrune = now
+
+	for key, bucket := range r.buckets {
+		if now.Sub(bucket.lastSeen) > rateLimiterIdleTimeout {
+			delete(r.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package pluginproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	macaron "gopkg.in/macaron.v1"
+)
+
+func TestGetRateLimitRule(t *testing.T) {
+	jsonData := simplejson.NewFromAny(map[string]interface{}{
+		"rateLimits": []interface{}{
+			map[string]interface{}{"requestsPerSecond": 10, "burst": 20},
+			map[string]interface{}{"path": "/api/v1/query", "requestsPerSecond": 0.5},
+			map[string]interface{}{"path": "api/v1/series", "requestsPerSecond": 0},
+		},
+	})
+
+	rule, ok := getRateLimitRule(jsonData, "api/v1/query_range")
+	require.True(t, ok)
+	assert.Equal(t, &rateLimitRule{Path: "api/v1/query", RequestsPerSecond: 0.5, Burst: 1}, rule)
+
+	rule, ok = getRateLimitRule(jsonData, "api/v1/series")
+	require.True(t, ok)
+	assert.Equal(t, &rateLimitRule{Path: "", RequestsPerSecond: 10, Burst: 20}, rule)
+
+	_, ok = getRateLimitRule(simplejson.New(), "api/v1/query")
+	assert.False(t, ok)
+	_, ok = getRateLimitRule(nil, "api/v1/query")
+	assert.False(t, ok)
+}
+
+func TestRateLimiterRegistry(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	registry := newRateLimiterRegistry(func() time.Time { return now })
+	rule := &rateLimitRule{RequestsPerSecond: 2, Burst: 3}
+
+	t.Run("allows bursts then refills the bucket over time", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			allowed, _ := registry.allow(1, 1, 1, rule)
+			assert.True(t, allowed)
+		}
+
+		allowed, wait := registry.allow(1, 1, 1, rule)
+		assert.False(t, allowed)
+		assert.Equal(t, 500*time.Millisecond, wait)
+
+		now = now.Add(500 * time.Millisecond)
+		allowed, _ = registry.allow(1, 1, 1, rule)
+		assert.True(t, allowed)
+	})
+
+	t.Run("limits each user and data source separately", func(t *testing.T) {
+		allowed, _ := registry.allow(1, 2, 1, rule)
+		assert.True(t, allowed)
+		allowed, _ = registry.allow(1, 1, 2, rule)
+		assert.True(t, allowed)
+	})
+
+	t.Run("removes idle buckets", func(t *testing.T) {
+		now = now.Add(rateLimiterIdleTimeout + time.Minute)
+		registry.allow(1, 3, 1, rule)
+		assert.Len(t, registry.buckets, 1)
+	})
+}
+
+func TestDataSourceProxyRateLimit(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	ds := &models.DataSource{
+		Id:   1001,
+		Uid:  "limited",
+		Url:  backend.URL,
+		Type: "custom",
+		JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"rateLimits": []interface{}{
+				map[string]interface{}{"path": "api/query", "requestsPerSecond": 0.1},
+			},
+		}),
+	}
+
+	request := func(userID int64, proxyPath string) *httptest.ResponseRecorder {
+		m := macaron.New()
+		m.Use(macaron.Renderer())
+		m.Get("/*", func(c *macaron.Context) {
+			ctx := &models.ReqContext{
+				SignedInUser: &models.SignedInUser{UserId: userID, OrgId: 1},
+				Context:      c,
+			}
+			proxy, err := NewDataSourceProxy(ds, &plugins.DataSourcePlugin{}, ctx, proxyPath, &setting.Cfg{DataProxyAuditLogging: true}, nil)
+			require.NoError(t, err)
+			proxy.HandleRequest()
+		})
+
+		recorder := &CloseNotifierResponseRecorder{ResponseRecorder: httptest.NewRecorder()}
+		m.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/datasources/proxy/1001/"+proxyPath, nil))
+		return recorder.ResponseRecorder
+	}
+
+	assert.Equal(t, 200, request(1, "api/query").Code)
+
+	limited := request(1, "api/query")
+	assert.Equal(t, 429, limited.Code)
+	assert.Equal(t, "10", limited.Header().Get("Retry-After"))
+
+	assert.Equal(t, 200, request(1, "api/labels").Code)
+	assert.Equal(t, 200, request(2, "api/query").Code)
+}
@@ -0,0 +1,248 @@
+package pluginproxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// maxCachedResponseSize is the size above which proxied responses are not cached
+const maxCachedResponseSize = 1 << 20
+
+const cacheStatusHeader = "X-Grafana-Cache"
+
+func init() {
+	remotecache.Register(cachedResponse{})
+}
+
+// cachedResponse is a proxied response stored in the response cache
+type cachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// cacheable returns true for successful responses that the backend allows to
+// store in a shared cache
+func (r cachedResponse) cacheable() bool {
+	if r.StatusCode != http.StatusOK || len(r.Body) > maxCachedResponseSize {
+		return false
+	}
+
+	for _, directive := range strings.Split(r.Header.Get("Cache-Control"), ",") {
+		name := strings.ToLower(strings.TrimSpace(strings.SplitN(directive, "=", 2)[0]))
+		switch name {
+		case "no-store", "no-cache", "private":
+			return false
+		}
+	}
+	return true
+}
+
+func (r cachedResponse) writeTo(w http.ResponseWriter, cacheStatus string) {
+	for k, vv := range r.Header {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
+	w.Header().Set(cacheStatusHeader, cacheStatus)
+	w.WriteHeader(r.StatusCode)
+	if _, err := w.Write(r.Body); err != nil {
+		logger.Error("Failed to write cached data proxy response", "error", err)
+	}
+}
+
+type responseCacheStorage interface {
+	get(key string) (cachedResponse, bool)
+	set(key string, res cachedResponse, ttl time.Duration)
+}
+
+type memoryResponseStorage struct {
+	cache *localcache.CacheService
+}
+
+func (s *memoryResponseStorage) get(key string) (cachedResponse, bool) {
+	value, ok := s.cache.Get(key)
+	if !ok {
+		return cachedResponse{}, false
+	}
+	res, ok := value.(cachedResponse)
+	return res, ok
+}
+
+func (s *memoryResponseStorage) set(key string, res cachedResponse, ttl time.Duration) {
+	s.cache.Set(key, res, ttl)
+}
+
+type remoteResponseStorage struct {
+	cache remotecache.CacheStorage
+}
+
+func (s *remoteResponseStorage) get(key string) (cachedResponse, bool) {
+	value, err := s.cache.Get(key)
+	if err != nil {
+		if err != remotecache.ErrCacheItemNotFound {
+			logger.Warn("Failed to read data proxy response from remote cache", "error", err)
+		}
+		return cachedResponse{}, false
+	}
+	res, ok := value.(cachedResponse)
+	return res, ok
+}
+
+func (s *remoteResponseStorage) set(key string, res cachedResponse, ttl time.Duration) {
+	if err := s.cache.Set(key, res, ttl); err != nil {
+		logger.Warn("Failed to write data proxy response to remote cache", "error", err)
+	}
+}
+
+// ResponseCache caches proxied GET responses of plugin routes that declare a
+// cacheTTL, and coalesces concurrent identical requests into a single
+// request to the data source.
+type ResponseCache struct {
+	storage responseCacheStorage
+	group   singleflight.Group
+}
+
+// NewResponseCache returns the response cache configured by the
+// [dataproxy] response_cache setting, or nil if caching is disabled.
+func NewResponseCache(cfg *setting.Cfg, localCache *localcache.CacheService, remoteCache remotecache.CacheStorage) *ResponseCache {
+	switch cfg.DataProxyResponseCache {
+	case "memory":
+		return &ResponseCache{storage: &memoryResponseStorage{cache: localCache}}
+	case "remote":
+		return &ResponseCache{storage: &remoteResponseStorage{cache: remoteCache}}
+	}
+	return nil
+}
+
+// serve writes the cached response for key to w. On a cache miss, forward
+// proxies the request to the data source, unless an identical request is
+// already in flight, in which case its response is shared.
+func (c *ResponseCache) serve(w http.ResponseWriter, key string, ttl time.Duration, forward func(w http.ResponseWriter)) {
+	if res, ok := c.storage.get(key); ok {
+		metrics.MDataSourceProxyCacheTotal.WithLabelValues("hit").Inc()
+		res.writeTo(w, "HIT")
+		return
+	}
+
+	leader := false
+	value, _, _ := c.group.Do(key, func() (interface{}, error) {
+		leader = true
+		buffer := newResponseBuffer()
+		forward(buffer)
+
+		res := buffer.response()
+		if res.cacheable() {
+			c.storage.set(key, res, ttl)
+		}
+		return res, nil
+	})
+
+	if leader {
+		metrics.MDataSourceProxyCacheTotal.WithLabelValues("miss").Inc()
+	} else {
+		metrics.MDataSourceProxyCacheTotal.WithLabelValues("coalesced").Inc()
+	}
+	value.(cachedResponse).writeTo(w, "MISS")
+}
+
+// responseCacheKey returns the key identifying the response to a request.
+// Every input the director uses to build the request to the data source is
+// part of the key, so that users are never served a response they could not
+// have gotten themselves.
+func (proxy *DataSourceProxy) responseCacheKey() string {
+	req := proxy.ctx.Req.Request
+
+	hash := sha256.New()
+	write := func(values ...string) {
+		for _, v := range values {
+			fmt.Fprintf(hash, "%d:%s", len(v), v)
+		}
+	}
+
+	write(proxy.proxyPath, req.URL.Query().Encode())
+	write(req.Header.Get("Authorization"), req.Header.Get("X-DS-Authorization"))
+	write(req.Header.Get("Accept"), req.Header.Get("Accept-Encoding"))
+
+	if proxy.ds.JsonData != nil {
+		for _, name := range proxy.ds.JsonData.Get("keepCookies").MustStringArray() {
+			if cookie, err := req.Cookie(name); err == nil {
+				write(name, cookie.Value)
+			}
+		}
+	}
+
+	oauthPassThru := proxy.ds.JsonData != nil && proxy.ds.JsonData.Get("oauthPassThru").MustBool()
+	if oauthPassThru || proxy.cfg.SendUserHeader {
+		write(fmt.Sprint(proxy.ctx.SignedInUser.UserId), proxy.ctx.SignedInUser.Login)
+	}
+
+	return fmt.Sprintf("dataproxy-%d-%d-%d-%s", proxy.ctx.SignedInUser.OrgId, proxy.ds.Id, proxy.ds.Version,
+		hex.EncodeToString(hash.Sum(nil)))
+}
+
+// responseCacheTTL returns how long the response to the request can be
+// cached, which is zero for requests that are not cacheable.
+func (proxy *DataSourceProxy) responseCacheTTL() time.Duration {
+	if proxy.cache == nil || proxy.route == nil || proxy.route.CacheTTL == "" {
+		return 0
+	}
+	if proxy.ctx.Req.Method != http.MethodGet {
+		return 0
+	}
+
+	ttl, err := time.ParseDuration(proxy.route.CacheTTL)
+	if err != nil {
+		logger.Warn("Invalid cacheTTL of plugin route", "path", proxy.route.Path, "cacheTTL", proxy.route.CacheTTL, "error", err)
+		return 0
+	}
+	return ttl
+}
+
+// responseBuffer is a http.ResponseWriter keeping the response in memory
+type responseBuffer struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: http.Header{}}
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if b.statusCode == 0 {
+		b.WriteHeader(http.StatusOK)
+	}
+	return b.body.Write(p)
+}
+
+func (b *responseBuffer) WriteHeader(statusCode int) {
+	if b.statusCode == 0 {
+		b.statusCode = statusCode
+	}
+}
+
+func (b *responseBuffer) response() cachedResponse {
+	statusCode := b.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	return cachedResponse{StatusCode: statusCode, Header: b.header, Body: b.body.Bytes()}
+}
@@ -0,0 +1,163 @@
+package pluginproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	macaron "gopkg.in/macaron.v1"
+)
+
+func TestNewResponseCache(t *testing.T) {
+	assert.Nil(t, NewResponseCache(&setting.Cfg{}, nil, nil))
+	assert.NotNil(t, NewResponseCache(&setting.Cfg{DataProxyResponseCache: "memory"}, localcache.New(time.Minute, time.Minute), nil))
+}
+
+func TestDataSourceProxyResponseCache(t *testing.T) {
+	var backendCalls int32
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&backendCalls, 1)
+		if r.URL.Path == "/api/slow" {
+			<-release
+		}
+		if r.URL.Path == "/api/missing" {
+			w.WriteHeader(404)
+		}
+		if cacheControl := r.URL.Query().Get("cache-control"); cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		_, _ = w.Write([]byte(`{"values":["` + r.URL.Query().Get("match") + `"]}`))
+	}))
+	defer backend.Close()
+
+	plugin := &plugins.DataSourcePlugin{
+		Routes: []*plugins.AppPluginRoute{
+			{Path: "api/labels", URL: backend.URL + "/api/labels", CacheTTL: "1m"},
+			{Path: "api/slow", URL: backend.URL + "/api/slow", CacheTTL: "1m"},
+			{Path: "api/missing", URL: backend.URL + "/api/missing", CacheTTL: "1m"},
+			{Path: "api/query", URL: backend.URL + "/api/query"},
+		},
+	}
+	ds := &models.DataSource{Id: 1, Url: backend.URL, Type: "custom", JsonData: simplejson.New()}
+
+	newCache := func() *ResponseCache {
+		return NewResponseCache(&setting.Cfg{DataProxyResponseCache: "memory"}, localcache.New(time.Minute, time.Minute), nil)
+	}
+
+	request := func(cache *ResponseCache, method, proxyPath string, header http.Header) *httptest.ResponseRecorder {
+		recorder := &CloseNotifierResponseRecorder{ResponseRecorder: httptest.NewRecorder()}
+		req := httptest.NewRequest(method, "/api/datasources/proxy/1/"+proxyPath, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		ctx := &models.ReqContext{
+			SignedInUser: &models.SignedInUser{UserId: 1, OrgId: 1},
+			Context: &macaron.Context{
+				Req:  macaron.Request{Request: req},
+				Resp: macaron.NewResponseWriter(method, recorder),
+			},
+		}
+
+		proxy, err := NewDataSourceProxy(ds, plugin, ctx, proxyPath, &setting.Cfg{}, cache)
+		require.NoError(t, err)
+		proxy.HandleRequest()
+		return recorder.ResponseRecorder
+	}
+
+	t.Run("caches GET responses of routes with a cache TTL", func(t *testing.T) {
+		atomic.StoreInt32(&backendCalls, 0)
+		cache := newCache()
+
+		first := request(cache, "GET", "api/labels?match=up", nil)
+		second := request(cache, "GET", "api/labels?match=up", nil)
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&backendCalls))
+		assert.Equal(t, "MISS", first.Header().Get(cacheStatusHeader))
+		assert.Equal(t, "HIT", second.Header().Get(cacheStatusHeader))
+		assert.Equal(t, 200, second.Code)
+		assert.Equal(t, `{"values":["up"]}`, second.Body.String())
+	})
+
+	t.Run("keys the cache on the query string and authorization", func(t *testing.T) {
+		atomic.StoreInt32(&backendCalls, 0)
+		cache := newCache()
+
+		request(cache, "GET", "api/labels?match=up", nil)
+		other := request(cache, "GET", "api/labels?match=down", nil)
+		request(cache, "GET", "api/labels?match=up", http.Header{"X-Ds-Authorization": {"Bearer other"}})
+
+		assert.Equal(t, int32(3), atomic.LoadInt32(&backendCalls))
+		assert.Equal(t, `{"values":["down"]}`, other.Body.String())
+	})
+
+	t.Run("does not cache errors, other methods or routes without a cache TTL", func(t *testing.T) {
+		atomic.StoreInt32(&backendCalls, 0)
+		cache := newCache()
+
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, 404, request(cache, "GET", "api/missing", nil).Code)
+			request(cache, "POST", "api/labels", nil)
+			assert.Empty(t, request(cache, "GET", "api/query", nil).Header().Get(cacheStatusHeader))
+			request(nil, "GET", "api/labels", nil)
+		}
+
+		assert.Equal(t, int32(8), atomic.LoadInt32(&backendCalls))
+	})
+
+	t.Run("does not cache responses the backend doesn't allow to share", func(t *testing.T) {
+		for _, cacheControl := range []string{"no-store", "no-cache", "private", "Private, max-age=60", `private="set-cookie"`} {
+			atomic.StoreInt32(&backendCalls, 0)
+			cache := newCache()
+
+			path := "api/labels?cache-control=" + url.QueryEscape(cacheControl)
+			request(cache, "GET", path, nil)
+			second := request(cache, "GET", path, nil)
+
+			assert.Equal(t, int32(2), atomic.LoadInt32(&backendCalls), cacheControl)
+			assert.Equal(t, "MISS", second.Header().Get(cacheStatusHeader), cacheControl)
+		}
+
+		atomic.StoreInt32(&backendCalls, 0)
+		cache := newCache()
+		request(cache, "GET", "api/labels?cache-control=public,max-age=60", nil)
+		request(cache, "GET", "api/labels?cache-control=public,max-age=60", nil)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&backendCalls))
+	})
+
+	t.Run("coalesces concurrent identical requests", func(t *testing.T) {
+		atomic.StoreInt32(&backendCalls, 0)
+		cache := newCache()
+
+		var wg sync.WaitGroup
+		bodies := make([]string, 5)
+		for i := range bodies {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				bodies[i] = request(cache, "GET", "api/slow?match=slow", nil).Body.String()
+			}(i)
+		}
+
+		// Give the requests time to wait on the one sent to the backend
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&backendCalls))
+		for _, body := range bodies {
+			assert.Equal(t, `{"values":["slow"]}`, body)
+		}
+	})
+}
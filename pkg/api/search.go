@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -14,6 +15,8 @@ import (
 
 func Search(c *models.ReqContext) Response {
 	query := c.Query("query")
+	text := c.Query("text")
+	textFields := c.QueryStrings("textField")
 	tags := c.QueryStrings("tag")
 	starred := c.Query("starred")
 	limit := c.QueryInt64("limit")
@@ -26,6 +29,12 @@ func Search(c *models.ReqContext) Response {
 		return Error(422, "Limit is above maximum allowed (5000), use page parameter to access hits beyond limit", nil)
 	}
 
+	for _, field := range textFields {
+		if !models.IsDashboardSearchField(field) {
+			return Error(400, fmt.Sprintf("Invalid text field %q", field), nil)
+		}
+	}
+
 	if c.Query("permission") == "Edit" {
 		permission = models.PERMISSION_EDIT
 	}
@@ -47,18 +56,21 @@ func Search(c *models.ReqContext) Response {
 	}
 
 	searchQuery := search.Query{
-		Title:        query,
-		Tags:         tags,
-		SignedInUser: c.SignedInUser,
-		Limit:        limit,
-		Page:         page,
-		IsStarred:    starred == "true",
-		OrgId:        c.OrgId,
-		DashboardIds: dbIDs,
-		Type:         dashboardType,
-		FolderIds:    folderIDs,
-		Permission:   permission,
-		Sort:         sort,
+		Title:         query,
+		Text:          text,
+		TextFields:    textFields,
+		DatasourceUid: c.Query("datasourceUid"),
+		Tags:          tags,
+		SignedInUser:  c.SignedInUser,
+		Limit:         limit,
+		Page:          page,
+		IsStarred:     starred == "true",
+		OrgId:         c.OrgId,
+		DashboardIds:  dbIDs,
+		Type:          dashboardType,
+		FolderIds:     folderIDs,
+		Permission:    permission,
+		Sort:          sort,
 	}
 
 	err := bus.Dispatch(&searchQuery)
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GetDashboardTrash lists the deleted dashboards and folders the user can
+// restore, that is the ones the user can save once restored in their folder.
+func GetDashboardTrash(c *models.ReqContext) Response {
+	query := models.GetDashboardTrashQuery{OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get deleted dashboards", err)
+	}
+
+	result := make([]*models.DashboardTrash, 0, len(query.Result))
+	for _, item := range query.Result {
+		canRestore, err := canRestoreFromTrash(c, item)
+		if err != nil {
+			return Error(500, "Failed to check permissions of deleted dashboard", err)
+		}
+		if canRestore {
+			result = append(result, item)
+		}
+	}
+
+	return JSON(200, result)
+}
+
+// RestoreDashboardFromTrash restores a deleted dashboard or folder with its
+// versions, permissions and alerts.
+func RestoreDashboardFromTrash(c *models.ReqContext) Response {
+	query := models.GetDashboardTrashByIdQuery{OrgId: c.OrgId, Id: c.ParamsInt64(":id")}
+	if err := bus.Dispatch(&query); err != nil {
+		return toDashboardTrashError(err)
+	}
+
+	canRestore, err := canRestoreFromTrash(c, query.Result)
+	if err != nil {
+		return Error(500, "Failed to check permissions of deleted dashboard", err)
+	}
+	if !canRestore {
+		return Error(403, "Access denied to this dashboard", nil)
+	}
+
+	cmd := models.RestoreDashboardFromTrashCommand{OrgId: c.OrgId, Id: query.Result.Id}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toDashboardTrashError(err)
+	}
+
+	dash := cmd.Result
+	return JSON(200, util.DynMap{
+		"id":      dash.Id,
+		"uid":     dash.Uid,
+		"title":   dash.Title,
+		"url":     models.GetDashboardFolderUrl(dash.IsFolder, dash.Uid, dash.Slug),
+		"message": fmt.Sprintf("%s restored", dash.Title),
+	})
+}
+
+// DeleteDashboardFromTrash permanently deletes a deleted dashboard or folder.
+func DeleteDashboardFromTrash(c *models.ReqContext) Response {
+	query := models.GetDashboardTrashByIdQuery{OrgId: c.OrgId, Id: c.ParamsInt64(":id")}
+	if err := bus.Dispatch(&query); err != nil {
+		return toDashboardTrashError(err)
+	}
+
+	cmd := models.DeleteDashboardFromTrashCommand{OrgId: c.OrgId, Id: query.Result.Id}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toDashboardTrashError(err)
+	}
+
+	return JSON(200, util.DynMap{
+		"id":      query.Result.Id,
+		"title":   query.Result.Title,
+		"message": fmt.Sprintf("%s permanently deleted", query.Result.Title),
+	})
+}
+
+// canRestoreFromTrash checks that the user can save a deleted dashboard once
+// restored in its folder, the General folder if its folder was deleted. The
+// permissions kept for the dashboard add up to the ones of its folder, and
+// replace the default ones in the General folder.
+func canRestoreFromTrash(c *models.ReqContext, item *models.DashboardTrash) (bool, error) {
+	folderId := item.FolderId
+	if folderId > 0 {
+		query := models.GetDashboardQuery{OrgId: c.OrgId, Id: folderId}
+		if err := bus.Dispatch(&query); err != nil {
+			if !errors.Is(err, models.ErrDashboardNotFound) {
+				return false, err
+			}
+			folderId = 0
+		}
+	}
+
+	folderGuardian := guardian.New(folderId, c.OrgId, c.SignedInUser)
+
+	aclQuery := models.GetDashboardTrashAclQuery{OrgId: c.OrgId, DashboardId: item.DashboardId}
+	if err := bus.Dispatch(&aclQuery); err != nil {
+		return false, err
+	}
+	if len(aclQuery.Result) == 0 {
+		return folderGuardian.CanSave()
+	}
+
+	acl := aclQuery.Result
+	if folderId > 0 {
+		folderAcl, err := folderGuardian.GetAcl()
+		if err != nil {
+			return false, err
+		}
+		acl = append(append([]*models.DashboardAclInfoDTO{}, folderAcl...), acl...)
+	}
+
+	return guardian.NewWithAcl(item.DashboardId, c.OrgId, c.SignedInUser, acl).CanSave()
+}
+
+func toDashboardTrashError(err error) Response {
+	if errors.Is(err, models.ErrDashboardTrashNotFound) {
+		return Error(404, err.Error(), nil)
+	}
+	if errors.Is(err, models.ErrDashboardTrashRestoreConflict) {
+		return Error(412, err.Error(), nil)
+	}
+	if errors.Is(err, models.ErrDashboardFolderMaxDepthExceeded) || errors.Is(err, models.ErrDashboardFolderCycle) {
+		return Error(400, err.Error(), nil)
+	}
+
+	return Error(500, "Failed to restore or delete dashboard", err)
+}
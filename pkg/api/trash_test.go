@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardTrashAPIEndpoint(t *testing.T) {
+	trash := []*models.DashboardTrash{
+		{Id: 1, OrgId: TestOrgID, DashboardId: 10, Uid: "dash", Title: "Dash", FolderId: 2},
+		{Id: 2, OrgId: TestOrgID, DashboardId: 11, Uid: "deleted-folder", Title: "In deleted folder", FolderId: 3},
+		{Id: 3, OrgId: TestOrgID, DashboardId: 12, Uid: "general", Title: "General"},
+		{Id: 4, OrgId: TestOrgID, DashboardId: 13, Uid: "restricted", Title: "Restricted"},
+		{Id: 5, OrgId: TestOrgID, DashboardId: 14, Uid: "shared", Title: "Shared", FolderId: 2},
+	}
+	keptAcl := map[int64][]*models.DashboardAclInfoDTO{
+		13: {{OrgId: TestOrgID, DashboardId: 13, UserId: 99, Permission: models.PERMISSION_ADMIN}},
+		14: {{OrgId: TestOrgID, DashboardId: 14, UserId: TestUserID, Permission: models.PERMISSION_EDIT}},
+	}
+
+	setup := func(t *testing.T, canSaveIn map[int64]bool) {
+		setupTestScenario(t, func(dashId int64) *guardian.FakeDashboardGuardian {
+			return &guardian.FakeDashboardGuardian{CanSaveValue: canSaveIn[dashId]}
+		})
+
+		bus.AddHandler("test", func(query *models.GetDashboardTrashQuery) error {
+			query.Result = trash
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetDashboardTrashByIdQuery) error {
+			for _, item := range trash {
+				if item.Id == query.Id {
+					query.Result = item
+					return nil
+				}
+			}
+			return models.ErrDashboardTrashNotFound
+		})
+		bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+			if query.Id != 2 {
+				return models.ErrDashboardNotFound
+			}
+			query.Result = &models.Dashboard{Id: 2, IsFolder: true}
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetDashboardTrashAclQuery) error {
+			query.Result = keptAcl[query.DashboardId]
+			return nil
+		})
+	}
+
+	t.Run("lists the dashboards the user can restore", func(t *testing.T) {
+		setup(t, map[int64]bool{2: true, 0: false})
+
+		sc := callAPI(t, models.ROLE_EDITOR, "GET", "/api/trash", "/api/trash", GetDashboardTrash)
+		require.Equal(t, 200, sc.resp.Code)
+
+		var result []models.DashboardTrash
+		require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&result))
+		require.Len(t, result, 2)
+		assert.Equal(t, "Dash", result[0].Title)
+		assert.Equal(t, "Shared", result[1].Title)
+	})
+
+	t.Run("dashboards of deleted folders are restored in the General folder", func(t *testing.T) {
+		setup(t, map[int64]bool{0: true})
+
+		sc := callAPI(t, models.ROLE_EDITOR, "GET", "/api/trash", "/api/trash", GetDashboardTrash)
+		require.Equal(t, 200, sc.resp.Code)
+
+		var result []models.DashboardTrash
+		require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&result))
+		require.Len(t, result, 3)
+		assert.Equal(t, "In deleted folder", result[0].Title)
+		assert.Equal(t, "General", result[1].Title)
+		assert.Equal(t, "Shared", result[2].Title)
+	})
+
+	t.Run("doesn't list or restore a dashboard whose kept permissions deny the user", func(t *testing.T) {
+		setup(t, map[int64]bool{0: true})
+		restored := false
+		bus.AddHandler("test", func(cmd *models.RestoreDashboardFromTrashCommand) error {
+			restored = true
+			return nil
+		})
+
+		sc := callAPI(t, models.ROLE_EDITOR, "GET", "/api/trash", "/api/trash", GetDashboardTrash)
+		require.Equal(t, 200, sc.resp.Code)
+
+		var result []models.DashboardTrash
+		require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&result))
+		for _, item := range result {
+			assert.NotEqual(t, "Restricted", item.Title)
+		}
+
+		sc = callAPI(t, models.ROLE_EDITOR, "POST", "/api/trash/4/restore", "/api/trash/:id/restore", RestoreDashboardFromTrash)
+		assert.Equal(t, 403, sc.resp.Code)
+		assert.False(t, restored)
+	})
+
+	t.Run("restores a dashboard", func(t *testing.T) {
+		setup(t, map[int64]bool{2: true})
+		restored := false
+		bus.AddHandler("test", func(cmd *models.RestoreDashboardFromTrashCommand) error {
+			restored = true
+			cmd.Result = &models.Dashboard{Id: 10, Uid: "dash", Title: "Dash", Slug: "dash", FolderId: 2}
+			return nil
+		})
+
+		sc := callAPI(t, models.ROLE_EDITOR, "POST", "/api/trash/1/restore", "/api/trash/:id/restore", RestoreDashboardFromTrash)
+		require.Equal(t, 200, sc.resp.Code)
+		assert.True(t, restored)
+
+		var result map[string]interface{}
+		require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&result))
+		assert.Equal(t, "dash", result["uid"])
+		assert.Equal(t, "/d/dash/dash", result["url"])
+	})
+
+	t.Run("doesn't restore a dashboard the user can't save", func(t *testing.T) {
+		setup(t, map[int64]bool{})
+		restored := false
+		bus.AddHandler("test", func(cmd *models.RestoreDashboardFromTrashCommand) error {
+			restored = true
+			return nil
+		})
+
+		sc := callAPI(t, models.ROLE_EDITOR, "POST", "/api/trash/1/restore", "/api/trash/:id/restore", RestoreDashboardFromTrash)
+		assert.Equal(t, 403, sc.resp.Code)
+		assert.False(t, restored)
+	})
+
+	t.Run("returns errors of the restore", func(t *testing.T) {
+		testCases := []struct {
+			url          string
+			err          error
+			expectedCode int
+		}{
+			{url: "/api/trash/6/restore", expectedCode: 404},
+			{url: "/api/trash/1/restore", err: models.ErrDashboardTrashRestoreConflict, expectedCode: 412},
+			{url: "/api/trash/1/restore", err: models.ErrDashboardFolderMaxDepthExceeded, expectedCode: 400},
+		}
+
+		for _, tc := range testCases {
+			setup(t, map[int64]bool{2: true})
+			err := tc.err
+			bus.AddHandler("test", func(cmd *models.RestoreDashboardFromTrashCommand) error {
+				return err
+			})
+
+			sc := callAPI(t, models.ROLE_EDITOR, "POST", tc.url, "/api/trash/:id/restore", RestoreDashboardFromTrash)
+			assert.Equal(t, tc.expectedCode, sc.resp.Code, tc.url)
+		}
+	})
+
+	t.Run("permanently deletes a dashboard", func(t *testing.T) {
+		setup(t, map[int64]bool{})
+		var deletedId int64
+		bus.AddHandler("test", func(cmd *models.DeleteDashboardFromTrashCommand) error {
+			deletedId = cmd.Id
+			return nil
+		})
+
+		sc := callAPI(t, models.ROLE_EDITOR, "DELETE", "/api/trash/3", "/api/trash/:id", DeleteDashboardFromTrash)
+		require.Equal(t, 200, sc.resp.Code)
+		assert.EqualValues(t, 3, deletedId)
+	})
+}
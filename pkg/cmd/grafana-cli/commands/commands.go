@@ -62,6 +62,13 @@ func runPluginCommand(command func(commandLine utils.CommandLine) error) func(co
 	}
 }
 
+func runCommand(command func(commandLine utils.CommandLine) error) func(context *cli.Context) error {
+	return func(context *cli.Context) error {
+		cmd := &utils.ContextCommandLine{Context: context}
+		return command(cmd)
+	}
+}
+
 // Command contains command state.
 type Command struct {
 	Client utils.ApiClient
@@ -128,6 +135,26 @@ var adminCommands = []*cli.Command{
 				Usage:  "Migrates passwords from unsecured fields to secure_json_data field. Return ok unless there is an error. Safe to execute multiple times.",
 				Action: runDbCommand(datamigrations.EncryptDatasourcePasswords),
 			},
+			{
+				Name:   "rotate-secret-key",
+				Usage:  "Re-encrypts the secrets of datasources, notification channels, plugin settings and OAuth tokens with the current secret_key. Safe to execute multiple times.",
+				Action: runDbCommand(datamigrations.RotateSecretKey),
+			},
+		},
+	},
+}
+
+var dashboardCommands = []*cli.Command{
+	{
+		Name:   "diff",
+		Usage:  "diff <base dashboard json file> <new dashboard json file>",
+		Action: runCommand(diffDashboardsCommand),
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the diff as JSON",
+				Value: false,
+			},
 		},
 	},
 }
@@ -143,4 +170,9 @@ var Commands = []*cli.Command{
 		Usage:       "Grafana admin commands",
 		Subcommands: adminCommands,
 	},
+	{
+		Name:        "dashboards",
+		Usage:       "Dashboard commands",
+		Subcommands: dashboardCommands,
+	},
 }
@@ -0,0 +1,175 @@
+package datamigrations
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// secureJSONColumn is a column storing a JSON map of encrypted values
+type secureJSONColumn struct {
+	table  string
+	column string
+}
+
+var secureJSONColumns = []secureJSONColumn{
+	{table: "data_source", column: "secure_json_data"},
+	{table: "alert_notification", column: "secure_settings"},
+	{table: "plugin_setting", column: "secure_json_data"},
+}
+
+// oauthTokenColumns are the columns of user_auth storing base64 encoded encrypted values
+var oauthTokenColumns = []string{"o_auth_access_token", "o_auth_refresh_token", "o_auth_token_type"}
+
+// RotateSecretKey re-encrypts the secrets of datasources, alert notification
+// channels, plugin settings and OAuth tokens with the current secret key.
+// Secrets encrypted with a previous secret key are decrypted with the key
+// of [security.previous_secret_keys] matching their key id.
+func RotateSecretKey(c utils.CommandLine, sqlStore *sqlstore.SqlStore) error {
+	keyring := setting.SecretsKeyring()
+
+	return sqlStore.WithTransactionalDbSession(context.Background(), func(session *sqlstore.DBSession) error {
+		logger.Info("\n")
+
+		for _, column := range secureJSONColumns {
+			rowsUpdated, err := rotateSecureJSONColumn(session, keyring, column)
+			if err != nil {
+				return errutil.Wrapf(err, "failed to rotate secrets of %s.%s", column.table, column.column)
+			}
+			logger.Infof("%s Re-encrypted %s of %d rows in %s\n", color.GreenString("✔"), column.column, rowsUpdated, column.table)
+		}
+
+		rowsUpdated, err := rotateOAuthTokens(session, keyring)
+		if err != nil {
+			return errutil.Wrap("failed to rotate secrets of user_auth", err)
+		}
+		logger.Infof("%s Re-encrypted OAuth tokens of %d rows in user_auth\n", color.GreenString("✔"), rowsUpdated)
+
+		logger.Info("\n")
+		logger.Info("Previous secret keys can be removed from [security.previous_secret_keys] once every Grafana instance uses the current secret key.\n")
+		return nil
+	})
+}
+
+func rotateSecureJSONColumn(session *sqlstore.DBSession, keyring *util.Keyring, column secureJSONColumn) (int, error) {
+	var rows []map[string][]byte
+	session.Table(column.table)
+	session.Cols("id", column.column)
+	session.Where(column.column + " IS NOT NULL")
+	if err := session.Find(&rows); err != nil {
+		return 0, err
+	}
+
+	var rowsUpdated int
+	for _, row := range rows {
+		if len(row[column.column]) == 0 {
+			continue
+		}
+
+		var secrets map[string][]byte
+		if err := json.Unmarshal(row[column.column], &secrets); err != nil {
+			return 0, errutil.Wrapf(err, "failed to unmarshal row %s", string(row["id"]))
+		}
+
+		updated := false
+		for key, value := range secrets {
+			rotated, ok, err := rotateSecret(keyring, value)
+			if err != nil {
+				return 0, errutil.Wrapf(err, "failed to rotate %s of row %s", key, string(row["id"]))
+			}
+			if ok {
+				secrets[key] = rotated
+				updated = true
+			}
+		}
+		if !updated {
+			continue
+		}
+
+		data, err := json.Marshal(secrets)
+		if err != nil {
+			return 0, err
+		}
+
+		session.Table(column.table)
+		session.Where("id = ?", string(row["id"]))
+		session.Cols(column.column)
+		if _, err := session.Update(map[string]interface{}{column.column: data}); err != nil {
+			return 0, err
+		}
+		rowsUpdated++
+	}
+
+	return rowsUpdated, nil
+}
+
+func rotateOAuthTokens(session *sqlstore.DBSession, keyring *util.Keyring) (int, error) {
+	var rows []map[string]string
+	session.Table("user_auth")
+	session.Cols(append([]string{"id"}, oauthTokenColumns...)...)
+	if err := session.Find(&rows); err != nil {
+		return 0, err
+	}
+
+	var rowsUpdated int
+	for _, row := range rows {
+		update := make(map[string]interface{})
+		for _, column := range oauthTokenColumns {
+			if row[column] == "" {
+				continue
+			}
+
+			decoded, err := base64.StdEncoding.DecodeString(row[column])
+			if err != nil {
+				return 0, errutil.Wrapf(err, "failed to decode %s of row %s", column, row["id"])
+			}
+
+			rotated, ok, err := rotateSecret(keyring, decoded)
+			if err != nil {
+				return 0, errutil.Wrapf(err, "failed to rotate %s of row %s", column, row["id"])
+			}
+			if ok {
+				update[column] = base64.StdEncoding.EncodeToString(rotated)
+			}
+		}
+		if len(update) == 0 {
+			continue
+		}
+
+		session.Table("user_auth")
+		session.Where("id = ?", row["id"])
+		if _, err := session.Update(update); err != nil {
+			return 0, err
+		}
+		rowsUpdated++
+	}
+
+	return rowsUpdated, nil
+}
+
+// rotateSecret returns the secret encrypted with the current key, or false
+// if it already is.
+func rotateSecret(keyring *util.Keyring, encrypted []byte) ([]byte, bool, error) {
+	if !keyring.NeedsRotation(encrypted) {
+		return nil, false, nil
+	}
+
+	decrypted, err := keyring.Decrypt(encrypted)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rotated, err := keyring.Encrypt(decrypted)
+	if err != nil {
+		return nil, false, err
+	}
+	return rotated, true, nil
+}
@@ -0,0 +1,85 @@
+package datamigrations
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/commandstest"
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateSecretKeyCommand(t *testing.T) {
+	defer func(secretKey, secretKeyID string, previousSecretKeys map[string]string) {
+		setting.SecretKey, setting.SecretKeyID, setting.PreviousSecretKeys = secretKey, secretKeyID, previousSecretKeys
+	}(setting.SecretKey, setting.SecretKeyID, setting.PreviousSecretKeys)
+
+	sqlstore := sqlstore.InitTestDB(t)
+	session := sqlstore.NewSession()
+	defer session.Close()
+
+	// secrets encrypted with the legacy key
+	setting.SecretKey = "old secret"
+	setting.SecretKeyID = ""
+	setting.PreviousSecretKeys = nil
+
+	_, err := session.Insert(&models.DataSource{
+		Type: "prometheus", Name: "prometheus", Uid: "prom", Created: time.Now(), Updated: time.Now(),
+		SecureJsonData: securejsondata.GetEncryptedJsonData(map[string]string{"password": "pwd"}),
+	})
+	require.NoError(t, err)
+	_, err = session.Insert(&models.AlertNotification{
+		Type: "slack", Name: "slack", Uid: "slack", Created: time.Now(), Updated: time.Now(),
+		SecureSettings: securejsondata.GetEncryptedJsonData(map[string]string{"url": "https://hooks.slack.com"}),
+	})
+	require.NoError(t, err)
+	accessToken, err := util.Encrypt([]byte("token"), "old secret")
+	require.NoError(t, err)
+	_, err = session.Insert(&models.UserAuth{
+		UserId: 1, AuthModule: "oauth_generic_oauth", AuthId: "1", Created: time.Now(),
+		OAuthAccessToken: base64.StdEncoding.EncodeToString(accessToken),
+	})
+	require.NoError(t, err)
+
+	// rotate to a new key
+	setting.SecretKey = "new secret"
+	setting.SecretKeyID = "2020-06"
+	setting.PreviousSecretKeys = map[string]string{util.LegacyKeyID: "old secret"}
+
+	c, err := commandstest.NewCliContext(map[string]string{})
+	require.NoError(t, err)
+	require.NoError(t, RotateSecretKey(c, sqlstore))
+	// rotating twice is a no-op
+	require.NoError(t, RotateSecretKey(c, sqlstore))
+
+	newKeyOnly := &util.Keyring{CurrentKeyID: "2020-06", Keys: map[string]string{"2020-06": "new secret"}}
+
+	var ds models.DataSource
+	_, err = session.Table("data_source").Where("uid = ?", "prom").Get(&ds)
+	require.NoError(t, err)
+	password, err := newKeyOnly.Decrypt(ds.SecureJsonData["password"])
+	require.NoError(t, err)
+	assert.Equal(t, "pwd", string(password))
+
+	var notification models.AlertNotification
+	_, err = session.Table("alert_notification").Where("uid = ?", "slack").Get(&notification)
+	require.NoError(t, err)
+	url, err := newKeyOnly.Decrypt(notification.SecureSettings["url"])
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.slack.com", string(url))
+
+	var userAuth models.UserAuth
+	_, err = session.Table("user_auth").Where("user_id = ?", 1).Get(&userAuth)
+	require.NoError(t, err)
+	decoded, err := base64.StdEncoding.DecodeString(userAuth.OAuthAccessToken)
+	require.NoError(t, err)
+	token, err := newKeyOnly.Decrypt(decoded)
+	require.NoError(t, err)
+	assert.Equal(t, "token", string(token))
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var errMissingDashboardFiles = errors.New("please specify the base and the new dashboard JSON files")
+
+// diffDashboardsCommand prints the semantic diff of two dashboard JSON files
+func diffDashboardsCommand(c utils.CommandLine) error {
+	if c.Args().Len() != 2 {
+		return errMissingDashboardFiles
+	}
+
+	base, err := readDashboardFile(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	new, err := readDashboardFile(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	diff := dashdiffs.CompareDashboards(base, new)
+
+	if c.Bool("json") {
+		output, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return err
+		}
+		logger.Info(string(output), "\n")
+		return nil
+	}
+
+	if diff.IsEmpty() {
+		logger.Info("The dashboards have no differences\n")
+		return nil
+	}
+	for _, line := range diff.Summary() {
+		logger.Info(line, "\n")
+	}
+	return nil
+}
+
+// readDashboardFile reads a dashboard JSON file, either the dashboard model
+// or a dashboard returned by the HTTP API with its meta data.
+func readDashboardFile(path string) (*simplejson.Json, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "failed to read dashboard file %s", path)
+	}
+
+	dashboard, err := simplejson.NewJson(data)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "failed to parse dashboard file %s", path)
+	}
+
+	if model, ok := dashboard.CheckGet("dashboard"); ok {
+		return model, nil
+	}
+	return dashboard, nil
+}
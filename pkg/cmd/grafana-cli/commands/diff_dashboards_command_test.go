@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/commandstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffDashboardsCommand(t *testing.T) {
+	t.Run("requires two dashboard files", func(t *testing.T) {
+		c, err := commandstest.NewCliContext(map[string]string{})
+		require.NoError(t, err)
+
+		assert.Equal(t, errMissingDashboardFiles, diffDashboardsCommand(c))
+	})
+
+	t.Run("reads dashboards returned by the HTTP API", func(t *testing.T) {
+		dir := t.TempDir()
+		model := filepath.Join(dir, "model.json")
+		require.NoError(t, ioutil.WriteFile(model, []byte(`{"title": "Hosts"}`), 0600))
+		response := filepath.Join(dir, "response.json")
+		require.NoError(t, ioutil.WriteFile(response, []byte(`{"meta": {"slug": "hosts"}, "dashboard": {"title": "Hosts"}}`), 0600))
+
+		for _, path := range []string{model, response} {
+			dashboard, err := readDashboardFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, "Hosts", dashboard.Get("title").MustString())
+		}
+	})
+
+	t.Run("fails for invalid dashboard files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "invalid.json")
+		require.NoError(t, ioutil.WriteFile(path, []byte(`{`), 0600))
+
+		_, err := readDashboardFile(path)
+		assert.Error(t, err)
+	})
+}
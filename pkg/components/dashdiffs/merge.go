@@ -0,0 +1,261 @@
+package dashdiffs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// MergeDashboards merges the changes made to the base version of a dashboard
+// in two later versions: current, the latest saved version, and incoming, the
+// version being saved. Panels are matched by id, variables and annotations by
+// name, and objects are merged property by property, so changes to different
+// panels, variables or properties don't conflict. Collapsed rows are merged
+// with their panels as a whole.
+//
+// The merged dashboard keeps the id and the version of current. When both
+// versions changed the same property differently, the conflicts are returned
+// and the merged dashboard keeps the value of current.
+func MergeDashboards(base, current, incoming *simplejson.Json) (*simplejson.Json, []*models.DashboardMergeConflict) {
+	m := &merger{conflicts: make([]*models.DashboardMergeConflict, 0)}
+
+	baseMap, currentMap, incomingMap := base.MustMap(), current.MustMap(), incoming.MustMap()
+	merged := make(map[string]interface{})
+	for _, key := range unionKeys(baseMap, currentMap, incomingMap) {
+		var value interface{}
+		switch key {
+		case "id", "version":
+			value = currentMap[key]
+		case "panels":
+			value = m.mergeItems(key, panelMergeKey, panelLabel,
+				arrayValue(baseMap[key]), arrayValue(currentMap[key]), arrayValue(incomingMap[key]))
+		case "templating":
+			value = m.mergeNamedList(key, "variable", baseMap[key], currentMap[key], incomingMap[key])
+		case "annotations":
+			value = m.mergeNamedList(key, "annotation", baseMap[key], currentMap[key], incomingMap[key])
+		default:
+			value = m.mergeValue(key, mergeItem{label: "dashboard"}, baseMap[key], currentMap[key], incomingMap[key])
+		}
+
+		if value != nil {
+			merged[key] = value
+		}
+	}
+
+	return simplejson.NewFromAny(merged), m.conflicts
+}
+
+type merger struct {
+	conflicts []*models.DashboardMergeConflict
+}
+
+// mergeItem is the dashboard, panel, variable or annotation being merged,
+// used to describe the conflicts.
+type mergeItem struct {
+	label string
+	path  string
+}
+
+// mergeValue returns the value changed by either version, merging objects
+// changed by both property by property.
+func (m *merger) mergeValue(path string, item mergeItem, base, current, incoming interface{}) interface{} {
+	switch {
+	case equalValues(current, incoming):
+		return current
+	case equalValues(base, current):
+		return incoming
+	case equalValues(base, incoming):
+		return current
+	}
+
+	baseMap, baseOk := base.(map[string]interface{})
+	currentMap, currentOk := current.(map[string]interface{})
+	incomingMap, incomingOk := incoming.(map[string]interface{})
+	if baseOk && currentOk && incomingOk {
+		merged := make(map[string]interface{})
+		for _, key := range unionKeys(baseMap, currentMap, incomingMap) {
+			if value := m.mergeValue(path+"."+key, item, baseMap[key], currentMap[key], incomingMap[key]); value != nil {
+				merged[key] = value
+			}
+		}
+		return merged
+	}
+
+	m.conflicts = append(m.conflicts, &models.DashboardMergeConflict{
+		Path:        path,
+		Description: describeConflict(path, item, base, current, incoming),
+		Base:        base,
+		Current:     current,
+		Incoming:    incoming,
+	})
+	return current
+}
+
+// mergeItems merges the panels, variables or annotations of the dashboard.
+// The merged items are in the order of incoming, the ones added by current
+// being inserted after the item preceding them in current.
+func (m *merger) mergeItems(path string, key func(item map[string]interface{}, index int) string, label func(item map[string]interface{}) string, base, current, incoming []interface{}) []interface{} {
+	baseItems := itemsByKey(base, key)
+	currentItems := itemsByKey(current, key)
+	incomingItems := itemsByKey(incoming, key)
+
+	mergeKey := func(k string) interface{} {
+		item := incomingItems[k]
+		if item == nil {
+			item = currentItems[k]
+		}
+		if item == nil {
+			item = baseItems[k]
+		}
+
+		itemPath := path + "." + k
+		return m.mergeValue(itemPath, mergeItem{label: label(item), path: itemPath},
+			itemValue(baseItems, k), itemValue(currentItems, k), itemValue(incomingItems, k))
+	}
+
+	merged := make([]interface{}, 0, len(incoming))
+	mergedKeys := make([]string, 0, len(incoming))
+	for _, k := range orderedKeys(incoming, key) {
+		if value := mergeKey(k); value != nil {
+			merged = append(merged, value)
+			mergedKeys = append(mergedKeys, k)
+		}
+	}
+
+	index := 0
+	for _, k := range orderedKeys(current, key) {
+		if _, ok := incomingItems[k]; !ok {
+			if value := mergeKey(k); value != nil {
+				merged = append(merged[:index], append([]interface{}{value}, merged[index:]...)...)
+				mergedKeys = append(mergedKeys[:index], append([]string{k}, mergedKeys[index:]...)...)
+			}
+		}
+		for i, mergedKey := range mergedKeys {
+			if mergedKey == k {
+				index = i + 1
+				break
+			}
+		}
+	}
+
+	return merged
+}
+
+// mergeNamedList merges the list of variables or annotations of the
+// dashboard by name. The other properties are the ones of current.
+func (m *merger) mergeNamedList(path string, kind string, base, current, incoming interface{}) interface{} {
+	if current == nil && incoming == nil {
+		return nil
+	}
+
+	merged := make(map[string]interface{})
+	for key, value := range mapValue(current) {
+		merged[key] = value
+	}
+
+	label := func(item map[string]interface{}) string {
+		return fmt.Sprintf("%s %q", kind, stringValue(item["name"]))
+	}
+	key := func(item map[string]interface{}, index int) string {
+		if name := stringValue(item["name"]); name != "" {
+			return name
+		}
+		return fmt.Sprintf("#%d", index)
+	}
+	merged["list"] = m.mergeItems(path+".list", key, label,
+		arrayValue(mapValue(base)["list"]), arrayValue(mapValue(current)["list"]), arrayValue(mapValue(incoming)["list"]))
+
+	return merged
+}
+
+func describeConflict(path string, item mergeItem, base, current, incoming interface{}) string {
+	if path != item.path {
+		property := strings.TrimPrefix(path, item.path+".")
+		if item.path == "" {
+			property = path
+		}
+		return fmt.Sprintf("%s: %s changed in both versions", item.label, property)
+	}
+
+	switch {
+	case base == nil:
+		return item.label + " added in both versions"
+	case current == nil || incoming == nil:
+		return item.label + " removed in one version and changed in the other"
+	}
+	return item.label + " changed in both versions"
+}
+
+// panelMergeKey matches panels by id, or by title for panels without id
+func panelMergeKey(panel map[string]interface{}, index int) string {
+	if id, ok := panelId(panel); ok {
+		return fmt.Sprint(id)
+	}
+	if title := stringValue(panel["title"]); title != "" {
+		return "title:" + title
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+func panelLabel(panel map[string]interface{}) string {
+	if id, ok := panelId(panel); ok {
+		return fmt.Sprintf("panel %q (id %d)", stringValue(panel["title"]), id)
+	}
+	return fmt.Sprintf("panel %q", stringValue(panel["title"]))
+}
+
+func itemsByKey(items []interface{}, key func(item map[string]interface{}, index int) string) map[string]map[string]interface{} {
+	byKey := make(map[string]map[string]interface{}, len(items))
+	for i, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			if _, exists := byKey[key(m, i)]; !exists {
+				byKey[key(m, i)] = m
+			}
+		}
+	}
+	return byKey
+}
+
+// itemValue returns the item with the key, nil if there is none
+func itemValue(items map[string]map[string]interface{}, key string) interface{} {
+	if item, ok := items[key]; ok {
+		return item
+	}
+	return nil
+}
+
+func orderedKeys(items []interface{}, key func(item map[string]interface{}, index int) string) []string {
+	keys := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		if m, ok := item.(map[string]interface{}); ok && !seen[key(m, i)] {
+			seen[key(m, i)] = true
+			keys = append(keys, key(m, i))
+		}
+	}
+	return keys
+}
+
+// unionKeys returns the properties of the objects, sorted by name
+func unionKeys(objects ...map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	keys := make([]string, 0)
+	for _, object := range objects {
+		for key := range object {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func arrayValue(value interface{}) []interface{} {
+	a, _ := value.([]interface{})
+	return a
+}
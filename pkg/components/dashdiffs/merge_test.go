@@ -0,0 +1,130 @@
+package dashdiffs
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeDashboards(t *testing.T) {
+	const baseJSON = `{
+		"id": 1,
+		"version": 3,
+		"title": "Hosts",
+		"time": {"from": "now-6h", "to": "now"},
+		"panels": [
+			{"id": 1, "type": "graph", "title": "CPU", "targets": [{"refId": "A", "expr": "cpu"}]},
+			{"id": 2, "type": "graph", "title": "Memory"},
+			{"id": 3, "type": "text", "title": "Notes"}
+		],
+		"templating": {"list": [
+			{"name": "host", "query": "hosts"},
+			{"name": "env", "query": "envs"}
+		]}
+	}`
+
+	parse := func(t *testing.T, data string) *simplejson.Json {
+		json, err := simplejson.NewJson([]byte(data))
+		require.NoError(t, err)
+		return json
+	}
+
+	t.Run("merges changes to different panels and variables", func(t *testing.T) {
+		current := parse(t, `{
+			"id": 1,
+			"version": 4,
+			"title": "Hosts",
+			"time": {"from": "now-6h", "to": "now"},
+			"panels": [
+				{"id": 1, "type": "graph", "title": "CPU", "targets": [{"refId": "A", "expr": "cpu_usage"}]},
+				{"id": 2, "type": "graph", "title": "Memory"},
+				{"id": 4, "type": "stat", "title": "Uptime"},
+				{"id": 3, "type": "text", "title": "Notes"}
+			],
+			"templating": {"list": [
+				{"name": "host", "query": "hosts"},
+				{"name": "env", "query": "environments"}
+			]}
+		}`)
+		incoming := parse(t, `{
+			"id": 1,
+			"version": 3,
+			"title": "Hosts",
+			"time": {"from": "now-24h", "to": "now"},
+			"panels": [
+				{"id": 1, "type": "graph", "title": "CPU load", "targets": [{"refId": "A", "expr": "cpu"}]},
+				{"id": 2, "type": "graph", "title": "Memory"}
+			],
+			"templating": {"list": [
+				{"name": "host", "query": "hosts{env=\"$env\"}"},
+				{"name": "env", "query": "envs"},
+				{"name": "region", "query": "regions"}
+			]}
+		}`)
+
+		merged, conflicts := MergeDashboards(parse(t, baseJSON), current, incoming)
+		assert.Empty(t, conflicts)
+
+		assert.EqualValues(t, 4, merged.Get("version").MustInt())
+		assert.Equal(t, "now-24h", merged.GetPath("time", "from").MustString())
+
+		panels := merged.Get("panels")
+		require.Len(t, panels.MustArray(), 3)
+		assert.Equal(t, "CPU load", panels.GetIndex(0).Get("title").MustString())
+		assert.Equal(t, "cpu_usage", panels.GetIndex(0).Get("targets").GetIndex(0).Get("expr").MustString())
+		assert.Equal(t, "Memory", panels.GetIndex(1).Get("title").MustString())
+		assert.Equal(t, "Uptime", panels.GetIndex(2).Get("title").MustString())
+
+		variables := merged.GetPath("templating", "list")
+		require.Len(t, variables.MustArray(), 3)
+		assert.Equal(t, `hosts{env="$env"}`, variables.GetIndex(0).Get("query").MustString())
+		assert.Equal(t, "environments", variables.GetIndex(1).Get("query").MustString())
+		assert.Equal(t, "region", variables.GetIndex(2).Get("name").MustString())
+	})
+
+	t.Run("returns the conflicting changes", func(t *testing.T) {
+		current := parse(t, `{
+			"id": 1,
+			"version": 4,
+			"title": "Hosts",
+			"time": {"from": "now-6h", "to": "now"},
+			"panels": [
+				{"id": 1, "type": "graph", "title": "CPU", "targets": [{"refId": "A", "expr": "cpu_usage"}]},
+				{"id": 2, "type": "graph", "title": "Memory"},
+				{"id": 3, "type": "text", "title": "Release notes"}
+			],
+			"templating": {"list": [{"name": "host", "query": "hosts"}, {"name": "env", "query": "envs"}]}
+		}`)
+		incoming := parse(t, `{
+			"id": 1,
+			"version": 3,
+			"title": "Hosts",
+			"time": {"from": "now-6h", "to": "now"},
+			"panels": [
+				{"id": 1, "type": "graph", "title": "CPU", "targets": [{"refId": "A", "expr": "cpu_total"}]},
+				{"id": 2, "type": "graph", "title": "Memory"}
+			],
+			"templating": {"list": [{"name": "host", "query": "hosts"}, {"name": "env", "query": "envs"}]}
+		}`)
+
+		_, conflicts := MergeDashboards(parse(t, baseJSON), current, incoming)
+		require.Len(t, conflicts, 2)
+
+		assert.Equal(t, "panels.1.targets", conflicts[0].Path)
+		assert.Equal(t, `panel "CPU" (id 1): targets changed in both versions`, conflicts[0].Description)
+		assert.Equal(t, "panels.3", conflicts[1].Path)
+		assert.Equal(t, `panel "Release notes" (id 3) removed in one version and changed in the other`, conflicts[1].Description)
+		assert.Nil(t, conflicts[1].Incoming)
+	})
+
+	t.Run("the same change in both versions doesn't conflict", func(t *testing.T) {
+		changed := parse(t, baseJSON)
+		changed.Set("title", "Servers")
+
+		merged, conflicts := MergeDashboards(parse(t, baseJSON), changed, changed)
+		assert.Empty(t, conflicts)
+		assert.Equal(t, "Servers", merged.Get("title").MustString())
+	})
+}
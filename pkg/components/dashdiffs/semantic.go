@@ -0,0 +1,379 @@
+package dashdiffs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// SemanticChangeType is the type of a change in a semantic diff
+type SemanticChangeType string
+
+const (
+	SemanticAdded   SemanticChangeType = "added"
+	SemanticRemoved SemanticChangeType = "removed"
+	SemanticChanged SemanticChangeType = "changed"
+)
+
+// SemanticDiff is a diff of two dashboards which matches their panels,
+// variables and annotations instead of comparing their position in the
+// dashboard JSON, so reordering them isn't reported as a change.
+type SemanticDiff struct {
+	Dashboard   []*SemanticChange   `json:"dashboard"`
+	Panels      []*SemanticItemDiff `json:"panels"`
+	Variables   []*SemanticItemDiff `json:"variables"`
+	Annotations []*SemanticItemDiff `json:"annotations"`
+}
+
+// SemanticItemDiff is a panel, variable or annotation which was added,
+// removed or changed. Panels are matched by id, then by title, variables
+// and annotations by name.
+type SemanticItemDiff struct {
+	Id      int64              `json:"id,omitempty"`
+	Name    string             `json:"name"`
+	Type    SemanticChangeType `json:"type"`
+	Changes []*SemanticChange  `json:"changes,omitempty"`
+}
+
+// SemanticChange is a change of a property, described in plain words, such
+// as "query A changed" or "thresholds changed".
+type SemanticChange struct {
+	Path        string             `json:"path"`
+	Type        SemanticChangeType `json:"type"`
+	Description string             `json:"description"`
+	Old         interface{}        `json:"old,omitempty"`
+	New         interface{}        `json:"new,omitempty"`
+}
+
+// IsEmpty returns true if the dashboards have no semantic differences
+func (d *SemanticDiff) IsEmpty() bool {
+	return len(d.Dashboard) == 0 && len(d.Panels) == 0 && len(d.Variables) == 0 && len(d.Annotations) == 0
+}
+
+// Summary describes the diff with a line per changed item, such as
+// `panel "CPU" (id 2): query A changed, thresholds changed`.
+func (d *SemanticDiff) Summary() []string {
+	lines := make([]string, 0)
+	if len(d.Dashboard) > 0 {
+		lines = append(lines, "dashboard: "+describeChanges(d.Dashboard))
+	}
+
+	for _, panel := range d.Panels {
+		lines = append(lines, describeItem(fmt.Sprintf("panel %q (id %d)", panel.Name, panel.Id), panel))
+	}
+	for _, variable := range d.Variables {
+		lines = append(lines, describeItem(fmt.Sprintf("variable %q", variable.Name), variable))
+	}
+	for _, annotation := range d.Annotations {
+		lines = append(lines, describeItem(fmt.Sprintf("annotation %q", annotation.Name), annotation))
+	}
+
+	return lines
+}
+
+func describeItem(label string, item *SemanticItemDiff) string {
+	if item.Type != SemanticChanged {
+		return label + " " + string(item.Type)
+	}
+	return label + ": " + describeChanges(item.Changes)
+}
+
+func describeChanges(changes []*SemanticChange) string {
+	descriptions := make([]string, 0, len(changes))
+	for _, change := range changes {
+		descriptions = append(descriptions, change.Description)
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// dashboardLabels describe the dashboard properties in changes
+var dashboardLabels = map[string]string{
+	"time":         "time range",
+	"timepicker":   "time picker",
+	"graphTooltip": "graph tooltip",
+}
+
+// panelLabels describe the panel properties in changes
+var panelLabels = map[string]string{
+	"type":             "visualization",
+	"datasource":       "data source",
+	"gridPos":          "position",
+	"options":          "display options",
+	"thresholds":       "thresholds",
+	"fieldConfig":      "field config",
+	"maxDataPoints":    "max data points",
+	"timeFrom":         "relative time",
+	"timeShift":        "time shift",
+	"hideTimeOverride": "time override visibility",
+}
+
+// ignoredDashboardKeys are the dashboard properties which change with every
+// version, or which are compared item by item.
+var ignoredDashboardKeys = map[string]bool{
+	"id":          true,
+	"version":     true,
+	"panels":      true,
+	"templating":  true,
+	"annotations": true,
+}
+
+// CompareDashboards computes the semantic diff of two dashboards
+func CompareDashboards(base, new *simplejson.Json) *SemanticDiff {
+	diff := &SemanticDiff{
+		Dashboard:   compareProperties("", base.MustMap(), new.MustMap(), ignoredDashboardKeys, dashboardLabels),
+		Panels:      comparePanels(flattenPanels(base), flattenPanels(new)),
+		Variables:   compareNamedItems(base.GetPath("templating", "list").MustArray(), new.GetPath("templating", "list").MustArray()),
+		Annotations: compareNamedItems(base.GetPath("annotations", "list").MustArray(), new.GetPath("annotations", "list").MustArray()),
+	}
+	return diff
+}
+
+// flattenPanels returns the panels of a dashboard, including the ones in
+// collapsed rows.
+func flattenPanels(dashboard *simplejson.Json) []map[string]interface{} {
+	panels := make([]map[string]interface{}, 0)
+	var flatten func(items []interface{})
+	flatten = func(items []interface{}) {
+		for _, item := range items {
+			panel, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			panels = append(panels, panel)
+			if rowPanels, ok := panel["panels"].([]interface{}); ok {
+				flatten(rowPanels)
+			}
+		}
+	}
+	flatten(dashboard.Get("panels").MustArray())
+	return panels
+}
+
+func comparePanels(basePanels, newPanels []map[string]interface{}) []*SemanticItemDiff {
+	matches := make(map[int]int, len(newPanels))
+	matched := make(map[int]bool, len(basePanels))
+
+	match := func(sameKey func(basePanel, newPanel map[string]interface{}) bool) {
+		for n, newPanel := range newPanels {
+			if _, ok := matches[n]; ok {
+				continue
+			}
+			for b, basePanel := range basePanels {
+				if !matched[b] && sameKey(basePanel, newPanel) {
+					matches[n] = b
+					matched[b] = true
+					break
+				}
+			}
+		}
+	}
+	match(func(basePanel, newPanel map[string]interface{}) bool {
+		id, ok := panelId(newPanel)
+		baseId, baseOk := panelId(basePanel)
+		return ok && baseOk && id == baseId
+	})
+	match(func(basePanel, newPanel map[string]interface{}) bool {
+		title := stringValue(newPanel["title"])
+		return title != "" && title == stringValue(basePanel["title"])
+	})
+
+	diffs := make([]*SemanticItemDiff, 0)
+	for n, newPanel := range newPanels {
+		id, _ := panelId(newPanel)
+		b, ok := matches[n]
+		if !ok {
+			diffs = append(diffs, &SemanticItemDiff{Id: id, Name: stringValue(newPanel["title"]), Type: SemanticAdded})
+			continue
+		}
+
+		if changes := comparePanel(basePanels[b], newPanel); len(changes) > 0 {
+			diffs = append(diffs, &SemanticItemDiff{Id: id, Name: stringValue(newPanel["title"]), Type: SemanticChanged, Changes: changes})
+		}
+	}
+
+	for b, basePanel := range basePanels {
+		if !matched[b] {
+			id, _ := panelId(basePanel)
+			diffs = append(diffs, &SemanticItemDiff{Id: id, Name: stringValue(basePanel["title"]), Type: SemanticRemoved})
+		}
+	}
+
+	return diffs
+}
+
+// comparePanel compares the queries by ref id, the thresholds and the
+// field config of two panels, then their other properties.
+func comparePanel(basePanel, newPanel map[string]interface{}) []*SemanticChange {
+	ignored := map[string]bool{"panels": true, "targets": true, "fieldConfig": true}
+	changes := compareProperties("", basePanel, newPanel, ignored, panelLabels)
+
+	changes = append(changes, compareTargets(basePanel["targets"], newPanel["targets"])...)
+
+	baseFieldConfig := mapValue(basePanel["fieldConfig"])
+	newFieldConfig := mapValue(newPanel["fieldConfig"])
+	baseDefaults := mapValue(baseFieldConfig["defaults"])
+	newDefaults := mapValue(newFieldConfig["defaults"])
+	changes = append(changes, compareProperties("fieldConfig.defaults.", baseDefaults, newDefaults, nil, nil)...)
+	if change := compareValues("fieldConfig.overrides", "overrides", baseFieldConfig["overrides"], newFieldConfig["overrides"]); change != nil {
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// compareTargets compares the queries of two panels by ref id
+func compareTargets(base, new interface{}) []*SemanticChange {
+	baseTargets := targetsByRefId(base)
+	newTargets := targetsByRefId(new)
+
+	refIds := make([]string, 0, len(baseTargets)+len(newTargets))
+	for refId := range baseTargets {
+		refIds = append(refIds, refId)
+	}
+	for refId := range newTargets {
+		if _, ok := baseTargets[refId]; !ok {
+			refIds = append(refIds, refId)
+		}
+	}
+	sort.Strings(refIds)
+
+	changes := make([]*SemanticChange, 0)
+	for _, refId := range refIds {
+		if change := compareValues("targets."+refId, "query "+refId, baseTargets[refId], newTargets[refId]); change != nil {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func targetsByRefId(value interface{}) map[string]interface{} {
+	targets := make(map[string]interface{})
+	items, _ := value.([]interface{})
+	for i, item := range items {
+		refId := stringValue(mapValue(item)["refId"])
+		if refId == "" {
+			refId = fmt.Sprintf("#%d", i+1)
+		}
+		targets[refId] = item
+	}
+	return targets
+}
+
+// compareNamedItems compares variables or annotations by name
+func compareNamedItems(baseItems, newItems []interface{}) []*SemanticItemDiff {
+	baseByName := make(map[string]map[string]interface{}, len(baseItems))
+	for _, item := range baseItems {
+		baseByName[stringValue(mapValue(item)["name"])] = mapValue(item)
+	}
+
+	diffs := make([]*SemanticItemDiff, 0)
+	seen := make(map[string]bool, len(newItems))
+	for _, item := range newItems {
+		newItem := mapValue(item)
+		name := stringValue(newItem["name"])
+		seen[name] = true
+
+		baseItem, ok := baseByName[name]
+		if !ok {
+			diffs = append(diffs, &SemanticItemDiff{Name: name, Type: SemanticAdded})
+			continue
+		}
+		if changes := compareProperties("", baseItem, newItem, nil, nil); len(changes) > 0 {
+			diffs = append(diffs, &SemanticItemDiff{Name: name, Type: SemanticChanged, Changes: changes})
+		}
+	}
+
+	for _, item := range baseItems {
+		if name := stringValue(mapValue(item)["name"]); !seen[name] {
+			diffs = append(diffs, &SemanticItemDiff{Name: name, Type: SemanticRemoved})
+		}
+	}
+
+	return diffs
+}
+
+// compareProperties compares the properties of two objects, sorted by name
+func compareProperties(pathPrefix string, base, new map[string]interface{}, ignored map[string]bool, labels map[string]string) []*SemanticChange {
+	keys := make([]string, 0, len(base)+len(new))
+	for key := range base {
+		keys = append(keys, key)
+	}
+	for key := range new {
+		if _, ok := base[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := make([]*SemanticChange, 0)
+	for _, key := range keys {
+		if ignored[key] {
+			continue
+		}
+
+		label, ok := labels[key]
+		if !ok {
+			label = key
+		}
+		if change := compareValues(pathPrefix+key, label, base[key], new[key]); change != nil {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// compareValues returns the change between two values, nil if they're equal
+func compareValues(path string, label string, base, new interface{}) *SemanticChange {
+	if equalValues(base, new) {
+		return nil
+	}
+
+	change := &SemanticChange{Path: path, Type: SemanticChanged, Old: base, New: new}
+	if base == nil {
+		change.Type = SemanticAdded
+	} else if new == nil {
+		change.Type = SemanticRemoved
+	}
+	change.Description = label + " " + string(change.Type)
+	return change
+}
+
+// equalValues compares two JSON values by their encoding, so numbers
+// decoded as float64 or json.Number are equal.
+func equalValues(a, b interface{}) bool {
+	aBytes, aErr := json.Marshal(a)
+	bBytes, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return false
+	}
+	return bytes.Equal(aBytes, bBytes)
+}
+
+func panelId(panel map[string]interface{}) (int64, bool) {
+	switch id := panel["id"].(type) {
+	case float64:
+		return int64(id), true
+	case int:
+		return int64(id), true
+	case int64:
+		return id, true
+	case json.Number:
+		value, err := id.Int64()
+		return value, err == nil
+	}
+	return 0, false
+}
+
+func mapValue(value interface{}) map[string]interface{} {
+	m, _ := value.(map[string]interface{})
+	return m
+}
+
+func stringValue(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
@@ -0,0 +1,110 @@
+package dashdiffs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareDashboards(t *testing.T) {
+	const (
+		baseJSON = `{
+			"id": 1,
+			"version": 3,
+			"title": "Hosts",
+			"time": {"from": "now-6h", "to": "now"},
+			"panels": [
+				{
+					"id": 1, "type": "graph", "title": "CPU",
+					"gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+					"targets": [{"refId": "A", "expr": "cpu"}, {"refId": "B", "expr": "load"}],
+					"fieldConfig": {"defaults": {"unit": "percent", "thresholds": {"steps": [{"value": 80}]}}}
+				},
+				{"id": 2, "type": "graph", "title": "Memory", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8}},
+				{"id": 3, "type": "text", "title": "Notes"},
+				{
+					"id": 4, "type": "row", "title": "Disks", "collapsed": true,
+					"panels": [{"id": 5, "type": "graph", "title": "IO", "targets": [{"refId": "A", "expr": "io"}]}]
+				}
+			],
+			"templating": {"list": [
+				{"name": "host", "query": "hosts"},
+				{"name": "env", "query": "envs"}
+			]}
+		}`
+
+		newJSON = `{
+			"id": 1,
+			"version": 4,
+			"title": "Hosts",
+			"time": {"from": "now-24h", "to": "now"},
+			"panels": [
+				{"id": 2, "type": "graph", "title": "Memory", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8}},
+				{
+					"id": 1, "type": "graph", "title": "CPU",
+					"gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+					"targets": [{"refId": "B", "expr": "load"}, {"refId": "A", "expr": "cpu_usage"}, {"refId": "C", "expr": "steal"}],
+					"fieldConfig": {"defaults": {"unit": "percent", "thresholds": {"steps": [{"value": 90}]}}}
+				},
+				{"id": 6, "type": "stat", "title": "Uptime"},
+				{
+					"id": 4, "type": "row", "title": "Disks", "collapsed": true,
+					"panels": [{"id": 7, "type": "graph", "title": "IO", "targets": [{"refId": "A", "expr": "io_time"}]}]
+				}
+			],
+			"templating": {"list": [
+				{"name": "env", "query": "envs"},
+				{"name": "host", "query": "hosts{env=\"$env\"}"},
+				{"name": "region", "query": "regions"}
+			]}
+		}`
+	)
+
+	base, err := simplejson.NewJson([]byte(baseJSON))
+	require.NoError(t, err)
+	new, err := simplejson.NewJson([]byte(newJSON))
+	require.NoError(t, err)
+
+	diff := CompareDashboards(base, new)
+
+	t.Run("reordered panels and variables aren't reported as changes", func(t *testing.T) {
+		for _, panel := range diff.Panels {
+			assert.NotEqual(t, "Memory", panel.Name)
+			assert.NotEqual(t, "Disks", panel.Name)
+		}
+		for _, variable := range diff.Variables {
+			assert.NotEqual(t, "env", variable.Name)
+		}
+	})
+
+	t.Run("summarizes the changes", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"dashboard: time range changed",
+			`panel "CPU" (id 1): query A changed, query C added, thresholds changed`,
+			`panel "Uptime" (id 6) added`,
+			`panel "IO" (id 7): id changed, query A changed`,
+			`panel "Notes" (id 3) removed`,
+			`variable "host": query changed`,
+			`variable "region" added`,
+		}, diff.Summary())
+	})
+
+	t.Run("reports the path and values of the changes", func(t *testing.T) {
+		require.Len(t, diff.Panels[0].Changes, 3)
+		change := diff.Panels[0].Changes[2]
+		assert.Equal(t, "fieldConfig.defaults.thresholds", change.Path)
+		assert.Equal(t, SemanticChanged, change.Type)
+
+		output, err := json.Marshal(diff.Panels[0].Changes[1])
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"path": "targets.C", "type": "added", "description": "query C added", "new": {"refId": "C", "expr": "steal"}}`, string(output))
+	})
+
+	t.Run("identical dashboards have no differences", func(t *testing.T) {
+		assert.True(t, CompareDashboards(base, base).IsEmpty())
+		assert.False(t, diff.IsEmpty())
+	})
+}
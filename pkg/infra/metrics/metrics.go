@@ -104,6 +104,12 @@ var (
 
 	// MRenderingQueue is a metric gauge for image rendering queue size
 	MRenderingQueue prometheus.Gauge
+
+	// MDataSourceProxyCacheTotal is a metric counter for cacheable dataproxy requests
+	MDataSourceProxyCacheTotal *prometheus.CounterVec
+
+	// MDataSourceUp is a metric gauge set to 1 when the last health check of a datasource succeeded
+	MDataSourceUp *prometheus.GaugeVec
 )
 
 // Timers
@@ -386,6 +392,19 @@ func init() {
 		Namespace: ExporterName,
 	})
 
+	MDataSourceProxyCacheTotal = newCounterVecStartingAtZero(
+		prometheus.CounterOpts{
+			Name:      "api_dataproxy_cache_requests_total",
+			Help:      "counter for cacheable dataproxy requests, by hit, miss or coalesced with an identical request",
+			Namespace: ExporterName,
+		}, []string{"result"}, "hit", "miss", "coalesced")
+
+	MDataSourceUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "datasource_up",
+		Help:      "1 if the last health check of the datasource succeeded, 0 otherwise",
+		Namespace: ExporterName,
+	}, []string{"org_id", "datasource_uid", "datasource_type"})
+
 	MDataSourceProxyReqTimer = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name:       "api_dataproxy_request_all_milliseconds",
 		Help:       "summary for dataproxy request duration",
@@ -532,6 +551,8 @@ func initMetricVars() {
 		MApiDashboardGet,
 		MApiDashboardSearch,
 		MDataSourceProxyReqTimer,
+		MDataSourceProxyCacheTotal,
+		MDataSourceUp,
 		MAlertingExecutionTime,
 		MApiAdminUserCreate,
 		MApiLoginPost,
@@ -68,6 +68,11 @@ func (uss *UsageStatsService) GetUsageReport() (UsageReport, error) {
 	metrics["stats.total_auth_token.count"] = statsQuery.Result.AuthTokens
 	metrics["stats.dashboard_versions.count"] = statsQuery.Result.DashboardVersions
 	metrics["stats.annotations.count"] = statsQuery.Result.Annotations
+	metrics["stats.dashboard_views.count"] = statsQuery.Result.DashboardViews
+	metrics["stats.dashboard_queries.count"] = statsQuery.Result.DashboardQueries
+	metrics["stats.dashboard_query_errors.count"] = statsQuery.Result.DashboardQueryErrors
+	metrics["stats.viewed_dashboards.count"] = statsQuery.Result.ViewedDashboards
+	metrics["stats.stale_dashboards.count"] = statsQuery.Result.StaleDashboards
 	metrics["stats.valid_license.count"] = getValidLicenseCount(uss.License.HasValidLicense())
 	metrics["stats.edition.oss.count"] = getOssEditionCount()
 	metrics["stats.edition.enterprise.count"] = getEnterpriseEditionCount()
@@ -51,6 +51,11 @@ func TestMetrics(t *testing.T) {
 				AuthTokens:            15,
 				DashboardVersions:     16,
 				Annotations:           17,
+				DashboardViews:        18,
+				DashboardQueries:      19,
+				DashboardQueryErrors:  20,
+				ViewedDashboards:      21,
+				StaleDashboards:       22,
 			}
 			getSystemStatsQuery = query
 			return nil
@@ -241,6 +246,11 @@ func TestMetrics(t *testing.T) {
 				So(metrics.Get("stats.avg_auth_token_per_user.count").MustInt64(), ShouldEqual, 5)
 				So(metrics.Get("stats.dashboard_versions.count").MustInt64(), ShouldEqual, 16)
 				So(metrics.Get("stats.annotations.count").MustInt64(), ShouldEqual, 17)
+				So(metrics.Get("stats.dashboard_views.count").MustInt64(), ShouldEqual, 18)
+				So(metrics.Get("stats.dashboard_queries.count").MustInt64(), ShouldEqual, 19)
+				So(metrics.Get("stats.dashboard_query_errors.count").MustInt64(), ShouldEqual, 20)
+				So(metrics.Get("stats.viewed_dashboards.count").MustInt64(), ShouldEqual, 21)
+				So(metrics.Get("stats.stale_dashboards.count").MustInt64(), ShouldEqual, 22)
 
 				So(metrics.Get("stats.ds."+models.DS_ES+".count").MustInt(), ShouldEqual, 9)
 				So(metrics.Get("stats.ds."+models.DS_PROMETHEUS+".count").MustInt(), ShouldEqual, 10)
@@ -132,6 +132,25 @@ func (alert *Alert) GetTagsFromSettings() []*Tag {
 	return tags
 }
 
+// GetDatasourceIdsFromSettings returns the ids of the data sources queried
+// by the conditions of the alert.
+func (alert *Alert) GetDatasourceIdsFromSettings() []int64 {
+	ids := []int64{}
+	if alert.Settings == nil {
+		return ids
+	}
+
+	seen := make(map[int64]bool)
+	for _, condition := range alert.Settings.Get("conditions").MustArray() {
+		id := simplejson.NewFromAny(condition).GetPath("query", "datasourceId").MustInt64()
+		if id > 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 type AlertingClusterInfo struct {
 	ServerId       string
 	ClusterSize    int
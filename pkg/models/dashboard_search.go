@@ -0,0 +1,129 @@
+package models
+
+import (
+	"strings"
+)
+
+// The fields of the dashboards indexed for full-text search
+const (
+	DashboardSearchFieldTitle            = "title"
+	DashboardSearchFieldDescription      = "description"
+	DashboardSearchFieldPanelTitle       = "panel_title"
+	DashboardSearchFieldPanelDescription = "panel_description"
+	DashboardSearchFieldQuery            = "query"
+	DashboardSearchFieldVariable         = "variable"
+)
+
+var DashboardSearchFields = []string{
+	DashboardSearchFieldTitle,
+	DashboardSearchFieldDescription,
+	DashboardSearchFieldPanelTitle,
+	DashboardSearchFieldPanelDescription,
+	DashboardSearchFieldQuery,
+	DashboardSearchFieldVariable,
+}
+
+// maxSearchTermLength is the maximum number of characters of an indexed text
+const maxSearchTermLength = 4000
+
+// searchQueryKeys are the keys holding the query expression in the targets
+// of the supported data sources
+var searchQueryKeys = []string{"expr", "expression", "query", "rawSql", "target", "metricName", "measurement"}
+
+// searchVariableKeys are the keys of a template variable that are indexed
+var searchVariableKeys = []string{"name", "label", "query", "definition"}
+
+// DashboardSearchTerm is a text of a dashboard indexed for full-text search
+type DashboardSearchTerm struct {
+	Id          int64
+	DashboardId int64
+	Field       string
+	Term        string
+}
+
+// IndexDashboardsForSearchCommand indexes the dashboards saved before
+// full-text search was added
+type IndexDashboardsForSearchCommand struct {
+	Indexed int
+}
+
+func IsDashboardSearchField(field string) bool {
+	for _, f := range DashboardSearchFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSearchTerms returns the texts of the dashboard indexed for full-text
+// search: its title and description, the titles, descriptions and queries of
+// its panels, including the panels of rows, and its template variables.
+func (d *Dashboard) GetSearchTerms() []*DashboardSearchTerm {
+	terms := make([]*DashboardSearchTerm, 0)
+	add := func(field string, values ...string) {
+		term := strings.TrimSpace(strings.Join(values, " "))
+		if term == "" {
+			return
+		}
+		if runes := []rune(term); len(runes) > maxSearchTermLength {
+			term = string(runes[:maxSearchTermLength])
+		}
+		terms = append(terms, &DashboardSearchTerm{DashboardId: d.Id, Field: field, Term: term})
+	}
+
+	add(DashboardSearchFieldTitle, d.Title)
+	if d.Data == nil {
+		return terms
+	}
+	add(DashboardSearchFieldDescription, d.Data.Get("description").MustString())
+
+	var addPanels func(panels []interface{})
+	addPanels = func(panels []interface{}) {
+		for _, item := range panels {
+			panel, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			title := stringValues(panel, "title")
+			if title == "" && libraryPanelUid(panel) != "" {
+				title = stringValues(panel["libraryPanel"].(map[string]interface{}), "name")
+			}
+			add(DashboardSearchFieldPanelTitle, title)
+			add(DashboardSearchFieldPanelDescription, stringValues(panel, "description"))
+
+			targets, _ := panel["targets"].([]interface{})
+			for _, item := range targets {
+				if target, ok := item.(map[string]interface{}); ok {
+					add(DashboardSearchFieldQuery, stringValues(target, searchQueryKeys...))
+				}
+			}
+
+			if rowPanels, ok := panel["panels"].([]interface{}); ok {
+				addPanels(rowPanels)
+			}
+		}
+	}
+	addPanels(d.Data.Get("panels").MustArray())
+
+	for _, item := range d.Data.GetPath("templating", "list").MustArray() {
+		if variable, ok := item.(map[string]interface{}); ok {
+			add(DashboardSearchFieldVariable, stringValues(variable, searchVariableKeys...))
+		}
+	}
+
+	return terms
+}
+
+// stringValues returns the string values of the keys of an object, separated
+// by spaces. Values that aren't strings are ignored.
+func stringValues(object map[string]interface{}, keys ...string) string {
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if value, ok := object[key].(string); ok && value != "" {
+			values = append(values, value)
+		}
+	}
+	return strings.Join(values, " ")
+}
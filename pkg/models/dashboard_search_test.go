@@ -0,0 +1,53 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardSearchTerms(t *testing.T) {
+	json, err := simplejson.NewJson([]byte(`{
+		"title": "API",
+		"description": "Traffic of the public API",
+		"panels": [
+			{"id": 1, "type": "graph", "title": "Requests", "description": "See the runbook",
+			 "targets": [{"refId": "A", "expr": "rate(http_requests_total[5m])", "legendFormat": "{{code}}"}]},
+			{"id": 2, "type": "row", "title": "Database", "collapsed": true, "panels": [
+				{"id": 3, "type": "table", "title": "Slow queries", "targets": [{"refId": "A", "rawSql": "SELECT * FROM slow_log", "format": "table"}]}
+			]},
+			{"id": 4, "libraryPanel": {"uid": "errors", "name": "Error rate"}},
+			{"id": 5, "type": "text", "title": ""}
+		],
+		"templating": {"list": [
+			{"name": "instance", "label": "Instance", "query": "label_values(up, instance)"},
+			{"name": "env", "query": {"namespace": "AWS/EC2"}}
+		]}
+	}`))
+	require.NoError(t, err)
+
+	dash := NewDashboardFromJson(json)
+	dash.Id = 7
+
+	terms := make([][2]string, 0)
+	for _, term := range dash.GetSearchTerms() {
+		assert.EqualValues(t, 7, term.DashboardId)
+		terms = append(terms, [2]string{term.Field, term.Term})
+	}
+
+	assert.Equal(t, [][2]string{
+		{DashboardSearchFieldTitle, "API"},
+		{DashboardSearchFieldDescription, "Traffic of the public API"},
+		{DashboardSearchFieldPanelTitle, "Requests"},
+		{DashboardSearchFieldPanelDescription, "See the runbook"},
+		{DashboardSearchFieldQuery, "rate(http_requests_total[5m])"},
+		{DashboardSearchFieldPanelTitle, "Database"},
+		{DashboardSearchFieldPanelTitle, "Slow queries"},
+		{DashboardSearchFieldQuery, "SELECT * FROM slow_log"},
+		{DashboardSearchFieldPanelTitle, "Error rate"},
+		{DashboardSearchFieldVariable, "instance Instance label_values(up, instance)"},
+		{DashboardSearchFieldVariable, "env"},
+	}, terms)
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrDashboardTrashNotFound        = errors.New("Deleted dashboard not found in the trash")
+	ErrDashboardTrashRestoreConflict = errors.New("A dashboard or folder with the same uid or the same name in the folder already exists")
+)
+
+// DashboardTrash is a dashboard or folder deleted by a user, which can be
+// restored until it expires. The content of the trash holds the dashboard,
+// or the folder with its subfolders and dashboards, and their alerts. Their
+// versions, permissions and stars are kept until the trash expires.
+type DashboardTrash struct {
+	Id          int64     `json:"id"`
+	OrgId       int64     `json:"orgId"`
+	DashboardId int64     `json:"dashboardId"`
+	Uid         string    `json:"uid"`
+	Title       string    `json:"title"`
+	IsFolder    bool      `json:"isFolder"`
+	FolderId    int64     `json:"folderId"`
+	Deleted     time.Time `json:"deleted"`
+	Content     string    `json:"-"`
+}
+
+// DashboardTrashItem is a dashboard or folder in the content of the trash,
+// to find the folder a trashed dashboard was in without decoding the
+// content.
+type DashboardTrashItem struct {
+	Id          int64
+	TrashId     int64
+	OrgId       int64
+	DashboardId int64
+	IsFolder    bool
+	FolderId    int64
+}
+
+// ---------------------
+// COMMANDS
+
+// TrashDashboardCommand moves a dashboard, or a folder with its content, to the trash
+type TrashDashboardCommand struct {
+	OrgId int64
+	Id    int64
+
+	Result *DashboardTrash
+}
+
+type RestoreDashboardFromTrashCommand struct {
+	OrgId int64
+	Id    int64
+
+	Result *Dashboard
+}
+
+// DeleteDashboardFromTrashCommand permanently deletes a dashboard in the trash
+type DeleteDashboardFromTrashCommand struct {
+	OrgId int64
+	Id    int64
+}
+
+type DeleteExpiredDashboardTrashCommand struct {
+	OlderThan   time.Time
+	DeletedRows int64
+}
+
+// ---------------------
+// QUERIES
+
+type GetDashboardTrashQuery struct {
+	OrgId int64
+
+	Result []*DashboardTrash
+}
+
+type GetDashboardTrashByIdQuery struct {
+	OrgId int64
+	Id    int64
+
+	Result *DashboardTrash
+}
+
+// GetDashboardTrashAclQuery returns the permissions kept for a dashboard or
+// folder in the trash.
+type GetDashboardTrashAclQuery struct {
+	OrgId       int64
+	DashboardId int64
+
+	Result []*DashboardAclInfoDTO
+}
@@ -26,6 +26,7 @@ type DashboardVersion struct {
 
 	Message string           `json:"message"`
 	Data    *simplejson.Json `json:"data"`
+	Pinned  bool             `json:"pinned"`
 }
 
 // DashboardVersionMeta extends the dashboard version model with the names
@@ -41,6 +42,7 @@ type DashboardVersionMeta struct {
 	Message       string           `json:"message"`
 	Data          *simplejson.Json `json:"data"`
 	CreatedBy     string           `json:"createdBy"`
+	Pinned        bool             `json:"pinned"`
 }
 
 // DashboardVersionDTO represents a dashboard version, without the dashboard
@@ -54,6 +56,7 @@ type DashboardVersionDTO struct {
 	Created       time.Time `json:"created"`
 	CreatedBy     string    `json:"createdBy"`
 	Message       string    `json:"message"`
+	Pinned        bool      `json:"pinned"`
 }
 
 //
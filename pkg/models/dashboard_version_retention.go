@@ -0,0 +1,104 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+)
+
+// Typed errors
+var (
+	ErrDashboardVersionRetentionNotFound = errors.New("Dashboard version retention policy not found")
+	ErrDashboardVersionRetentionInvalid  = errors.New("Versions to keep cannot be negative and max age must be a valid duration, such as 30d or 1y")
+)
+
+// DashboardVersionRetention is the retention policy of the versions of the
+// dashboards in a folder and its subfolders, or the default policy of an org
+// when FolderId is 0, which also applies to the General folder.
+//
+// The latest VersionsToKeep versions of a dashboard are kept, as well as the
+// versions saved within MaxAge. A limit of 0, or an empty max age, is not
+// applied, so a policy without limits keeps all versions. Pinned versions and
+// the latest version of a dashboard are never deleted.
+type DashboardVersionRetention struct {
+	Id             int64     `json:"id"`
+	OrgId          int64     `json:"orgId"`
+	FolderId       int64     `json:"folderId"`
+	VersionsToKeep int       `json:"versionsToKeep"`
+	MaxAge         string    `json:"maxAge"`
+	Created        time.Time `json:"created"`
+	Updated        time.Time `json:"updated"`
+}
+
+// MaxAgeDuration returns the max age of the versions, 0 when there is none
+func (r *DashboardVersionRetention) MaxAgeDuration() (time.Duration, error) {
+	if r.MaxAge == "" {
+		return 0, nil
+	}
+	return gtime.ParseInterval(r.MaxAge)
+}
+
+// Validate checks the limits of the policy
+func (r *DashboardVersionRetention) Validate() error {
+	if r.VersionsToKeep < 0 {
+		return ErrDashboardVersionRetentionInvalid
+	}
+	if maxAge, err := r.MaxAgeDuration(); err != nil || maxAge < 0 {
+		return ErrDashboardVersionRetentionInvalid
+	}
+	return nil
+}
+
+//
+// COMMANDS
+//
+
+// SaveDashboardVersionRetentionCommand creates or updates the retention
+// policy of a folder, or the default policy of the org when FolderId is 0.
+type SaveDashboardVersionRetentionCommand struct {
+	VersionsToKeep int    `json:"versionsToKeep"`
+	MaxAge         string `json:"maxAge"`
+
+	OrgId    int64 `json:"-"`
+	FolderId int64 `json:"-"`
+
+	Result *DashboardVersionRetention `json:"-"`
+}
+
+type DeleteDashboardVersionRetentionCommand struct {
+	OrgId    int64
+	FolderId int64
+}
+
+// SetDashboardVersionPinnedCommand pins a dashboard version so it's never
+// deleted by the retention policies, or unpins it.
+type SetDashboardVersionPinnedCommand struct {
+	OrgId       int64
+	DashboardId int64
+	Version     int
+	Pinned      bool
+}
+
+//
+// QUERIES
+//
+
+// GetDashboardVersionRetentionsQuery returns the retention policies of an org
+type GetDashboardVersionRetentionsQuery struct {
+	OrgId int64
+
+	Result []*DashboardVersionRetention
+}
+
+// GetDashboardVersionRetentionQuery returns the retention policy applied to
+// the dashboards of a folder: the policy of the folder, or of the closest
+// parent folder having one, or the default policy of the org. When none of
+// them has a policy, the result has no id and keeps the number of versions
+// of the versions_to_keep setting.
+type GetDashboardVersionRetentionQuery struct {
+	OrgId    int64
+	FolderId int64
+
+	Result *DashboardVersionRetention
+}
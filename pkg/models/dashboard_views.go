@@ -0,0 +1,139 @@
+package models
+
+import (
+	"time"
+)
+
+// MostViewedDashboardsDays is the number of days over which the most viewed
+// dashboards are ranked, and for which the daily views are kept
+const MostViewedDashboardsDays = 30
+
+// DashboardUserView is the number of views of a dashboard by a user, and
+// when the user last viewed it, as a unix timestamp
+type DashboardUserView struct {
+	Id          int64
+	DashboardId int64
+	UserId      int64
+	Views       int64
+	LastViewed  int64
+}
+
+// DashboardDailyView is the number of views of a dashboard by all users
+// during a day, formatted as 2006-01-02 in UTC
+type DashboardDailyView struct {
+	Id          int64
+	DashboardId int64
+	Day         string
+	Views       int64
+}
+
+// DashboardDailyQuery is the number of data source queries run by a
+// dashboard during a day, and how many of them failed
+type DashboardDailyQuery struct {
+	Id          int64
+	DashboardId int64
+	Day         string
+	Queries     int64
+	Errors      int64
+}
+
+// DashboardViews are views of a dashboard by a user, 0 for anonymous users
+type DashboardViews struct {
+	DashboardId int64
+	UserId      int64
+	Count       int64
+	LastViewed  time.Time
+}
+
+// DashboardQueries are data source queries run by a dashboard
+type DashboardQueries struct {
+	DashboardId int64
+	OrgId       int64
+	Count       int64
+	Errors      int64
+	LastQueried time.Time
+}
+
+// DashboardInsights is the usage of a dashboard over the last days
+type DashboardInsights struct {
+	DashboardId   int64                     `json:"dashboardId"`
+	Days          int                       `json:"days"`
+	Views         int64                     `json:"views"`
+	UniqueViewers int64                     `json:"uniqueViewers"`
+	Queries       int64                     `json:"queries"`
+	Errors        int64                     `json:"errors"`
+	LastViewed    *time.Time                `json:"lastViewed"`
+	Daily         []*DashboardDailyInsights `json:"daily"`
+}
+
+// DashboardDailyInsights is the usage of a dashboard during a day
+type DashboardDailyInsights struct {
+	Day     string `json:"day"`
+	Views   int64  `json:"views"`
+	Queries int64  `json:"queries"`
+	Errors  int64  `json:"errors"`
+}
+
+// StaleDashboard is a dashboard nobody viewed for a while
+type StaleDashboard struct {
+	Id          int64      `json:"id"`
+	Uid         string     `json:"uid"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"-"`
+	Url         string     `json:"url"`
+	FolderId    int64      `json:"folderId"`
+	FolderTitle string     `json:"folderTitle"`
+	Created     time.Time  `json:"created"`
+	Updated     time.Time  `json:"updated"`
+	LastViewed  *time.Time `json:"lastViewed"`
+}
+
+// ---------------------
+// COMMANDS
+
+// RecordDashboardViewCommand records a view of a dashboard. Views are saved
+// asynchronously.
+type RecordDashboardViewCommand struct {
+	DashboardId int64
+	UserId      int64
+}
+
+// RecordDashboardQueriesCommand records data source queries run by a
+// dashboard. Queries are sampled and saved asynchronously, only when the
+// dashboard belongs to the organization.
+type RecordDashboardQueriesCommand struct {
+	DashboardId int64
+	OrgId       int64
+	Queries     int64
+	Errors      int64
+}
+
+type SaveDashboardViewsCommand struct {
+	Views   []*DashboardViews
+	Queries []*DashboardQueries
+}
+
+type DeleteOldDashboardViewsCommand struct {
+	OlderThan time.Time
+
+	DeletedRows int64
+}
+
+// ---------------------
+// QUERIES
+
+type GetDashboardInsightsQuery struct {
+	DashboardId int64
+	Days        int
+
+	Result *DashboardInsights
+}
+
+// GetStaleDashboardsQuery returns the dashboards of an organization created
+// before and not viewed in the last days
+type GetStaleDashboardsQuery struct {
+	OrgId int64
+	Days  int
+
+	Result []*StaleDashboard
+}
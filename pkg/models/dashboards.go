@@ -14,6 +14,10 @@ import (
 
 const RootFolderName = "General"
 
+// MaxFolderDepth is the maximum number of nested folder levels, the folders
+// at the root being the first level
+const MaxFolderDepth = 5
+
 // Typed errors
 var (
 	ErrDashboardNotFound = DashboardErr{
@@ -47,10 +51,22 @@ var (
 		Reason:     "Dashboard title cannot be empty",
 		StatusCode: 400,
 	}
-	ErrDashboardFolderCannotHaveParent = DashboardErr{
-		Reason:     "A Dashboard Folder cannot be added to another folder",
+	ErrDashboardFolderCycle = DashboardErr{
+		Reason:     "A folder cannot be moved into itself or one of its subfolders",
+		StatusCode: 400,
+	}
+	ErrDashboardFolderMaxDepthExceeded = DashboardErr{
+		Reason:     "Folders cannot be nested more than 5 levels deep",
 		StatusCode: 400,
 	}
+	ErrDashboardLibraryPanelNotFound = DashboardErr{
+		Reason:     "The dashboard uses a library panel that doesn't exist",
+		StatusCode: 400,
+	}
+	ErrDashboardLibraryPanelAccessDenied = DashboardErr{
+		Reason:     "Access denied to a library panel used by the dashboard",
+		StatusCode: 403,
+	}
 	ErrDashboardsWithSameSlugExists = DashboardErr{
 		Reason:     "Multiple dashboards with the same slug exists",
 		StatusCode: 412,
@@ -142,6 +158,27 @@ func (d UpdatePluginDashboardError) Error() string {
 	return "Dashboard belong to plugin"
 }
 
+// DashboardMergeConflict is a part of a dashboard changed both by the
+// dashboard being saved and by a version saved in between, so the changes
+// can't be merged automatically.
+type DashboardMergeConflict struct {
+	Path        string      `json:"path"`
+	Description string      `json:"description"`
+	Base        interface{} `json:"base,omitempty"`
+	Current     interface{} `json:"current,omitempty"`
+	Incoming    interface{} `json:"incoming,omitempty"`
+}
+
+// DashboardMergeConflictError is returned when a dashboard has been changed
+// by someone else and the changes overlap with the ones being saved.
+type DashboardMergeConflictError struct {
+	Conflicts []*DashboardMergeConflict
+}
+
+func (e DashboardMergeConflictError) Error() string {
+	return "The dashboard has been changed by someone else and the changes conflict"
+}
+
 const (
 	DashTypeDB       = "db"
 	DashTypeSnapshot = "snapshot"
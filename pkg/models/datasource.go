@@ -222,6 +222,12 @@ type GetDataSourceByNameQuery struct {
 	Result *DataSource
 }
 
+type GetDataSourceByUidQuery struct {
+	Uid    string
+	OrgId  int64
+	Result *DataSource
+}
+
 // ---------------------
 //  Permissions
 // ---------------------
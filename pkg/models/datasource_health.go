@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// DataSourceHealth is the result of a health check of a data source,
+// recorded by the background health checker.
+type DataSourceHealth struct {
+	Id           int64     `json:"-"`
+	OrgId        int64     `json:"-"`
+	DataSourceId int64     `json:"-"`
+	Status       string    `json:"status"`
+	Message      string    `json:"message"`
+	DurationMs   int64     `json:"durationMs"`
+	Created      time.Time `json:"created"`
+}
+
+// ---------------------
+// COMMANDS
+
+type SaveDataSourceHealthCommand struct {
+	OrgId        int64
+	DataSourceId int64
+	Status       string
+	Message      string
+	DurationMs   int64
+
+	Result *DataSourceHealth
+}
+
+type DeleteOldDataSourceHealthCommand struct {
+	OlderThan   time.Time
+	DeletedRows int64
+}
+
+// ---------------------
+// QUERIES
+
+type GetDataSourceHealthHistoryQuery struct {
+	OrgId        int64
+	DataSourceId int64
+	Limit        int
+
+	Result []*DataSourceHealth
+}
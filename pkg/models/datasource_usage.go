@@ -0,0 +1,151 @@
+package models
+
+import (
+	"regexp"
+)
+
+// The kinds of references of the dashboards to data sources
+const (
+	// DatasourceRefDirect references a data source by name or uid
+	DatasourceRefDirect = "direct"
+	// DatasourceRefDefault references the default data source of the org
+	DatasourceRefDefault = "default"
+	// DatasourceRefVariable references the data sources of a type through
+	// a data source variable
+	DatasourceRefVariable = "variable"
+)
+
+const mixedDatasource = "-- Mixed --"
+
+// builtinDatasources are the data sources of the panels which aren't data
+// sources of the org
+var builtinDatasources = map[string]bool{
+	"-- Grafana --":   true,
+	mixedDatasource:   true,
+	"-- Dashboard --": true,
+}
+
+var variableRefPattern = regexp.MustCompile(`^(?:\$(\w+)|\$\{(\w+)(?::\w+)?\}|\[\[(\w+)(?::\w+)?\]\])$`)
+
+// DashboardDatasourceRef is a reference of a dashboard to a data source
+type DashboardDatasourceRef struct {
+	Id          int64
+	DashboardId int64
+	Kind        string
+	Ref         string
+}
+
+// GetDatasourceRefs returns the references to data sources of the panels,
+// queries, template variables and annotations of the dashboard.
+func (d *Dashboard) GetDatasourceRefs() []*DashboardDatasourceRef {
+	refs := make([]*DashboardDatasourceRef, 0)
+	if d.Data == nil {
+		return refs
+	}
+
+	// the plugin types of the data source variables by name
+	variableTypes := make(map[string]string)
+	variables := d.Data.GetPath("templating", "list").MustArray()
+	for _, item := range variables {
+		if variable, ok := item.(map[string]interface{}); ok && variable["type"] == "datasource" {
+			name, _ := variable["name"].(string)
+			pluginType, _ := variable["query"].(string)
+			variableTypes[name] = pluginType
+		}
+	}
+
+	seen := make(map[DashboardDatasourceRef]bool)
+	add := func(kind string, value string) {
+		ref := DashboardDatasourceRef{DashboardId: d.Id, Kind: kind, Ref: value}
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, &ref)
+		}
+	}
+	addDatasource := func(datasource interface{}) {
+		// data sources are referenced by name, or by an object with an uid
+		if object, ok := datasource.(map[string]interface{}); ok {
+			datasource = object["uid"]
+		}
+
+		value, _ := datasource.(string)
+		if value == "" || value == "default" {
+			add(DatasourceRefDefault, "")
+			return
+		}
+		if builtinDatasources[value] {
+			return
+		}
+
+		if match := variableRefPattern.FindStringSubmatch(value); match != nil {
+			if pluginType := variableTypes[match[1]+match[2]+match[3]]; pluginType != "" {
+				add(DatasourceRefVariable, pluginType)
+			}
+			return
+		}
+		add(DatasourceRefDirect, value)
+	}
+
+	var addPanels func(panels []interface{})
+	addPanels = func(panels []interface{}) {
+		for _, item := range panels {
+			panel, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			// the queries of mixed panels have their own data source
+			targets, _ := panel["targets"].([]interface{})
+			for _, item := range targets {
+				target, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				switch {
+				case target["datasource"] != nil:
+					addDatasource(target["datasource"])
+				case panel["datasource"] == mixedDatasource:
+					addDatasource(nil)
+				default:
+					addDatasource(panel["datasource"])
+				}
+			}
+
+			if rowPanels, ok := panel["panels"].([]interface{}); ok {
+				addPanels(rowPanels)
+			}
+		}
+	}
+	addPanels(d.Data.Get("panels").MustArray())
+
+	for _, item := range variables {
+		if variable, ok := item.(map[string]interface{}); ok && (variable["type"] == "query" || variable["type"] == "adhoc") {
+			addDatasource(variable["datasource"])
+		}
+	}
+
+	for _, item := range d.Data.GetPath("annotations", "list").MustArray() {
+		if annotation, ok := item.(map[string]interface{}); ok {
+			addDatasource(annotation["datasource"])
+		}
+	}
+
+	return refs
+}
+
+// GetAlertsByDataSourceQuery returns the alerts of an org querying a data
+// source.
+type GetAlertsByDataSourceQuery struct {
+	OrgId        int64
+	DataSourceId int64
+
+	Result []*AlertListItemDTO
+}
+
+// GetUnusedDataSourcesQuery returns the data sources of an org which are
+// used by no dashboard nor alert.
+type GetUnusedDataSourcesQuery struct {
+	OrgId int64
+
+	Result []*DataSource
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardDatasourceRefs(t *testing.T) {
+	json, err := simplejson.NewJson([]byte(`{
+		"title": "API",
+		"panels": [
+			{"id": 1, "type": "graph", "datasource": "Prometheus", "targets": [{"refId": "A", "expr": "up"}, {"refId": "B", "expr": "down"}]},
+			{"id": 2, "type": "graph", "datasource": "-- Mixed --", "targets": [
+				{"refId": "A", "datasource": "Loki"},
+				{"refId": "B"},
+				{"refId": "C", "datasource": "-- Grafana --"}
+			]},
+			{"id": 3, "type": "row", "collapsed": true, "panels": [
+				{"id": 4, "type": "table", "datasource": "${ds}", "targets": [{"refId": "A"}]},
+				{"id": 5, "type": "table", "datasource": {"uid": "mysql-uid", "type": "mysql"}, "targets": [{"refId": "A"}]}
+			]},
+			{"id": 6, "type": "text", "datasource": "Elasticsearch"},
+			{"id": 7, "type": "graph", "datasource": "$unknown", "targets": [{"refId": "A"}]}
+		],
+		"templating": {"list": [
+			{"name": "ds", "type": "datasource", "query": "prometheus"},
+			{"name": "instance", "type": "query", "datasource": "Prometheus", "query": "label_values(up, instance)"},
+			{"name": "env", "type": "custom", "query": "dev,prod"}
+		]},
+		"annotations": {"list": [
+			{"name": "Annotations & Alerts", "datasource": "-- Grafana --"},
+			{"name": "Deploys", "datasource": "Deploys"}
+		]}
+	}`))
+	require.NoError(t, err)
+
+	dash := NewDashboardFromJson(json)
+	dash.Id = 7
+
+	refs := make([][2]string, 0)
+	for _, ref := range dash.GetDatasourceRefs() {
+		assert.EqualValues(t, 7, ref.DashboardId)
+		refs = append(refs, [2]string{ref.Kind, ref.Ref})
+	}
+
+	assert.Equal(t, [][2]string{
+		{DatasourceRefDirect, "Prometheus"},
+		{DatasourceRefDirect, "Loki"},
+		{DatasourceRefDefault, ""},
+		{DatasourceRefVariable, "prometheus"},
+		{DatasourceRefDirect, "mysql-uid"},
+		{DatasourceRefDirect, "Deploys"},
+	}, refs)
+}
+
+func TestAlertDatasourceIds(t *testing.T) {
+	settings, err := simplejson.NewJson([]byte(`{
+		"conditions": [
+			{"query": {"params": ["A", "5m", "now"], "datasourceId": 2}},
+			{"query": {"params": ["B", "5m", "now"], "datasourceId": 3}},
+			{"query": {"params": ["C", "5m", "now"], "datasourceId": 2}}
+		]
+	}`))
+	require.NoError(t, err)
+
+	alert := &Alert{Settings: settings}
+	assert.Equal(t, []int64{2, 3}, alert.GetDatasourceIdsFromSettings())
+	assert.Empty(t, (&Alert{}).GetDatasourceIdsFromSettings())
+}
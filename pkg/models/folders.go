@@ -12,9 +12,10 @@ var (
 	ErrFolderVersionMismatch         = errors.New("The folder has been changed by someone else")
 	ErrFolderTitleEmpty              = errors.New("Folder title cannot be empty")
 	ErrFolderWithSameUIDExists       = errors.New("A folder/dashboard with the same uid already exists")
-	ErrFolderSameNameExists          = errors.New("A folder or dashboard in the general folder with the same name already exists")
+	ErrFolderSameNameExists          = errors.New("A folder or dashboard in the parent folder with the same name already exists")
 	ErrFolderFailedGenerateUniqueUid = errors.New("Failed to generate unique folder id")
 	ErrFolderAccessDenied            = errors.New("Access denied to folder")
+	ErrParentFolderNotFound          = errors.New("Parent folder not found")
 )
 
 type Folder struct {
@@ -30,6 +31,10 @@ type Folder struct {
 	UpdatedBy int64
 	CreatedBy int64
 	HasAcl    bool
+
+	// ParentId is the id of the parent folder, 0 for the folders at the root
+	ParentId  int64
+	ParentUid string
 }
 
 // GetDashboardModel turns the command into the saveable model
@@ -75,8 +80,9 @@ func (cmd *UpdateFolderCommand) UpdateDashboardModel(dashFolder *Dashboard, orgI
 //
 
 type CreateFolderCommand struct {
-	Uid   string `json:"uid"`
-	Title string `json:"title"`
+	Uid       string `json:"uid"`
+	Title     string `json:"title"`
+	ParentUid string `json:"parentUid"`
 
 	Result *Folder
 }
@@ -90,6 +96,16 @@ type UpdateFolderCommand struct {
 	Result *Folder
 }
 
+// MoveFolderCommand moves a folder, with its content, into another folder,
+// or to the root when ParentUid is empty
+type MoveFolderCommand struct {
+	ParentUid string `json:"parentUid"`
+	Version   int    `json:"version"`
+	Overwrite bool   `json:"overwrite"`
+
+	Result *Folder
+}
+
 //
 // QUERIES
 //
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Typed errors
+var (
+	ErrLibraryPanelNotFound                = errors.New("Library panel not found")
+	ErrLibraryPanelWithSameUidExists       = errors.New("A library panel with the same uid already exists")
+	ErrLibraryPanelWithSameNameExists      = errors.New("A library panel with the same name already exists in the folder")
+	ErrLibraryPanelVersionMismatch         = errors.New("The library panel has been changed by someone else")
+	ErrLibraryPanelInUse                   = errors.New("The library panel is used by dashboards and cannot be deleted")
+	ErrLibraryPanelNameEmpty               = errors.New("Library panel name cannot be empty")
+	ErrLibraryPanelInvalidUid              = errors.New("uid contains illegal characters")
+	ErrLibraryPanelUidTooLong              = errors.New("uid too long. max 40 characters")
+	ErrLibraryPanelFailedGenerateUniqueUid = errors.New("Failed to generate unique library panel uid")
+)
+
+// LibraryPanel is a panel shared by dashboards, which reference it by uid.
+// Its permissions are the permissions of its folder.
+type LibraryPanel struct {
+	Id        int64            `json:"id"`
+	OrgId     int64            `json:"orgId"`
+	FolderId  int64            `json:"folderId"`
+	Uid       string           `json:"uid"`
+	Name      string           `json:"name"`
+	Model     *simplejson.Json `json:"model"`
+	Version   int64            `json:"version"`
+	Created   time.Time        `json:"created"`
+	Updated   time.Time        `json:"updated"`
+	CreatedBy int64            `json:"createdBy"`
+	UpdatedBy int64            `json:"updatedBy"`
+}
+
+// LibraryPanelDashboard connects a library panel to a dashboard using it
+type LibraryPanelDashboard struct {
+	Id             int64
+	LibraryPanelId int64
+	DashboardId    int64
+}
+
+// LibraryPanelUids returns the uids of the library panels used by the
+// dashboard, including the ones in collapsed rows.
+func (d *Dashboard) LibraryPanelUids() []string {
+	uids := make([]string, 0)
+	seen := make(map[string]bool)
+	d.replacePanels(func(panel map[string]interface{}) map[string]interface{} {
+		if uid := libraryPanelUid(panel); uid != "" && !seen[uid] {
+			seen[uid] = true
+			uids = append(uids, uid)
+		}
+		return panel
+	})
+	return uids
+}
+
+// StripLibraryPanels keeps only the position and the reference of the
+// library panels in the dashboard, their model being stored in the library.
+func (d *Dashboard) StripLibraryPanels() {
+	d.replacePanels(func(panel map[string]interface{}) map[string]interface{} {
+		uid := libraryPanelUid(panel)
+		if uid == "" {
+			return panel
+		}
+
+		stripped := map[string]interface{}{
+			"libraryPanel": map[string]interface{}{"uid": uid, "name": panel["libraryPanel"].(map[string]interface{})["name"]},
+		}
+		for _, key := range []string{"id", "gridPos"} {
+			if value, ok := panel[key]; ok {
+				stripped[key] = value
+			}
+		}
+		return stripped
+	})
+}
+
+// LoadLibraryPanels replaces the library panels of the dashboard with their
+// model, keeping their position in the dashboard. Library panels missing
+// from panels are left as they are.
+func (d *Dashboard) LoadLibraryPanels(panels map[string]*LibraryPanel) {
+	d.replacePanels(func(panel map[string]interface{}) map[string]interface{} {
+		libraryPanel, ok := panels[libraryPanelUid(panel)]
+		if !ok {
+			return panel
+		}
+
+		loaded := make(map[string]interface{})
+		for key, value := range libraryPanel.Model.MustMap() {
+			loaded[key] = value
+		}
+		for _, key := range []string{"id", "gridPos"} {
+			if value, ok := panel[key]; ok {
+				loaded[key] = value
+			}
+		}
+		loaded["libraryPanel"] = map[string]interface{}{
+			"uid":     libraryPanel.Uid,
+			"name":    libraryPanel.Name,
+			"version": libraryPanel.Version,
+		}
+		return loaded
+	})
+}
+
+// replacePanels replaces the panels of the dashboard, and of its collapsed
+// rows, with the panel returned by fn.
+func (d *Dashboard) replacePanels(fn func(panel map[string]interface{}) map[string]interface{}) {
+	if d.Data == nil {
+		return
+	}
+
+	var replace func(panels []interface{})
+	replace = func(panels []interface{}) {
+		for i, item := range panels {
+			panel, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if rowPanels, ok := panel["panels"].([]interface{}); ok {
+				replace(rowPanels)
+			}
+			panels[i] = fn(panel)
+		}
+	}
+	replace(d.Data.Get("panels").MustArray())
+}
+
+func libraryPanelUid(panel map[string]interface{}) string {
+	ref, ok := panel["libraryPanel"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	uid, _ := ref["uid"].(string)
+	return uid
+}
+
+//
+// COMMANDS
+//
+
+type CreateLibraryPanelCommand struct {
+	FolderId int64            `json:"folderId"`
+	Uid      string           `json:"uid"`
+	Name     string           `json:"name"`
+	Model    *simplejson.Json `json:"model"`
+
+	OrgId  int64 `json:"-"`
+	UserId int64 `json:"-"`
+
+	Result *LibraryPanel `json:"-"`
+}
+
+type UpdateLibraryPanelCommand struct {
+	FolderId int64            `json:"folderId"`
+	Name     string           `json:"name"`
+	Model    *simplejson.Json `json:"model"`
+	Version  int64            `json:"version"`
+
+	Uid    string `json:"-"`
+	OrgId  int64  `json:"-"`
+	UserId int64  `json:"-"`
+
+	Result *LibraryPanel `json:"-"`
+}
+
+type DeleteLibraryPanelCommand struct {
+	OrgId int64
+	Uid   string
+}
+
+//
+// QUERIES
+//
+
+type GetLibraryPanelQuery struct {
+	OrgId int64
+	Uid   string
+
+	Result *LibraryPanel
+}
+
+// GetLibraryPanelsQuery returns the library panels of an org, optionally
+// filtered by uids, folders or name.
+type GetLibraryPanelsQuery struct {
+	OrgId     int64
+	Uids      []string
+	FolderIds []int64
+	Name      string
+	Limit     int
+
+	Result []*LibraryPanel
+}
+
+// GetLibraryPanelDashboardsQuery returns the dashboards using a library panel
+type GetLibraryPanelDashboardsQuery struct {
+	OrgId          int64
+	LibraryPanelId int64
+
+	Result []*Dashboard
+}
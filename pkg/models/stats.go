@@ -19,6 +19,13 @@ type SystemStats struct {
 	DashboardVersions     int64
 	Annotations           int64
 
+	// dashboard usage over the last MostViewedDashboardsDays days
+	DashboardViews       int64
+	DashboardQueries     int64
+	DashboardQueryErrors int64
+	ViewedDashboards     int64
+	StaleDashboards      int64
+
 	Admins         int
 	Editors        int
 	Viewers        int
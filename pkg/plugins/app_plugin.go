@@ -34,6 +34,7 @@ type AppPluginRoute struct {
 	Headers      []AppPluginRouteHeader   `json:"headers"`
 	TokenAuth    *JwtTokenAuth            `json:"tokenAuth"`
 	JwtTokenAuth *JwtTokenAuth            `json:"jwtTokenAuth"`
+	CacheTTL     string                   `json:"cacheTTL"`
 }
 
 // AppPluginRouteHeader describes an HTTP header that is forwarded with
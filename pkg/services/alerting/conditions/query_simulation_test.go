@@ -0,0 +1,81 @@
+package conditions
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	_ "github.com/grafana/grafana/pkg/tsdb/testdatasource"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestQueryConditionWithSimulation(t *testing.T) {
+	bus.AddHandler("test", func(query *models.GetDataSourceByIdQuery) error {
+		query.Result = &models.DataSource{Id: 1, Type: "testdata"}
+		return nil
+	})
+	t.Cleanup(bus.ClearBusHandlers)
+
+	Convey("when evaluating query condition against the testdata simulation", t, func() {
+		eval := func(reducer string) *alerting.ConditionResult {
+			jsonModel, err := simplejson.NewJson([]byte(`{
+				"type": "query",
+				"query": {
+					"params": ["A", "10m", "now"],
+					"datasourceId": 1,
+					"model": {
+						"refId": "A",
+						"scenarioId": "simulation",
+						"simulation": {
+							"timeStep": 60,
+							"series": [
+								{
+									"alias": "spike",
+									"labels": "{host=a}",
+									"baseline": 10,
+									"signals": [{"type": "spike", "at": "now-2m", "duration": "1m", "value": 100}]
+								},
+								{
+									"alias": "step",
+									"labels": "{host=b}",
+									"baseline": 10,
+									"signals": [{"type": "step", "at": "now-3m", "value": 200}]
+								}
+							]
+						}
+					}
+				},
+				"reducer": {"type": "` + reducer + `"},
+				"evaluator": {"type": "gt", "params": [50]}
+			}`))
+			So(err, ShouldBeNil)
+
+			condition, err := newQueryCondition(jsonModel, 0)
+			So(err, ShouldBeNil)
+
+			cr, err := condition.Eval(&alerting.EvalContext{Rule: &alerting.Rule{}})
+			So(err, ShouldBeNil)
+			return cr
+		}
+
+		Convey("should only fire for the series whose last value is above the threshold", func() {
+			cr := eval("last")
+
+			So(cr.Firing, ShouldBeTrue)
+			So(len(cr.EvalMatches), ShouldEqual, 1)
+			So(cr.EvalMatches[0].Metric, ShouldEqual, "step")
+			So(cr.EvalMatches[0].Tags, ShouldResemble, map[string]string{"host": "b"})
+			So(cr.EvalMatches[0].Value.Float64, ShouldEqual, 210)
+		})
+
+		Convey("should fire for both series when reducing to the max", func() {
+			cr := eval("max")
+
+			So(cr.Firing, ShouldBeTrue)
+			So(len(cr.EvalMatches), ShouldEqual, 2)
+			So(cr.EvalMatches[0].Value.Float64, ShouldEqual, 110)
+		})
+	})
+}
@@ -44,6 +44,8 @@ func (srv *CleanUpService) Run(ctx context.Context) error {
 			srv.cleanUpTmpFiles()
 			srv.deleteExpiredSnapshots()
 			srv.deleteExpiredDashboardVersions()
+			srv.deleteExpiredDashboardTrash()
+			srv.deleteOldDashboardViews()
 			srv.cleanUpOldAnnotations(ctxWithTimeout)
 
 			err := srv.ServerLockService.LockAndExecute(ctx, "delete old login attempts",
@@ -124,6 +126,32 @@ func (srv *CleanUpService) deleteExpiredDashboardVersions() {
 	}
 }
 
+func (srv *CleanUpService) deleteExpiredDashboardTrash() {
+	if setting.DashboardTrashRetention <= 0 {
+		return
+	}
+
+	cmd := models.DeleteExpiredDashboardTrashCommand{
+		OlderThan: time.Now().Add(-setting.DashboardTrashRetention),
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		srv.log.Error("Failed to delete expired dashboards from the trash", "error", err.Error())
+	} else {
+		srv.log.Debug("Deleted expired dashboards from the trash", "rows affected", cmd.DeletedRows)
+	}
+}
+
+func (srv *CleanUpService) deleteOldDashboardViews() {
+	cmd := models.DeleteOldDashboardViewsCommand{
+		OlderThan: time.Now().AddDate(0, 0, -models.MostViewedDashboardsDays),
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		srv.log.Error("Failed to delete old dashboard views", "error", err.Error())
+	} else {
+		srv.log.Debug("Deleted old dashboard views", "rows affected", cmd.DeletedRows)
+	}
+}
+
 func (srv *CleanUpService) deleteOldLoginAttempts() {
 	if srv.Cfg.DisableBruteForceLoginProtection {
 		return
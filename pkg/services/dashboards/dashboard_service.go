@@ -1,9 +1,11 @@
 package dashboards
 
 import (
+	"errors"
 	"strings"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/gtime"
 	"github.com/grafana/grafana/pkg/setting"
 
@@ -53,6 +55,10 @@ type SaveDashboardDTO struct {
 	Message   string
 	Overwrite bool
 	Dashboard *models.Dashboard
+
+	// Merged is set when the dashboard has been merged with the changes
+	// saved by someone else since the version it's based on.
+	Merged bool
 }
 
 type dashboardServiceImpl struct {
@@ -92,8 +98,8 @@ func (dr *dashboardServiceImpl) buildSaveDashboardCommand(dto *SaveDashboardDTO,
 		return nil, models.ErrDashboardTitleEmpty
 	}
 
-	if dash.IsFolder && dash.FolderId > 0 {
-		return nil, models.ErrDashboardFolderCannotHaveParent
+	if dash.IsFolder && dash.Id > 0 && dash.FolderId == dash.Id {
+		return nil, models.ErrDashboardFolderCycle
 	}
 
 	if dash.IsFolder && strings.EqualFold(dash.Title, models.RootFolderName) {
@@ -122,6 +128,10 @@ func (dr *dashboardServiceImpl) buildSaveDashboardCommand(dto *SaveDashboardDTO,
 		}
 	}
 
+	if err := validateLibraryPanels(dash, dto.OrgId, dto.User); err != nil {
+		return nil, err
+	}
+
 	validateBeforeSaveCmd := models.ValidateDashboardBeforeSaveCommand{
 		OrgId:     dto.OrgId,
 		Dashboard: dash,
@@ -207,9 +217,15 @@ func validateDashboardRefreshInterval(dash *models.Dashboard) error {
 }
 
 func (dr *dashboardServiceImpl) updateAlerting(cmd *models.SaveDashboardCommand, dto *SaveDashboardDTO) error {
+	// the alerts of library panels are in their model from the library
+	dash, err := withLibraryPanels(cmd.Result)
+	if err != nil {
+		return err
+	}
+
 	alertCmd := models.UpdateDashboardAlertsCommand{
 		OrgId:     dto.OrgId,
-		Dashboard: cmd.Result,
+		Dashboard: dash,
 		User:      dto.User,
 	}
 
@@ -283,6 +299,12 @@ func (dr *dashboardServiceImpl) SaveDashboard(dto *SaveDashboardDTO, allowUiUpda
 	}
 
 	cmd, err := dr.buildSaveDashboardCommand(dto, true, !allowUiUpdate)
+	if errors.Is(err, models.ErrDashboardVersionMismatch) && !dto.Overwrite {
+		if err := dr.mergeDashboard(dto); err != nil {
+			return nil, err
+		}
+		cmd, err = dr.buildSaveDashboardCommand(dto, true, !allowUiUpdate)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -300,8 +322,56 @@ func (dr *dashboardServiceImpl) SaveDashboard(dto *SaveDashboardDTO, allowUiUpda
 	return cmd.Result, nil
 }
 
-// DeleteDashboard removes dashboard from the DB. Errors out if the dashboard was provisioned. Should be used for
-// operations by the user where we want to make sure user does not delete provisioned dashboard.
+// mergeDashboard merges the dashboard being saved with the changes saved by
+// someone else since the version it's based on, using that version as the
+// common base. Returns a DashboardMergeConflictError when the changes overlap,
+// and ErrDashboardVersionMismatch when the base version no longer exists.
+func (dr *dashboardServiceImpl) mergeDashboard(dto *SaveDashboardDTO) error {
+	dash := dto.Dashboard
+
+	guard := guardian.New(dash.Id, dto.OrgId, dto.User)
+	if canSave, err := guard.CanSave(); err != nil || !canSave {
+		if err != nil {
+			return err
+		}
+		return models.ErrDashboardUpdateAccessDenied
+	}
+
+	currentQuery := models.GetDashboardQuery{Id: dash.Id, OrgId: dto.OrgId}
+	if err := bus.Dispatch(&currentQuery); err != nil {
+		return err
+	}
+	current := currentQuery.Result
+
+	baseQuery := models.GetDashboardVersionQuery{DashboardId: dash.Id, OrgId: dto.OrgId, Version: dash.Version}
+	if err := bus.Dispatch(&baseQuery); err != nil {
+		if errors.Is(err, models.ErrDashboardVersionNotFound) {
+			return models.ErrDashboardVersionMismatch
+		}
+		return err
+	}
+
+	// saved versions only have the references of the library panels
+	dash.StripLibraryPanels()
+
+	merged, conflicts := dashdiffs.MergeDashboards(baseQuery.Result.Data, current.Data, dash.Data)
+	if len(conflicts) > 0 {
+		return models.DashboardMergeConflictError{Conflicts: conflicts}
+	}
+
+	dr.log.Info("Merged dashboard with changes saved in between", "dashboardUid", dash.Uid, "baseVersion", dash.Version, "currentVersion", current.Version)
+
+	dash.Data = merged
+	dash.Title = merged.Get("title").MustString()
+	dash.SetVersion(current.Version)
+	dto.Merged = true
+
+	return nil
+}
+
+// DeleteDashboard moves dashboard to the trash, or removes it from the DB when the trash is disabled. Errors out if
+// the dashboard was provisioned. Should be used for operations by the user where we want to make sure user does not
+// delete provisioned dashboard.
 func (dr *dashboardServiceImpl) DeleteDashboard(dashboardId int64, orgId int64) error {
 	return dr.deleteDashboard(dashboardId, orgId, true)
 }
@@ -321,11 +391,22 @@ func (dr *dashboardServiceImpl) deleteDashboard(dashboardId int64, orgId int64,
 		if provisionedData != nil {
 			return models.ErrDashboardCannotDeleteProvisionedDashboard
 		}
+		return trashDashboard(dashboardId, orgId)
 	}
 	cmd := &models.DeleteDashboardCommand{OrgId: orgId, Id: dashboardId}
 	return bus.Dispatch(cmd)
 }
 
+// trashDashboard moves a dashboard or folder deleted by the user to the trash,
+// or permanently deletes it when the trash is disabled.
+func trashDashboard(dashboardId int64, orgId int64) error {
+	if setting.DashboardTrashRetention <= 0 {
+		return bus.Dispatch(&models.DeleteDashboardCommand{OrgId: orgId, Id: dashboardId})
+	}
+
+	return bus.Dispatch(&models.TrashDashboardCommand{OrgId: orgId, Id: dashboardId})
+}
+
 func (dr *dashboardServiceImpl) ImportDashboard(dto *SaveDashboardDTO) (*models.Dashboard, error) {
 	if err := validateDashboardRefreshInterval(dto.Dashboard); err != nil {
 		dr.log.Warn("Changing refresh interval for imported dashboard to minimum refresh interval", "dashboardUid", dto.Dashboard.Uid, "dashboardTitle", dto.Dashboard.Title, "minRefreshInterval", setting.MinRefreshInterval)
@@ -3,11 +3,13 @@ package dashboards
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/setting"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	. "github.com/smartystreets/goconvey/convey"
@@ -37,11 +39,12 @@ func TestDashboardService(t *testing.T) {
 				}
 			})
 
-			Convey("Should return validation error if it's a folder and have a folder id", func() {
+			Convey("Should return validation error if it's a folder and is its own parent", func() {
 				dto.Dashboard = models.NewDashboardFolder("Folder")
+				dto.Dashboard.SetId(1)
 				dto.Dashboard.FolderId = 1
 				_, err := service.SaveDashboard(dto, false)
-				So(err, ShouldEqual, models.ErrDashboardFolderCannotHaveParent)
+				So(err, ShouldEqual, models.ErrDashboardFolderCycle)
 			})
 
 			Convey("Should return validation error if folder is named General", func() {
@@ -154,6 +157,89 @@ func TestDashboardService(t *testing.T) {
 			})
 		})
 
+		Convey("Given a dashboard changed by someone else", func() {
+			panel := func(id int, title string) map[string]interface{} {
+				return map[string]interface{}{"id": id, "type": "graph", "title": title}
+			}
+			dashboardVersion := func(version int, panels ...interface{}) *simplejson.Json {
+				return simplejson.NewFromAny(map[string]interface{}{
+					"id": 1, "uid": "hosts", "title": "Hosts", "version": version, "panels": panels,
+				})
+			}
+
+			bus.AddHandler("test", func(cmd *models.GetProvisionedDashboardDataByIdQuery) error {
+				return nil
+			})
+			bus.AddHandler("test", func(cmd *models.ValidateDashboardAlertsCommand) error {
+				return nil
+			})
+			bus.AddHandler("test", func(cmd *models.ValidateDashboardBeforeSaveCommand) error {
+				if cmd.Dashboard.Version != 4 {
+					return models.ErrDashboardVersionMismatch
+				}
+				cmd.Result = &models.ValidateDashboardBeforeSaveResult{}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetDashboardVersionQuery) error {
+				So(query.Version, ShouldEqual, 3)
+				query.Result = &models.DashboardVersion{Version: 3, Data: dashboardVersion(3, panel(1, "CPU"), panel(2, "Memory"))}
+				return nil
+			})
+			bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+				query.Result = models.NewDashboardFromJson(dashboardVersion(4, panel(1, "CPU usage"), panel(2, "Memory")))
+				return nil
+			})
+			var saved *models.SaveDashboardCommand
+			bus.AddHandler("test", func(cmd *models.SaveDashboardCommand) error {
+				saved = cmd
+				cmd.Result = cmd.GetDashboardModel()
+				return nil
+			})
+			bus.AddHandler("test", func(cmd *models.UpdateDashboardAlertsCommand) error {
+				return nil
+			})
+
+			Convey("Changes to different panels should be merged", func() {
+				dto := &SaveDashboardDTO{
+					Dashboard: models.NewDashboardFromJson(dashboardVersion(3, panel(1, "CPU"), panel(2, "Memory used"))),
+					User:      &models.SignedInUser{UserId: 1},
+				}
+
+				_, err := service.SaveDashboard(dto, true)
+				So(err, ShouldBeNil)
+				So(dto.Merged, ShouldBeTrue)
+				So(saved.Dashboard.Get("version").MustInt(), ShouldEqual, 4)
+				So(saved.Dashboard.Get("panels").GetIndex(0).Get("title").MustString(), ShouldEqual, "CPU usage")
+				So(saved.Dashboard.Get("panels").GetIndex(1).Get("title").MustString(), ShouldEqual, "Memory used")
+			})
+
+			Convey("Changes to the same panel should return the conflicts", func() {
+				dto := &SaveDashboardDTO{
+					Dashboard: models.NewDashboardFromJson(dashboardVersion(3, panel(1, "CPU load"), panel(2, "Memory"))),
+					User:      &models.SignedInUser{UserId: 1},
+				}
+
+				_, err := service.SaveDashboard(dto, true)
+				conflictErr, ok := err.(models.DashboardMergeConflictError)
+				So(ok, ShouldBeTrue)
+				So(conflictErr.Conflicts, ShouldHaveLength, 1)
+				So(conflictErr.Conflicts[0].Path, ShouldEqual, "panels.1.title")
+				So(saved, ShouldBeNil)
+			})
+
+			Convey("Changes should not be merged when overwriting", func() {
+				dto := &SaveDashboardDTO{
+					Dashboard: models.NewDashboardFromJson(dashboardVersion(3, panel(1, "CPU load"))),
+					User:      &models.SignedInUser{UserId: 1},
+					Overwrite: true,
+				}
+
+				_, err := service.SaveDashboard(dto, true)
+				So(err, ShouldEqual, models.ErrDashboardVersionMismatch)
+				So(dto.Merged, ShouldBeFalse)
+			})
+		})
+
 		Convey("Save provisioned dashboard validation", func() {
 			dto := &SaveDashboardDTO{}
 
@@ -293,6 +379,28 @@ func TestDashboardService(t *testing.T) {
 				err := service.DeleteDashboard(1, 1)
 				So(err, ShouldBeNil)
 				So(result.deleteWasCalled, ShouldBeTrue)
+				So(result.trashWasCalled, ShouldBeFalse)
+			})
+
+			Convey("Given trash is enabled", func() {
+				setting.DashboardTrashRetention = 24 * time.Hour
+				Reset(func() {
+					setting.DashboardTrashRetention = 0
+				})
+
+				Convey("DeleteDashboard should move it to the trash", func() {
+					err := service.DeleteDashboard(1, 1)
+					So(err, ShouldBeNil)
+					So(result.trashWasCalled, ShouldBeTrue)
+					So(result.deleteWasCalled, ShouldBeFalse)
+				})
+
+				Convey("DeleteProvisionedDashboard should delete it", func() {
+					err := service.DeleteProvisionedDashboard(1, 1)
+					So(err, ShouldBeNil)
+					So(result.deleteWasCalled, ShouldBeTrue)
+					So(result.trashWasCalled, ShouldBeFalse)
+				})
 			})
 		})
 
@@ -304,6 +412,7 @@ func TestDashboardService(t *testing.T) {
 
 type Result struct {
 	deleteWasCalled bool
+	trashWasCalled  bool
 }
 
 func setupDeleteHandlers(provisioned bool) *Result {
@@ -324,5 +433,12 @@ func setupDeleteHandlers(provisioned bool) *Result {
 		return nil
 	})
 
+	bus.AddHandler("test", func(cmd *models.TrashDashboardCommand) error {
+		So(cmd.Id, ShouldEqual, 1)
+		So(cmd.OrgId, ShouldEqual, 1)
+		result.trashWasCalled = true
+		return nil
+	})
+
 	return result
 }
@@ -14,7 +14,9 @@ type FolderService interface {
 	GetFolderByUID(uid string) (*models.Folder, error)
 	CreateFolder(cmd *models.CreateFolderCommand) error
 	UpdateFolder(uid string, cmd *models.UpdateFolderCommand) error
+	MoveFolder(uid string, cmd *models.MoveFolderCommand) error
 	DeleteFolder(uid string) (*models.Folder, error)
+	GetFolderChildren(uid string, limit int64) (search.HitList, error)
 }
 
 // NewFolderService factory for creating a new folder service
@@ -44,9 +46,11 @@ func (dr *dashboardServiceImpl) GetFolders(limit int64) ([]*models.Folder, error
 
 	for _, hit := range searchQuery.Result {
 		folders = append(folders, &models.Folder{
-			Id:    hit.Id,
-			Uid:   hit.Uid,
-			Title: hit.Title,
+			Id:        hit.Id,
+			Uid:       hit.Uid,
+			Title:     hit.Title,
+			ParentId:  hit.FolderId,
+			ParentUid: hit.FolderUid,
 		})
 	}
 
@@ -69,7 +73,7 @@ func (dr *dashboardServiceImpl) GetFolderByID(id int64) (*models.Folder, error)
 		return nil, models.ErrFolderAccessDenied
 	}
 
-	return dashToFolder(dashFolder), nil
+	return toFolder(dashFolder)
 }
 
 func (dr *dashboardServiceImpl) GetFolderByUID(uid string) (*models.Folder, error) {
@@ -88,12 +92,20 @@ func (dr *dashboardServiceImpl) GetFolderByUID(uid string) (*models.Folder, erro
 		return nil, models.ErrFolderAccessDenied
 	}
 
-	return dashToFolder(dashFolder), nil
+	return toFolder(dashFolder)
 }
 
 func (dr *dashboardServiceImpl) CreateFolder(cmd *models.CreateFolderCommand) error {
 	dashFolder := cmd.GetDashboardModel(dr.orgId, dr.user.UserId)
 
+	if cmd.ParentUid != "" {
+		parentId, err := getParentFolderId(dr.orgId, cmd.ParentUid)
+		if err != nil {
+			return err
+		}
+		dashFolder.FolderId = parentId
+	}
+
 	dto := &SaveDashboardDTO{
 		Dashboard: dashFolder,
 		OrgId:     dr.orgId,
@@ -116,9 +128,8 @@ func (dr *dashboardServiceImpl) CreateFolder(cmd *models.CreateFolderCommand) er
 		return toFolderError(err)
 	}
 
-	cmd.Result = dashToFolder(dashFolder)
-
-	return nil
+	cmd.Result, err = toFolder(dashFolder)
+	return err
 }
 
 func (dr *dashboardServiceImpl) UpdateFolder(existingUid string, cmd *models.UpdateFolderCommand) error {
@@ -153,9 +164,83 @@ func (dr *dashboardServiceImpl) UpdateFolder(existingUid string, cmd *models.Upd
 		return toFolderError(err)
 	}
 
-	cmd.Result = dashToFolder(dashFolder)
+	cmd.Result, err = toFolder(dashFolder)
+	return err
+}
+
+func (dr *dashboardServiceImpl) MoveFolder(uid string, cmd *models.MoveFolderCommand) error {
+	query := models.GetDashboardQuery{OrgId: dr.orgId, Uid: uid}
+	dashFolder, err := getFolder(query)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	parentId := int64(0)
+	if cmd.ParentUid != "" {
+		if parentId, err = getParentFolderId(dr.orgId, cmd.ParentUid); err != nil {
+			return err
+		}
+	}
+
+	dashFolder.FolderId = parentId
+	if cmd.Version != 0 {
+		dashFolder.SetVersion(cmd.Version)
+	}
+	if dr.user.UserId == 0 {
+		dashFolder.UpdatedBy = -1
+	} else {
+		dashFolder.UpdatedBy = dr.user.UserId
+	}
+
+	dto := &SaveDashboardDTO{
+		Dashboard: dashFolder,
+		OrgId:     dr.orgId,
+		User:      dr.user,
+		Overwrite: cmd.Overwrite,
+	}
+
+	saveDashboardCmd, err := dr.buildSaveDashboardCommand(dto, false, false)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	err = bus.Dispatch(saveDashboardCmd)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	query = models.GetDashboardQuery{OrgId: dr.orgId, Id: saveDashboardCmd.Result.Id}
+	dashFolder, err = getFolder(query)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	cmd.Result, err = toFolder(dashFolder)
+	return err
+}
+
+// GetFolderChildren returns the folders and dashboards directly in a folder
+// that the user can view
+func (dr *dashboardServiceImpl) GetFolderChildren(uid string, limit int64) (search.HitList, error) {
+	folder, err := dr.GetFolderByUID(uid)
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	searchQuery := search.Query{
+		SignedInUser: dr.user,
+		DashboardIds: make([]int64, 0),
+		FolderIds:    []int64{folder.Id},
+		Limit:        limit,
+		OrgId:        dr.orgId,
+		Permission:   models.PERMISSION_VIEW,
+	}
+
+	if err := bus.Dispatch(&searchQuery); err != nil {
+		return nil, err
+	}
+
+	return searchQuery.Result, nil
 }
 
 func (dr *dashboardServiceImpl) DeleteFolder(uid string) (*models.Folder, error) {
@@ -173,8 +258,7 @@ func (dr *dashboardServiceImpl) DeleteFolder(uid string) (*models.Folder, error)
 		return nil, models.ErrFolderAccessDenied
 	}
 
-	deleteCmd := models.DeleteDashboardCommand{OrgId: dr.orgId, Id: dashFolder.Id}
-	if err := bus.Dispatch(&deleteCmd); err != nil {
+	if err := trashDashboard(dashFolder.Id, dr.orgId); err != nil {
 		return nil, toFolderError(err)
 	}
 
@@ -193,6 +277,35 @@ func getFolder(query models.GetDashboardQuery) (*models.Dashboard, error) {
 	return query.Result, nil
 }
 
+// getParentFolderId returns the id of the folder to create or move a folder into
+func getParentFolderId(orgId int64, parentUid string) (int64, error) {
+	parent, err := getFolder(models.GetDashboardQuery{OrgId: orgId, Uid: parentUid})
+	if err != nil {
+		if err == models.ErrFolderNotFound {
+			return 0, models.ErrParentFolderNotFound
+		}
+		return 0, err
+	}
+
+	return parent.Id, nil
+}
+
+// toFolder turns a folder dashboard into a folder, with the uid of its parent
+func toFolder(dash *models.Dashboard) (*models.Folder, error) {
+	folder := dashToFolder(dash)
+	if folder.ParentId == 0 {
+		return folder, nil
+	}
+
+	query := models.GetDashboardRefByIdQuery{Id: folder.ParentId}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, toFolderError(err)
+	}
+	folder.ParentUid = query.Result.Uid
+
+	return folder, nil
+}
+
 func dashToFolder(dash *models.Dashboard) *models.Folder {
 	return &models.Folder{
 		Id:        dash.Id,
@@ -205,6 +318,7 @@ func dashToFolder(dash *models.Dashboard) *models.Folder {
 		CreatedBy: dash.CreatedBy,
 		Updated:   dash.Updated,
 		UpdatedBy: dash.UpdatedBy,
+		ParentId:  dash.FolderId,
 	}
 }
 
@@ -65,6 +65,12 @@ func TestFolderService(t *testing.T) {
 				So(err, ShouldEqual, models.ErrFolderAccessDenied)
 			})
 
+			Convey("When moving folder should return access denied error", func() {
+				err := service.MoveFolder("uid", &models.MoveFolderCommand{})
+				So(err, ShouldNotBeNil)
+				So(err, ShouldEqual, models.ErrFolderAccessDenied)
+			})
+
 			Convey("When deleting folder by uid should return access denied error", func() {
 				_, err := service.DeleteFolder("uid")
 				So(err, ShouldNotBeNil)
@@ -172,6 +178,19 @@ func TestFolderService(t *testing.T) {
 				So(f.Title, ShouldEqual, dashFolder.Title)
 			})
 
+			Convey("When get subfolder by uid should return folder with its parent", func() {
+				dashFolder.FolderId = 2
+				bus.AddHandler("test", func(query *models.GetDashboardRefByIdQuery) error {
+					query.Result = &models.DashboardRef{Uid: "parent-uid"}
+					return nil
+				})
+
+				f, err := service.GetFolderByUID("uid")
+				So(err, ShouldBeNil)
+				So(f.ParentId, ShouldEqual, 2)
+				So(f.ParentUid, ShouldEqual, "parent-uid")
+			})
+
 			Reset(func() {
 				guardian.New = origNewGuardian
 			})
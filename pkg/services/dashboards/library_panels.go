@@ -0,0 +1,173 @@
+package dashboards
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+)
+
+// LoadLibraryPanels replaces the library panels of a dashboard with their
+// model from the library. When user is set, the library panels in folders the
+// user can't view are left as references.
+func LoadLibraryPanels(dash *models.Dashboard, user *models.SignedInUser) error {
+	uids := dash.LibraryPanelUids()
+	if len(uids) == 0 {
+		return nil
+	}
+
+	query := models.GetLibraryPanelsQuery{OrgId: dash.OrgId, Uids: uids}
+	if err := bus.Dispatch(&query); err != nil {
+		return err
+	}
+
+	canViewFolder := make(map[int64]bool)
+	panels := make(map[string]*models.LibraryPanel, len(query.Result))
+	for _, panel := range query.Result {
+		if user != nil {
+			canView, ok := canViewFolder[panel.FolderId]
+			if !ok {
+				var err error
+				if canView, err = guardian.New(panel.FolderId, dash.OrgId, user).CanView(); err != nil {
+					return err
+				}
+				canViewFolder[panel.FolderId] = canView
+			}
+			if !canView {
+				continue
+			}
+		}
+		panels[panel.Uid] = panel
+	}
+	dash.LoadLibraryPanels(panels)
+	return nil
+}
+
+// withLibraryPanels returns a copy of a saved dashboard with the model of its
+// library panels, or the dashboard itself if it has no library panels.
+func withLibraryPanels(dash *models.Dashboard) (*models.Dashboard, error) {
+	if dash == nil || len(dash.LibraryPanelUids()) == 0 {
+		return dash, nil
+	}
+
+	data, err := dash.Data.Encode()
+	if err != nil {
+		return nil, err
+	}
+	resolved := *dash
+	if resolved.Data, err = simplejson.NewJson(data); err != nil {
+		return nil, err
+	}
+
+	if err := LoadLibraryPanels(&resolved, nil); err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+// ValidateLibraryPanelAlerts validates the alert of the model of a library
+// panel before it's saved, since its alerts are updated afterwards.
+func ValidateLibraryPanelAlerts(model *simplejson.Json, orgId int64, user *models.SignedInUser) error {
+	// the id of the panel is set by the dashboards using it
+	panel := map[string]interface{}{"id": 1}
+	for k, v := range model.MustMap() {
+		panel[k] = v
+	}
+	dash := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+		"panels": []interface{}{panel},
+	}))
+	dash.OrgId = orgId
+
+	cmd := models.ValidateDashboardAlertsCommand{OrgId: orgId, Dashboard: dash, User: user}
+	return bus.Dispatch(&cmd)
+}
+
+// UpdateLibraryPanelAlerts updates the alerts of the dashboards using a
+// library panel from its model. Alerts are otherwise only extracted when a
+// dashboard is saved, so a new version of the library panel wouldn't reach
+// the alert engine.
+func UpdateLibraryPanelAlerts(panel *models.LibraryPanel, user *models.SignedInUser) error {
+	query := models.GetLibraryPanelDashboardsQuery{OrgId: panel.OrgId, LibraryPanelId: panel.Id}
+	if err := bus.Dispatch(&query); err != nil {
+		return err
+	}
+
+	for _, connected := range query.Result {
+		dashQuery := models.GetDashboardQuery{Id: connected.Id, OrgId: panel.OrgId}
+		if err := bus.Dispatch(&dashQuery); err != nil {
+			return err
+		}
+
+		dash, err := withLibraryPanels(dashQuery.Result)
+		if err != nil {
+			return err
+		}
+
+		alertCmd := models.UpdateDashboardAlertsCommand{
+			OrgId:     panel.OrgId,
+			Dashboard: dash,
+			User:      user,
+		}
+		if err := bus.Dispatch(&alertCmd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateLibraryPanels checks that the user can view the folders of the
+// library panels added to a dashboard.
+func validateLibraryPanels(dash *models.Dashboard, orgId int64, user *models.SignedInUser) error {
+	uids := dash.LibraryPanelUids()
+	if len(uids) == 0 {
+		return nil
+	}
+
+	connected := make(map[string]bool)
+	if dash.Id > 0 {
+		query := models.GetDashboardQuery{Id: dash.Id, OrgId: orgId}
+		if err := bus.Dispatch(&query); err != nil && !errors.Is(err, models.ErrDashboardNotFound) {
+			return err
+		}
+		if query.Result != nil {
+			for _, uid := range query.Result.LibraryPanelUids() {
+				connected[uid] = true
+			}
+		}
+	}
+
+	added := make([]string, 0, len(uids))
+	for _, uid := range uids {
+		if !connected[uid] {
+			added = append(added, uid)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	query := models.GetLibraryPanelsQuery{OrgId: orgId, Uids: added}
+	if err := bus.Dispatch(&query); err != nil {
+		return err
+	}
+
+	checked := make(map[int64]bool)
+	for _, panel := range query.Result {
+		if checked[panel.FolderId] {
+			continue
+		}
+		canView, err := guardian.New(panel.FolderId, orgId, user).CanView()
+		if err != nil {
+			return err
+		}
+		if !canView {
+			return models.ErrDashboardLibraryPanelAccessDenied
+		}
+		checked[panel.FolderId] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package dashboards
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryPanels(t *testing.T) {
+	cpu := &models.LibraryPanel{
+		Id: 1, OrgId: 1, FolderId: 2, Uid: "cpu", Name: "CPU", Version: 3,
+		Model: simplejson.NewFromAny(map[string]interface{}{
+			"type":  "graph",
+			"title": "CPU",
+			"alert": map[string]interface{}{"name": "CPU alert"},
+		}),
+	}
+	secret := &models.LibraryPanel{
+		Id: 2, OrgId: 1, FolderId: 3, Uid: "secret", Name: "Secret", Version: 1,
+		Model: simplejson.NewFromAny(map[string]interface{}{"type": "graph", "title": "Secret"}),
+	}
+
+	// the user can view folder 2 but not folder 3, and can save dashboards
+	setup := func(t *testing.T) {
+		t.Cleanup(bus.ClearBusHandlers)
+
+		origNewGuardian := guardian.New
+		t.Cleanup(func() { guardian.New = origNewGuardian })
+		guardian.New = func(dashId int64, orgId int64, user *models.SignedInUser) guardian.DashboardGuardian {
+			return &guardian.FakeDashboardGuardian{CanViewValue: dashId != 3, CanSaveValue: true}
+		}
+
+		bus.AddHandler("test", func(query *models.GetLibraryPanelsQuery) error {
+			query.Result = make([]*models.LibraryPanel, 0)
+			for _, panel := range []*models.LibraryPanel{cpu, secret} {
+				for _, uid := range query.Uids {
+					if panel.Uid == uid {
+						query.Result = append(query.Result, panel)
+					}
+				}
+			}
+			return nil
+		})
+	}
+
+	newDashboard := func(panels ...interface{}) *models.Dashboard {
+		dash := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+			"title":  "Hosts",
+			"panels": panels,
+		}))
+		dash.OrgId = 1
+		return dash
+	}
+
+	t.Run("loads the library panels of a dashboard", func(t *testing.T) {
+		setup(t)
+
+		dash := newDashboard(
+			map[string]interface{}{"id": 1, "gridPos": map[string]interface{}{"x": 6}, "libraryPanel": map[string]interface{}{"uid": "cpu"}},
+			map[string]interface{}{"id": 2, "libraryPanel": map[string]interface{}{"uid": "missing"}},
+		)
+		require.NoError(t, LoadLibraryPanels(dash, nil))
+
+		panels := dash.Data.Get("panels")
+		assert.Equal(t, "graph", panels.GetIndex(0).Get("type").MustString())
+		assert.Equal(t, 1, panels.GetIndex(0).Get("id").MustInt())
+		assert.Equal(t, 6, panels.GetIndex(0).Get("gridPos").Get("x").MustInt())
+		assert.EqualValues(t, 3, panels.GetIndex(0).Get("libraryPanel").Get("version").MustInt64())
+		assert.Equal(t, "missing", panels.GetIndex(1).Get("libraryPanel").Get("uid").MustString())
+		_, hasType := panels.GetIndex(1).CheckGet("type")
+		assert.False(t, hasType)
+	})
+
+	t.Run("doesn't load the library panels in folders the user can't view", func(t *testing.T) {
+		setup(t)
+
+		dash := newDashboard(
+			map[string]interface{}{"id": 1, "libraryPanel": map[string]interface{}{"uid": "cpu"}},
+			map[string]interface{}{"id": 2, "libraryPanel": map[string]interface{}{"uid": "secret", "name": "Secret"}},
+		)
+		require.NoError(t, LoadLibraryPanels(dash, &models.SignedInUser{OrgId: 1, UserId: 1}))
+
+		panels := dash.Data.Get("panels")
+		assert.Equal(t, "CPU", panels.GetIndex(0).Get("title").MustString())
+		assert.Equal(t, map[string]interface{}{"uid": "secret", "name": "Secret"}, panels.GetIndex(1).Get("libraryPanel").MustMap())
+		_, hasTitle := panels.GetIndex(1).CheckGet("title")
+		assert.False(t, hasTitle)
+	})
+
+	save := func(t *testing.T, dash *models.Dashboard) (*models.Dashboard, error) {
+		bus.AddHandler("test", func(cmd *models.ValidateDashboardAlertsCommand) error {
+			return nil
+		})
+		bus.AddHandler("test", func(cmd *models.ValidateDashboardBeforeSaveCommand) error {
+			cmd.Result = &models.ValidateDashboardBeforeSaveResult{}
+			return nil
+		})
+		bus.AddHandler("test", func(cmd *models.SaveDashboardCommand) error {
+			cmd.Result = cmd.GetDashboardModel()
+			cmd.Result.StripLibraryPanels()
+			return nil
+		})
+		var alerting *models.Dashboard
+		bus.AddHandler("test", func(cmd *models.UpdateDashboardAlertsCommand) error {
+			alerting = cmd.Dashboard
+			return nil
+		})
+
+		service := &dashboardServiceImpl{log: log.New("test.logger")}
+		dto := &SaveDashboardDTO{OrgId: 1, Dashboard: dash, User: &models.SignedInUser{OrgId: 1, UserId: 1}}
+		saved, err := service.SaveDashboard(dto, true)
+		if err != nil {
+			return nil, err
+		}
+		_, hasTitle := saved.Data.Get("panels").GetIndex(0).CheckGet("title")
+		assert.False(t, hasTitle, "the saved dashboard should only reference the library panel")
+		return alerting, nil
+	}
+
+	t.Run("updates the alerts of the library panels of a saved dashboard", func(t *testing.T) {
+		setup(t)
+
+		alerting, err := save(t, newDashboard(
+			map[string]interface{}{"id": 1, "libraryPanel": map[string]interface{}{"uid": "cpu", "name": "CPU"}, "title": "CPU"},
+		))
+		require.NoError(t, err)
+
+		require.NotNil(t, alerting)
+		assert.Equal(t, "CPU alert", alerting.Data.Get("panels").GetIndex(0).Get("alert").Get("name").MustString())
+	})
+
+	t.Run("updates the alerts of the dashboards using an updated library panel", func(t *testing.T) {
+		setup(t)
+		bus.AddHandler("test", func(query *models.GetLibraryPanelDashboardsQuery) error {
+			assert.Equal(t, cpu.Id, query.LibraryPanelId)
+			query.Result = []*models.Dashboard{{Id: 10, OrgId: 1}, {Id: 11, OrgId: 1}}
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+			query.Result = newDashboard(map[string]interface{}{"id": 1, "libraryPanel": map[string]interface{}{"uid": "cpu", "name": "CPU"}})
+			query.Result.SetId(query.Id)
+			return nil
+		})
+		var alerting []*models.Dashboard
+		bus.AddHandler("test", func(cmd *models.UpdateDashboardAlertsCommand) error {
+			alerting = append(alerting, cmd.Dashboard)
+			return nil
+		})
+
+		require.NoError(t, UpdateLibraryPanelAlerts(cpu, &models.SignedInUser{OrgId: 1, UserId: 1}))
+
+		require.Len(t, alerting, 2)
+		for i, id := range []int64{10, 11} {
+			assert.Equal(t, id, alerting[i].Id)
+			assert.Equal(t, "CPU alert", alerting[i].Data.Get("panels").GetIndex(0).Get("alert").Get("name").MustString())
+		}
+	})
+
+	t.Run("validates the alert of a library panel without panel id", func(t *testing.T) {
+		setup(t)
+		var validated *models.Dashboard
+		bus.AddHandler("test", func(cmd *models.ValidateDashboardAlertsCommand) error {
+			validated = cmd.Dashboard
+			return nil
+		})
+
+		require.NoError(t, ValidateLibraryPanelAlerts(cpu.Model, 1, &models.SignedInUser{OrgId: 1, UserId: 1}))
+
+		require.NotNil(t, validated)
+		panel := validated.Data.Get("panels").GetIndex(0)
+		assert.Equal(t, 1, panel.Get("id").MustInt())
+		assert.Equal(t, "CPU alert", panel.Get("alert").Get("name").MustString())
+		_, hasId := cpu.Model.CheckGet("id")
+		assert.False(t, hasId, "the model of the library panel should be left unchanged")
+	})
+
+	t.Run("refuses to add library panels in folders the user can't view", func(t *testing.T) {
+		setup(t)
+
+		_, err := save(t, newDashboard(
+			map[string]interface{}{"id": 1, "libraryPanel": map[string]interface{}{"uid": "secret", "name": "Secret"}},
+		))
+		assert.Equal(t, models.ErrDashboardLibraryPanelAccessDenied, err)
+	})
+
+	t.Run("keeps the library panels the dashboard already uses", func(t *testing.T) {
+		setup(t)
+		bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+			query.Result = newDashboard(map[string]interface{}{"id": 1, "libraryPanel": map[string]interface{}{"uid": "secret", "name": "Secret"}})
+			return nil
+		})
+
+		dash := newDashboard(
+			map[string]interface{}{"id": 1, "libraryPanel": map[string]interface{}{"uid": "secret", "name": "Secret"}},
+		)
+		dash.SetId(10)
+		_, err := save(t, dash)
+		assert.NoError(t, err)
+	})
+}
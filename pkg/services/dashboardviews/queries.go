@@ -0,0 +1,32 @@
+package dashboardviews
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// DashboardIdHeader is the header with the id of the dashboard sending a
+// data source query
+const DashboardIdHeader = "X-Dashboard-Id"
+
+var logger = log.New("dashboardviews")
+
+// RecordQueries records the data source queries of a request, and how many
+// of them failed, when the request was sent by a dashboard of the
+// organization.
+func RecordQueries(req *http.Request, orgId int64, queries int, failed int) {
+	dashboardId, err := strconv.ParseInt(req.Header.Get(DashboardIdHeader), 10, 64)
+	if err != nil || dashboardId <= 0 || queries == 0 {
+		return
+	}
+
+	cmd := models.RecordDashboardQueriesCommand{DashboardId: dashboardId, OrgId: orgId, Queries: int64(queries), Errors: int64(failed)}
+	if err := bus.Dispatch(&cmd); err != nil && !errors.Is(err, bus.ErrHandlerNotFound) {
+		logger.Warn("Failed to record dashboard queries", "dashboardId", dashboardId, "error", err)
+	}
+}
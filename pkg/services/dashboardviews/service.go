@@ -0,0 +1,170 @@
+package dashboardviews
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// flushInterval is how often the recorded views and queries are saved
+	flushInterval = time.Second * 30
+	// maxPendingViews is the number of dashboards and users with pending
+	// views, or dashboards with pending queries, above which they are saved
+	// before the next interval
+	maxPendingViews = 10000
+)
+
+func init() {
+	registry.RegisterService(&DashboardViewsService{})
+}
+
+// DashboardViewsService records the views of the dashboards and the data
+// source queries they run. They are aggregated in memory and saved
+// periodically, so that recording them doesn't slow down loading the
+// dashboard or running its queries. Queries are sampled according to the
+// query_sample_rate setting.
+type DashboardViewsService struct {
+	Bus bus.Bus `inject:""`
+
+	log            log.Logger
+	mutex          sync.Mutex
+	pending        map[viewKey]*models.DashboardViews
+	pendingQueries map[queryKey]*models.DashboardQueries
+	flush          chan struct{}
+	sample         func() bool
+}
+
+type viewKey struct {
+	dashboardId int64
+	userId      int64
+}
+
+type queryKey struct {
+	dashboardId int64
+	orgId       int64
+}
+
+func (s *DashboardViewsService) Init() error {
+	s.log = logger
+	s.pending = make(map[viewKey]*models.DashboardViews)
+	s.pendingQueries = make(map[queryKey]*models.DashboardQueries)
+	s.flush = make(chan struct{}, 1)
+	s.sample = func() bool {
+		return rand.Float64() < setting.DashboardQuerySampleRate
+	}
+	s.Bus.AddHandler(s.recordView)
+	s.Bus.AddHandler(s.recordQueries)
+	return nil
+}
+
+func (s *DashboardViewsService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.saveViews()
+		case <-s.flush:
+			s.saveViews()
+		case <-ctx.Done():
+			s.saveViews()
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *DashboardViewsService) recordView(cmd *models.RecordDashboardViewCommand) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := viewKey{dashboardId: cmd.DashboardId, userId: cmd.UserId}
+	views, ok := s.pending[key]
+	if !ok {
+		views = &models.DashboardViews{DashboardId: cmd.DashboardId, UserId: cmd.UserId}
+		s.pending[key] = views
+	}
+	views.Count++
+	views.LastViewed = time.Now()
+
+	s.flushIfFull()
+	return nil
+}
+
+// recordQueries records a sample of the queries, scaled up by the sample
+// rate.
+func (s *DashboardViewsService) recordQueries(cmd *models.RecordDashboardQueriesCommand) error {
+	if cmd.DashboardId == 0 || !s.sample() {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := queryKey{dashboardId: cmd.DashboardId, orgId: cmd.OrgId}
+	queries, ok := s.pendingQueries[key]
+	if !ok {
+		queries = &models.DashboardQueries{DashboardId: cmd.DashboardId, OrgId: cmd.OrgId}
+		s.pendingQueries[key] = queries
+	}
+	queries.Count += scale(cmd.Queries)
+	queries.Errors += scale(cmd.Errors)
+	queries.LastQueried = time.Now()
+
+	s.flushIfFull()
+	return nil
+}
+
+func scale(count int64) int64 {
+	if setting.DashboardQuerySampleRate <= 0 {
+		return 0
+	}
+	return int64(math.Round(float64(count) / setting.DashboardQuerySampleRate))
+}
+
+// flushIfFull signals that the pending views and queries must be saved
+// when there are too many of them. The mutex must be held.
+func (s *DashboardViewsService) flushIfFull() {
+	if len(s.pending) >= maxPendingViews || len(s.pendingQueries) >= maxPendingViews {
+		select {
+		case s.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// saveViews saves the views and queries recorded since the last save
+func (s *DashboardViewsService) saveViews() {
+	s.mutex.Lock()
+	pending, pendingQueries := s.pending, s.pendingQueries
+	s.pending = make(map[viewKey]*models.DashboardViews)
+	s.pendingQueries = make(map[queryKey]*models.DashboardQueries)
+	s.mutex.Unlock()
+
+	if len(pending) == 0 && len(pendingQueries) == 0 {
+		return
+	}
+
+	cmd := models.SaveDashboardViewsCommand{
+		Views:   make([]*models.DashboardViews, 0, len(pending)),
+		Queries: make([]*models.DashboardQueries, 0, len(pendingQueries)),
+	}
+	for _, views := range pending {
+		cmd.Views = append(cmd.Views, views)
+	}
+	for _, queries := range pendingQueries {
+		cmd.Queries = append(cmd.Queries, queries)
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		s.log.Error("Failed to save dashboard views", "views", len(cmd.Views), "queries", len(cmd.Queries), "error", err)
+	}
+}
@@ -0,0 +1,88 @@
+package dashboardviews
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardViewsService(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	var saved []*models.DashboardViews
+	bus.AddHandler("test", func(cmd *models.SaveDashboardViewsCommand) error {
+		saved = append(saved, cmd.Views...)
+		return nil
+	})
+
+	s := &DashboardViewsService{Bus: bus.GetBus()}
+	require.NoError(t, s.Init())
+
+	for _, cmd := range []models.RecordDashboardViewCommand{
+		{DashboardId: 1, UserId: 2},
+		{DashboardId: 1, UserId: 2},
+		{DashboardId: 1, UserId: 3},
+		{DashboardId: 4, UserId: 0},
+	} {
+		require.NoError(t, bus.Dispatch(&cmd))
+	}
+
+	s.saveViews()
+	require.Len(t, saved, 3)
+	counts := make(map[viewKey]int64)
+	for _, views := range saved {
+		assert.False(t, views.LastViewed.IsZero())
+		counts[viewKey{dashboardId: views.DashboardId, userId: views.UserId}] = views.Count
+	}
+	assert.Equal(t, map[viewKey]int64{{1, 2}: 2, {1, 3}: 1, {4, 0}: 1}, counts)
+
+	// the saved views are not saved again
+	saved = nil
+	s.saveViews()
+	assert.Empty(t, saved)
+}
+
+func TestDashboardViewsService_Queries(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	var saved []*models.DashboardQueries
+	bus.AddHandler("test", func(cmd *models.SaveDashboardViewsCommand) error {
+		saved = append(saved, cmd.Queries...)
+		return nil
+	})
+
+	s := &DashboardViewsService{Bus: bus.GetBus()}
+	require.NoError(t, s.Init())
+
+	sampleRate := setting.DashboardQuerySampleRate
+	t.Cleanup(func() { setting.DashboardQuerySampleRate = sampleRate })
+	setting.DashboardQuerySampleRate = 0.5
+
+	sampled := false
+	s.sample = func() bool {
+		sampled = !sampled
+		return sampled
+	}
+
+	for _, cmd := range []models.RecordDashboardQueriesCommand{
+		{DashboardId: 1, OrgId: 1, Queries: 3, Errors: 1},
+		{DashboardId: 1, OrgId: 1, Queries: 2},
+		{DashboardId: 1, OrgId: 1, Queries: 2},
+		{DashboardId: 0, OrgId: 1, Queries: 1},
+	} {
+		require.NoError(t, bus.Dispatch(&cmd))
+	}
+
+	s.saveViews()
+	require.Len(t, saved, 1)
+	// the sampled queries are scaled up by the sample rate
+	assert.EqualValues(t, 1, saved[0].DashboardId)
+	assert.EqualValues(t, 1, saved[0].OrgId)
+	assert.EqualValues(t, 10, saved[0].Count)
+	assert.EqualValues(t, 2, saved[0].Errors)
+	assert.False(t, saved[0].LastQueried.IsZero())
+}
@@ -0,0 +1,247 @@
+package datasources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/datasource/wrapper"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// healthCheckConcurrency is the number of datasources checked at the same time
+const healthCheckConcurrency = 10
+
+// CheckHealth checks the health of a datasource with its backend plugin, or
+// with the query endpoint of its type for the core datasources. It returns
+// tsdb.ErrHealthCheckNotSupported if neither can check the datasource.
+func CheckHealth(ctx context.Context, manager backendplugin.Manager, ds *models.DataSource, user *models.SignedInUser) (*tsdb.HealthCheckResult, error) {
+	result, err := checkPluginHealth(ctx, manager, ds, user)
+	if err == nil {
+		return result, nil
+	}
+	if !errors.Is(err, backendplugin.ErrPluginNotRegistered) && !errors.Is(err, backendplugin.ErrMethodNotImplemented) {
+		return nil, err
+	}
+
+	return tsdb.CheckHealth(ctx, ds)
+}
+
+func checkPluginHealth(ctx context.Context, manager backendplugin.Manager, ds *models.DataSource, user *models.SignedInUser) (*tsdb.HealthCheckResult, error) {
+	dsInstanceSettings, err := wrapper.ModelToInstanceSettings(ds)
+	if err != nil {
+		return nil, err
+	}
+
+	pCtx := backend.PluginContext{
+		OrgID:                      ds.OrgId,
+		PluginID:                   ds.Type,
+		DataSourceInstanceSettings: dsInstanceSettings,
+	}
+	if user != nil {
+		pCtx.User = wrapper.BackendUserFromSignedInUser(user)
+	}
+
+	resp, err := manager.CheckHealth(ctx, pCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &tsdb.HealthCheckResult{Status: tsdb.HealthStatusError, Message: resp.Message}
+	if resp.Status == backend.HealthStatusOk {
+		result.Status = tsdb.HealthStatusOK
+	}
+	if len(resp.JSONDetails) > 0 {
+		if err := json.Unmarshal(resp.JSONDetails, &result.Details); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// HealthCheckService periodically checks the health of all datasources,
+// records the results and exposes them as the grafana_datasource_up metric.
+//
+// Every instance checks the datasources, as reachability may differ between
+// instances, but the results are only recorded by one instance.
+type HealthCheckService struct {
+	Cfg                  *setting.Cfg                  `inject:""`
+	ServerLockService    *serverlock.ServerLockService `inject:""`
+	BackendPluginManager backendplugin.Manager         `inject:""`
+
+	log    log.Logger
+	labels map[string][]string
+}
+
+func init() {
+	registry.RegisterService(&HealthCheckService{})
+}
+
+func (s *HealthCheckService) Init() error {
+	s.log = log.New("datasources.health")
+	s.labels = make(map[string][]string)
+	return nil
+}
+
+func (s *HealthCheckService) IsDisabled() bool {
+	return !s.Cfg.DataSourceHealthCheckEnabled
+}
+
+func (s *HealthCheckService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.Cfg.DataSourceHealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.checkAll(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+type healthCheck struct {
+	ds       *models.DataSource
+	result   *tsdb.HealthCheckResult
+	duration time.Duration
+}
+
+func (s *HealthCheckService) checkAll(ctx context.Context) {
+	query := models.GetAllDataSourcesQuery{}
+	if err := bus.Dispatch(&query); err != nil {
+		s.log.Error("Failed to get datasources", "error", err)
+		return
+	}
+
+	checks := s.checkDataSources(ctx, query.Result)
+	if ctx.Err() != nil {
+		return
+	}
+
+	s.updateMetrics(checks)
+
+	// Only one instance records the results of each round of checks
+	err := s.ServerLockService.LockAndExecute(ctx, "record datasource health", s.Cfg.DataSourceHealthCheckInterval/2, func() {
+		s.recordHistory(checks)
+	})
+	if err != nil {
+		s.log.Error("Failed to lock and record datasource health", "error", err)
+	}
+}
+
+// checkDataSources checks the datasources concurrently, and returns the
+// results of the datasources supporting health checks.
+func (s *HealthCheckService) checkDataSources(ctx context.Context, dataSources []*models.DataSource) []*healthCheck {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	checks := make([]*healthCheck, 0, len(dataSources))
+	sem := make(chan struct{}, healthCheckConcurrency)
+
+	for _, ds := range dataSources {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ds *models.DataSource) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			check := s.checkDataSource(ctx, ds)
+			if check == nil {
+				return
+			}
+
+			mu.Lock()
+			checks = append(checks, check)
+			mu.Unlock()
+		}(ds)
+	}
+	wg.Wait()
+
+	return checks
+}
+
+func (s *HealthCheckService) checkDataSource(ctx context.Context, ds *models.DataSource) *healthCheck {
+	ctx, cancel := context.WithTimeout(ctx, s.Cfg.DataSourceHealthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	result, err := CheckHealth(ctx, s.BackendPluginManager, ds, nil)
+	if errors.Is(err, tsdb.ErrHealthCheckNotSupported) {
+		return nil
+	}
+	if err != nil {
+		result = tsdb.HealthCheckError(err)
+	}
+	if result.Status != tsdb.HealthStatusOK && ctx.Err() == context.DeadlineExceeded {
+		result = &tsdb.HealthCheckResult{Status: tsdb.HealthStatusError, Message: "Health check timed out"}
+	}
+
+	if result.Status != tsdb.HealthStatusOK {
+		s.log.Debug("Datasource health check failed", "datasource", ds.Name, "orgId", ds.OrgId, "message", result.Message)
+	}
+
+	return &healthCheck{ds: ds, result: result, duration: time.Since(start)}
+}
+
+// updateMetrics sets grafana_datasource_up, and removes the series of the
+// datasources that no longer exist or can no longer be checked.
+func (s *HealthCheckService) updateMetrics(checks []*healthCheck) {
+	seen := make(map[string][]string, len(checks))
+	for _, check := range checks {
+		labels := []string{strconv.FormatInt(check.ds.OrgId, 10), check.ds.Uid, check.ds.Type}
+		seen[check.ds.Uid+"|"+labels[0]] = labels
+
+		up := 0.0
+		if check.result.Status == tsdb.HealthStatusOK {
+			up = 1
+		}
+		metrics.MDataSourceUp.WithLabelValues(labels...).Set(up)
+	}
+
+	for key, labels := range s.labels {
+		if current, ok := seen[key]; !ok || current[2] != labels[2] {
+			metrics.MDataSourceUp.DeleteLabelValues(labels...)
+		}
+	}
+	s.labels = seen
+}
+
+func (s *HealthCheckService) recordHistory(checks []*healthCheck) {
+	for _, check := range checks {
+		cmd := models.SaveDataSourceHealthCommand{
+			OrgId:        check.ds.OrgId,
+			DataSourceId: check.ds.Id,
+			Status:       string(check.result.Status),
+			Message:      check.result.Message,
+			DurationMs:   check.duration.Milliseconds(),
+		}
+		if err := bus.Dispatch(&cmd); err != nil {
+			s.log.Error("Failed to record datasource health", "datasource", check.ds.Name, "orgId", check.ds.OrgId, "error", err)
+		}
+	}
+
+	cmd := models.DeleteOldDataSourceHealthCommand{OlderThan: time.Now().Add(-s.Cfg.DataSourceHealthHistoryMaxAge)}
+	if err := bus.Dispatch(&cmd); err != nil {
+		s.log.Error("Failed to delete old datasource health", "error", err)
+		return
+	}
+	if cmd.DeletedRows > 0 {
+		s.log.Debug("Deleted old datasource health", "rows", cmd.DeletedRows)
+	}
+}
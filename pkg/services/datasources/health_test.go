@@ -0,0 +1,93 @@
+package datasources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackendPluginManager struct {
+	backendplugin.Manager
+	results map[string]*backend.CheckHealthResult
+}
+
+func (m *fakeBackendPluginManager) CheckHealth(ctx context.Context, pCtx backend.PluginContext) (*backend.CheckHealthResult, error) {
+	result, ok := m.results[pCtx.PluginID]
+	if !ok {
+		return nil, backendplugin.ErrPluginNotRegistered
+	}
+	return result, nil
+}
+
+type fakeHealthEndpoint struct {
+	result *tsdb.HealthCheckResult
+}
+
+func (e *fakeHealthEndpoint) Query(ctx context.Context, ds *models.DataSource, query *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	return nil, nil
+}
+
+func (e *fakeHealthEndpoint) CheckHealth(ctx context.Context, ds *models.DataSource) *tsdb.HealthCheckResult {
+	return e.result
+}
+
+func TestCheckHealth(t *testing.T) {
+	tsdb.RegisterTsdbQueryEndpoint("core-health", func(*models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+		return &fakeHealthEndpoint{result: tsdb.HealthCheckOK("core working")}, nil
+	})
+	manager := &fakeBackendPluginManager{results: map[string]*backend.CheckHealthResult{
+		"plugin-health": {Status: backend.HealthStatusError, Message: "plugin failing", JSONDetails: []byte(`{"verbose": "details"}`)},
+	}}
+
+	t.Run("backend plugins check their own health", func(t *testing.T) {
+		ds := &models.DataSource{Type: "plugin-health", JsonData: simplejson.New()}
+		result, err := CheckHealth(context.Background(), manager, ds, nil)
+		require.NoError(t, err)
+		assert.Equal(t, &tsdb.HealthCheckResult{
+			Status:  tsdb.HealthStatusError,
+			Message: "plugin failing",
+			Details: map[string]interface{}{"verbose": "details"},
+		}, result)
+	})
+
+	t.Run("core datasources are checked by their query endpoint", func(t *testing.T) {
+		ds := &models.DataSource{Type: "core-health", JsonData: simplejson.New()}
+		result, err := CheckHealth(context.Background(), manager, ds, nil)
+		require.NoError(t, err)
+		assert.Equal(t, tsdb.HealthCheckOK("core working"), result)
+	})
+
+	t.Run("unknown datasources are not supported", func(t *testing.T) {
+		ds := &models.DataSource{Type: "unknown-health", JsonData: simplejson.New()}
+		_, err := CheckHealth(context.Background(), manager, ds, nil)
+		assert.Equal(t, tsdb.ErrHealthCheckNotSupported, err)
+	})
+}
+
+func TestHealthCheckServiceMetrics(t *testing.T) {
+	s := &HealthCheckService{log: log.New("test"), labels: map[string][]string{}}
+	up := &models.DataSource{OrgId: 1, Uid: "up", Type: "prometheus"}
+	down := &models.DataSource{OrgId: 1, Uid: "down", Type: "graphite"}
+
+	s.updateMetrics([]*healthCheck{
+		{ds: up, result: tsdb.HealthCheckOK("working")},
+		{ds: down, result: &tsdb.HealthCheckResult{Status: tsdb.HealthStatusError}},
+	})
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.MDataSourceUp.WithLabelValues("1", "up", "prometheus")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.MDataSourceUp.WithLabelValues("1", "down", "graphite")))
+
+	s.updateMetrics([]*healthCheck{
+		{ds: up, result: tsdb.HealthCheckOK("working")},
+	})
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.MDataSourceUp))
+}
@@ -44,6 +44,19 @@ var New = func(dashId int64, orgId int64, user *models.SignedInUser) DashboardGu
 	}
 }
 
+// NewWithAcl creates a dashboard guardian checking the given acl instead of
+// the one of the dashboard, such as the permissions kept for a deleted
+// dashboard.
+func NewWithAcl(dashId int64, orgId int64, user *models.SignedInUser, acl []*models.DashboardAclInfoDTO) DashboardGuardian {
+	return &dashboardGuardianImpl{
+		user:   user,
+		dashId: dashId,
+		orgId:  orgId,
+		acl:    acl,
+		log:    log.New("dashboard.permissions"),
+	}
+}
+
 func (g *dashboardGuardianImpl) CanSave() (bool, error) {
 	return g.HasPermission(models.PERMISSION_EDIT)
 }
@@ -92,6 +105,9 @@ func (g *dashboardGuardianImpl) logHasPermissionResult(permission models.Permiss
 	return hasPermission, err
 }
 
+// checkAcl checks the permission against the acl of the dashboard, which
+// includes the permissions inherited from all of its parent folders. The
+// highest permission granted anywhere in the folder chain wins.
 func (g *dashboardGuardianImpl) checkAcl(permission models.PermissionType, acl []*models.DashboardAclInfoDTO) (bool, error) {
 	orgRole := g.user.OrgRole
 	teamAclItems := []*models.DashboardAclInfoDTO{}
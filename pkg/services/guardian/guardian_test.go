@@ -15,6 +15,7 @@ var (
 	dashboardID        = int64(1)
 	parentFolderID     = int64(2)
 	childDashboardID   = int64(3)
+	rootFolderID       = int64(4)
 	userID             = int64(1)
 	otherUserID        = int64(2)
 	teamID             = int64(1)
@@ -167,6 +168,12 @@ func TestGuardianViewer(t *testing.T) {
 			sc.parentFolderPermissionScenario(VIEWER, models.PERMISSION_ADMIN, FULL_ACCESS)
 			sc.parentFolderPermissionScenario(VIEWER, models.PERMISSION_EDIT, EDITOR_ACCESS)
 			sc.parentFolderPermissionScenario(VIEWER, models.PERMISSION_VIEW, VIEWER_ACCESS)
+
+			// root folder of the parent folder has user or team with permission
+			sc.rootFolderPermissionScenario(USER, models.PERMISSION_ADMIN, FULL_ACCESS)
+			sc.rootFolderPermissionScenario(USER, models.PERMISSION_VIEW, VIEWER_ACCESS)
+			sc.rootFolderPermissionScenario(TEAM, models.PERMISSION_EDIT, EDITOR_ACCESS)
+			sc.rootFolderPermissionScenario(TEAM, models.PERMISSION_VIEW, VIEWER_ACCESS)
 		})
 
 		apiKeyScenario("Given api key with viewer role", t, models.ROLE_VIEWER, func(sc *scenarioContext) {
@@ -248,6 +255,29 @@ func (sc *scenarioContext) parentFolderPermissionScenario(pt permissionType, per
 	})
 }
 
+func (sc *scenarioContext) rootFolderPermissionScenario(pt permissionType, permission models.PermissionType, flag permissionFlags) {
+	_, callerFile, callerLine, _ := runtime.Caller(1)
+	sc.callerFile = callerFile
+	sc.callerLine = callerLine
+
+	// the parent folder only gives access to another user
+	folderPermissionList := []*models.DashboardAclInfoDTO{
+		{OrgId: orgID, DashboardId: parentFolderID, UserId: otherUserID, Permission: models.PERMISSION_ADMIN, Inherited: true},
+	}
+
+	switch pt {
+	case USER:
+		folderPermissionList = append(folderPermissionList, &models.DashboardAclInfoDTO{OrgId: orgID, DashboardId: rootFolderID, UserId: userID, Permission: permission, Inherited: true})
+	case TEAM:
+		folderPermissionList = append(folderPermissionList, &models.DashboardAclInfoDTO{OrgId: orgID, DashboardId: rootFolderID, TeamId: teamID, Permission: permission, Inherited: true})
+	}
+
+	permissionScenario(fmt.Sprintf("and root folder has %s with permission to %s", pt.String(), permission.String()), childDashboardID, sc, folderPermissionList, func(sc *scenarioContext) {
+		sc.expectedFlags = flag
+		sc.verifyExpectedPermissionsFlags()
+	})
+}
+
 func (sc *scenarioContext) verifyExpectedPermissionsFlags() {
 	canAdmin, _ := sc.g.CanAdmin()
 	canEdit, _ := sc.g.CanEdit()
@@ -24,6 +24,8 @@ type Hit struct {
 	FolderUid   string   `json:"folderUid,omitempty"`
 	FolderTitle string   `json:"folderTitle,omitempty"`
 	FolderUrl   string   `json:"folderUrl,omitempty"`
+	// FolderPath is the titles of the folders containing the dashboard, from the root folder
+	FolderPath []string `json:"folderPath,omitempty"`
 }
 
 type HitList []*Hit
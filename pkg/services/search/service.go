@@ -15,34 +15,42 @@ func init() {
 }
 
 type Query struct {
-	Title        string
-	Tags         []string
-	OrgId        int64
-	SignedInUser *models.SignedInUser
-	Limit        int64
-	Page         int64
-	IsStarred    bool
-	Type         string
-	DashboardIds []int64
-	FolderIds    []int64
-	Permission   models.PermissionType
-	Sort         string
+	Title         string
+	Text          string
+	TextFields    []string
+	DatasourceUid string
+	Tags          []string
+	OrgId         int64
+	SignedInUser  *models.SignedInUser
+	Limit         int64
+	Page          int64
+	IsStarred     bool
+	Type          string
+	DashboardIds  []int64
+	FolderIds     []int64
+	Permission    models.PermissionType
+	Sort          string
 
 	Result HitList
 }
 
 type FindPersistedDashboardsQuery struct {
-	Title        string
-	OrgId        int64
-	SignedInUser *models.SignedInUser
-	IsStarred    bool
-	DashboardIds []int64
-	Type         string
-	FolderIds    []int64
-	Tags         []string
-	Limit        int64
-	Page         int64
-	Permission   models.PermissionType
+	Title         string
+	Text          string
+	TextFields    []string
+	DatasourceUid string
+	OrgId         int64
+	SignedInUser  *models.SignedInUser
+	IsStarred     bool
+	DashboardIds  []int64
+	Type          string
+	FolderIds     []int64
+	Tags          []string
+	Limit         int64
+	Page          int64
+	Permission    models.PermissionType
+	// SortByRelevance sorts the result by relevance to Text
+	SortByRelevance bool
 
 	Filters []interface{}
 
@@ -59,8 +67,13 @@ type SearchService struct {
 func (s *SearchService) Init() error {
 	s.Bus.AddHandler(s.searchHandler)
 	s.sortOptions = map[string]SortOption{
-		sortAlphaAsc.Name:  sortAlphaAsc,
-		sortAlphaDesc.Name: sortAlphaDesc,
+		sortAlphaAsc.Name:       sortAlphaAsc,
+		sortAlphaDesc.Name:      sortAlphaDesc,
+		sortRelevance.Name:      sortRelevance,
+		sortViewedRecently.Name: sortViewedRecently,
+		sortViewsDesc.Name:      sortViewsDesc,
+		sortUpdatedDesc.Name:    sortUpdatedDesc,
+		sortCreatedDesc.Name:    sortCreatedDesc,
 	}
 
 	return nil
@@ -68,20 +81,31 @@ func (s *SearchService) Init() error {
 
 func (s *SearchService) searchHandler(query *Query) error {
 	dashboardQuery := FindPersistedDashboardsQuery{
-		Title:        query.Title,
-		SignedInUser: query.SignedInUser,
-		IsStarred:    query.IsStarred,
-		DashboardIds: query.DashboardIds,
-		Type:         query.Type,
-		FolderIds:    query.FolderIds,
-		Tags:         query.Tags,
-		Limit:        query.Limit,
-		Page:         query.Page,
-		Permission:   query.Permission,
+		Title:         query.Title,
+		Text:          query.Text,
+		TextFields:    query.TextFields,
+		DatasourceUid: query.DatasourceUid,
+		SignedInUser:  query.SignedInUser,
+		IsStarred:     query.IsStarred,
+		DashboardIds:  query.DashboardIds,
+		Type:          query.Type,
+		FolderIds:     query.FolderIds,
+		Tags:          query.Tags,
+		Limit:         query.Limit,
+		Page:          query.Page,
+		Permission:    query.Permission,
+	}
+
+	// full-text search results are sorted by relevance by default
+	if query.Text != "" && (query.Sort == "" || query.Sort == sortRelevance.Name) {
+		dashboardQuery.SortByRelevance = true
 	}
 
 	if sortOpt, exists := s.sortOptions[query.Sort]; exists {
 		for _, filter := range sortOpt.Filter {
+			if userFilter, ok := filter.(SortOptionUserFilter); ok {
+				filter = userFilter.ForUser(query.SignedInUser.UserId)
+			}
 			dashboardQuery.Filters = append(dashboardQuery.Filters, filter)
 		}
 	}
@@ -91,7 +115,7 @@ func (s *SearchService) searchHandler(query *Query) error {
 	}
 
 	hits := dashboardQuery.Result
-	if query.Sort == "" {
+	if query.Sort == "" && !dashboardQuery.SortByRelevance {
 		hits = sortedHits(hits)
 	}
 
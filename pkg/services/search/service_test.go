@@ -5,6 +5,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -55,3 +56,62 @@ func TestSearch_SortedResults(t *testing.T) {
 	assert.Equal(t, "BB", query.Result[3].Tags[1])
 	assert.Equal(t, "EE", query.Result[3].Tags[2])
 }
+
+func TestSearch_FullTextSortedByRelevance(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	var dashboardQuery *FindPersistedDashboardsQuery
+	bus.AddHandler("test", func(query *FindPersistedDashboardsQuery) error {
+		dashboardQuery = query
+		query.Result = HitList{
+			&Hit{Id: 2, Title: "Requests", Type: "dash-db"},
+			&Hit{Id: 1, Title: "API", Type: "dash-db"},
+		}
+		return nil
+	})
+
+	bus.AddHandler("test", func(query *models.GetUserStarsQuery) error {
+		query.Result = map[int64]bool{}
+		return nil
+	})
+
+	svc := &SearchService{Bus: bus.GetBus()}
+	require.NoError(t, svc.Init())
+
+	query := &Query{Text: "http_requests_total", SignedInUser: &models.SignedInUser{}}
+	require.NoError(t, svc.searchHandler(query))
+
+	assert.True(t, dashboardQuery.SortByRelevance)
+	assert.Equal(t, "http_requests_total", dashboardQuery.Text)
+	// the order of the store is kept
+	assert.Equal(t, "Requests", query.Result[0].Title)
+
+	query = &Query{Text: "http_requests_total", Sort: sortAlphaAsc.Name, SignedInUser: &models.SignedInUser{}}
+	require.NoError(t, svc.searchHandler(query))
+	assert.False(t, dashboardQuery.SortByRelevance)
+}
+
+func TestSearch_SortByUserViews(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	var dashboardQuery *FindPersistedDashboardsQuery
+	bus.AddHandler("test", func(query *FindPersistedDashboardsQuery) error {
+		dashboardQuery = query
+		query.Result = HitList{}
+		return nil
+	})
+
+	bus.AddHandler("test", func(query *models.GetUserStarsQuery) error {
+		query.Result = map[int64]bool{}
+		return nil
+	})
+
+	svc := &SearchService{Bus: bus.GetBus()}
+	require.NoError(t, svc.Init())
+
+	query := &Query{Sort: sortViewedRecently.Name, SignedInUser: &models.SignedInUser{UserId: 5}}
+	require.NoError(t, svc.searchHandler(query))
+
+	require.Len(t, dashboardQuery.Filters, 1)
+	assert.Equal(t, searchstore.ViewedByUserSorter{UserId: 5}, dashboardQuery.Filters[0])
+}
@@ -3,6 +3,7 @@ package search
 import (
 	"sort"
 
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
 )
 
@@ -23,6 +24,45 @@ var (
 			searchstore.TitleSorter{Descending: true},
 		},
 	}
+	sortViewedRecently = SortOption{
+		Name:        "viewed-recently",
+		DisplayName: "Recently viewed",
+		Description: "Sort results by when you last viewed them, most recent first",
+		Filter: []SortOptionFilter{
+			searchstore.ViewedByUserSorter{},
+		},
+	}
+	sortViewsDesc = SortOption{
+		Name:        "views-desc",
+		DisplayName: "Most viewed",
+		Description: "Sort results by their number of views during the last 30 days, most viewed first",
+		Filter: []SortOptionFilter{
+			searchstore.MostViewedSorter{Days: models.MostViewedDashboardsDays},
+		},
+	}
+	sortUpdatedDesc = SortOption{
+		Name:        "updated-desc",
+		DisplayName: "Recently updated",
+		Description: "Sort results by when they were last updated, most recent first",
+		Filter: []SortOptionFilter{
+			searchstore.UpdatedSorter{},
+		},
+	}
+	sortCreatedDesc = SortOption{
+		Name:        "created-desc",
+		DisplayName: "Recently created",
+		Description: "Sort results by when they were created, most recent first",
+		Filter: []SortOptionFilter{
+			searchstore.CreatedSorter{},
+		},
+	}
+	// sortRelevance needs the text of the query, its filter is added
+	// when searching
+	sortRelevance = SortOption{
+		Name:        "relevance",
+		DisplayName: "Relevance",
+		Description: "Sort results by how well they match the full-text query",
+	}
 )
 
 type SortOption struct {
@@ -36,6 +76,13 @@ type SortOptionFilter interface {
 	searchstore.FilterOrderBy
 }
 
+// SortOptionUserFilter is a SortOptionFilter depending on the user
+// searching, such as the dashboards recently viewed by the user.
+type SortOptionUserFilter interface {
+	SortOptionFilter
+	ForUser(userId int64) searchstore.FilterOrderBy
+}
+
 // RegisterSortOption allows for hooking in more search options from
 // other services.
 func (s *SearchService) RegisterSortOption(option SortOption) {
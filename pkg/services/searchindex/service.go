@@ -0,0 +1,49 @@
+package searchindex
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+)
+
+func init() {
+	registry.RegisterService(&SearchIndexService{})
+}
+
+// SearchIndexService indexes the dashboards having no indexed texts in the
+// background once the server has started, on a single instance when several
+// instances share the database.
+type SearchIndexService struct {
+	ServerLockService *serverlock.ServerLockService `inject:""`
+
+	log log.Logger
+}
+
+func (s *SearchIndexService) Init() error {
+	s.log = log.New("searchindex")
+	return nil
+}
+
+func (s *SearchIndexService) Run(ctx context.Context) error {
+	err := s.ServerLockService.LockAndExecute(ctx, "index dashboards for search", time.Hour, func() {
+		cmd := models.IndexDashboardsForSearchCommand{}
+		if err := bus.Dispatch(&cmd); err != nil {
+			s.log.Error("Failed to index dashboards for search", "error", err)
+			return
+		}
+		if cmd.Indexed > 0 {
+			s.log.Info("Indexed dashboards for search", "count", cmd.Indexed)
+		}
+	})
+	if err != nil {
+		s.log.Error("Failed to lock and execute the indexing of dashboards for search", "error", err)
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
@@ -92,6 +92,9 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		dash.SetUid(uid)
 	}
 
+	// the model of library panels is loaded from the library
+	dash.StripLibraryPanels()
+
 	parentVersion := dash.Version
 	var affectedRows int64
 	var err error
@@ -160,6 +163,14 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		}
 	}
 
+	if err := saveDashboardIndex(sess, dash); err != nil {
+		return err
+	}
+
+	if err := connectLibraryPanels(sess, dash); err != nil {
+		return err
+	}
+
 	cmd.Result = dash
 
 	return err
@@ -228,10 +239,13 @@ func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSear
 
 	filters = append(filters, query.Filters...)
 
-	if query.OrgId != 0 {
-		filters = append(filters, searchstore.OrgFilter{OrgId: query.OrgId})
-	} else if query.SignedInUser.OrgId != 0 {
-		filters = append(filters, searchstore.OrgFilter{OrgId: query.SignedInUser.OrgId})
+	orgId := query.OrgId
+	if orgId == 0 {
+		orgId = query.SignedInUser.OrgId
+	}
+
+	if orgId != 0 {
+		filters = append(filters, searchstore.OrgFilter{OrgId: orgId})
 	}
 
 	if len(query.Tags) > 0 {
@@ -250,6 +264,25 @@ func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSear
 		filters = append(filters, searchstore.TitleFilter{Dialect: dialect, Title: query.Title})
 	}
 
+	if len(query.Text) > 0 {
+		filters = append(filters, searchstore.FullTextFilter{Dialect: dialect, Text: query.Text, Fields: query.TextFields})
+		if query.SortByRelevance {
+			filters = append(filters, searchstore.RelevanceSorter{Dialect: dialect, Text: query.Text, Fields: query.TextFields})
+		}
+	}
+
+	if len(query.DatasourceUid) > 0 {
+		var ds models.DataSource
+		has, err := x.Where("org_id=? AND uid=?", orgId, query.DatasourceUid).Get(&ds)
+		if err != nil {
+			return nil, err
+		}
+		if !has {
+			return []DashboardSearchProjection{}, nil
+		}
+		filters = append(filters, searchstore.DatasourceFilter{Uid: ds.Uid, Name: ds.Name, Type: ds.Type, IsDefault: ds.IsDefault})
+	}
+
 	if len(query.Type) > 0 {
 		filters = append(filters, searchstore.TypeFilter{Dialect: dialect, Type: query.Type})
 	}
@@ -288,6 +321,48 @@ func SearchDashboards(query *search.FindPersistedDashboardsQuery) error {
 
 	makeQueryResult(query, res)
 
+	return setFolderPaths(query)
+}
+
+// setFolderPaths sets the path of the folders containing the search hits
+func setFolderPaths(query *search.FindPersistedDashboardsQuery) error {
+	inFolder := false
+	for _, hit := range query.Result {
+		inFolder = inFolder || hit.FolderId > 0
+	}
+	if !inFolder {
+		return nil
+	}
+
+	orgId := query.OrgId
+	if orgId == 0 {
+		orgId = query.SignedInUser.OrgId
+	}
+
+	var folders []*models.Dashboard
+	err := x.Where("org_id=? AND is_folder=?", orgId, dialect.BooleanStr(true)).Cols("id", "title", "folder_id").Find(&folders)
+	if err != nil {
+		return err
+	}
+
+	foldersById := make(map[int64]*models.Dashboard, len(folders))
+	for _, folder := range folders {
+		foldersById[folder.Id] = folder
+	}
+
+	for _, hit := range query.Result {
+		var path []string
+		for id := hit.FolderId; id > 0 && len(path) < models.MaxFolderDepth; {
+			folder, ok := foldersById[id]
+			if !ok {
+				break
+			}
+			path = append([]string{folder.Title}, path...)
+			id = folder.FolderId
+		}
+		hit.FolderPath = path
+	}
+
 	return nil
 }
 
@@ -368,22 +443,49 @@ func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error
 
 	deletes := []string{
 		"DELETE FROM dashboard_tag WHERE dashboard_id = ? ",
+		"DELETE FROM dashboard_search_term WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_datasource_ref WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_user_view WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_daily_view WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_daily_query WHERE dashboard_id = ?",
 		"DELETE FROM star WHERE dashboard_id = ? ",
 		"DELETE FROM dashboard WHERE id = ?",
 		"DELETE FROM playlist_item WHERE type = 'dashboard_by_id' AND value = ?",
 		"DELETE FROM dashboard_version WHERE dashboard_id = ?",
 		"DELETE FROM annotation WHERE dashboard_id = ?",
 		"DELETE FROM dashboard_provisioning WHERE dashboard_id = ?",
+		"DELETE FROM library_panel_dashboard WHERE dashboard_id = ?",
 	}
 
 	if dashboard.IsFolder {
+		// delete the subfolders first, with their content
+		var subfolders []*models.Dashboard
+		err := sess.Where("org_id=? AND folder_id=? AND is_folder=?", dashboard.OrgId, dashboard.Id, dialect.BooleanStr(true)).Cols("id").Find(&subfolders)
+		if err != nil {
+			return err
+		}
+
+		for _, subfolder := range subfolders {
+			if err := deleteDashboard(&models.DeleteDashboardCommand{Id: subfolder.Id, OrgId: cmd.OrgId}, sess); err != nil {
+				return err
+			}
+		}
+
 		deletes = append(deletes, "DELETE FROM dashboard_provisioning WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM library_panel_dashboard WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM dashboard_search_term WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM dashboard_datasource_ref WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM dashboard_user_view WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM dashboard_daily_view WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "DELETE FROM dashboard_daily_query WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
+		deletes = append(deletes, "UPDATE library_panel SET folder_id = 0 WHERE folder_id = ?")
+		deletes = append(deletes, "DELETE FROM dashboard_version_retention WHERE folder_id = ?")
 		deletes = append(deletes, "DELETE FROM dashboard WHERE folder_id = ?")
 
 		dashIds := []struct {
 			Id int64
 		}{}
-		err := sess.SQL("select id from dashboard where folder_id = ?", dashboard.Id).Find(&dashIds)
+		err = sess.SQL("select id from dashboard where folder_id = ?", dashboard.Id).Find(&dashIds)
 		if err != nil {
 			return err
 		}
@@ -447,8 +549,8 @@ func GetDashboardPermissionsForUser(query *models.GetDashboardPermissionsForUser
 
 	// check dashboards that have ACLs via user id, team id or role
 	sql := `SELECT d.id AS dashboard_id, MAX(COALESCE(da.permission, pt.permission)) AS permission
-	FROM dashboard AS d
-		LEFT JOIN dashboard_acl as da on d.folder_id = da.dashboard_id or d.id = da.dashboard_id
+	FROM dashboard AS d` + permissions.FolderAncestorJoins() + `
+		LEFT JOIN dashboard_acl as da on da.dashboard_id IN (` + permissions.DashboardAndAncestorIds() + `)
 		LEFT JOIN team_member as ugm on ugm.team_id =  da.team_id
 		LEFT JOIN org_user ou ON ou.role = da.role AND ou.user_id = ?
 	`
@@ -590,6 +692,12 @@ func getExistingDashboardByIdOrUidForUpdate(sess *DBSession, cmd *models.Validat
 		if !folderExists {
 			return models.ErrDashboardFolderNotFound
 		}
+
+		if dash.IsFolder {
+			if err := validateFolderParent(sess, dash); err != nil {
+				return err
+			}
+		}
 	}
 
 	if !dashWithIdExists && !dashWithUidExists {
@@ -617,7 +725,7 @@ func getExistingDashboardByIdOrUidForUpdate(sess *DBSession, cmd *models.Validat
 		return models.ErrDashboardTypeMismatch
 	}
 
-	if !dash.IsFolder && dash.FolderId != existing.FolderId {
+	if dash.FolderId != existing.FolderId {
 		cmd.Result.IsParentFolderChanged = true
 	}
 
@@ -638,11 +746,79 @@ func getExistingDashboardByIdOrUidForUpdate(sess *DBSession, cmd *models.Validat
 	return nil
 }
 
+// validateFolderParent returns an error if the parent of a folder is the
+// folder itself or one of its subfolders, or if the folder and its
+// subfolders would be nested more than models.MaxFolderDepth levels deep.
+func validateFolderParent(sess *DBSession, folder *models.Dashboard) error {
+	depth := 1
+	for parentId := folder.FolderId; parentId > 0; depth++ {
+		if parentId == folder.Id {
+			return models.ErrDashboardFolderCycle
+		}
+		if depth > models.MaxFolderDepth {
+			return models.ErrDashboardFolderMaxDepthExceeded
+		}
+
+		var parent models.Dashboard
+		exists, err := sess.Where("org_id=? AND id=?", folder.OrgId, parentId).Cols("folder_id").Get(&parent)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			break
+		}
+		parentId = parent.FolderId
+	}
+
+	if folder.Id > 0 {
+		subfolderLevels, err := getSubfolderLevels(sess, folder)
+		if err != nil {
+			return err
+		}
+		depth += subfolderLevels
+	}
+
+	if depth > models.MaxFolderDepth {
+		return models.ErrDashboardFolderMaxDepthExceeded
+	}
+
+	return nil
+}
+
+// getSubfolderLevels returns the number of levels of subfolders in a folder
+func getSubfolderLevels(sess *DBSession, folder *models.Dashboard) (int, error) {
+	levels := 0
+	for parentIds := []int64{folder.Id}; levels <= models.MaxFolderDepth; levels++ {
+		var subfolders []*models.Dashboard
+		err := sess.Where("org_id=? AND is_folder=?", folder.OrgId, dialect.BooleanStr(true)).In("folder_id", parentIds).Cols("id").Find(&subfolders)
+		if err != nil {
+			return 0, err
+		}
+		if len(subfolders) == 0 {
+			break
+		}
+
+		parentIds = make([]int64, 0, len(subfolders))
+		for _, subfolder := range subfolders {
+			parentIds = append(parentIds, subfolder.Id)
+		}
+	}
+
+	return levels, nil
+}
+
 func getExistingDashboardByTitleAndFolder(sess *DBSession, cmd *models.ValidateDashboardBeforeSaveCommand) error {
 	dash := cmd.Dashboard
 	var existing models.Dashboard
 
-	exists, err := sess.Where("org_id=? AND slug=? AND (is_folder=? OR folder_id=?)", dash.OrgId, dash.Slug, dialect.BooleanStr(true), dash.FolderId).Get(&existing)
+	// folders only need a unique name among the folders and dashboards of their parent folder
+	where := sess.Where("org_id=? AND slug=?", dash.OrgId, dash.Slug)
+	if dash.IsFolder {
+		where.And("folder_id=?", dash.FolderId)
+	} else {
+		where.And("(folder_id=? OR (is_folder=? AND folder_id=0))", dash.FolderId, dialect.BooleanStr(true))
+	}
+	exists, err := where.Get(&existing)
 	if err != nil {
 		return err
 	}
@@ -3,6 +3,7 @@ package sqlstore
 import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/permissions"
 )
 
 func init() {
@@ -43,8 +44,9 @@ func UpdateDashboardAcl(cmd *models.UpdateDashboardAclCommand) error {
 // GetDashboardAclInfoList returns a list of permissions for a dashboard. They can be fetched from three
 // different places.
 // 1) Permissions for the dashboard
-// 2) permissions for its parent folder
-// 3) if no specific permissions have been set for the dashboard or its parent folder then get the default permissions
+// 2) permissions for its parent folders, up to the root folder
+// 3) if no specific permissions have been set for the root folder, or for the dashboard when it's in the
+// General folder, then get the default permissions
 func GetDashboardAclInfoList(query *models.GetDashboardAclInfoListQuery) error {
 	var err error
 
@@ -75,7 +77,7 @@ func GetDashboardAclInfoList(query *models.GetDashboardAclInfoListQuery) error {
 		err = x.SQL(sql).Find(&query.Result)
 	} else {
 		rawSQL := `
-			-- get permissions for the dashboard and its parent folder
+			-- get permissions for the dashboard and its parent folders
 			SELECT
 				da.id,
 				da.org_id,
@@ -94,18 +96,13 @@ func GetDashboardAclInfoList(query *models.GetDashboardAclInfoListQuery) error {
 				d.slug,
 				d.uid,
 				d.is_folder,
-				CASE WHEN (da.dashboard_id = -1 AND d.folder_id > 0) OR da.dashboard_id = d.folder_id THEN ` + dialect.BooleanStr(true) + ` ELSE ` + falseStr + ` END AS inherited
-			FROM dashboard as d
-				LEFT JOIN dashboard folder on folder.id = d.folder_id
+				CASE WHEN (da.dashboard_id = -1 AND d.folder_id > 0) OR (da.dashboard_id <> -1 AND da.dashboard_id <> d.id) THEN ` + dialect.BooleanStr(true) + ` ELSE ` + falseStr + ` END AS inherited
+			FROM dashboard as d` + permissions.FolderAncestorJoins() + `
 				LEFT JOIN dashboard_acl AS da ON
-				da.dashboard_id = d.id OR
-				da.dashboard_id = d.folder_id OR
+				da.dashboard_id IN (` + permissions.DashboardAndAncestorIds() + `) OR
 				(
 					-- include default permissions -->
-					da.org_id = -1 AND (
-					  (folder.id IS NOT NULL AND folder.has_acl = ` + falseStr + `) OR
-					  (folder.id IS NULL AND d.has_acl = ` + falseStr + `)
-					)
+					da.org_id = -1 AND ` + permissions.RootHasAcl() + ` = ` + falseStr + `
 				)
 				LEFT JOIN ` + dialect.Quote("user") + ` AS u ON u.id = da.user_id
 				LEFT JOIN team ug on ug.id = da.team_id
@@ -0,0 +1,130 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNestedFolders(t *testing.T) {
+	InitTestDB(t)
+
+	save := func(t *testing.T, title string, folderId int64, isFolder bool) *models.Dashboard {
+		t.Helper()
+		cmd := models.SaveDashboardCommand{
+			OrgId:     1,
+			FolderId:  folderId,
+			IsFolder:  isFolder,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": title}),
+		}
+		require.NoError(t, SaveDashboard(&cmd))
+		return cmd.Result
+	}
+
+	newFolder := func(title string, parentId int64) *models.Dashboard {
+		folder := models.NewDashboardFolder(title)
+		folder.OrgId = 1
+		folder.FolderId = parentId
+		return folder
+	}
+
+	get := func(t *testing.T, id int64) *models.Dashboard {
+		t.Helper()
+		query := models.GetDashboardQuery{OrgId: 1, Id: id}
+		require.NoError(t, GetDashboard(&query))
+		return query.Result
+	}
+
+	validate := func(dash *models.Dashboard) error {
+		return ValidateDashboardBeforeSave(&models.ValidateDashboardBeforeSaveCommand{OrgId: 1, Dashboard: dash})
+	}
+
+	teamA := save(t, "Team A", 0, true)
+	backend := save(t, "Backend", teamA.Id, true)
+	services := save(t, "Services", backend.Id, true)
+	dash := save(t, "API", services.Id, false)
+	teamB := save(t, "Team B", 0, true)
+
+	t.Run("subfolders can have the name of a folder of another parent", func(t *testing.T) {
+		save(t, "Backend", teamB.Id, true)
+	})
+
+	t.Run("subfolders must have a unique name in their parent folder", func(t *testing.T) {
+		err := validate(newFolder("Backend", teamA.Id))
+		require.Equal(t, models.ErrDashboardWithSameNameInFolderExists, err)
+	})
+
+	t.Run("a folder can't be moved into one of its subfolders", func(t *testing.T) {
+		folder := get(t, teamA.Id)
+		folder.FolderId = services.Id
+		require.Equal(t, models.ErrDashboardFolderCycle, validate(folder))
+	})
+
+	t.Run("folders can't be nested too deep", func(t *testing.T) {
+		parentId := services.Id
+		for level := 4; level <= models.MaxFolderDepth; level++ {
+			parentId = save(t, "Level", parentId, true).Id
+		}
+
+		err := validate(newFolder("Too deep", parentId))
+		require.Equal(t, models.ErrDashboardFolderMaxDepthExceeded, err)
+
+		teamC := save(t, "Team C", 0, true)
+		folder := get(t, backend.Id)
+		folder.FolderId = teamC.Id
+		require.NoError(t, validate(folder))
+
+		folder = get(t, teamA.Id)
+		folder.FolderId = teamC.Id
+		require.Equal(t, models.ErrDashboardFolderMaxDepthExceeded, validate(folder))
+	})
+
+	t.Run("permissions are inherited from all the parent folders", func(t *testing.T) {
+		viewer := models.SignedInUser{UserId: 10, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+		otherViewer := models.SignedInUser{UserId: 11, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+
+		query := models.GetDashboardAclInfoListQuery{OrgId: 1, DashboardId: dash.Id}
+		require.NoError(t, GetDashboardAclInfoList(&query))
+		require.Len(t, query.Result, 2)
+		for _, item := range query.Result {
+			assert.EqualValues(t, -1, item.DashboardId)
+			assert.True(t, item.Inherited)
+		}
+
+		err := testHelperUpdateDashboardAcl(teamA.Id, models.DashboardAcl{
+			OrgId: 1, DashboardId: teamA.Id, UserId: viewer.UserId, Permission: models.PERMISSION_VIEW,
+		})
+		require.NoError(t, err)
+
+		query = models.GetDashboardAclInfoListQuery{OrgId: 1, DashboardId: dash.Id}
+		require.NoError(t, GetDashboardAclInfoList(&query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, teamA.Id, query.Result[0].DashboardId)
+		assert.Equal(t, viewer.UserId, query.Result[0].UserId)
+		assert.True(t, query.Result[0].Inherited)
+
+		find := func(user models.SignedInUser) []*search.Hit {
+			query := search.FindPersistedDashboardsQuery{SignedInUser: &user, OrgId: 1, DashboardIds: []int64{dash.Id}}
+			require.NoError(t, SearchDashboards(&query))
+			return query.Result
+		}
+
+		hits := find(viewer)
+		require.Len(t, hits, 1)
+		assert.Equal(t, []string{"Team A", "Backend", "Services"}, hits[0].FolderPath)
+		assert.Empty(t, find(otherViewer))
+	})
+
+	t.Run("deleting a folder deletes its subfolders and their dashboards", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{OrgId: 1, Id: teamA.Id}))
+
+		for _, id := range []int64{backend.Id, services.Id, dash.Id} {
+			err := GetDashboard(&models.GetDashboardQuery{OrgId: 1, Id: id})
+			assert.Equal(t, models.ErrDashboardNotFound, err)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package sqlstore
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", IndexDashboardsForSearch)
+}
+
+// saveDashboardSearchTerms replaces the texts of the dashboard indexed for
+// full-text search.
+func saveDashboardSearchTerms(sess *DBSession, dash *models.Dashboard) error {
+	if _, err := sess.Exec("DELETE FROM dashboard_search_term WHERE dashboard_id=?", dash.Id); err != nil {
+		return err
+	}
+
+	for _, term := range dash.GetSearchTerms() {
+		if _, err := sess.Insert(term); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveDashboardDatasourceRefs replaces the references of the dashboard to
+// data sources.
+func saveDashboardDatasourceRefs(sess *DBSession, dash *models.Dashboard) error {
+	if _, err := sess.Exec("DELETE FROM dashboard_datasource_ref WHERE dashboard_id=?", dash.Id); err != nil {
+		return err
+	}
+
+	for _, ref := range dash.GetDatasourceRefs() {
+		if _, err := sess.Insert(ref); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveDashboardIndex indexes a dashboard for full-text search and saves its
+// references to data sources, with the model of its library panels from the
+// library.
+func saveDashboardIndex(sess *DBSession, dash *models.Dashboard) error {
+	resolved, err := resolveLibraryPanels(sess, dash)
+	if err != nil {
+		return err
+	}
+
+	if err := saveDashboardSearchTerms(sess, resolved); err != nil {
+		return err
+	}
+	return saveDashboardDatasourceRefs(sess, resolved)
+}
+
+// IndexDashboardsForSearch indexes the dashboards having no indexed texts,
+// which were saved before full-text search was added, along with their
+// references to data sources.
+func IndexDashboardsForSearch(cmd *models.IndexDashboardsForSearchCommand) error {
+	const batchSize = 100
+
+	var lastId int64
+	for {
+		var dashboards []*models.Dashboard
+		err := x.Where(`id > ? AND NOT EXISTS (
+			SELECT 1 FROM dashboard_search_term WHERE dashboard_search_term.dashboard_id = dashboard.id)`, lastId).
+			OrderBy("id").Limit(batchSize).Find(&dashboards)
+		if err != nil {
+			return err
+		}
+		if len(dashboards) == 0 {
+			break
+		}
+
+		err = inTransaction(func(sess *DBSession) error {
+			for _, dash := range dashboards {
+				if err := saveDashboardIndex(sess, dash); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		cmd.Indexed += len(dashboards)
+		lastId = dashboards[len(dashboards)-1].Id
+	}
+
+	return nil
+}
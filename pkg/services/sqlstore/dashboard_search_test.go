@@ -0,0 +1,97 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardSearchTerms(t *testing.T) {
+	InitTestDB(t)
+
+	saveDashboard := func(t *testing.T, data map[string]interface{}) *models.Dashboard {
+		t.Helper()
+		cmd := models.SaveDashboardCommand{OrgId: 1, Overwrite: true, Dashboard: simplejson.NewFromAny(data)}
+		require.NoError(t, SaveDashboard(&cmd))
+		return cmd.Result
+	}
+
+	find := func(t *testing.T, text string) []string {
+		t.Helper()
+		query := search.FindPersistedDashboardsQuery{
+			Text:            text,
+			SortByRelevance: true,
+			SignedInUser:    &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN},
+		}
+		require.NoError(t, SearchDashboards(&query))
+		titles := make([]string, 0, len(query.Result))
+		for _, hit := range query.Result {
+			titles = append(titles, hit.Title)
+		}
+		return titles
+	}
+
+	panel := func(expr string) []interface{} {
+		return []interface{}{
+			map[string]interface{}{"id": 1, "title": "Requests", "targets": []interface{}{map[string]interface{}{"expr": expr}}},
+		}
+	}
+
+	dash := saveDashboard(t, map[string]interface{}{"title": "API", "panels": panel("http_requests_total")})
+
+	t.Run("the index is updated when saving a dashboard", func(t *testing.T) {
+		assert.Equal(t, []string{"API"}, find(t, "http_requests_total"))
+
+		saveDashboard(t, map[string]interface{}{"id": dash.Id, "title": "API", "panels": panel("grpc_requests_total")})
+		assert.Empty(t, find(t, "http_requests_total"))
+		assert.Equal(t, []string{"API"}, find(t, "grpc_requests_total"))
+	})
+
+	t.Run("dashboards saved before the index existed are indexed", func(t *testing.T) {
+		_, err := x.Exec("DELETE FROM dashboard_search_term")
+		require.NoError(t, err)
+		assert.Empty(t, find(t, "grpc_requests_total"))
+
+		cmd := models.IndexDashboardsForSearchCommand{}
+		require.NoError(t, IndexDashboardsForSearch(&cmd))
+		assert.Equal(t, 1, cmd.Indexed)
+		assert.Equal(t, []string{"API"}, find(t, "grpc_requests_total"))
+	})
+
+	t.Run("library panels are indexed with their model from the library", func(t *testing.T) {
+		create := models.CreateLibraryPanelCommand{
+			OrgId: 1,
+			Name:  "Latency",
+			Model: simplejson.NewFromAny(map[string]interface{}{"title": "Latency", "targets": []interface{}{map[string]interface{}{"expr": "http_latency_seconds"}}}),
+		}
+		require.NoError(t, CreateLibraryPanel(&create))
+		saveDashboard(t, map[string]interface{}{
+			"title":  "Latency",
+			"panels": []interface{}{map[string]interface{}{"id": 1, "libraryPanel": map[string]interface{}{"uid": create.Result.Uid, "name": "Latency"}}},
+		})
+		assert.Equal(t, []string{"Latency"}, find(t, "http_latency_seconds"))
+
+		update := models.UpdateLibraryPanelCommand{
+			OrgId:   1,
+			Uid:     create.Result.Uid,
+			Name:    "Latency",
+			Version: create.Result.Version,
+			Model:   simplejson.NewFromAny(map[string]interface{}{"title": "Latency", "targets": []interface{}{map[string]interface{}{"expr": "grpc_latency_seconds"}}}),
+		}
+		require.NoError(t, UpdateLibraryPanel(&update))
+		assert.Empty(t, find(t, "http_latency_seconds"))
+		assert.Equal(t, []string{"Latency"}, find(t, "grpc_latency_seconds"))
+	})
+
+	t.Run("the index of a deleted dashboard is deleted", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{Id: dash.Id, OrgId: 1}))
+
+		count, err := x.Where("dashboard_id=?", dash.Id).Count(&models.DashboardSearchTerm{})
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,333 @@
+package sqlstore
+
+import (
+	"encoding/json"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", TrashDashboard)
+	bus.AddHandler("sql", GetDashboardTrash)
+	bus.AddHandler("sql", GetDashboardTrashById)
+	bus.AddHandler("sql", GetDashboardTrashAcl)
+	bus.AddHandler("sql", RestoreDashboardFromTrash)
+	bus.AddHandler("sql", DeleteDashboardFromTrash)
+	bus.AddHandler("sql", DeleteExpiredDashboardTrash)
+}
+
+// dashboardTrashContent is the content of the trash for a deleted dashboard,
+// or a deleted folder with its subfolders and dashboards. The deleted
+// dashboard or folder is the first of the dashboards.
+type dashboardTrashContent struct {
+	Dashboards []*models.Dashboard
+	Alerts     []*models.Alert
+}
+
+// TrashDashboard moves a dashboard, or a folder with its subfolders and
+// dashboards, to the trash. Their rows and alerts are moved to the trash,
+// while the rows referencing them, such as versions, permissions and
+// stars, are kept to be restored with them.
+func TrashDashboard(cmd *models.TrashDashboardCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		dashboard := models.Dashboard{Id: cmd.Id, OrgId: cmd.OrgId}
+		has, err := sess.Get(&dashboard)
+		if err != nil {
+			return err
+		} else if !has {
+			return models.ErrDashboardNotFound
+		}
+
+		content := dashboardTrashContent{Dashboards: []*models.Dashboard{&dashboard}}
+		if dashboard.IsFolder {
+			if content.Dashboards, err = getFolderTree(sess, &dashboard); err != nil {
+				return err
+			}
+		}
+
+		dashboardIds := make([]int64, 0, len(content.Dashboards))
+		for _, dash := range content.Dashboards {
+			dashboardIds = append(dashboardIds, dash.Id)
+		}
+
+		if err := sess.In("dashboard_id", dashboardIds).Find(&content.Alerts); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(content)
+		if err != nil {
+			return err
+		}
+
+		trash := &models.DashboardTrash{
+			OrgId:       dashboard.OrgId,
+			DashboardId: dashboard.Id,
+			Uid:         dashboard.Uid,
+			Title:       dashboard.Title,
+			IsFolder:    dashboard.IsFolder,
+			FolderId:    dashboard.FolderId,
+			Deleted:     getTimeNow(),
+			Content:     string(data),
+		}
+		if _, err := sess.Insert(trash); err != nil {
+			return err
+		}
+		for _, dash := range content.Dashboards {
+			item := &models.DashboardTrashItem{
+				TrashId:     trash.Id,
+				OrgId:       dash.OrgId,
+				DashboardId: dash.Id,
+				IsFolder:    dash.IsFolder,
+				FolderId:    dash.FolderId,
+			}
+			if _, err := sess.Insert(item); err != nil {
+				return err
+			}
+		}
+
+		if _, err := sess.In("dashboard_id", dashboardIds).Delete(&models.Alert{}); err != nil {
+			return err
+		}
+		if _, err := sess.In("dashboard_id", dashboardIds).Delete(&models.DashboardProvisioning{}); err != nil {
+			return err
+		}
+		if _, err := sess.In("id", dashboardIds).Delete(&models.Dashboard{}); err != nil {
+			return err
+		}
+
+		cmd.Result = trash
+		return nil
+	})
+}
+
+// getFolderTree returns a folder with all its subfolders and dashboards
+func getFolderTree(sess *DBSession, folder *models.Dashboard) ([]*models.Dashboard, error) {
+	tree := []*models.Dashboard{folder}
+	for parentIds := []int64{folder.Id}; len(parentIds) > 0; {
+		var children []*models.Dashboard
+		if err := sess.Where("org_id=?", folder.OrgId).In("folder_id", parentIds).Find(&children); err != nil {
+			return nil, err
+		}
+
+		parentIds = nil
+		for _, child := range children {
+			tree = append(tree, child)
+			if child.IsFolder {
+				parentIds = append(parentIds, child.Id)
+			}
+		}
+	}
+
+	return tree, nil
+}
+
+func GetDashboardTrash(query *models.GetDashboardTrashQuery) error {
+	query.Result = make([]*models.DashboardTrash, 0)
+	return x.Where("org_id=?", query.OrgId).Omit("content").Desc("deleted").Desc("id").Find(&query.Result)
+}
+
+func GetDashboardTrashById(query *models.GetDashboardTrashByIdQuery) error {
+	trash, err := getDashboardTrash(newSession(), query.OrgId, query.Id)
+	if err != nil {
+		return err
+	}
+
+	trash.Content = ""
+	query.Result = trash
+	return nil
+}
+
+// GetDashboardTrashAcl returns the permissions kept for a dashboard or
+// folder in the trash, without the ones of its folders or the defaults.
+func GetDashboardTrashAcl(query *models.GetDashboardTrashAclQuery) error {
+	rawSQL := `SELECT
+		da.id,
+		da.org_id,
+		da.dashboard_id,
+		da.user_id,
+		da.team_id,
+		da.permission,
+		da.role,
+		da.created,
+		da.updated
+		FROM dashboard_acl AS da
+		WHERE da.org_id = ? AND da.dashboard_id = ?
+		ORDER BY da.id ASC`
+
+	query.Result = make([]*models.DashboardAclInfoDTO, 0)
+	if err := x.SQL(rawSQL, query.OrgId, query.DashboardId).Find(&query.Result); err != nil {
+		return err
+	}
+
+	for _, p := range query.Result {
+		p.PermissionName = p.Permission.String()
+	}
+	return nil
+}
+
+func getDashboardTrash(sess *DBSession, orgId int64, id int64) (*models.DashboardTrash, error) {
+	trash := models.DashboardTrash{}
+	has, err := sess.Where("org_id=? AND id=?", orgId, id).Get(&trash)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, models.ErrDashboardTrashNotFound
+	}
+
+	return &trash, nil
+}
+
+// RestoreDashboardFromTrash restores a dashboard, or a folder with its
+// content, from the trash. It's restored in the General folder if its folder
+// no longer exists.
+func RestoreDashboardFromTrash(cmd *models.RestoreDashboardFromTrashCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		trash, err := getDashboardTrash(sess, cmd.OrgId, cmd.Id)
+		if err != nil {
+			return err
+		}
+
+		var content dashboardTrashContent
+		if err := json.Unmarshal([]byte(trash.Content), &content); err != nil {
+			return err
+		}
+		if len(content.Dashboards) == 0 {
+			return models.ErrDashboardTrashNotFound
+		}
+
+		restored := content.Dashboards[0]
+		if restored.FolderId > 0 {
+			exists, err := sess.Where("org_id=? AND id=? AND is_folder=?", restored.OrgId, restored.FolderId, dialect.BooleanStr(true)).Exist(&models.Dashboard{})
+			if err != nil {
+				return err
+			}
+			if !exists {
+				restored.FolderId = 0
+			}
+		}
+
+		exists, err := sess.Where("org_id=? AND folder_id=? AND title=?", restored.OrgId, restored.FolderId, restored.Title).Exist(&models.Dashboard{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return models.ErrDashboardTrashRestoreConflict
+		}
+
+		for _, dash := range content.Dashboards {
+			exists, err := sess.Where("id=? OR (org_id=? AND uid=?)", dash.Id, dash.OrgId, dash.Uid).Exist(&models.Dashboard{})
+			if err != nil {
+				return err
+			}
+			if exists {
+				return models.ErrDashboardTrashRestoreConflict
+			}
+
+			if _, err := sess.Insert(dash); err != nil {
+				return err
+			}
+		}
+
+		if restored.IsFolder && restored.FolderId > 0 {
+			if err := validateFolderParent(sess, restored); err != nil {
+				return err
+			}
+		}
+
+		for _, alert := range content.Alerts {
+			if _, err := sess.Insert(alert); err != nil {
+				return err
+			}
+		}
+
+		if err := deleteDashboardTrashRows(sess, trash); err != nil {
+			return err
+		}
+
+		cmd.Result = restored
+		return nil
+	})
+}
+
+func DeleteDashboardFromTrash(cmd *models.DeleteDashboardFromTrashCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		trash, err := getDashboardTrash(sess, cmd.OrgId, cmd.Id)
+		if err != nil {
+			return err
+		}
+
+		return deleteDashboardTrash(sess, trash)
+	})
+}
+
+// DeleteExpiredDashboardTrash permanently deletes the dashboards deleted
+// before cmd.OlderThan.
+func DeleteExpiredDashboardTrash(cmd *models.DeleteExpiredDashboardTrashCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		var expired []*models.DashboardTrash
+		if err := sess.Where("deleted < ?", cmd.OlderThan).Find(&expired); err != nil {
+			return err
+		}
+
+		for _, trash := range expired {
+			if err := deleteDashboardTrash(sess, trash); err != nil {
+				return err
+			}
+		}
+
+		cmd.DeletedRows = int64(len(expired))
+		return nil
+	})
+}
+
+// deleteDashboardTrash permanently deletes a dashboard in the trash, with
+// the rows still referencing it and its alerts.
+func deleteDashboardTrash(sess *DBSession, trash *models.DashboardTrash) error {
+	var content dashboardTrashContent
+	if err := json.Unmarshal([]byte(trash.Content), &content); err != nil {
+		return err
+	}
+
+	deletes := []string{
+		"DELETE FROM dashboard_tag WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_search_term WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_datasource_ref WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_user_view WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_daily_view WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_daily_query WHERE dashboard_id = ?",
+		"DELETE FROM star WHERE dashboard_id = ?",
+		"DELETE FROM playlist_item WHERE type = 'dashboard_by_id' AND value = ?",
+		"DELETE FROM dashboard_version WHERE dashboard_id = ?",
+		"DELETE FROM annotation WHERE dashboard_id = ?",
+		"DELETE FROM dashboard_acl WHERE dashboard_id = ?",
+		"DELETE FROM library_panel_dashboard WHERE dashboard_id = ?",
+		"UPDATE library_panel SET folder_id = 0 WHERE folder_id = ?",
+		"DELETE FROM dashboard_version_retention WHERE folder_id = ?",
+	}
+
+	for _, dash := range content.Dashboards {
+		for _, sql := range deletes {
+			if _, err := sess.Exec(sql, dash.Id); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, alert := range content.Alerts {
+		if err := deleteAlertByIdInternal(alert.Id, "Dashboard deleted from trash", sess); err != nil {
+			return err
+		}
+	}
+
+	return deleteDashboardTrashRows(sess, trash)
+}
+
+func deleteDashboardTrashRows(sess *DBSession, trash *models.DashboardTrash) error {
+	if _, err := sess.Where("trash_id=?", trash.Id).Delete(&models.DashboardTrashItem{}); err != nil {
+		return err
+	}
+
+	_, err := sess.ID(trash.Id).Delete(&models.DashboardTrash{})
+	return err
+}
@@ -0,0 +1,170 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardTrash(t *testing.T) {
+	InitTestDB(t)
+
+	save := func(t *testing.T, title string, folderId int64, isFolder bool) *models.Dashboard {
+		t.Helper()
+		cmd := models.SaveDashboardCommand{
+			OrgId:     1,
+			FolderId:  folderId,
+			IsFolder:  isFolder,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": title, "tags": []interface{}{"prod"}}),
+		}
+		require.NoError(t, SaveDashboard(&cmd))
+		return cmd.Result
+	}
+
+	exists := func(t *testing.T, id int64) bool {
+		t.Helper()
+		err := GetDashboard(&models.GetDashboardQuery{OrgId: 1, Id: id})
+		if err == models.ErrDashboardNotFound {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+
+	countAlerts := func(t *testing.T, dashboardId int64) int64 {
+		t.Helper()
+		count, err := x.Where("dashboard_id=?", dashboardId).Count(&models.Alert{})
+		require.NoError(t, err)
+		return count
+	}
+
+	folder := save(t, "Team", 0, true)
+	subfolder := save(t, "Backend", folder.Id, true)
+	dash := save(t, "API", subfolder.Id, false)
+
+	_, err := x.Insert(&models.Alert{OrgId: 1, DashboardId: dash.Id, PanelId: 1, Name: "Errors", Settings: simplejson.New(), EvalData: simplejson.New(), NewStateDate: time.Now(), Created: time.Now(), Updated: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, testHelperUpdateDashboardAcl(folder.Id, models.DashboardAcl{
+		OrgId: 1, DashboardId: folder.Id, UserId: 10, Permission: models.PERMISSION_EDIT,
+	}))
+	require.NoError(t, StarDashboard(&models.StarDashboardCommand{UserId: 10, DashboardId: dash.Id}))
+
+	var trashId int64
+
+	t.Run("deleting a folder moves it to the trash with its content", func(t *testing.T) {
+		cmd := models.TrashDashboardCommand{OrgId: 1, Id: folder.Id}
+		require.NoError(t, TrashDashboard(&cmd))
+		trashId = cmd.Result.Id
+
+		for _, id := range []int64{folder.Id, subfolder.Id, dash.Id} {
+			assert.False(t, exists(t, id))
+		}
+		assert.Zero(t, countAlerts(t, dash.Id))
+
+		query := models.GetDashboardTrashQuery{OrgId: 1}
+		require.NoError(t, GetDashboardTrash(&query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, "Team", query.Result[0].Title)
+		assert.True(t, query.Result[0].IsFolder)
+		assert.Equal(t, folder.Id, query.Result[0].DashboardId)
+		assert.Empty(t, query.Result[0].Content)
+
+		query = models.GetDashboardTrashQuery{OrgId: 2}
+		require.NoError(t, GetDashboardTrash(&query))
+		assert.Empty(t, query.Result)
+
+		var items []*models.DashboardTrashItem
+		require.NoError(t, x.Where("trash_id=?", trashId).Asc("id").Find(&items))
+		require.Len(t, items, 3)
+		assert.Equal(t, dash.Id, items[2].DashboardId)
+		assert.Equal(t, subfolder.Id, items[2].FolderId)
+
+		acl := models.GetDashboardTrashAclQuery{OrgId: 1, DashboardId: folder.Id}
+		require.NoError(t, GetDashboardTrashAcl(&acl))
+		require.Len(t, acl.Result, 1)
+		assert.EqualValues(t, 10, acl.Result[0].UserId)
+		assert.Equal(t, models.PERMISSION_EDIT, acl.Result[0].Permission)
+	})
+
+	t.Run("restoring a folder restores its content, alerts, permissions and stars", func(t *testing.T) {
+		cmd := models.RestoreDashboardFromTrashCommand{OrgId: 1, Id: trashId}
+		require.NoError(t, RestoreDashboardFromTrash(&cmd))
+		assert.Equal(t, folder.Id, cmd.Result.Id)
+
+		for _, id := range []int64{folder.Id, subfolder.Id, dash.Id} {
+			assert.True(t, exists(t, id))
+		}
+		assert.EqualValues(t, 1, countAlerts(t, dash.Id))
+
+		acl := models.GetDashboardAclInfoListQuery{OrgId: 1, DashboardId: dash.Id}
+		require.NoError(t, GetDashboardAclInfoList(&acl))
+		require.Len(t, acl.Result, 1)
+		assert.EqualValues(t, 10, acl.Result[0].UserId)
+
+		starred := models.IsStarredByUserQuery{UserId: 10, DashboardId: dash.Id}
+		require.NoError(t, IsStarredByUser(&starred))
+		assert.True(t, starred.Result)
+
+		err := GetDashboardTrashById(&models.GetDashboardTrashByIdQuery{OrgId: 1, Id: trashId})
+		assert.Equal(t, models.ErrDashboardTrashNotFound, err)
+
+		items, err := x.Where("trash_id=?", trashId).Count(&models.DashboardTrashItem{})
+		require.NoError(t, err)
+		assert.Zero(t, items)
+	})
+
+	t.Run("a dashboard can't be restored over a dashboard with the same name", func(t *testing.T) {
+		cmd := models.TrashDashboardCommand{OrgId: 1, Id: dash.Id}
+		require.NoError(t, TrashDashboard(&cmd))
+		trashId = cmd.Result.Id
+
+		replacement := save(t, "API", subfolder.Id, false)
+
+		err := RestoreDashboardFromTrash(&models.RestoreDashboardFromTrashCommand{OrgId: 1, Id: trashId})
+		assert.Equal(t, models.ErrDashboardTrashRestoreConflict, err)
+
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{OrgId: 1, Id: replacement.Id}))
+	})
+
+	t.Run("a dashboard is restored in the General folder when its folder no longer exists", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{OrgId: 1, Id: subfolder.Id}))
+
+		cmd := models.RestoreDashboardFromTrashCommand{OrgId: 1, Id: trashId}
+		require.NoError(t, RestoreDashboardFromTrash(&cmd))
+		assert.Zero(t, cmd.Result.FolderId)
+
+		query := models.GetDashboardQuery{OrgId: 1, Id: dash.Id}
+		require.NoError(t, GetDashboard(&query))
+		assert.Zero(t, query.Result.FolderId)
+	})
+
+	t.Run("expired dashboards are permanently deleted", func(t *testing.T) {
+		getTimeNow = func() time.Time { return time.Now().Add(-48 * time.Hour) }
+		t.Cleanup(func() { getTimeNow = time.Now })
+
+		require.NoError(t, TrashDashboard(&models.TrashDashboardCommand{OrgId: 1, Id: dash.Id}))
+		getTimeNow = time.Now
+		recent := save(t, "Recent", 0, false)
+		require.NoError(t, TrashDashboard(&models.TrashDashboardCommand{OrgId: 1, Id: recent.Id}))
+
+		cmd := models.DeleteExpiredDashboardTrashCommand{OlderThan: time.Now().Add(-24 * time.Hour)}
+		require.NoError(t, DeleteExpiredDashboardTrash(&cmd))
+		assert.EqualValues(t, 1, cmd.DeletedRows)
+
+		query := models.GetDashboardTrashQuery{OrgId: 1}
+		require.NoError(t, GetDashboardTrash(&query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, "Recent", query.Result[0].Title)
+
+		versions := models.GetDashboardVersionsQuery{OrgId: 1, DashboardId: dash.Id}
+		assert.Equal(t, models.ErrNoVersionsForDashboardId, GetDashboardVersions(&versions))
+
+		starred := models.IsStarredByUserQuery{UserId: 10, DashboardId: dash.Id}
+		require.NoError(t, IsStarredByUser(&starred))
+		assert.False(t, starred.Result)
+	})
+}
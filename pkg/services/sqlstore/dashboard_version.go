@@ -2,10 +2,10 @@ package sqlstore
 
 import (
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/setting"
 )
 
 func init() {
@@ -49,7 +49,8 @@ func GetDashboardVersions(query *models.GetDashboardVersionsQuery) error {
 				dashboard_version.created,
 				dashboard_version.created_by as created_by_id,
 				dashboard_version.message,
-				dashboard_version.data,`+
+				dashboard_version.data,
+				dashboard_version.pinned,`+
 			dialect.Quote("user")+`.login as created_by`).
 		Join("LEFT", dialect.Quote("user"), `dashboard_version.created_by = `+dialect.Quote("user")+`.id`).
 		Join("LEFT", "dashboard", `dashboard.id = dashboard_version.dashboard_id`).
@@ -75,60 +76,208 @@ func DeleteExpiredVersions(cmd *models.DeleteExpiredVersionsCommand) error {
 }
 
 func deleteExpiredVersions(cmd *models.DeleteExpiredVersionsCommand, perBatch int, maxBatches int) error {
-	versionsToKeep := setting.DashboardVersionsToKeep
-	if versionsToKeep < 1 {
-		versionsToKeep = 1
-	}
-
-	for batch := 0; batch < maxBatches; batch++ {
-		deleted := int64(0)
-
-		batchErr := inTransaction(func(sess *DBSession) error {
-			// Idea of this query is finding version IDs to delete based on formula:
-			// min_version_to_keep = min_version + (versions_count - versions_to_keep)
-			// where version stats is processed for each dashboard. This guarantees that we keep at least versions_to_keep
-			// versions, but in some cases (when versions are sparse) this number may be more.
-			versionIdsToDeleteQuery := `SELECT id
-				FROM dashboard_version, (
-					SELECT dashboard_id, count(version) as count, min(version) as min
-					FROM dashboard_version
-					GROUP BY dashboard_id
-				) AS vtd
-				WHERE dashboard_version.dashboard_id=vtd.dashboard_id
-				AND version < vtd.min + vtd.count - ?
-				LIMIT ?`
-
-			var versionIdsToDelete []interface{}
-			err := sess.SQL(versionIdsToDeleteQuery, versionsToKeep, perBatch).Find(&versionIdsToDelete)
-			if err != nil {
-				return err
-			}
+	sess := newSession()
+	defer sess.Close()
+
+	retentions, err := getVersionRetentions(sess, 0)
+	if err != nil {
+		return err
+	}
 
-			if len(versionIdsToDelete) < 1 {
-				return nil
+	// versions older than the shortest max age of the policies may expire
+	now := getTimeNow()
+	minMaxAge := time.Duration(0)
+	for _, orgPolicies := range retentions.policies {
+		for _, policy := range orgPolicies {
+			if maxAge, err := policy.MaxAgeDuration(); err == nil && maxAge > 0 && (minMaxAge == 0 || maxAge < minMaxAge) {
+				minMaxAge = maxAge
 			}
+		}
+	}
+
+	var stats []*dashboardVersionStats
+	err = sess.SQL(`SELECT dashboard_version.dashboard_id,
+			COALESCE(dashboard.org_id, 0) AS org_id,
+			COALESCE(dashboard.folder_id, 0) AS folder_id,
+			COUNT(*) AS count,
+			COUNT(CASE WHEN dashboard_version.created < ? THEN 1 END) AS old_count
+		FROM dashboard_version
+		LEFT JOIN dashboard ON dashboard.id = dashboard_version.dashboard_id
+		WHERE dashboard_version.pinned = `+dialect.BooleanStr(false)+`
+		GROUP BY dashboard_version.dashboard_id, dashboard.org_id, dashboard.folder_id
+		ORDER BY dashboard_version.dashboard_id`, now.Add(-minMaxAge)).Find(&stats)
+	if err != nil {
+		return err
+	}
+
+	// the versions of trashed dashboards are kept for them to be restored,
+	// with the policy of the folder they were in
+	trashed, err := resolveTrashedDashboards(sess, stats, retentions)
+	if err != nil {
+		return err
+	}
+
+	maxToDelete := perBatch * maxBatches
+	versionIdsToDelete := make([]interface{}, 0)
+	for _, stat := range stats {
+		if len(versionIdsToDelete) >= maxToDelete {
+			break
+		}
+		if dash, ok := trashed[stat.DashboardId]; ok {
+			stat.OrgId, stat.FolderId = dash.OrgId, dash.FolderId
+		}
+
+		policy := retentions.resolve(stat.OrgId, stat.FolderId)
+		maxAge, err := policy.MaxAgeDuration()
+		if err != nil {
+			sqlog.Warn("Invalid dashboard version retention policy", "orgId", policy.OrgId, "folderId", policy.FolderId, "error", err)
+			continue
+		}
+		if !mayHaveExpiredVersions(stat, policy.VersionsToKeep, maxAge) {
+			continue
+		}
 
-			deleteExpiredSql := `DELETE FROM dashboard_version WHERE id IN (?` + strings.Repeat(",?", len(versionIdsToDelete)-1) + `)`
-			sqlOrArgs := append([]interface{}{deleteExpiredSql}, versionIdsToDelete...)
+		expired, err := getExpiredVersionIds(sess, stat.DashboardId, policy.VersionsToKeep, now.Add(-maxAge), maxAge > 0)
+		if err != nil {
+			return err
+		}
+		versionIdsToDelete = append(versionIdsToDelete, expired...)
+	}
+	if len(versionIdsToDelete) > maxToDelete {
+		versionIdsToDelete = versionIdsToDelete[:maxToDelete]
+	}
+
+	for start := 0; start < len(versionIdsToDelete); start += perBatch {
+		end := start + perBatch
+		if end > len(versionIdsToDelete) {
+			end = len(versionIdsToDelete)
+		}
+		batch := versionIdsToDelete[start:end]
+
+		err := inTransaction(func(sess *DBSession) error {
+			deleteExpiredSql := `DELETE FROM dashboard_version WHERE id IN (?` + strings.Repeat(",?", len(batch)-1) + `)`
+			sqlOrArgs := append([]interface{}{deleteExpiredSql}, batch...)
 			expiredResponse, err := sess.Exec(sqlOrArgs...)
 			if err != nil {
 				return err
 			}
 
-			deleted, err = expiredResponse.RowsAffected()
+			deleted, err := expiredResponse.RowsAffected()
+			cmd.DeletedRows += deleted
 			return err
 		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveTrashedDashboards returns the trashed dashboards, by id, of the
+// versions stats without dashboard, and adds the trashed folders to the
+// folder chain of the retention policies.
+func resolveTrashedDashboards(sess *DBSession, stats []*dashboardVersionStats, retentions *versionRetentions) (map[int64]*models.DashboardTrashItem, error) {
+	trashed := make(map[int64]*models.DashboardTrashItem)
 
-		if batchErr != nil {
-			return batchErr
+	missingIds := make([]int64, 0)
+	for _, stat := range stats {
+		if stat.OrgId == 0 {
+			missingIds = append(missingIds, stat.DashboardId)
 		}
+	}
 
-		cmd.DeletedRows += deleted
+	// the ids are looked up in batches to stay below the limit of
+	// parameters of the databases
+	const batchSize = 500
+	trashIds := make(map[int64]bool)
+	for start := 0; start < len(missingIds); start += batchSize {
+		end := start + batchSize
+		if end > len(missingIds) {
+			end = len(missingIds)
+		}
 
-		if deleted < int64(perBatch) {
-			break
+		var items []*models.DashboardTrashItem
+		if err := sess.In("dashboard_id", missingIds[start:end]).Find(&items); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			trashed[item.DashboardId] = item
+			trashIds[item.TrashId] = true
 		}
 	}
+	if len(trashIds) == 0 {
+		return trashed, nil
+	}
 
-	return nil
+	ids := make([]int64, 0, len(trashIds))
+	for id := range trashIds {
+		ids = append(ids, id)
+	}
+	var folders []*models.DashboardTrashItem
+	if err := sess.In("trash_id", ids).Where("is_folder=?", dialect.BooleanStr(true)).Find(&folders); err != nil {
+		return nil, err
+	}
+	for _, folder := range folders {
+		if _, ok := retentions.parents[folder.DashboardId]; !ok {
+			retentions.parents[folder.DashboardId] = folder.FolderId
+		}
+	}
+
+	return trashed, nil
+}
+
+// dashboardVersionStats counts the versions of a dashboard which aren't
+// pinned, and the ones older than the shortest max age of the policies.
+type dashboardVersionStats struct {
+	DashboardId int64
+	OrgId       int64
+	FolderId    int64
+	Count       int
+	OldCount    int
+}
+
+// mayHaveExpiredVersions checks the version counts of a dashboard to avoid
+// loading the versions of the dashboards which have none to delete.
+func mayHaveExpiredVersions(stat *dashboardVersionStats, versionsToKeep int, maxAge time.Duration) bool {
+	switch {
+	case stat.Count <= 1:
+		return false
+	case versionsToKeep > 0 && stat.Count <= versionsToKeep:
+		return false
+	case maxAge > 0 && stat.OldCount == 0:
+		return false
+	}
+	return versionsToKeep > 0 || maxAge > 0
+}
+
+// getExpiredVersionIds returns the ids of the versions of a dashboard which
+// are neither among the latest versionsToKeep versions nor saved after
+// keepAfter. Pinned versions and the latest version are always kept.
+func getExpiredVersionIds(sess *DBSession, dashboardId int64, versionsToKeep int, keepAfter time.Time, hasMaxAge bool) ([]interface{}, error) {
+	var versions []*models.DashboardVersion
+	err := sess.Table("dashboard_version").
+		Where("dashboard_id=?", dashboardId).
+		Cols("id", "version", "created", "pinned").
+		OrderBy("version DESC").
+		Find(&versions)
+	if err != nil {
+		return nil, err
+	}
+
+	expired := make([]interface{}, 0)
+	unpinned := 0
+	for i, version := range versions {
+		if version.Pinned {
+			continue
+		}
+		unpinned++
+
+		withinCount := versionsToKeep > 0 && unpinned <= versionsToKeep
+		withinAge := hasMaxAge && version.Created.After(keepAfter)
+		if i > 0 && !withinCount && !withinAge {
+			expired = append(expired, version.Id)
+		}
+	}
+	return expired, nil
 }
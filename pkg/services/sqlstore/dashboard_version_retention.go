@@ -0,0 +1,183 @@
+package sqlstore
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func init() {
+	bus.AddHandler("sql", SaveDashboardVersionRetention)
+	bus.AddHandler("sql", DeleteDashboardVersionRetention)
+	bus.AddHandler("sql", GetDashboardVersionRetentions)
+	bus.AddHandler("sql", GetDashboardVersionRetention)
+	bus.AddHandler("sql", SetDashboardVersionPinned)
+}
+
+func SaveDashboardVersionRetention(cmd *models.SaveDashboardVersionRetentionCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		retention := models.DashboardVersionRetention{
+			OrgId:          cmd.OrgId,
+			FolderId:       cmd.FolderId,
+			VersionsToKeep: cmd.VersionsToKeep,
+			MaxAge:         cmd.MaxAge,
+		}
+		if err := retention.Validate(); err != nil {
+			return err
+		}
+
+		if cmd.FolderId > 0 {
+			var folder models.Dashboard
+			exists, err := sess.Where("id=? AND org_id=? AND is_folder=?", cmd.FolderId, cmd.OrgId, dialect.BooleanStr(true)).Cols("id").Get(&folder)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return models.ErrFolderNotFound
+			}
+		}
+
+		var existing models.DashboardVersionRetention
+		has, err := sess.Where("org_id=? AND folder_id=?", cmd.OrgId, cmd.FolderId).Get(&existing)
+		if err != nil {
+			return err
+		}
+
+		retention.Updated = getTimeNow()
+		if has {
+			retention.Id = existing.Id
+			retention.Created = existing.Created
+			if _, err := sess.ID(existing.Id).MustCols("versions_to_keep", "max_age").Update(&retention); err != nil {
+				return err
+			}
+		} else {
+			retention.Created = retention.Updated
+			if _, err := sess.Insert(&retention); err != nil {
+				return err
+			}
+		}
+
+		cmd.Result = &retention
+		return nil
+	})
+}
+
+func DeleteDashboardVersionRetention(cmd *models.DeleteDashboardVersionRetentionCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		res, err := sess.Exec("DELETE FROM dashboard_version_retention WHERE org_id=? AND folder_id=?", cmd.OrgId, cmd.FolderId)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return models.ErrDashboardVersionRetentionNotFound
+		}
+		return nil
+	})
+}
+
+func GetDashboardVersionRetentions(query *models.GetDashboardVersionRetentionsQuery) error {
+	query.Result = make([]*models.DashboardVersionRetention, 0)
+	return x.Where("org_id=?", query.OrgId).OrderBy("folder_id").Find(&query.Result)
+}
+
+func GetDashboardVersionRetention(query *models.GetDashboardVersionRetentionQuery) error {
+	retentions, err := getVersionRetentions(newSession(), query.OrgId)
+	if err != nil {
+		return err
+	}
+
+	query.Result = retentions.resolve(query.OrgId, query.FolderId)
+	return nil
+}
+
+func SetDashboardVersionPinned(cmd *models.SetDashboardVersionPinnedCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		var version models.DashboardVersion
+		has, err := sess.Table("dashboard_version").
+			Join("INNER", "dashboard", "dashboard.id = dashboard_version.dashboard_id").
+			Where("dashboard_version.dashboard_id=? AND dashboard_version.version=? AND dashboard.org_id=?", cmd.DashboardId, cmd.Version, cmd.OrgId).
+			Cols("dashboard_version.id").
+			Get(&version)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrDashboardVersionNotFound
+		}
+
+		_, err = sess.Exec("UPDATE dashboard_version SET pinned=? WHERE id=?", cmd.Pinned, version.Id)
+		return err
+	})
+}
+
+// versionRetentions resolves the retention policy of the dashboards of a
+// folder through the folder chain.
+type versionRetentions struct {
+	// policies by org id, then by folder id, 0 for the org default
+	policies map[int64]map[int64]*models.DashboardVersionRetention
+	// parent folder ids by folder id
+	parents map[int64]int64
+}
+
+// getVersionRetentions loads the retention policies and the folders of an
+// org, or of all orgs when orgId is 0.
+func getVersionRetentions(sess *DBSession, orgId int64) (*versionRetentions, error) {
+	policies := make([]*models.DashboardVersionRetention, 0)
+	folders := make([]*models.Dashboard, 0)
+
+	if orgId > 0 {
+		sess.Where("org_id=?", orgId)
+	}
+	if err := sess.Find(&policies); err != nil {
+		return nil, err
+	}
+
+	sess.Where("is_folder=?", dialect.BooleanStr(true))
+	if orgId > 0 {
+		sess.And("org_id=?", orgId)
+	}
+	if err := sess.Cols("id", "folder_id").Find(&folders); err != nil {
+		return nil, err
+	}
+
+	retentions := &versionRetentions{
+		policies: make(map[int64]map[int64]*models.DashboardVersionRetention),
+		parents:  make(map[int64]int64, len(folders)),
+	}
+	for _, policy := range policies {
+		if retentions.policies[policy.OrgId] == nil {
+			retentions.policies[policy.OrgId] = make(map[int64]*models.DashboardVersionRetention)
+		}
+		retentions.policies[policy.OrgId][policy.FolderId] = policy
+	}
+	for _, folder := range folders {
+		retentions.parents[folder.Id] = folder.FolderId
+	}
+
+	return retentions, nil
+}
+
+// resolve returns the policy of the folder, of its closest parent folder
+// having one, or the org default. Falls back to the versions_to_keep setting.
+func (r *versionRetentions) resolve(orgId int64, folderId int64) *models.DashboardVersionRetention {
+	orgPolicies := r.policies[orgId]
+	for depth := 0; folderId > 0 && depth <= models.MaxFolderDepth; depth++ {
+		if policy, ok := orgPolicies[folderId]; ok {
+			return policy
+		}
+		folderId = r.parents[folderId]
+	}
+
+	if policy, ok := orgPolicies[0]; ok {
+		return policy
+	}
+
+	versionsToKeep := setting.DashboardVersionsToKeep
+	if versionsToKeep < 1 {
+		versionsToKeep = 1
+	}
+	return &models.DashboardVersionRetention{OrgId: orgId, VersionsToKeep: versionsToKeep}
+}
@@ -0,0 +1,144 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardVersionRetention(t *testing.T) {
+	InitTestDB(t)
+	setting.DashboardVersionsToKeep = 20
+
+	saveDashboard := func(t *testing.T, id int64, title string, folderId int64, isFolder bool) *models.Dashboard {
+		t.Helper()
+		cmd := models.SaveDashboardCommand{
+			OrgId:     1,
+			FolderId:  folderId,
+			IsFolder:  isFolder,
+			Overwrite: true,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"id": id, "title": title}),
+		}
+		require.NoError(t, SaveDashboard(&cmd))
+		return cmd.Result
+	}
+
+	// saveVersions saves new versions of a dashboard, and makes its first
+	// three versions more than a year old
+	saveVersions := func(t *testing.T, dash *models.Dashboard, count int) {
+		t.Helper()
+		for i := 0; i < count; i++ {
+			dash = saveDashboard(t, dash.Id, dash.Title, dash.FolderId, false)
+		}
+		_, err := x.Exec("UPDATE dashboard_version SET created = ? WHERE dashboard_id = ? AND version <= 3",
+			time.Now().AddDate(-1, 0, -1), dash.Id)
+		require.NoError(t, err)
+	}
+
+	setRetention := func(t *testing.T, folderId int64, versionsToKeep int, maxAge string) {
+		t.Helper()
+		cmd := models.SaveDashboardVersionRetentionCommand{OrgId: 1, FolderId: folderId, VersionsToKeep: versionsToKeep, MaxAge: maxAge}
+		require.NoError(t, SaveDashboardVersionRetention(&cmd))
+	}
+
+	versions := func(t *testing.T, dashboardId int64) []int {
+		t.Helper()
+		query := models.GetDashboardVersionsQuery{DashboardId: dashboardId, OrgId: 1}
+		require.NoError(t, GetDashboardVersions(&query))
+		result := make([]int, 0, len(query.Result))
+		for _, version := range query.Result {
+			result = append(result, version.Version)
+		}
+		return result
+	}
+
+	compliance := saveDashboard(t, 0, "Compliance", 0, true)
+	audits := saveDashboard(t, 0, "Audits", compliance.Id, true)
+	scratch := saveDashboard(t, 0, "Scratch", 0, true)
+
+	t.Run("policies are inherited through the folder chain", func(t *testing.T) {
+		setRetention(t, compliance.Id, 0, "1y")
+
+		query := models.GetDashboardVersionRetentionQuery{OrgId: 1, FolderId: audits.Id}
+		require.NoError(t, GetDashboardVersionRetention(&query))
+		assert.Equal(t, compliance.Id, query.Result.FolderId)
+		assert.Equal(t, "1y", query.Result.MaxAge)
+
+		query = models.GetDashboardVersionRetentionQuery{OrgId: 1, FolderId: scratch.Id}
+		require.NoError(t, GetDashboardVersionRetention(&query))
+		assert.Zero(t, query.Result.Id)
+		assert.Equal(t, 20, query.Result.VersionsToKeep)
+
+		setRetention(t, 0, 10, "")
+		query = models.GetDashboardVersionRetentionQuery{OrgId: 1, FolderId: scratch.Id}
+		require.NoError(t, GetDashboardVersionRetention(&query))
+		assert.NotZero(t, query.Result.Id)
+		assert.Equal(t, 10, query.Result.VersionsToKeep)
+	})
+
+	t.Run("invalid policies are rejected", func(t *testing.T) {
+		cmd := models.SaveDashboardVersionRetentionCommand{OrgId: 1, FolderId: scratch.Id, MaxAge: "forever"}
+		assert.Equal(t, models.ErrDashboardVersionRetentionInvalid, SaveDashboardVersionRetention(&cmd))
+
+		cmd = models.SaveDashboardVersionRetentionCommand{OrgId: 1, FolderId: 1000, VersionsToKeep: 5}
+		assert.Equal(t, models.ErrFolderNotFound, SaveDashboardVersionRetention(&cmd))
+	})
+
+	t.Run("expired versions are deleted according to the policy of their folder", func(t *testing.T) {
+		setRetention(t, scratch.Id, 5, "")
+
+		audit := saveDashboard(t, 0, "Audit log", audits.Id, false)
+		saveVersions(t, audit, 7)
+		notes := saveDashboard(t, 0, "Notes", scratch.Id, false)
+		saveVersions(t, notes, 7)
+
+		require.NoError(t, SetDashboardVersionPinned(&models.SetDashboardVersionPinnedCommand{
+			OrgId: 1, DashboardId: notes.Id, Version: 2, Pinned: true,
+		}))
+
+		require.NoError(t, DeleteExpiredVersions(&models.DeleteExpiredVersionsCommand{}))
+
+		// the versions older than a year are deleted
+		assert.Equal(t, []int{8, 7, 6, 5, 4}, versions(t, audit.Id))
+		// the latest 5 versions and the pinned version are kept
+		assert.Equal(t, []int{8, 7, 6, 5, 4, 2}, versions(t, notes.Id))
+	})
+
+	t.Run("pinning a version of another org fails", func(t *testing.T) {
+		err := SetDashboardVersionPinned(&models.SetDashboardVersionPinnedCommand{OrgId: 2, DashboardId: compliance.Id, Version: 1, Pinned: true})
+		assert.Equal(t, models.ErrDashboardVersionNotFound, err)
+	})
+
+	t.Run("deleted policies are removed from the org policies", func(t *testing.T) {
+		require.NoError(t, DeleteDashboardVersionRetention(&models.DeleteDashboardVersionRetentionCommand{OrgId: 1, FolderId: scratch.Id}))
+		assert.Equal(t, models.ErrDashboardVersionRetentionNotFound,
+			DeleteDashboardVersionRetention(&models.DeleteDashboardVersionRetentionCommand{OrgId: 1, FolderId: scratch.Id}))
+
+		query := models.GetDashboardVersionRetentionsQuery{OrgId: 1}
+		require.NoError(t, GetDashboardVersionRetentions(&query))
+		require.Len(t, query.Result, 2)
+		assert.Zero(t, query.Result[0].FolderId)
+		assert.Equal(t, compliance.Id, query.Result[1].FolderId)
+	})
+
+	t.Run("expired versions of trashed dashboards are deleted according to the policy of their folder", func(t *testing.T) {
+		archive := saveDashboard(t, 0, "Archive", 0, true)
+		old := saveDashboard(t, 0, "Old", archive.Id, true)
+		setRetention(t, archive.Id, 0, "1y")
+
+		report := saveDashboard(t, 0, "Report", old.Id, false)
+		saveVersions(t, report, 7)
+		require.NoError(t, TrashDashboard(&models.TrashDashboardCommand{OrgId: 1, Id: archive.Id}))
+
+		require.NoError(t, DeleteExpiredVersions(&models.DeleteExpiredVersionsCommand{}))
+
+		var remaining []int
+		require.NoError(t, x.Table("dashboard_version").Where("dashboard_id=?", report.Id).Desc("version").Cols("version").Find(&remaining))
+		assert.Equal(t, []int{8, 7, 6, 5, 4}, remaining)
+	})
+}
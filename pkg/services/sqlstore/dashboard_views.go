@@ -0,0 +1,262 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", SaveDashboardViews)
+	bus.AddHandler("sql", DeleteOldDashboardViews)
+	bus.AddHandler("sql", GetDashboardInsights)
+	bus.AddHandler("sql", GetStaleDashboards)
+}
+
+const dayFormat = "2006-01-02"
+
+// saveDashboardViewsAttempts is how many times a batch of views is saved
+// before giving up when it conflicts with the batches of other instances.
+const saveDashboardViewsAttempts = 3
+
+// SaveDashboardViews adds views to the views of the dashboards by user,
+// when the user isn't anonymous, and by day, and queries to the queries of
+// the dashboards by day, when the dashboards belong to the organization of
+// the queries.
+func SaveDashboardViews(cmd *models.SaveDashboardViewsCommand) error {
+	return retryOnConflict(saveDashboardViewsAttempts, func() error {
+		return inTransaction(func(sess *DBSession) error {
+			return saveDashboardViews(sess, cmd)
+		})
+	})
+}
+
+// retryOnConflict calls fn again when it fails because another instance
+// inserted the same row or locked the same rows concurrently. The whole
+// transaction is retried since some databases abort it on the first error.
+func retryOnConflict(attempts int, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = fn()
+		if err == nil || !(dialect.IsUniqueConstraintViolation(err) || dialect.IsDeadlock(err)) {
+			return err
+		}
+		sqlog.Debug("Retrying after a conflict with another instance", "attempt", attempt, "error", err)
+	}
+	return err
+}
+
+func saveDashboardViews(sess *DBSession, cmd *models.SaveDashboardViewsCommand) error {
+	for _, views := range cmd.Views {
+		if views.UserId > 0 {
+			userView := &models.DashboardUserView{
+				DashboardId: views.DashboardId,
+				UserId:      views.UserId,
+				Views:       views.Count,
+				LastViewed:  views.LastViewed.Unix(),
+			}
+			res, err := sess.Exec(`UPDATE dashboard_user_view
+				SET views = views + ?, last_viewed = CASE WHEN last_viewed < ? THEN ? ELSE last_viewed END
+				WHERE dashboard_id = ? AND user_id = ?`,
+				userView.Views, userView.LastViewed, userView.LastViewed, userView.DashboardId, userView.UserId)
+			if err != nil {
+				return err
+			}
+			if err := insertIfNotUpdated(sess, res, userView, "dashboard_id=? AND user_id=?", userView.DashboardId, userView.UserId); err != nil {
+				return err
+			}
+		}
+
+		dailyView := &models.DashboardDailyView{
+			DashboardId: views.DashboardId,
+			Day:         views.LastViewed.UTC().Format(dayFormat),
+			Views:       views.Count,
+		}
+		res, err := sess.Exec("UPDATE dashboard_daily_view SET views = views + ? WHERE dashboard_id = ? AND day = ?",
+			dailyView.Views, dailyView.DashboardId, dailyView.Day)
+		if err != nil {
+			return err
+		}
+		if err := insertIfNotUpdated(sess, res, dailyView, "dashboard_id=? AND day=?", dailyView.DashboardId, dailyView.Day); err != nil {
+			return err
+		}
+	}
+
+	for _, queries := range cmd.Queries {
+		// the dashboard id comes from a request header, so queries sent
+		// with the id of a dashboard of another organization are ignored
+		count, err := sess.Table("dashboard").Where("id = ? AND org_id = ?", queries.DashboardId, queries.OrgId).Count()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			continue
+		}
+
+		dailyQuery := &models.DashboardDailyQuery{
+			DashboardId: queries.DashboardId,
+			Day:         queries.LastQueried.UTC().Format(dayFormat),
+			Queries:     queries.Count,
+			Errors:      queries.Errors,
+		}
+		res, err := sess.Exec("UPDATE dashboard_daily_query SET queries = queries + ?, errors = errors + ? WHERE dashboard_id = ? AND day = ?",
+			dailyQuery.Queries, dailyQuery.Errors, dailyQuery.DashboardId, dailyQuery.Day)
+		if err != nil {
+			return err
+		}
+		if err := insertIfNotUpdated(sess, res, dailyQuery, "dashboard_id=? AND day=?", dailyQuery.DashboardId, dailyQuery.Day); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertIfNotUpdated inserts the row when the update didn't affect any row
+// and no row matches the condition. The insert fails with a unique
+// constraint violation when another instance inserted the row in between,
+// which SaveDashboardViews retries.
+func insertIfNotUpdated(sess *DBSession, res sql.Result, row interface{}, condition string, args ...interface{}) error {
+	if affected, err := res.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+
+	count, err := sess.Table(row).Where(condition, args...).Count()
+	if err != nil || count > 0 {
+		return err
+	}
+
+	_, err = sess.Insert(row)
+	return err
+}
+
+// DeleteOldDashboardViews deletes the daily views and queries of the days
+// before OlderThan.
+func DeleteOldDashboardViews(cmd *models.DeleteOldDashboardViewsCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		day := cmd.OlderThan.UTC().Format(dayFormat)
+		for _, table := range []string{"dashboard_daily_view", "dashboard_daily_query"} {
+			res, err := sess.Exec("DELETE FROM "+table+" WHERE day < ?", day)
+			if err != nil {
+				return err
+			}
+
+			deleted, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			cmd.DeletedRows += deleted
+		}
+
+		return nil
+	})
+}
+
+// GetDashboardInsights returns the views and queries of a dashboard during
+// the last days, today included, with the usage of every day.
+func GetDashboardInsights(query *models.GetDashboardInsightsQuery) error {
+	start := time.Now().UTC().AddDate(0, 0, 1-query.Days).Truncate(24 * time.Hour)
+
+	insights := &models.DashboardInsights{
+		DashboardId: query.DashboardId,
+		Days:        query.Days,
+		Daily:       make([]*models.DashboardDailyInsights, 0, query.Days),
+	}
+	daily := make(map[string]*models.DashboardDailyInsights, query.Days)
+	for day := start; len(insights.Daily) < query.Days; day = day.AddDate(0, 0, 1) {
+		dayInsights := &models.DashboardDailyInsights{Day: day.Format(dayFormat)}
+		insights.Daily = append(insights.Daily, dayInsights)
+		daily[dayInsights.Day] = dayInsights
+	}
+
+	var dailyViews []*models.DashboardDailyView
+	if err := x.Where("dashboard_id = ? AND day >= ?", query.DashboardId, start.Format(dayFormat)).Find(&dailyViews); err != nil {
+		return err
+	}
+	for _, view := range dailyViews {
+		if dayInsights, ok := daily[view.Day]; ok {
+			dayInsights.Views = view.Views
+			insights.Views += view.Views
+		}
+	}
+
+	var dailyQueries []*models.DashboardDailyQuery
+	if err := x.Where("dashboard_id = ? AND day >= ?", query.DashboardId, start.Format(dayFormat)).Find(&dailyQueries); err != nil {
+		return err
+	}
+	for _, queries := range dailyQueries {
+		if dayInsights, ok := daily[queries.Day]; ok {
+			dayInsights.Queries = queries.Queries
+			dayInsights.Errors = queries.Errors
+			insights.Queries += queries.Queries
+			insights.Errors += queries.Errors
+		}
+	}
+
+	var viewers struct {
+		Count      int64
+		LastViewed int64
+	}
+	_, err := x.SQL(`SELECT
+		COALESCE(SUM(CASE WHEN last_viewed >= ? THEN 1 ELSE 0 END), 0) AS count,
+		COALESCE(MAX(last_viewed), 0) AS last_viewed
+		FROM dashboard_user_view WHERE dashboard_id = ?`, start.Unix(), query.DashboardId).Get(&viewers)
+	if err != nil {
+		return err
+	}
+	insights.UniqueViewers = viewers.Count
+	if viewers.LastViewed > 0 {
+		lastViewed := time.Unix(viewers.LastViewed, 0)
+		insights.LastViewed = &lastViewed
+	}
+
+	query.Result = insights
+	return nil
+}
+
+// staleDashboardCondition matches the dashboards created before since and
+// not viewed since then.
+func staleDashboardCondition(since time.Time) (string, []interface{}) {
+	return `dashboard.is_folder = ? AND dashboard.created < ?
+		AND NOT EXISTS (SELECT 1 FROM dashboard_user_view
+			WHERE dashboard_user_view.dashboard_id = dashboard.id AND dashboard_user_view.last_viewed >= ?)
+		AND NOT EXISTS (SELECT 1 FROM dashboard_daily_view
+			WHERE dashboard_daily_view.dashboard_id = dashboard.id AND dashboard_daily_view.day >= ?)`,
+		[]interface{}{dialect.BooleanStr(false), since, since.Unix(), since.UTC().Format(dayFormat)}
+}
+
+// GetStaleDashboards returns the dashboards of an organization that nobody
+// viewed in the last days, the ones never viewed first.
+func GetStaleDashboards(query *models.GetStaleDashboardsQuery) error {
+	condition, params := staleDashboardCondition(time.Now().AddDate(0, 0, -query.Days))
+
+	var dashboards []struct {
+		models.StaleDashboard `xorm:"extends"`
+		ViewedAt              int64
+	}
+	sql := `SELECT dashboard.id, dashboard.uid, dashboard.title, dashboard.slug, dashboard.folder_id,
+			COALESCE(folder.title, '') AS folder_title, dashboard.created, dashboard.updated,
+			COALESCE((SELECT MAX(dashboard_user_view.last_viewed) FROM dashboard_user_view
+				WHERE dashboard_user_view.dashboard_id = dashboard.id), 0) AS viewed_at
+		FROM dashboard
+		LEFT OUTER JOIN dashboard AS folder ON folder.id = dashboard.folder_id
+		WHERE dashboard.org_id = ? AND ` + condition + `
+		ORDER BY viewed_at ASC, dashboard.title ASC`
+	if err := x.SQL(sql, append([]interface{}{query.OrgId}, params...)...).Find(&dashboards); err != nil {
+		return err
+	}
+
+	query.Result = make([]*models.StaleDashboard, 0, len(dashboards))
+	for i := range dashboards {
+		dash := &dashboards[i].StaleDashboard
+		if dashboards[i].ViewedAt > 0 {
+			lastViewed := time.Unix(dashboards[i].ViewedAt, 0)
+			dash.LastViewed = &lastViewed
+		}
+		query.Result = append(query.Result, dash)
+	}
+
+	return nil
+}
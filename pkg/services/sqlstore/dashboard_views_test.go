@@ -0,0 +1,214 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardViews(t *testing.T) {
+	InitTestDB(t)
+
+	now := time.Date(2020, 9, 15, 12, 0, 0, 0, time.UTC)
+	save := func(t *testing.T, views ...*models.DashboardViews) {
+		t.Helper()
+		require.NoError(t, SaveDashboardViews(&models.SaveDashboardViewsCommand{Views: views}))
+	}
+
+	save(t,
+		&models.DashboardViews{DashboardId: 1, UserId: 2, Count: 3, LastViewed: now},
+		&models.DashboardViews{DashboardId: 1, UserId: 0, Count: 1, LastViewed: now},
+	)
+	save(t,
+		&models.DashboardViews{DashboardId: 1, UserId: 2, Count: 2, LastViewed: now.Add(-time.Hour)},
+		&models.DashboardViews{DashboardId: 1, UserId: 2, Count: 1, LastViewed: now.AddDate(0, 0, -40)},
+	)
+
+	t.Run("views are added to the views of the user", func(t *testing.T) {
+		var userViews []*models.DashboardUserView
+		require.NoError(t, x.Find(&userViews))
+		require.Len(t, userViews, 1)
+		assert.EqualValues(t, 6, userViews[0].Views)
+		// the last view is kept when older views are saved later
+		assert.Equal(t, now.Unix(), userViews[0].LastViewed)
+	})
+
+	t.Run("views are added to the views of the day", func(t *testing.T) {
+		var dailyViews []*models.DashboardDailyView
+		require.NoError(t, x.OrderBy("day").Find(&dailyViews))
+		require.Len(t, dailyViews, 2)
+		assert.Equal(t, "2020-08-06", dailyViews[0].Day)
+		assert.EqualValues(t, 1, dailyViews[0].Views)
+		assert.Equal(t, "2020-09-15", dailyViews[1].Day)
+		assert.EqualValues(t, 6, dailyViews[1].Views)
+	})
+
+	dash := models.SaveDashboardCommand{OrgId: 1, Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Queried"})}
+	require.NoError(t, SaveDashboard(&dash))
+	otherOrgDash := models.SaveDashboardCommand{OrgId: 2, Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Other org"})}
+	require.NoError(t, SaveDashboard(&otherOrgDash))
+
+	require.NoError(t, SaveDashboardViews(&models.SaveDashboardViewsCommand{Queries: []*models.DashboardQueries{
+		{DashboardId: dash.Result.Id, OrgId: 1, Count: 10, Errors: 1, LastQueried: now},
+		{DashboardId: dash.Result.Id, OrgId: 1, Count: 4, LastQueried: now.AddDate(0, 0, -40)},
+	}}))
+	require.NoError(t, SaveDashboardViews(&models.SaveDashboardViewsCommand{Queries: []*models.DashboardQueries{
+		{DashboardId: dash.Result.Id, OrgId: 1, Count: 5, Errors: 2, LastQueried: now},
+		{DashboardId: otherOrgDash.Result.Id, OrgId: 1, Count: 3, LastQueried: now},
+		{DashboardId: 1000, OrgId: 1, Count: 3, LastQueried: now},
+	}}))
+
+	t.Run("queries are added to the queries of the day", func(t *testing.T) {
+		var dailyQueries []*models.DashboardDailyQuery
+		require.NoError(t, x.OrderBy("day").Find(&dailyQueries))
+		require.Len(t, dailyQueries, 2)
+		assert.Equal(t, dash.Result.Id, dailyQueries[1].DashboardId)
+		assert.Equal(t, "2020-09-15", dailyQueries[1].Day)
+		assert.EqualValues(t, 15, dailyQueries[1].Queries)
+		assert.EqualValues(t, 3, dailyQueries[1].Errors)
+	})
+
+	t.Run("queries of dashboards of other organizations are ignored", func(t *testing.T) {
+		count, err := x.Where("dashboard_id <> ?", dash.Result.Id).Count(&models.DashboardDailyQuery{})
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("old daily views and queries are deleted", func(t *testing.T) {
+		cmd := models.DeleteOldDashboardViewsCommand{OlderThan: now.AddDate(0, 0, -models.MostViewedDashboardsDays)}
+		require.NoError(t, DeleteOldDashboardViews(&cmd))
+		assert.EqualValues(t, 2, cmd.DeletedRows)
+	})
+}
+
+func TestDashboardInsights(t *testing.T) {
+	InitTestDB(t)
+
+	saveDashboard := func(t *testing.T, title string, created time.Time) *models.Dashboard {
+		t.Helper()
+		cmd := models.SaveDashboardCommand{OrgId: 1, Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": title})}
+		require.NoError(t, SaveDashboard(&cmd))
+		_, err := x.Exec("UPDATE dashboard SET created = ? WHERE id = ?", created, cmd.Result.Id)
+		require.NoError(t, err)
+		return cmd.Result
+	}
+
+	now := time.Now()
+	viewed := saveDashboard(t, "Viewed", now.AddDate(0, 0, -60))
+	viewedAnonymously := saveDashboard(t, "Viewed anonymously", now.AddDate(0, 0, -60))
+	viewedLongAgo := saveDashboard(t, "Viewed long ago", now.AddDate(0, 0, -60))
+	saveDashboard(t, "Never viewed", now.AddDate(0, 0, -60))
+	saveDashboard(t, "New", now)
+
+	require.NoError(t, SaveDashboardViews(&models.SaveDashboardViewsCommand{
+		Views: []*models.DashboardViews{
+			{DashboardId: viewed.Id, UserId: 1, Count: 2, LastViewed: now},
+			{DashboardId: viewed.Id, UserId: 2, Count: 1, LastViewed: now.AddDate(0, 0, -1)},
+			{DashboardId: viewed.Id, UserId: 3, Count: 1, LastViewed: now.AddDate(0, 0, -10)},
+			{DashboardId: viewedAnonymously.Id, UserId: 0, Count: 1, LastViewed: now},
+			{DashboardId: viewedLongAgo.Id, UserId: 1, Count: 1, LastViewed: now.AddDate(0, 0, -45)},
+		},
+		Queries: []*models.DashboardQueries{
+			{DashboardId: viewed.Id, OrgId: 1, Count: 20, Errors: 2, LastQueried: now},
+			{DashboardId: viewed.Id, OrgId: 1, Count: 8, LastQueried: now.AddDate(0, 0, -1)},
+		},
+	}))
+
+	t.Run("get dashboard insights", func(t *testing.T) {
+		query := models.GetDashboardInsightsQuery{DashboardId: viewed.Id, Days: 7}
+		require.NoError(t, GetDashboardInsights(&query))
+
+		insights := query.Result
+		assert.EqualValues(t, 3, insights.Views)
+		assert.EqualValues(t, 2, insights.UniqueViewers)
+		assert.EqualValues(t, 28, insights.Queries)
+		assert.EqualValues(t, 2, insights.Errors)
+		require.NotNil(t, insights.LastViewed)
+		assert.Equal(t, now.Unix(), insights.LastViewed.Unix())
+
+		require.Len(t, insights.Daily, 7)
+		today := insights.Daily[6]
+		assert.Equal(t, now.UTC().Format("2006-01-02"), today.Day)
+		assert.EqualValues(t, 2, today.Views)
+		assert.EqualValues(t, 20, today.Queries)
+		assert.EqualValues(t, 2, today.Errors)
+		assert.EqualValues(t, 8, insights.Daily[5].Queries)
+		assert.Zero(t, insights.Daily[0].Views)
+	})
+
+	t.Run("get insights of a dashboard never viewed", func(t *testing.T) {
+		query := models.GetDashboardInsightsQuery{DashboardId: 1000, Days: 30}
+		require.NoError(t, GetDashboardInsights(&query))
+		assert.Zero(t, query.Result.Views)
+		assert.Nil(t, query.Result.LastViewed)
+		assert.Len(t, query.Result.Daily, 30)
+	})
+
+	t.Run("get stale dashboards", func(t *testing.T) {
+		query := models.GetStaleDashboardsQuery{OrgId: 1, Days: 30}
+		require.NoError(t, GetStaleDashboards(&query))
+		require.Len(t, query.Result, 2)
+		assert.Equal(t, "Never viewed", query.Result[0].Title)
+		assert.Nil(t, query.Result[0].LastViewed)
+		assert.Equal(t, "Viewed long ago", query.Result[1].Title)
+		assert.Equal(t, viewedLongAgo.Uid, query.Result[1].Uid)
+		require.NotNil(t, query.Result[1].LastViewed)
+	})
+
+	t.Run("system stats include the usage of the dashboards", func(t *testing.T) {
+		query := models.GetSystemStatsQuery{}
+		require.NoError(t, GetSystemStats(&query))
+		assert.EqualValues(t, 5, query.Result.DashboardViews)
+		assert.EqualValues(t, 28, query.Result.DashboardQueries)
+		assert.EqualValues(t, 2, query.Result.DashboardQueryErrors)
+		assert.EqualValues(t, 2, query.Result.ViewedDashboards)
+		assert.EqualValues(t, 2, query.Result.StaleDashboards)
+	})
+}
+
+func TestSaveDashboardViewsConflict(t *testing.T) {
+	InitTestDB(t)
+
+	view := &models.DashboardDailyView{DashboardId: 1, Day: "2020-09-15", Views: 1}
+	_, err := x.Insert(view)
+	require.NoError(t, err)
+
+	t.Run("the batch is retried when another instance inserted the same row", func(t *testing.T) {
+		attempts := 0
+		err := retryOnConflict(saveDashboardViewsAttempts, func() error {
+			attempts++
+			return inTransaction(func(sess *DBSession) error {
+				if attempts == 1 {
+					// the row inserted by the other instance isn't seen before the insert
+					_, err := sess.Insert(&models.DashboardDailyView{DashboardId: 1, Day: "2020-09-15", Views: 1})
+					return err
+				}
+				_, err := sess.Exec("UPDATE dashboard_daily_view SET views = views + 1 WHERE dashboard_id = 1")
+				return err
+			})
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+
+		var dailyViews []*models.DashboardDailyView
+		require.NoError(t, x.Find(&dailyViews))
+		require.Len(t, dailyViews, 1)
+		assert.EqualValues(t, 2, dailyViews[0].Views)
+	})
+
+	t.Run("the error is returned when the conflicts persist", func(t *testing.T) {
+		attempts := 0
+		err := retryOnConflict(saveDashboardViewsAttempts, func() error {
+			attempts++
+			_, err := x.Insert(&models.DashboardDailyView{DashboardId: 1, Day: "2020-09-15", Views: 1})
+			return err
+		})
+		require.Error(t, err)
+		assert.True(t, dialect.IsUniqueConstraintViolation(err))
+		assert.Equal(t, saveDashboardViewsAttempts, attempts)
+	})
+}
@@ -25,6 +25,7 @@ func init() {
 	bus.AddHandler("sql", UpdateDataSource)
 	bus.AddHandler("sql", GetDataSourceById)
 	bus.AddHandler("sql", GetDataSourceByName)
+	bus.AddHandler("sql", GetDataSourceByUid)
 }
 
 func getDataSourceByID(id, orgID int64, engine *xorm.Engine) (*models.DataSource, error) {
@@ -67,6 +68,20 @@ func GetDataSourceByName(query *models.GetDataSourceByNameQuery) error {
 	return err
 }
 
+func GetDataSourceByUid(query *models.GetDataSourceByUidQuery) error {
+	datasource := models.DataSource{OrgId: query.OrgId, Uid: query.Uid}
+	has, err := x.Get(&datasource)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return models.ErrDataSourceNotFound
+	}
+
+	query.Result = &datasource
+	return nil
+}
+
 func GetDataSources(query *models.GetDataSourcesQuery) error {
 	sess := x.Limit(5000, 0).Where("org_id=?", query.OrgId).Asc("name")
 
@@ -83,6 +98,10 @@ func GetAllDataSources(query *models.GetAllDataSourcesQuery) error {
 
 func DeleteDataSourceById(cmd *models.DeleteDataSourceByIdCommand) error {
 	return inTransaction(func(sess *DBSession) error {
+		if _, err := sess.Exec("DELETE FROM data_source_health WHERE data_source_id=? and org_id=?", cmd.Id, cmd.OrgId); err != nil {
+			return err
+		}
+
 		var rawSql = "DELETE FROM data_source WHERE id=? and org_id=?"
 		result, err := sess.Exec(rawSql, cmd.Id, cmd.OrgId)
 		affected, _ := result.RowsAffected()
@@ -93,6 +112,10 @@ func DeleteDataSourceById(cmd *models.DeleteDataSourceByIdCommand) error {
 
 func DeleteDataSourceByName(cmd *models.DeleteDataSourceByNameCommand) error {
 	return inTransaction(func(sess *DBSession) error {
+		if _, err := sess.Exec("DELETE FROM data_source_health WHERE data_source_id IN (SELECT id FROM data_source WHERE name=? and org_id=?)", cmd.Name, cmd.OrgId); err != nil {
+			return err
+		}
+
 		var rawSql = "DELETE FROM data_source WHERE name=? and org_id=?"
 		result, err := sess.Exec(rawSql, cmd.Name, cmd.OrgId)
 		affected, _ := result.RowsAffected()
@@ -0,0 +1,57 @@
+package sqlstore
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", SaveDataSourceHealth)
+	bus.AddHandler("sql", DeleteOldDataSourceHealth)
+	bus.AddHandler("sql", GetDataSourceHealthHistory)
+}
+
+func SaveDataSourceHealth(cmd *models.SaveDataSourceHealthCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		health := &models.DataSourceHealth{
+			OrgId:        cmd.OrgId,
+			DataSourceId: cmd.DataSourceId,
+			Status:       cmd.Status,
+			Message:      cmd.Message,
+			DurationMs:   cmd.DurationMs,
+			Created:      getTimeNow(),
+		}
+
+		if _, err := sess.Insert(health); err != nil {
+			return err
+		}
+
+		cmd.Result = health
+		return nil
+	})
+}
+
+func DeleteOldDataSourceHealth(cmd *models.DeleteOldDataSourceHealthCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		result, err := sess.Exec("DELETE FROM data_source_health WHERE created < ?", cmd.OlderThan)
+		if err != nil {
+			return err
+		}
+
+		cmd.DeletedRows, err = result.RowsAffected()
+		return err
+	})
+}
+
+func GetDataSourceHealthHistory(query *models.GetDataSourceHealthHistoryQuery) error {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query.Result = make([]*models.DataSourceHealth, 0)
+	return x.Where("org_id=? AND data_source_id=?", query.OrgId, query.DataSourceId).
+		Desc("created", "id").
+		Limit(limit, 0).
+		Find(&query.Result)
+}
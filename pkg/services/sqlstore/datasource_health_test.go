@@ -0,0 +1,49 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataSourceHealth(t *testing.T) {
+	InitTestDB(t)
+	t.Cleanup(func() { getTimeNow = time.Now })
+
+	now := time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)
+	save := func(dsID int64, status string, at time.Time) {
+		getTimeNow = func() time.Time { return at }
+		err := SaveDataSourceHealth(&models.SaveDataSourceHealthCommand{OrgId: 1, DataSourceId: dsID, Status: status, DurationMs: 5})
+		require.NoError(t, err)
+	}
+
+	save(1, "OK", now.Add(-48*time.Hour))
+	save(1, "ERROR", now.Add(-time.Hour))
+	save(1, "OK", now)
+	save(2, "OK", now)
+
+	t.Run("history is returned newest first", func(t *testing.T) {
+		query := models.GetDataSourceHealthHistoryQuery{OrgId: 1, DataSourceId: 1, Limit: 2}
+		err := GetDataSourceHealthHistory(&query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 2)
+		assert.Equal(t, "OK", query.Result[0].Status)
+		assert.Equal(t, "ERROR", query.Result[1].Status)
+		assert.EqualValues(t, 5, query.Result[1].DurationMs)
+	})
+
+	t.Run("old results are deleted", func(t *testing.T) {
+		cmd := models.DeleteOldDataSourceHealthCommand{OlderThan: now.Add(-24 * time.Hour)}
+		err := DeleteOldDataSourceHealth(&cmd)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, cmd.DeletedRows)
+
+		query := models.GetDataSourceHealthHistoryQuery{OrgId: 1, DataSourceId: 1}
+		err = GetDataSourceHealthHistory(&query)
+		require.NoError(t, err)
+		assert.Len(t, query.Result, 2)
+	})
+}
@@ -84,6 +84,20 @@ func TestDataAccess(t *testing.T) {
 		})
 	})
 
+	t.Run("GetDataSourceByUid", func(t *testing.T) {
+		InitTestDB(t)
+		ds := initDatasource()
+
+		query := models.GetDataSourceByUidQuery{Uid: ds.Uid, OrgId: 10}
+		err := GetDataSourceByUid(&query)
+		require.NoError(t, err)
+		require.Equal(t, ds.Id, query.Result.Id)
+
+		query = models.GetDataSourceByUidQuery{Uid: ds.Uid, OrgId: 11}
+		err = GetDataSourceByUid(&query)
+		require.Equal(t, models.ErrDataSourceNotFound, err)
+	})
+
 	t.Run("UpdateDataSource", func(t *testing.T) {
 		t.Run("updates datasource with version", func(t *testing.T) {
 			InitTestDB(t)
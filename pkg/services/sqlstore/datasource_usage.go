@@ -0,0 +1,110 @@
+package sqlstore
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
+)
+
+func init() {
+	bus.AddHandler("sql", GetAlertsByDataSource)
+	bus.AddHandler("sql", GetUnusedDataSources)
+}
+
+func GetAlertsByDataSource(query *models.GetAlertsByDataSourceQuery) error {
+	alerts, err := getAlertsWithSettings(newSession(), query.OrgId)
+	if err != nil {
+		return err
+	}
+
+	query.Result = make([]*models.AlertListItemDTO, 0)
+	dashboardIds := make([]int64, 0)
+	for _, alert := range alerts {
+		for _, id := range alert.GetDatasourceIdsFromSettings() {
+			if id == query.DataSourceId {
+				query.Result = append(query.Result, &models.AlertListItemDTO{
+					Id:           alert.Id,
+					DashboardId:  alert.DashboardId,
+					PanelId:      alert.PanelId,
+					Name:         alert.Name,
+					State:        alert.State,
+					NewStateDate: alert.NewStateDate,
+				})
+				dashboardIds = append(dashboardIds, alert.DashboardId)
+				break
+			}
+		}
+	}
+
+	if len(dashboardIds) == 0 {
+		return nil
+	}
+
+	dashboards := make([]*models.Dashboard, 0)
+	if err := x.In("id", dashboardIds).Cols("id", "uid", "slug").Find(&dashboards); err != nil {
+		return err
+	}
+	dashboardsById := make(map[int64]*models.Dashboard, len(dashboards))
+	for _, dash := range dashboards {
+		dashboardsById[dash.Id] = dash
+	}
+	for _, alert := range query.Result {
+		if dash, ok := dashboardsById[alert.DashboardId]; ok {
+			alert.DashboardUid = dash.Uid
+			alert.DashboardSlug = dash.Slug
+		}
+	}
+
+	return nil
+}
+
+func GetUnusedDataSources(query *models.GetUnusedDataSourcesQuery) error {
+	sess := newSession()
+	defer sess.Close()
+
+	datasources := make([]*models.DataSource, 0)
+	if err := sess.Where("org_id=?", query.OrgId).Asc("name").Find(&datasources); err != nil {
+		return err
+	}
+
+	alerts, err := getAlertsWithSettings(sess, query.OrgId)
+	if err != nil {
+		return err
+	}
+	usedByAlerts := make(map[int64]bool)
+	for _, alert := range alerts {
+		for _, id := range alert.GetDatasourceIdsFromSettings() {
+			usedByAlerts[id] = true
+		}
+	}
+
+	query.Result = make([]*models.DataSource, 0)
+	for _, ds := range datasources {
+		if usedByAlerts[ds.Id] {
+			continue
+		}
+
+		filter := searchstore.DatasourceFilter{Uid: ds.Uid, Name: ds.Name, Type: ds.Type, IsDefault: ds.IsDefault}
+		where, params := filter.Where()
+		sql := "SELECT 1 FROM dashboard WHERE dashboard.org_id = ? AND " + where + " " + dialect.Limit(1)
+		res, err := sess.Query(append([]interface{}{sql, query.OrgId}, params...)...)
+		if err != nil {
+			return err
+		}
+		if len(res) == 0 {
+			query.Result = append(query.Result, ds)
+		}
+	}
+
+	return nil
+}
+
+// getAlertsWithSettings returns the alerts of the org with their settings.
+func getAlertsWithSettings(sess *DBSession, orgId int64) ([]*models.Alert, error) {
+	alerts := make([]*models.Alert, 0)
+	err := sess.Where("org_id=?", orgId).
+		Cols("id", "dashboard_id", "panel_id", "name", "state", "new_state_date", "settings").
+		Asc("name").
+		Find(&alerts)
+	return alerts, err
+}
@@ -0,0 +1,122 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataSourceUsage(t *testing.T) {
+	InitTestDB(t)
+
+	addDataSource := func(t *testing.T, name string, dsType string, isDefault bool) *models.DataSource {
+		t.Helper()
+		cmd := models.AddDataSourceCommand{OrgId: 1, Name: name, Uid: name + "-uid", Type: dsType, Access: models.DS_ACCESS_PROXY, IsDefault: isDefault}
+		require.NoError(t, AddDataSource(&cmd))
+		return cmd.Result
+	}
+
+	saveDashboard := func(t *testing.T, title string, datasource interface{}) *models.Dashboard {
+		t.Helper()
+		cmd := models.SaveDashboardCommand{OrgId: 1, Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title": title,
+			"panels": []interface{}{
+				map[string]interface{}{"id": 1, "datasource": datasource, "targets": []interface{}{map[string]interface{}{"refId": "A"}}},
+			},
+			"templating": map[string]interface{}{"list": []interface{}{
+				map[string]interface{}{"name": "ds", "type": "datasource", "query": "loki"},
+			}},
+		})}
+		require.NoError(t, SaveDashboard(&cmd))
+		return cmd.Result
+	}
+
+	dashboardsUsing := func(t *testing.T, uid string) []string {
+		t.Helper()
+		query := search.FindPersistedDashboardsQuery{
+			DatasourceUid: uid,
+			SignedInUser:  &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN},
+		}
+		require.NoError(t, SearchDashboards(&query))
+		titles := make([]string, 0, len(query.Result))
+		for _, hit := range query.Result {
+			titles = append(titles, hit.Title)
+		}
+		return titles
+	}
+
+	prometheus := addDataSource(t, "Prometheus", "prometheus", true)
+	loki := addDataSource(t, "Loki", "loki", false)
+	graphite := addDataSource(t, "Graphite", "graphite", false)
+	mysql := addDataSource(t, "MySQL", "mysql", false)
+	addDataSource(t, "InfluxDB", "influxdb", false)
+	elasticsearch := addDataSource(t, "Elasticsearch", "elasticsearch", false)
+
+	saveDashboard(t, "Default", nil)
+	saveDashboard(t, "Logs", "$ds")
+	saveDashboard(t, "Graphite by uid", map[string]interface{}{"uid": graphite.Uid})
+	alerting := saveDashboard(t, "Alerting", "Prometheus")
+
+	libraryPanel := models.CreateLibraryPanelCommand{
+		OrgId: 1,
+		Name:  "Errors",
+		Model: simplejson.NewFromAny(map[string]interface{}{"datasource": "Elasticsearch", "targets": []interface{}{map[string]interface{}{"refId": "A"}}}),
+	}
+	require.NoError(t, CreateLibraryPanel(&libraryPanel))
+	library := models.SaveDashboardCommand{OrgId: 1, Dashboard: simplejson.NewFromAny(map[string]interface{}{
+		"title": "Errors",
+		"panels": []interface{}{
+			map[string]interface{}{"id": 1, "libraryPanel": map[string]interface{}{"uid": libraryPanel.Result.Uid, "name": "Errors"}},
+		},
+	})}
+	require.NoError(t, SaveDashboard(&library))
+
+	_, err := x.Insert(&models.Alert{
+		OrgId:       1,
+		DashboardId: alerting.Id,
+		PanelId:     1,
+		Name:        "Slow queries",
+		State:       models.AlertStateOK,
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"query": map[string]interface{}{"datasourceId": mysql.Id}},
+			},
+		}),
+		NewStateDate: time.Now(),
+		Created:      time.Now(),
+		Updated:      time.Now(),
+	})
+	require.NoError(t, err)
+
+	t.Run("search dashboards by data source", func(t *testing.T) {
+		assert.Equal(t, []string{"Alerting", "Default"}, dashboardsUsing(t, prometheus.Uid))
+		assert.Equal(t, []string{"Logs"}, dashboardsUsing(t, loki.Uid))
+		assert.Equal(t, []string{"Graphite by uid"}, dashboardsUsing(t, graphite.Uid))
+		assert.Equal(t, []string{"Errors"}, dashboardsUsing(t, elasticsearch.Uid))
+		assert.Empty(t, dashboardsUsing(t, "unknown"))
+	})
+
+	t.Run("get alerts by data source", func(t *testing.T) {
+		query := models.GetAlertsByDataSourceQuery{OrgId: 1, DataSourceId: mysql.Id}
+		require.NoError(t, GetAlertsByDataSource(&query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, "Slow queries", query.Result[0].Name)
+		assert.Equal(t, alerting.Uid, query.Result[0].DashboardUid)
+
+		query = models.GetAlertsByDataSourceQuery{OrgId: 1, DataSourceId: prometheus.Id}
+		require.NoError(t, GetAlertsByDataSource(&query))
+		assert.Empty(t, query.Result)
+	})
+
+	t.Run("get unused data sources", func(t *testing.T) {
+		query := models.GetUnusedDataSourcesQuery{OrgId: 1}
+		require.NoError(t, GetUnusedDataSources(&query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, "InfluxDB", query.Result[0].Name)
+	})
+}
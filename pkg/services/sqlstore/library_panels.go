@@ -0,0 +1,328 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateLibraryPanel)
+	bus.AddHandler("sql", UpdateLibraryPanel)
+	bus.AddHandler("sql", DeleteLibraryPanel)
+	bus.AddHandler("sql", GetLibraryPanel)
+	bus.AddHandler("sql", GetLibraryPanels)
+	bus.AddHandler("sql", GetLibraryPanelDashboards)
+}
+
+func CreateLibraryPanel(cmd *models.CreateLibraryPanelCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if cmd.Uid == "" {
+			uid, err := generateNewLibraryPanelUid(sess, cmd.OrgId)
+			if err != nil {
+				return err
+			}
+			cmd.Uid = uid
+		} else if !util.IsValidShortUID(cmd.Uid) {
+			return models.ErrLibraryPanelInvalidUid
+		} else if len(cmd.Uid) > 40 {
+			return models.ErrLibraryPanelUidTooLong
+		}
+
+		exists, err := sess.Where("org_id=? AND uid=?", cmd.OrgId, cmd.Uid).Exist(&models.LibraryPanel{})
+		if err != nil {
+			return err
+		} else if exists {
+			return models.ErrLibraryPanelWithSameUidExists
+		}
+
+		panel := &models.LibraryPanel{
+			OrgId:     cmd.OrgId,
+			FolderId:  cmd.FolderId,
+			Uid:       cmd.Uid,
+			Name:      cmd.Name,
+			Model:     libraryPanelModel(cmd.Model),
+			Version:   1,
+			Created:   time.Now(),
+			Updated:   time.Now(),
+			CreatedBy: cmd.UserId,
+			UpdatedBy: cmd.UserId,
+		}
+		if err := validateLibraryPanel(sess, panel); err != nil {
+			return err
+		}
+
+		if _, err := sess.Insert(panel); err != nil {
+			return err
+		}
+
+		cmd.Result = panel
+		return nil
+	})
+}
+
+// UpdateLibraryPanel saves a new version of a library panel, which is used by
+// all the dashboards referencing it.
+func UpdateLibraryPanel(cmd *models.UpdateLibraryPanelCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		existing, err := getLibraryPanel(sess, cmd.OrgId, cmd.Uid)
+		if err != nil {
+			return err
+		}
+		if cmd.Version != existing.Version {
+			return models.ErrLibraryPanelVersionMismatch
+		}
+
+		panel := &models.LibraryPanel{
+			Id:        existing.Id,
+			OrgId:     existing.OrgId,
+			FolderId:  cmd.FolderId,
+			Uid:       existing.Uid,
+			Name:      cmd.Name,
+			Model:     libraryPanelModel(cmd.Model),
+			Version:   existing.Version + 1,
+			Created:   existing.Created,
+			Updated:   time.Now(),
+			CreatedBy: existing.CreatedBy,
+			UpdatedBy: cmd.UserId,
+		}
+		if err := validateLibraryPanel(sess, panel); err != nil {
+			return err
+		}
+
+		// the version check in the update guards against concurrent updates
+		affectedRows, err := sess.ID(panel.Id).Where("version=?", existing.Version).MustCols("folder_id").Update(panel)
+		if err != nil {
+			return err
+		} else if affectedRows == 0 {
+			return models.ErrLibraryPanelVersionMismatch
+		}
+
+		if err := reindexLibraryPanelDashboards(sess, panel); err != nil {
+			return err
+		}
+
+		cmd.Result = panel
+		return nil
+	})
+}
+
+// DeleteLibraryPanel deletes a library panel, unless dashboards use it
+func DeleteLibraryPanel(cmd *models.DeleteLibraryPanelCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		panel, err := getLibraryPanel(sess, cmd.OrgId, cmd.Uid)
+		if err != nil {
+			return err
+		}
+
+		dashboards, err := getLibraryPanelDashboards(sess, panel.OrgId, panel.Id)
+		if err != nil {
+			return err
+		} else if len(dashboards) > 0 {
+			return models.ErrLibraryPanelInUse
+		}
+
+		// the remaining connections are the ones of deleted dashboards in the trash
+		if _, err := sess.Exec("DELETE FROM library_panel_dashboard WHERE library_panel_id = ?", panel.Id); err != nil {
+			return err
+		}
+		_, err = sess.ID(panel.Id).Delete(&models.LibraryPanel{})
+		return err
+	})
+}
+
+func GetLibraryPanel(query *models.GetLibraryPanelQuery) error {
+	panel, err := getLibraryPanel(newSession(), query.OrgId, query.Uid)
+	if err != nil {
+		return err
+	}
+
+	query.Result = panel
+	return nil
+}
+
+func GetLibraryPanels(query *models.GetLibraryPanelsQuery) error {
+	sess := x.Where("org_id=?", query.OrgId)
+	if len(query.Uids) > 0 {
+		sess.In("uid", query.Uids)
+	}
+	if len(query.FolderIds) > 0 {
+		sess.In("folder_id", query.FolderIds)
+	}
+	if query.Name != "" {
+		sess.And("name "+dialect.LikeStr()+" ?", "%"+query.Name+"%")
+	}
+	if query.Limit > 0 {
+		sess.Limit(query.Limit)
+	}
+
+	query.Result = make([]*models.LibraryPanel, 0)
+	return sess.Asc("name").Find(&query.Result)
+}
+
+func GetLibraryPanelDashboards(query *models.GetLibraryPanelDashboardsQuery) error {
+	dashboards, err := getLibraryPanelDashboards(newSession(), query.OrgId, query.LibraryPanelId)
+	if err != nil {
+		return err
+	}
+
+	query.Result = dashboards
+	return nil
+}
+
+func getLibraryPanel(sess *DBSession, orgId int64, uid string) (*models.LibraryPanel, error) {
+	panel := models.LibraryPanel{}
+	has, err := sess.Where("org_id=? AND uid=?", orgId, uid).Get(&panel)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, models.ErrLibraryPanelNotFound
+	}
+
+	return &panel, nil
+}
+
+func getLibraryPanelDashboards(sess *DBSession, orgId int64, libraryPanelId int64) ([]*models.Dashboard, error) {
+	dashboards := make([]*models.Dashboard, 0)
+	err := sess.Table("dashboard").
+		Join("INNER", "library_panel_dashboard", "library_panel_dashboard.dashboard_id = dashboard.id").
+		Where("dashboard.org_id=? AND library_panel_dashboard.library_panel_id=?", orgId, libraryPanelId).
+		Cols("dashboard.id", "dashboard.uid", "dashboard.title", "dashboard.slug", "dashboard.folder_id").
+		Asc("dashboard.title").
+		Find(&dashboards)
+	return dashboards, err
+}
+
+func validateLibraryPanel(sess *DBSession, panel *models.LibraryPanel) error {
+	if panel.Name == "" {
+		return models.ErrLibraryPanelNameEmpty
+	}
+
+	if panel.FolderId > 0 {
+		exists, err := sess.Where("org_id=? AND id=? AND is_folder=?", panel.OrgId, panel.FolderId, dialect.BooleanStr(true)).Exist(&models.Dashboard{})
+		if err != nil {
+			return err
+		} else if !exists {
+			return models.ErrFolderNotFound
+		}
+	}
+
+	exists, err := sess.Where("org_id=? AND folder_id=? AND name=? AND id<>?", panel.OrgId, panel.FolderId, panel.Name, panel.Id).Exist(&models.LibraryPanel{})
+	if err != nil {
+		return err
+	} else if exists {
+		return models.ErrLibraryPanelWithSameNameExists
+	}
+
+	return nil
+}
+
+// libraryPanelModel removes the position and the library panel reference from
+// a panel model, which belong to the dashboards using it.
+func libraryPanelModel(model *simplejson.Json) *simplejson.Json {
+	if model == nil {
+		return simplejson.New()
+	}
+
+	for _, key := range []string{"id", "gridPos", "libraryPanel"} {
+		model.Del(key)
+	}
+	return model
+}
+
+func generateNewLibraryPanelUid(sess *DBSession, orgId int64) (string, error) {
+	for i := 0; i < 3; i++ {
+		uid := generateNewUid()
+
+		exists, err := sess.Where("org_id=? AND uid=?", orgId, uid).Exist(&models.LibraryPanel{})
+		if err != nil {
+			return "", err
+		}
+
+		if !exists {
+			return uid, nil
+		}
+	}
+
+	return "", models.ErrLibraryPanelFailedGenerateUniqueUid
+}
+
+// resolveLibraryPanels returns a copy of a saved dashboard with the model of
+// its library panels, or the dashboard itself if it has no library panels.
+func resolveLibraryPanels(sess *DBSession, dash *models.Dashboard) (*models.Dashboard, error) {
+	uids := dash.LibraryPanelUids()
+	if len(uids) == 0 {
+		return dash, nil
+	}
+
+	var libraryPanels []*models.LibraryPanel
+	if err := sess.Where("org_id=?", dash.OrgId).In("uid", uids).Find(&libraryPanels); err != nil {
+		return nil, err
+	}
+	panels := make(map[string]*models.LibraryPanel, len(libraryPanels))
+	for _, panel := range libraryPanels {
+		panels[panel.Uid] = panel
+	}
+
+	data, err := dash.Data.Encode()
+	if err != nil {
+		return nil, err
+	}
+	resolved := *dash
+	if resolved.Data, err = simplejson.NewJson(data); err != nil {
+		return nil, err
+	}
+	resolved.LoadLibraryPanels(panels)
+	return &resolved, nil
+}
+
+// reindexLibraryPanelDashboards indexes again the dashboards using a library
+// panel, and their references to data sources, once its model has changed.
+func reindexLibraryPanelDashboards(sess *DBSession, panel *models.LibraryPanel) error {
+	var dashboards []*models.Dashboard
+	err := sess.Table("dashboard").
+		Join("INNER", "library_panel_dashboard", "library_panel_dashboard.dashboard_id = dashboard.id").
+		Where("dashboard.org_id=? AND library_panel_dashboard.library_panel_id=?", panel.OrgId, panel.Id).
+		Find(&dashboards)
+	if err != nil {
+		return err
+	}
+
+	for _, dash := range dashboards {
+		if err := saveDashboardIndex(sess, dash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// connectLibraryPanels records the library panels used by a dashboard
+func connectLibraryPanels(sess *DBSession, dash *models.Dashboard) error {
+	if _, err := sess.Exec("DELETE FROM library_panel_dashboard WHERE dashboard_id = ?", dash.Id); err != nil {
+		return err
+	}
+
+	uids := dash.LibraryPanelUids()
+	if len(uids) == 0 {
+		return nil
+	}
+
+	var panels []*models.LibraryPanel
+	if err := sess.Where("org_id=?", dash.OrgId).In("uid", uids).Cols("id").Find(&panels); err != nil {
+		return err
+	}
+	if len(panels) != len(uids) {
+		return models.ErrDashboardLibraryPanelNotFound
+	}
+
+	for _, panel := range panels {
+		if _, err := sess.Insert(&models.LibraryPanelDashboard{LibraryPanelId: panel.Id, DashboardId: dash.Id}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
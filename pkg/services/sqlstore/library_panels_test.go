@@ -0,0 +1,172 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryPanels(t *testing.T) {
+	InitTestDB(t)
+
+	saveFolder := models.SaveDashboardCommand{
+		OrgId:     1,
+		IsFolder:  true,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Team"}),
+	}
+	require.NoError(t, SaveDashboard(&saveFolder))
+	folder := saveFolder.Result
+
+	create := func(t *testing.T, name string, folderId int64) *models.LibraryPanel {
+		t.Helper()
+		cmd := models.CreateLibraryPanelCommand{
+			OrgId:    1,
+			UserId:   1,
+			FolderId: folderId,
+			Name:     name,
+			Model: simplejson.NewFromAny(map[string]interface{}{
+				"id": 4, "type": "graph", "title": name, "gridPos": map[string]interface{}{"x": 0},
+			}),
+		}
+		require.NoError(t, CreateLibraryPanel(&cmd))
+		return cmd.Result
+	}
+
+	saveDashboard := func(t *testing.T, id int64, title string, libraryPanelUid string) (*models.Dashboard, error) {
+		t.Helper()
+		panels := []interface{}{
+			map[string]interface{}{"id": 1, "type": "text"},
+		}
+		if libraryPanelUid != "" {
+			panels = append(panels, map[string]interface{}{
+				"id": 2, "type": "row", "collapsed": true,
+				"panels": []interface{}{
+					map[string]interface{}{
+						"id":           3,
+						"type":         "graph",
+						"title":        "Local copy",
+						"gridPos":      map[string]interface{}{"x": 12, "y": 8},
+						"libraryPanel": map[string]interface{}{"uid": libraryPanelUid, "name": "CPU"},
+					},
+				},
+			})
+		}
+
+		cmd := models.SaveDashboardCommand{
+			OrgId:     1,
+			Overwrite: true,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"id": id, "title": title, "panels": panels}),
+		}
+		err := SaveDashboard(&cmd)
+		return cmd.Result, err
+	}
+
+	connected := func(t *testing.T, panel *models.LibraryPanel) []string {
+		t.Helper()
+		query := models.GetLibraryPanelDashboardsQuery{OrgId: 1, LibraryPanelId: panel.Id}
+		require.NoError(t, GetLibraryPanelDashboards(&query))
+		titles := make([]string, 0)
+		for _, dash := range query.Result {
+			titles = append(titles, dash.Title)
+		}
+		return titles
+	}
+
+	cpu := create(t, "CPU", folder.Id)
+
+	t.Run("the position of the panel isn't stored in the library", func(t *testing.T) {
+		query := models.GetLibraryPanelQuery{OrgId: 1, Uid: cpu.Uid}
+		require.NoError(t, GetLibraryPanel(&query))
+		assert.EqualValues(t, 1, query.Result.Version)
+		assert.Equal(t, map[string]interface{}{"type": "graph", "title": "CPU"}, query.Result.Model.MustMap())
+	})
+
+	t.Run("library panels must have a unique name in their folder", func(t *testing.T) {
+		err := CreateLibraryPanel(&models.CreateLibraryPanelCommand{OrgId: 1, FolderId: folder.Id, Name: "CPU"})
+		assert.Equal(t, models.ErrLibraryPanelWithSameNameExists, err)
+
+		create(t, "CPU", 0)
+	})
+
+	t.Run("library panels must be in an existing folder", func(t *testing.T) {
+		err := CreateLibraryPanel(&models.CreateLibraryPanelCommand{OrgId: 1, FolderId: 1000, Name: "Memory"})
+		assert.Equal(t, models.ErrFolderNotFound, err)
+	})
+
+	t.Run("saving a dashboard connects it to its library panels and keeps only their reference", func(t *testing.T) {
+		dash, err := saveDashboard(t, 0, "Hosts", cpu.Uid)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Hosts"}, connected(t, cpu))
+
+		query := models.GetDashboardQuery{OrgId: 1, Id: dash.Id}
+		require.NoError(t, GetDashboard(&query))
+		panel := query.Result.Data.Get("panels").GetIndex(1).Get("panels").GetIndex(0)
+		assert.EqualValues(t, 3, panel.Get("id").MustInt64())
+		assert.EqualValues(t, 12, panel.Get("gridPos").Get("x").MustInt64())
+		assert.Equal(t, cpu.Uid, panel.Get("libraryPanel").Get("uid").MustString())
+		_, hasTitle := panel.CheckGet("title")
+		assert.False(t, hasTitle)
+
+		_, err = saveDashboard(t, dash.Id, "Hosts", "")
+		require.NoError(t, err)
+		assert.Empty(t, connected(t, cpu))
+	})
+
+	t.Run("a dashboard can't use a library panel that doesn't exist", func(t *testing.T) {
+		_, err := saveDashboard(t, 0, "Broken", "missing")
+		assert.Equal(t, models.ErrDashboardLibraryPanelNotFound, err)
+	})
+
+	t.Run("updating a library panel bumps its version", func(t *testing.T) {
+		cmd := models.UpdateLibraryPanelCommand{
+			OrgId:    1,
+			Uid:      cpu.Uid,
+			FolderId: folder.Id,
+			Name:     "CPU usage",
+			Model:    simplejson.NewFromAny(map[string]interface{}{"type": "stat"}),
+			Version:  1,
+		}
+		require.NoError(t, UpdateLibraryPanel(&cmd))
+		assert.EqualValues(t, 2, cmd.Result.Version)
+
+		query := models.GetLibraryPanelQuery{OrgId: 1, Uid: cpu.Uid}
+		require.NoError(t, GetLibraryPanel(&query))
+		assert.Equal(t, "CPU usage", query.Result.Name)
+		assert.EqualValues(t, 2, query.Result.Version)
+
+		assert.Equal(t, models.ErrLibraryPanelVersionMismatch, UpdateLibraryPanel(&cmd))
+	})
+
+	t.Run("library panels used by dashboards can't be deleted", func(t *testing.T) {
+		query := models.GetDashboardQuery{OrgId: 1, Slug: "hosts"}
+		require.NoError(t, GetDashboard(&query))
+		_, err := saveDashboard(t, query.Result.Id, "Hosts", cpu.Uid)
+		require.NoError(t, err)
+
+		err = DeleteLibraryPanel(&models.DeleteLibraryPanelCommand{OrgId: 1, Uid: cpu.Uid})
+		assert.Equal(t, models.ErrLibraryPanelInUse, err)
+
+		require.NoError(t, TrashDashboard(&models.TrashDashboardCommand{OrgId: 1, Id: query.Result.Id}))
+		require.NoError(t, DeleteLibraryPanel(&models.DeleteLibraryPanelCommand{OrgId: 1, Uid: cpu.Uid}))
+
+		err = GetLibraryPanel(&models.GetLibraryPanelQuery{OrgId: 1, Uid: cpu.Uid})
+		assert.Equal(t, models.ErrLibraryPanelNotFound, err)
+	})
+
+	t.Run("library panels can be searched by name", func(t *testing.T) {
+		create(t, "Memory", folder.Id)
+
+		query := models.GetLibraryPanelsQuery{OrgId: 1, Name: "mem"}
+		require.NoError(t, GetLibraryPanels(&query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, "Memory", query.Result[0].Name)
+
+		query = models.GetLibraryPanelsQuery{OrgId: 1, FolderIds: []int64{0}}
+		require.NoError(t, GetLibraryPanels(&query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, "CPU", query.Result[0].Name)
+	})
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addDashboardDatasourceRefMigrations(mg *Migrator) {
+	dashboardDatasourceRefV1 := Table{
+		Name: "dashboard_datasource_ref",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "kind", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "ref", Type: DB_NVarchar, Length: 190, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id"}},
+			{Cols: []string{"kind", "ref"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_datasource_ref table", NewAddTableMigration(dashboardDatasourceRefV1))
+	mg.AddMigration("add index dashboard_datasource_ref.dashboard_id", NewAddIndexMigration(dashboardDatasourceRefV1, dashboardDatasourceRefV1.Indices[0]))
+	mg.AddMigration("add index dashboard_datasource_ref.kind_ref", NewAddIndexMigration(dashboardDatasourceRefV1, dashboardDatasourceRefV1.Indices[1]))
+}
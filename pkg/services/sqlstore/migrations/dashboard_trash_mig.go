@@ -0,0 +1,48 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addDashboardTrashMigrations(mg *Migrator) {
+	dashboardTrashV1 := Table{
+		Name: "dashboard_trash",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "title", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "is_folder", Type: DB_Bool, Nullable: false},
+			{Name: "folder_id", Type: DB_BigInt, Nullable: false},
+			{Name: "deleted", Type: DB_DateTime, Nullable: false},
+			{Name: "content", Type: DB_MediumText, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "deleted"}},
+			{Cols: []string{"deleted"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_trash table", NewAddTableMigration(dashboardTrashV1))
+	mg.AddMigration("add index dashboard_trash.org_id_deleted", NewAddIndexMigration(dashboardTrashV1, dashboardTrashV1.Indices[0]))
+	mg.AddMigration("add index dashboard_trash.deleted", NewAddIndexMigration(dashboardTrashV1, dashboardTrashV1.Indices[1]))
+
+	dashboardTrashItemV1 := Table{
+		Name: "dashboard_trash_item",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "trash_id", Type: DB_BigInt, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "is_folder", Type: DB_Bool, Nullable: false},
+			{Name: "folder_id", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"trash_id"}},
+			{Cols: []string{"dashboard_id"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_trash_item table", NewAddTableMigration(dashboardTrashItemV1))
+	mg.AddMigration("add index dashboard_trash_item.trash_id", NewAddIndexMigration(dashboardTrashItemV1, dashboardTrashItemV1.Indices[0]))
+	mg.AddMigration("add index dashboard_trash_item.dashboard_id", NewAddIndexMigration(dashboardTrashItemV1, dashboardTrashItemV1.Indices[1]))
+}
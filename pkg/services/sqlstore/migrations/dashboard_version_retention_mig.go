@@ -0,0 +1,28 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addDashboardVersionRetentionMigrations(mg *Migrator) {
+	dashboardVersionRetentionV1 := Table{
+		Name: "dashboard_version_retention",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "folder_id", Type: DB_BigInt, Nullable: false},
+			{Name: "versions_to_keep", Type: DB_Int, Nullable: false},
+			{Name: "max_age", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "folder_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create dashboard_version_retention table", NewAddTableMigration(dashboardVersionRetentionV1))
+	mg.AddMigration("add unique index dashboard_version_retention.org_id_folder_id", NewAddIndexMigration(dashboardVersionRetentionV1, dashboardVersionRetentionV1.Indices[0]))
+
+	mg.AddMigration("add pinned column to dashboard_version", NewAddColumnMigration(Table{Name: "dashboard_version"}, &Column{
+		Name: "pinned", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+}
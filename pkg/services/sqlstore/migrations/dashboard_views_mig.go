@@ -0,0 +1,61 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addDashboardViewsMigrations(mg *Migrator) {
+	dashboardUserViewV1 := Table{
+		Name: "dashboard_user_view",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "views", Type: DB_BigInt, Nullable: false},
+			{Name: "last_viewed", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id", "user_id"}, Type: UniqueIndex},
+			{Cols: []string{"user_id"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_user_view table", NewAddTableMigration(dashboardUserViewV1))
+	mg.AddMigration("add unique index dashboard_user_view.dashboard_id_user_id", NewAddIndexMigration(dashboardUserViewV1, dashboardUserViewV1.Indices[0]))
+	mg.AddMigration("add index dashboard_user_view.user_id", NewAddIndexMigration(dashboardUserViewV1, dashboardUserViewV1.Indices[1]))
+
+	dashboardDailyViewV1 := Table{
+		Name: "dashboard_daily_view",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "day", Type: DB_NVarchar, Length: 10, Nullable: false},
+			{Name: "views", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id", "day"}, Type: UniqueIndex},
+			{Cols: []string{"day"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_daily_view table", NewAddTableMigration(dashboardDailyViewV1))
+	mg.AddMigration("add unique index dashboard_daily_view.dashboard_id_day", NewAddIndexMigration(dashboardDailyViewV1, dashboardDailyViewV1.Indices[0]))
+	mg.AddMigration("add index dashboard_daily_view.day", NewAddIndexMigration(dashboardDailyViewV1, dashboardDailyViewV1.Indices[1]))
+
+	dashboardDailyQueryV1 := Table{
+		Name: "dashboard_daily_query",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "day", Type: DB_NVarchar, Length: 10, Nullable: false},
+			{Name: "queries", Type: DB_BigInt, Nullable: false},
+			{Name: "errors", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id", "day"}, Type: UniqueIndex},
+			{Cols: []string{"day"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_daily_query table", NewAddTableMigration(dashboardDailyQueryV1))
+	mg.AddMigration("add unique index dashboard_daily_query.dashboard_id_day", NewAddIndexMigration(dashboardDailyQueryV1, dashboardDailyQueryV1.Indices[0]))
+	mg.AddMigration("add index dashboard_daily_query.day", NewAddIndexMigration(dashboardDailyQueryV1, dashboardDailyQueryV1.Indices[1]))
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addDataSourceHealthMigrations(mg *Migrator) {
+	dataSourceHealthV1 := Table{
+		Name: "data_source_health",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "data_source_id", Type: DB_BigInt, Nullable: false},
+			{Name: "status", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "message", Type: DB_Text, Nullable: true},
+			{Name: "duration_ms", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "data_source_id", "created"}},
+			{Cols: []string{"created"}},
+		},
+	}
+
+	mg.AddMigration("create data_source_health table", NewAddTableMigration(dataSourceHealthV1))
+	mg.AddMigration("add index data_source_health.org_id_data_source_id_created", NewAddIndexMigration(dataSourceHealthV1, dataSourceHealthV1.Indices[0]))
+	mg.AddMigration("add index data_source_health.created", NewAddIndexMigration(dataSourceHealthV1, dataSourceHealthV1.Indices[1]))
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addLibraryPanelMigrations(mg *Migrator) {
+	libraryPanelV1 := Table{
+		Name: "library_panel",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "folder_id", Type: DB_BigInt, Nullable: false},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 150, Nullable: false},
+			{Name: "model", Type: DB_MediumText, Nullable: false},
+			{Name: "version", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+			{Name: "created_by", Type: DB_BigInt, Nullable: false},
+			{Name: "updated_by", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "folder_id", "name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create library_panel table", NewAddTableMigration(libraryPanelV1))
+	mg.AddMigration("add unique index library_panel.org_id_uid", NewAddIndexMigration(libraryPanelV1, libraryPanelV1.Indices[0]))
+	mg.AddMigration("add unique index library_panel.org_id_folder_id_name", NewAddIndexMigration(libraryPanelV1, libraryPanelV1.Indices[1]))
+
+	libraryPanelDashboardV1 := Table{
+		Name: "library_panel_dashboard",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "library_panel_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"library_panel_id", "dashboard_id"}, Type: UniqueIndex},
+			{Cols: []string{"dashboard_id"}},
+		},
+	}
+
+	mg.AddMigration("create library_panel_dashboard table", NewAddTableMigration(libraryPanelDashboardV1))
+	mg.AddMigration("add unique index library_panel_dashboard.library_panel_id_dashboard_id", NewAddIndexMigration(libraryPanelDashboardV1, libraryPanelDashboardV1.Indices[0]))
+	mg.AddMigration("add index library_panel_dashboard.dashboard_id", NewAddIndexMigration(libraryPanelDashboardV1, libraryPanelDashboardV1.Indices[1]))
+}
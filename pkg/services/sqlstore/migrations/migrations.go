@@ -34,6 +34,13 @@ func AddMigrations(mg *Migrator) {
 	addServerlockMigrations(mg)
 	addUserAuthTokenMigrations(mg)
 	addCacheMigration(mg)
+	addDataSourceHealthMigrations(mg)
+	addDashboardTrashMigrations(mg)
+	addLibraryPanelMigrations(mg)
+	addDashboardVersionRetentionMigrations(mg)
+	addDashboardSearchMigrations(mg)
+	addDashboardDatasourceRefMigrations(mg)
+	addDashboardViewsMigrations(mg)
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
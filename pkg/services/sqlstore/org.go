@@ -199,6 +199,11 @@ func DeleteOrg(cmd *models.DeleteOrgCommand) error {
 		deletes := []string{
 			"DELETE FROM star WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND star.dashboard_id = dashboard.id)",
 			"DELETE FROM dashboard_tag WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_tag.dashboard_id = dashboard.id)",
+			"DELETE FROM dashboard_search_term WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_search_term.dashboard_id = dashboard.id)",
+			"DELETE FROM dashboard_datasource_ref WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_datasource_ref.dashboard_id = dashboard.id)",
+			"DELETE FROM dashboard_user_view WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_user_view.dashboard_id = dashboard.id)",
+			"DELETE FROM dashboard_daily_view WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_daily_view.dashboard_id = dashboard.id)",
+			"DELETE FROM dashboard_daily_query WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_daily_query.dashboard_id = dashboard.id)",
 			"DELETE FROM dashboard WHERE org_id = ?",
 			"DELETE FROM api_key WHERE org_id = ?",
 			"DELETE FROM data_source WHERE org_id = ?",
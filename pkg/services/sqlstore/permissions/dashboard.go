@@ -31,11 +31,9 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 		dashboard.id IN (
 			SELECT distinct DashboardId from (
 				SELECT d.id AS DashboardId
-					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
+					FROM dashboard AS d` + FolderAncestorJoins() + `
 					LEFT JOIN dashboard_acl AS da ON
-						da.dashboard_id = d.id OR
-						da.dashboard_id = d.folder_id
+						da.dashboard_id IN (` + DashboardAndAncestorIds() + `)
 					LEFT JOIN team_member as ugm on ugm.team_id = da.team_id
 					WHERE
 						d.org_id = ? AND
@@ -47,15 +45,11 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 						)
 				UNION
 				SELECT d.id AS DashboardId
-					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
+					FROM dashboard AS d` + FolderAncestorJoins() + `
 					LEFT JOIN dashboard_acl AS da ON
 						(
 							-- include default permissions -->
-							da.org_id = -1 AND (
-							  (folder.id IS NOT NULL AND folder.has_acl = ` + falseStr + `) OR
-							  (folder.id IS NULL AND d.has_acl = ` + falseStr + `)
-							)
+							da.org_id = -1 AND ` + RootHasAcl() + ` = ` + falseStr + `
 						)
 					WHERE
 						d.org_id = ? AND
@@ -0,0 +1,48 @@
+package permissions
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// FolderAncestorJoins joins the folders containing the dashboard aliased d,
+// from its folder aliased f1 up to fN, N being models.MaxFolderDepth. The
+// aliases of the levels above the root folder are NULL.
+func FolderAncestorJoins() string {
+	var sb strings.Builder
+	parent := "d"
+	for i := 1; i <= models.MaxFolderDepth; i++ {
+		alias := folderAlias(i)
+		sb.WriteString("\n LEFT JOIN dashboard AS " + alias + " ON " + alias + ".id = " + parent + ".folder_id")
+		parent = alias
+	}
+	return sb.String()
+}
+
+// DashboardAndAncestorIds lists the ids of the dashboard aliased d and of
+// the folders joined by FolderAncestorJoins, for an IN clause.
+func DashboardAndAncestorIds() string {
+	ids := []string{"d.id"}
+	for i := 1; i <= models.MaxFolderDepth; i++ {
+		ids = append(ids, folderAlias(i)+".id")
+	}
+	return strings.Join(ids, ", ")
+}
+
+// RootHasAcl is the has_acl flag of the root folder containing the dashboard
+// aliased d, or of the dashboard itself when it's in the General folder. The
+// default permissions apply when it's false.
+func RootHasAcl() string {
+	flags := make([]string, 0, models.MaxFolderDepth+1)
+	for i := models.MaxFolderDepth; i >= 1; i-- {
+		flags = append(flags, folderAlias(i)+".has_acl")
+	}
+	flags = append(flags, "d.has_acl")
+	return "COALESCE(" + strings.Join(flags, ", ") + ")"
+}
+
+func folderAlias(level int) string {
+	return "f" + strconv.Itoa(level)
+}
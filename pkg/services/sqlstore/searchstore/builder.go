@@ -11,7 +11,7 @@ import (
 // Builder defaults to returning a SQL query to get a list of all dashboards
 // in default order, but can be modified by applying filters.
 type Builder struct {
-	// List of FilterWhere/FilterGroupBy/FilterOrderBy/FilterLeftJoin
+	// List of FilterWhere/FilterGroupBy/FilterOrderBy/FilterSelect/FilterLeftJoin
 	// to modify the query.
 	Filters []interface{}
 	Dialect migrator.Dialect
@@ -60,6 +60,9 @@ func (b *Builder) buildSelect() {
 }
 
 func (b *Builder) applyFilters() (ordering string) {
+	selects := []string{}
+	selectParams := []interface{}{}
+
 	joins := []string{}
 	orderJoins := []string{}
 
@@ -72,6 +75,12 @@ func (b *Builder) applyFilters() (ordering string) {
 	orders := []string{}
 
 	for _, f := range b.Filters {
+		if f, ok := f.(FilterSelect); ok {
+			sql, params := f.Select()
+			selects = append(selects, ", "+sql)
+			selectParams = append(selectParams, params...)
+		}
+
 		if f, ok := f.(FilterLeftJoin); ok {
 			joins = append(joins, fmt.Sprintf(" LEFT OUTER JOIN %s ", f.LeftJoin()))
 		}
@@ -100,7 +109,8 @@ func (b *Builder) applyFilters() (ordering string) {
 		}
 	}
 
-	b.sql.WriteString("SELECT dashboard.id FROM dashboard")
+	b.sql.WriteString("SELECT dashboard.id" + strings.Join(selects, "") + " FROM dashboard")
+	b.params = append(b.params, selectParams...)
 	b.sql.WriteString(strings.Join(joins, ""))
 
 	if len(wheres) > 0 {
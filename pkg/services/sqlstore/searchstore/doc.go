@@ -15,13 +15,14 @@
 // requirements.
 //
 // A filter is any data type which implements one or more of the
-// FilterWhere, FilterGroupBy, FilterOrderBy, or FilterLeftJoin
-// interfaces. The filters will be applied (in order) to limit or
+// FilterWhere, FilterGroupBy, FilterOrderBy, FilterSelect, or
+// FilterLeftJoin interfaces. The filters will be applied (in order) to limit or
 // reorder the results.
 //
 // Filters will be applied in order with the final result like such:
 //
-//     SELECT id FROM dashboard LEFT OUTER JOIN <FilterLeftJoin...>
+//     SELECT id, <FilterSelect...>
+//     FROM dashboard LEFT OUTER JOIN <FilterLeftJoin...>
 //     WHERE <FilterWhere[0]> AND ... AND <FilterWhere[n]>
 //     GROUP BY <FilterGroupBy...>
 //     ORDER BY <FilterOrderBy...>
@@ -3,7 +3,9 @@ package searchstore
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 )
 
@@ -26,6 +28,13 @@ type FilterOrderBy interface {
 	OrderBy() string
 }
 
+// FilterSelect adds the returned column, with its parameters, to the
+// query of the dashboard IDs. The column can be referenced by its alias
+// in FilterOrderBy.
+type FilterSelect interface {
+	Select() (string, []interface{})
+}
+
 // FilterLeftJoin adds the returned string as a "LEFT OUTER JOIN" to
 // allow for fetching extra columns from a table outside of the
 // dashboard column.
@@ -82,6 +91,61 @@ func (f TitleFilter) Where() (string, []interface{}) {
 	return fmt.Sprintf("dashboard.title %s ?", f.Dialect.LikeStr()), []interface{}{"%" + f.Title + "%"}
 }
 
+// FullTextFilter limits the result to the dashboards having indexed texts
+// matching every word of Text, in any of Fields or in all the fields when
+// Fields is empty.
+type FullTextFilter struct {
+	Dialect migrator.Dialect
+	Text    string
+	Fields  []string
+}
+
+func (f FullTextFilter) Where() (string, []interface{}) {
+	words := strings.Fields(f.Text)
+	if len(words) == 0 {
+		return "", nil
+	}
+
+	conditions := make([]string, 0, len(words))
+	params := make([]interface{}, 0)
+	for _, word := range words {
+		sql, wordParams := searchTermsMatching(f.Dialect, word, f.Fields)
+		conditions = append(conditions, "EXISTS (SELECT 1 "+sql+")")
+		params = append(params, wordParams...)
+	}
+
+	return strings.Join(conditions, " AND "), params
+}
+
+// DatasourceFilter limits the result to the dashboards referencing the
+// data source by uid or name, through a data source variable of its type,
+// or as the default data source of the org.
+type DatasourceFilter struct {
+	Uid       string
+	Name      string
+	Type      string
+	IsDefault bool
+}
+
+func (f DatasourceFilter) Where() (string, []interface{}) {
+	sql := `EXISTS (SELECT 1
+			 FROM dashboard_datasource_ref
+			 WHERE dashboard_datasource_ref.dashboard_id = dashboard.id AND (
+			 	(dashboard_datasource_ref.kind = ? AND dashboard_datasource_ref.ref IN (?, ?)) OR
+			 	(dashboard_datasource_ref.kind = ? AND dashboard_datasource_ref.ref = ?)`
+	params := []interface{}{
+		models.DatasourceRefDirect, f.Uid, f.Name,
+		models.DatasourceRefVariable, f.Type,
+	}
+
+	if f.IsDefault {
+		sql += " OR dashboard_datasource_ref.kind = ?"
+		params = append(params, models.DatasourceRefDefault)
+	}
+
+	return sql + "))", params
+}
+
 type FolderFilter struct {
 	IDs []int64
 }
@@ -130,6 +194,131 @@ func (s TitleSorter) OrderBy() string {
 	return "dashboard.title ASC"
 }
 
+// UpdatedSorter sorts the dashboards by their last update, most recent
+// first.
+type UpdatedSorter struct{}
+
+func (s UpdatedSorter) OrderBy() string {
+	return "dashboard.updated DESC, dashboard.title ASC"
+}
+
+// CreatedSorter sorts the dashboards by their creation, most recent first.
+type CreatedSorter struct{}
+
+func (s CreatedSorter) OrderBy() string {
+	return "dashboard.created DESC, dashboard.title ASC"
+}
+
+// The sorters by views select their sort key with a correlated subquery,
+// rather than joining the views, as the query of the dashboard IDs may be
+// grouped by dashboard ID.
+
+// ViewedByUserSorter sorts the dashboards by when the user last viewed them,
+// most recent first.
+type ViewedByUserSorter struct {
+	UserId int64
+}
+
+// ForUser returns the sorter of the dashboards viewed by the user.
+func (s ViewedByUserSorter) ForUser(userId int64) FilterOrderBy {
+	s.UserId = userId
+	return s
+}
+
+func (s ViewedByUserSorter) Select() (string, []interface{}) {
+	return `COALESCE((SELECT dashboard_user_view.last_viewed
+			 FROM dashboard_user_view
+			 WHERE dashboard_user_view.dashboard_id = dashboard.id AND dashboard_user_view.user_id = ?), 0) AS last_viewed_by_user`,
+		[]interface{}{s.UserId}
+}
+
+func (s ViewedByUserSorter) OrderBy() string {
+	return "last_viewed_by_user DESC, dashboard.title ASC"
+}
+
+// MostViewedSorter sorts the dashboards by their number of views by all
+// users during the last Days days, most viewed first.
+type MostViewedSorter struct {
+	Days int
+}
+
+func (s MostViewedSorter) Select() (string, []interface{}) {
+	since := time.Now().UTC().AddDate(0, 0, -s.Days).Format("2006-01-02")
+	return `COALESCE((SELECT SUM(dashboard_daily_view.views)
+			 FROM dashboard_daily_view
+			 WHERE dashboard_daily_view.dashboard_id = dashboard.id AND dashboard_daily_view.day > ?), 0) AS view_count`,
+		[]interface{}{since}
+}
+
+func (s MostViewedSorter) OrderBy() string {
+	return "view_count DESC, dashboard.title ASC"
+}
+
+// searchTermWeights are the weights of the matches in the indexed fields of
+// a dashboard when sorting by relevance.
+var searchTermWeights = []struct {
+	Field  string
+	Weight int
+}{
+	{models.DashboardSearchFieldTitle, 8},
+	{models.DashboardSearchFieldPanelTitle, 4},
+	{models.DashboardSearchFieldDescription, 2},
+	{models.DashboardSearchFieldPanelDescription, 2},
+	{models.DashboardSearchFieldQuery, 2},
+}
+
+// RelevanceSorter sorts the dashboards by the sum of the weights of their
+// indexed texts matching the words of Text, then by title.
+type RelevanceSorter struct {
+	Dialect migrator.Dialect
+	Text    string
+	Fields  []string
+}
+
+func (s RelevanceSorter) Select() (string, []interface{}) {
+	words := strings.Fields(s.Text)
+	if len(words) == 0 {
+		return "0 AS relevance", nil
+	}
+
+	weight := "CASE dashboard_search_term.field"
+	for _, w := range searchTermWeights {
+		weight += fmt.Sprintf(" WHEN '%s' THEN %d", w.Field, w.Weight)
+	}
+	weight += " ELSE 1 END"
+
+	scores := make([]string, 0, len(words))
+	params := make([]interface{}, 0)
+	for _, word := range words {
+		sql, wordParams := searchTermsMatching(s.Dialect, word, s.Fields)
+		scores = append(scores, fmt.Sprintf("(SELECT COALESCE(SUM(%s), 0) %s)", weight, sql))
+		params = append(params, wordParams...)
+	}
+
+	return "(" + strings.Join(scores, " + ") + ") AS relevance", params
+}
+
+func (s RelevanceSorter) OrderBy() string {
+	return "relevance DESC, dashboard.title ASC"
+}
+
+// searchTermsMatching returns the FROM and WHERE clauses selecting the
+// indexed texts of a dashboard containing the word.
+func searchTermsMatching(dialect migrator.Dialect, word string, fields []string) (string, []interface{}) {
+	sql := fmt.Sprintf(`FROM dashboard_search_term
+			 WHERE dashboard_search_term.dashboard_id = dashboard.id AND dashboard_search_term.term %s ?`, dialect.LikeStr())
+	params := []interface{}{"%" + word + "%"}
+
+	if len(fields) > 0 {
+		sql += " AND dashboard_search_term.field IN (?" + strings.Repeat(",?", len(fields)-1) + ")"
+		for _, field := range fields {
+			params = append(params, field)
+		}
+	}
+
+	return sql, params
+}
+
 func sqlIDin(column string, ids []int64) (string, []interface{}) {
 	length := len(ids)
 	if length < 1 {
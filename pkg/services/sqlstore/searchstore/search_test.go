@@ -152,6 +152,116 @@ func TestBuilder_Permissions(t *testing.T) {
 	assert.Len(t, res, 0)
 }
 
+func TestBuilder_FullText(t *testing.T) {
+	db := setupTestEnvironment(t)
+
+	for _, data := range []string{
+		`{"title": "Errors", "panels": [{"id": 1, "title": "5xx", "targets": [{"expr": "sum(http_requests_total{code=~\"5..\"})"}]}]}`,
+		`{"title": "HTTP requests", "description": "runbook: https://wiki/http", "panels": [
+			{"id": 1, "title": "Requests", "targets": [{"expr": "sum(http_requests_total)"}]},
+			{"id": 2, "title": "Latency", "targets": [{"expr": "http_request_duration_seconds"}]}
+		]}`,
+		`{"title": "Nodes", "panels": [{"id": 1, "title": "Load", "description": "See the http runbook", "targets": [{"expr": "node_load1"}]}]}`,
+	} {
+		dashboard, err := simplejson.NewJson([]byte(data))
+		require.NoError(t, err)
+		err = sqlstore.SaveDashboard(&models.SaveDashboardCommand{Dashboard: dashboard, UserId: 1, OrgId: 1})
+		require.NoError(t, err)
+	}
+
+	search := func(t *testing.T, filters ...interface{}) []string {
+		t.Helper()
+		builder := &searchstore.Builder{
+			Filters: append([]interface{}{searchstore.OrgFilter{OrgId: 1}}, filters...),
+			Dialect: dialect,
+		}
+
+		res := []sqlstore.DashboardSearchProjection{}
+		err := db.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			sql, params := builder.ToSql(limit, page)
+			return sess.SQL(sql, params...).Find(&res)
+		})
+		require.NoError(t, err)
+
+		titles := make([]string, 0, len(res))
+		for _, r := range res {
+			titles = append(titles, r.Title)
+		}
+		return titles
+	}
+
+	t.Run("matches every word in any field", func(t *testing.T) {
+		assert.Equal(t, []string{"Errors", "HTTP requests"},
+			search(t, searchstore.FullTextFilter{Dialect: dialect, Text: "http_requests_total"}))
+		assert.Equal(t, []string{"HTTP requests", "Nodes"},
+			search(t, searchstore.FullTextFilter{Dialect: dialect, Text: "http runbook"}))
+	})
+
+	t.Run("matches in the given fields only", func(t *testing.T) {
+		fields := []string{models.DashboardSearchFieldPanelDescription}
+		assert.Equal(t, []string{"Nodes"},
+			search(t, searchstore.FullTextFilter{Dialect: dialect, Text: "runbook", Fields: fields}))
+	})
+
+	t.Run("sorts by relevance", func(t *testing.T) {
+		assert.Equal(t, []string{"HTTP requests", "Errors", "Nodes"}, search(t,
+			searchstore.FullTextFilter{Dialect: dialect, Text: "http"},
+			searchstore.RelevanceSorter{Dialect: dialect, Text: "http"},
+		))
+	})
+}
+
+func TestBuilder_SortByViews(t *testing.T) {
+	db := setupTestEnvironment(t)
+	err := createDashboards(0, 4, 1)
+	require.NoError(t, err)
+
+	// dashboards are named A (id 1) to D (id 4)
+	now := time.Now()
+	err = sqlstore.SaveDashboardViews(&models.SaveDashboardViewsCommand{Views: []*models.DashboardViews{
+		{DashboardId: 2, UserId: 1, Count: 1, LastViewed: now.Add(-time.Hour)},
+		{DashboardId: 3, UserId: 1, Count: 1, LastViewed: now},
+		{DashboardId: 4, UserId: 2, Count: 5, LastViewed: now},
+		{DashboardId: 2, UserId: 2, Count: 1, LastViewed: now.AddDate(0, 0, -40)},
+		{DashboardId: 2, UserId: 0, Count: 2, LastViewed: now},
+	}})
+	require.NoError(t, err)
+
+	search := func(t *testing.T, sorter interface{}) []string {
+		t.Helper()
+		builder := &searchstore.Builder{
+			Filters: []interface{}{
+				searchstore.OrgFilter{OrgId: 1},
+				// the views must be counted when the results are grouped by dashboard
+				searchstore.TagsFilter{Tags: []string{"templated"}},
+				sorter,
+			},
+			Dialect: dialect,
+		}
+
+		res := []sqlstore.DashboardSearchProjection{}
+		err := db.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			sql, params := builder.ToSql(limit, page)
+			return sess.SQL(sql, params...).Find(&res)
+		})
+		require.NoError(t, err)
+
+		titles := make([]string, 0, len(res))
+		for _, r := range res {
+			titles = append(titles, r.Title)
+		}
+		return titles
+	}
+
+	t.Run("recently viewed by the user", func(t *testing.T) {
+		assert.Equal(t, []string{"C", "B", "A", "D"}, search(t, searchstore.ViewedByUserSorter{UserId: 1}))
+	})
+
+	t.Run("most viewed", func(t *testing.T) {
+		assert.Equal(t, []string{"D", "B", "C", "A"}, search(t, searchstore.MostViewedSorter{Days: 30}))
+	})
+}
+
 func setupTestEnvironment(t *testing.T) *sqlstore.SqlStore {
 	t.Helper()
 	store := sqlstore.InitTestDB(t)
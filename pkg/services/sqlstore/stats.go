@@ -81,6 +81,15 @@ func GetSystemStats(query *models.GetSystemStatsQuery) error {
 	sb.Write(`(SELECT COUNT(id) FROM ` + dialect.Quote("team") + `) AS teams,`)
 	sb.Write(`(SELECT COUNT(id) FROM ` + dialect.Quote("user_auth_token") + `) AS auth_tokens,`)
 
+	usageSince := time.Now().AddDate(0, 0, -models.MostViewedDashboardsDays)
+	usageSinceDay := usageSince.UTC().Format(dayFormat)
+	sb.Write(`(SELECT COALESCE(SUM(views), 0) FROM `+dialect.Quote("dashboard_daily_view")+` WHERE day >= ?) AS dashboard_views,`, usageSinceDay)
+	sb.Write(`(SELECT COALESCE(SUM(queries), 0) FROM `+dialect.Quote("dashboard_daily_query")+` WHERE day >= ?) AS dashboard_queries,`, usageSinceDay)
+	sb.Write(`(SELECT COALESCE(SUM(errors), 0) FROM `+dialect.Quote("dashboard_daily_query")+` WHERE day >= ?) AS dashboard_query_errors,`, usageSinceDay)
+	sb.Write(`(SELECT COUNT(DISTINCT dashboard_id) FROM `+dialect.Quote("dashboard_daily_view")+` WHERE day >= ?) AS viewed_dashboards,`, usageSinceDay)
+	staleCondition, staleParams := staleDashboardCondition(usageSince)
+	sb.Write(`(SELECT COUNT(*) FROM `+dialect.Quote("dashboard")+` WHERE `+staleCondition+`) AS stale_dashboards,`, staleParams...)
+
 	sb.Write(roleCounterSQL())
 
 	var stats models.SystemStats
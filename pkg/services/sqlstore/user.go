@@ -572,6 +572,7 @@ func deleteUserInTransaction(sess *DBSession, cmd *models.DeleteUserCommand) err
 
 	deletes := []string{
 		"DELETE FROM star WHERE user_id = ?",
+		"DELETE FROM dashboard_user_view WHERE user_id = ?",
 		"DELETE FROM " + dialect.Quote("user") + " WHERE id = ?",
 		"DELETE FROM org_user WHERE user_id = ?",
 		"DELETE FROM dashboard_acl WHERE user_id = ?",
@@ -7,7 +7,6 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
-	"github.com/grafana/grafana/pkg/util"
 )
 
 var getTime = time.Now
@@ -255,7 +254,7 @@ func DeleteAuthInfo(cmd *models.DeleteAuthInfoCommand) error {
 }
 
 // decodeAndDecrypt will decode the string with the standard bas64 decoder
-// and then decrypt it with grafana's secretKey
+// and then decrypt it with grafana's secret keys
 func decodeAndDecrypt(s string) (string, error) {
 	// Bail out if empty string since it'll cause a segfault in util.Decrypt
 	if s == "" {
@@ -265,17 +264,17 @@ func decodeAndDecrypt(s string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	decrypted, err := util.Decrypt(decoded, setting.SecretKey)
+	decrypted, err := setting.SecretsKeyring().Decrypt(decoded)
 	if err != nil {
 		return "", err
 	}
 	return string(decrypted), nil
 }
 
-// encryptAndEncode will encrypt a string with grafana's secretKey, and
+// encryptAndEncode will encrypt a string with grafana's secret key, and
 // then encode it with the standard bas64 encoder
 func encryptAndEncode(s string) (string, error) {
-	encrypted, err := util.Encrypt([]byte(s), setting.SecretKey)
+	encrypted, err := setting.SecretsKeyring().Encrypt([]byte(s))
 	if err != nil {
 		return "", err
 	}
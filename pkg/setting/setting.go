@@ -116,9 +116,19 @@ var (
 	SnapShotRemoveExpired bool
 	SnapshotPublicMode    bool
 
+	// File based data sources
+	SqliteDataSourceAllowedPaths []string
+	FileDataSourceAllowedPaths   []string
+
 	// Dashboard history
 	DashboardVersionsToKeep int
 	MinRefreshInterval      string
+	// DashboardTrashRetention is how long deleted dashboards are kept in the
+	// trash, deleted dashboards aren't moved to the trash when 0
+	DashboardTrashRetention time.Duration
+	// DashboardQuerySampleRate is the fraction of the data source queries
+	// recorded in the dashboard insights
+	DashboardQuerySampleRate float64
 
 	// User settings
 	AllowUserSignUp         bool
@@ -289,7 +299,15 @@ type Cfg struct {
 	SAMLEnabled bool
 
 	// Dataproxy
-	SendUserHeader bool
+	SendUserHeader         bool
+	DataProxyResponseCache string
+	DataProxyAuditLogging  bool
+
+	// Data source health checks
+	DataSourceHealthCheckEnabled  bool
+	DataSourceHealthCheckInterval time.Duration
+	DataSourceHealthCheckTimeout  time.Duration
+	DataSourceHealthHistoryMaxAge time.Duration
 
 	// DistributedCache
 	RemoteCacheOptions *RemoteCacheOptions
@@ -683,6 +701,16 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	DataProxyLogging = dataproxy.Key("logging").MustBool(false)
 	DataProxyTimeout = dataproxy.Key("timeout").MustInt(30)
 	cfg.SendUserHeader = dataproxy.Key("send_user_header").MustBool(false)
+	cfg.DataProxyAuditLogging = dataproxy.Key("audit_logging").MustBool(false)
+	cfg.DataProxyResponseCache = dataproxy.Key("response_cache").In("", []string{"", "memory", "remote"})
+
+	// read file based data source settings
+	SqliteDataSourceAllowedPaths = readAllowedPaths(iniFile.Section("datasources.sqlite"))
+	FileDataSourceAllowedPaths = readAllowedPaths(iniFile.Section("datasources.file"))
+
+	if err := readDataSourceHealthSettings(iniFile, cfg); err != nil {
+		return err
+	}
 
 	if err := readSecuritySettings(iniFile, cfg); err != nil {
 		return err
@@ -696,6 +724,14 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	dashboards := iniFile.Section("dashboards")
 	DashboardVersionsToKeep = dashboards.Key("versions_to_keep").MustInt(20)
 	MinRefreshInterval = valueAsString(dashboards, "min_refresh_interval", "5s")
+	DashboardTrashRetention, err = gtime.ParseInterval(valueAsString(dashboards, "trash_retention", "30d"))
+	if err != nil {
+		return err
+	}
+	DashboardQuerySampleRate = dashboards.Key("query_sample_rate").MustFloat64(1)
+	if DashboardQuerySampleRate < 0 || DashboardQuerySampleRate > 1 {
+		return fmt.Errorf("dashboards query_sample_rate must be between 0 and 1, got %v", DashboardQuerySampleRate)
+	}
 
 	cfg.DefaultHomeDashboardPath = dashboards.Key("default_home_dashboard_path").MustString("")
 
@@ -795,6 +831,19 @@ func valueAsString(section *ini.Section, keyName string, defaultValue string) st
 	return section.Key(keyName).MustString(defaultValue)
 }
 
+// readAllowedPaths reads the comma separated list of directories a file based
+// data source is allowed to read from, relative paths are resolved against the home path.
+func readAllowedPaths(section *ini.Section) []string {
+	var paths []string
+	for _, p := range util.SplitString(valueAsString(section, "allowed_paths", "")) {
+		if p == "" {
+			continue
+		}
+		paths = append(paths, makeAbsolute(p, HomePath))
+	}
+	return paths
+}
+
 type RemoteCacheOptions struct {
 	Name    string
 	ConnStr string
@@ -894,6 +943,9 @@ func (cfg *Cfg) SectionWithEnvOverrides(s string) *DynamicSection {
 func readSecuritySettings(iniFile *ini.File, cfg *Cfg) error {
 	security := iniFile.Section("security")
 	SecretKey = valueAsString(security, "secret_key", "")
+	if err := readSecretKeySettings(iniFile); err != nil {
+		return err
+	}
 	DisableGravatar = security.Key("disable_gravatar").MustBool(true)
 	cfg.DisableBruteForceLoginProtection = security.Key("disable_brute_force_login_protection").MustBool(false)
 	DisableBruteForceLoginProtection = cfg.DisableBruteForceLoginProtection
@@ -1115,6 +1167,28 @@ func readSnapshotsSettings(iniFile *ini.File) error {
 	return nil
 }
 
+func readDataSourceHealthSettings(iniFile *ini.File, cfg *Cfg) error {
+	health := iniFile.Section("datasources.health")
+	cfg.DataSourceHealthCheckEnabled = health.Key("enabled").MustBool(false)
+
+	var err error
+	cfg.DataSourceHealthCheckInterval, err = gtime.ParseInterval(valueAsString(health, "interval", "1m"))
+	if err != nil {
+		return err
+	}
+	if cfg.DataSourceHealthCheckInterval < 10*time.Second {
+		cfg.DataSourceHealthCheckInterval = 10 * time.Second
+	}
+
+	cfg.DataSourceHealthCheckTimeout, err = gtime.ParseInterval(valueAsString(health, "timeout", "10s"))
+	if err != nil {
+		return err
+	}
+
+	cfg.DataSourceHealthHistoryMaxAge, err = gtime.ParseInterval(valueAsString(health, "history_max_age", "7d"))
+	return err
+}
+
 func readServerSettings(iniFile *ini.File, cfg *Cfg) error {
 	server := iniFile.Section("server")
 	var err error
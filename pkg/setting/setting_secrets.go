@@ -0,0 +1,101 @@
+package setting
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/util"
+	"gopkg.in/ini.v1"
+)
+
+var (
+	// SecretKeyID is the ID of SecretKey, embedded in the secrets it encrypts
+	SecretKeyID string
+	// PreviousSecretKeys are the keys, by ID, of secrets that have not been
+	// rotated to SecretKey yet
+	PreviousSecretKeys map[string]string
+	// EnvelopeEncryption encrypts secrets with a data key, itself encrypted with SecretKey
+	EnvelopeEncryption bool
+)
+
+var secretsKeyring struct {
+	sync.Mutex
+	keyring            *util.Keyring
+	secretKey          string
+	secretKeyID        string
+	previousSecretKeys map[string]string
+	envelopeEncryption bool
+}
+
+// SecretsKeyring returns the keyring encrypting the secrets stored in the
+// database with SecretKey, and decrypting them with SecretKey or any of
+// the previous secret keys. The keyring is built once, and only built again
+// if the secret key settings change.
+func SecretsKeyring() *util.Keyring {
+	secretsKeyring.Lock()
+	defer secretsKeyring.Unlock()
+
+	if secretsKeyring.keyring != nil &&
+		secretsKeyring.secretKey == SecretKey &&
+		secretsKeyring.secretKeyID == SecretKeyID &&
+		secretsKeyring.envelopeEncryption == EnvelopeEncryption &&
+		equalKeys(secretsKeyring.previousSecretKeys, PreviousSecretKeys) {
+		return secretsKeyring.keyring
+	}
+
+	currentID := SecretKeyID
+	if currentID == "" {
+		currentID = util.LegacyKeyID
+	}
+
+	previous := make(map[string]string, len(PreviousSecretKeys))
+	keys := make(map[string]string, len(PreviousSecretKeys)+1)
+	for id, key := range PreviousSecretKeys {
+		previous[id] = key
+		keys[id] = key
+	}
+	keys[currentID] = SecretKey
+
+	secretsKeyring.keyring = &util.Keyring{CurrentKeyID: currentID, Keys: keys, Envelope: EnvelopeEncryption}
+	secretsKeyring.secretKey = SecretKey
+	secretsKeyring.secretKeyID = SecretKeyID
+	secretsKeyring.previousSecretKeys = previous
+	secretsKeyring.envelopeEncryption = EnvelopeEncryption
+	return secretsKeyring.keyring
+}
+
+func equalKeys(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id, key := range a {
+		if other, ok := b[id]; !ok || other != key {
+			return false
+		}
+	}
+	return true
+}
+
+func readSecretKeySettings(iniFile *ini.File) error {
+	security := iniFile.Section("security")
+	SecretKeyID = valueAsString(security, "secret_key_id", "")
+	EnvelopeEncryption = security.Key("envelope_encryption").MustBool(false)
+	if SecretKeyID != "" {
+		if err := util.ValidateKeyID(SecretKeyID); err != nil {
+			return err
+		}
+	}
+
+	PreviousSecretKeys = make(map[string]string)
+	for _, key := range iniFile.Section("security.previous_secret_keys").Keys() {
+		if err := util.ValidateKeyID(key.Name()); err != nil {
+			return err
+		}
+		if key.Name() == SecretKeyID || (SecretKeyID == "" && key.Name() == util.LegacyKeyID) {
+			return fmt.Errorf("previous secret key %q has the id of the current secret key", key.Name())
+		}
+		PreviousSecretKeys[key.Name()] = key.Value()
+	}
+
+	return nil
+}
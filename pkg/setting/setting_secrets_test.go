@@ -0,0 +1,82 @@
+package setting
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestSecretKeySettings(t *testing.T) {
+	defer func(secretKey, secretKeyID string, previousSecretKeys map[string]string, envelopeEncryption bool) {
+		SecretKey, SecretKeyID, PreviousSecretKeys, EnvelopeEncryption = secretKey, secretKeyID, previousSecretKeys, envelopeEncryption
+	}(SecretKey, SecretKeyID, PreviousSecretKeys, EnvelopeEncryption)
+
+	t.Run("reads the key id and previous keys", func(t *testing.T) {
+		iniFile, err := ini.Load([]byte(`
+[security]
+secret_key = new
+secret_key_id = 2020-06
+envelope_encryption = true
+
+[security.previous_secret_keys]
+legacy = old
+`))
+		require.NoError(t, err)
+		require.NoError(t, readSecretKeySettings(iniFile))
+		SecretKey = "new"
+
+		keyring := SecretsKeyring()
+		assert.Equal(t, &util.Keyring{
+			CurrentKeyID: "2020-06",
+			Keys:         map[string]string{"2020-06": "new", util.LegacyKeyID: "old"},
+			Envelope:     true,
+		}, keyring)
+	})
+
+	t.Run("defaults to the legacy key", func(t *testing.T) {
+		iniFile, err := ini.Load([]byte(`
+[security]
+secret_key = key
+`))
+		require.NoError(t, err)
+		require.NoError(t, readSecretKeySettings(iniFile))
+		SecretKey = "key"
+
+		assert.Equal(t, &util.Keyring{
+			CurrentKeyID: util.LegacyKeyID,
+			Keys:         map[string]string{util.LegacyKeyID: "key"},
+		}, SecretsKeyring())
+	})
+
+	t.Run("builds the keyring once", func(t *testing.T) {
+		iniFile, err := ini.Load([]byte(`
+[security]
+secret_key = key
+`))
+		require.NoError(t, err)
+		require.NoError(t, readSecretKeySettings(iniFile))
+		SecretKey = "key"
+
+		keyring := SecretsKeyring()
+		assert.Same(t, keyring, SecretsKeyring())
+
+		SecretKey = "other key"
+		assert.NotSame(t, keyring, SecretsKeyring())
+		assert.Equal(t, "other key", SecretsKeyring().Keys[util.LegacyKeyID])
+	})
+
+	t.Run("fails for a previous key with the id of the current key", func(t *testing.T) {
+		iniFile, err := ini.Load([]byte(`
+[security]
+secret_key = key
+
+[security.previous_secret_keys]
+legacy = old
+`))
+		require.NoError(t, err)
+		assert.Error(t, readSecretKeySettings(iniFile))
+	})
+}
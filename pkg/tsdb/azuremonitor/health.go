@@ -0,0 +1,47 @@
+package azuremonitor
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// CheckHealth lists the resource groups of the default subscription, which
+// fails if the credentials can't read the subscription.
+func (e *AzureMonitorExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	subscription := dsInfo.JsonData.Get("subscriptionId").MustString()
+	if subscription == "" {
+		return tsdb.HealthCheckError(fmt.Errorf("no default subscription configured"))
+	}
+
+	azDatasource := &AzureMonitorDatasource{
+		httpClient: e.httpClient,
+		dsInfo:     dsInfo,
+	}
+
+	req, err := azDatasource.createRequest(ctx, dsInfo)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	req.URL.Path = path.Join(req.URL.Path, subscription, "resourceGroups")
+	req.URL.RawQuery = url.Values{"api-version": []string{"2018-05-01"}, "$top": []string{"1"}}.Encode()
+
+	res, err := ctxhttp.Do(ctx, e.httpClient, req)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return tsdb.HealthCheckError(fmt.Errorf("Azure Monitor returned status %s: %s", res.Status, string(body)))
+	}
+
+	return tsdb.HealthCheckOK("Successfully queried the Azure Monitor service")
+}
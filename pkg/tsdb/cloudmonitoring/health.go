@@ -0,0 +1,43 @@
+package cloudmonitoring
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// CheckHealth lists a single metric descriptor of the default project,
+// which fails if the credentials can't read the project.
+func (e *CloudMonitoringExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	projectName, err := e.getDefaultProject(ctx)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	if projectName == "" {
+		return tsdb.HealthCheckError(fmt.Errorf("no default project configured"))
+	}
+
+	req, err := e.createRequest(ctx, dsInfo, nil, fmt.Sprintf("cloudmonitoring%s", "v3/projects/"+projectName+"/metricDescriptors"))
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	req.URL.RawQuery = url.Values{"pageSize": []string{"1"}}.Encode()
+
+	res, err := ctxhttp.Do(ctx, e.httpClient, req)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return tsdb.HealthCheckError(fmt.Errorf("Google Cloud Monitoring returned status %s: %s", res.Status, string(body)))
+	}
+
+	return tsdb.HealthCheckOK("Successfully queried the Google Cloud Monitoring API")
+}
@@ -0,0 +1,30 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth lists a single page of metrics in the default region, which
+// fails if the credentials are invalid or lack the cloudwatch:ListMetrics permission.
+func (e *cloudWatchExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	e.DataSource = dsInfo
+
+	client, err := e.getCWClient(defaultRegion)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+
+	metrics.MAwsCloudWatchListMetrics.Inc()
+	if _, err := client.ListMetricsWithContext(ctx, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2")}); err != nil {
+		return tsdb.HealthCheckError(fmt.Errorf("failed to call cloudwatch:ListMetrics: %w", err))
+	}
+
+	return tsdb.HealthCheckOK("Data source is working")
+}
@@ -0,0 +1,207 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/tsdb"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureClient returns the responses of a fixture in order, one per multi
+// search request.
+type fixtureClient struct {
+	*fakeClient
+	responses []*es.MultiSearchResponse
+}
+
+func (c *fixtureClient) ExecuteMultisearch(r *es.MultiSearchRequest) (*es.MultiSearchResponse, error) {
+	c.multisearchRequests = append(c.multisearchRequests, r)
+	res := c.responses[0]
+	c.responses = c.responses[1:]
+	return res, nil
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return b
+}
+
+// executeFixture executes the query of the fixture with the given name and
+// returns the search requests made and the query result. The client returns
+// the fixture responses in the given order.
+func executeFixture(t *testing.T, name string, responseFiles ...string) ([]*es.SearchRequest, *tsdb.QueryResult) {
+	t.Helper()
+
+	c := &fixtureClient{fakeClient: newFakeClient(70)}
+	for _, file := range responseFiles {
+		var res es.MultiSearchResponse
+		require.NoError(t, json.Unmarshal(readFixture(t, file), &res))
+		c.responses = append(c.responses, &res)
+	}
+
+	from := time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC)
+	to := time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC)
+	resp, err := executeTsdbQuery(c, string(readFixture(t, name+".query.json")), from, to, 15*time.Second)
+	require.NoError(t, err)
+	require.Empty(t, c.responses)
+
+	var requests []*es.SearchRequest
+	for _, r := range c.multisearchRequests {
+		requests = append(requests, r.Requests...)
+	}
+	require.Len(t, resp.Results, 1)
+	for _, res := range resp.Results {
+		return requests, res
+	}
+	return requests, nil
+}
+
+func assertRequestFixture(t *testing.T, name string, r *es.SearchRequest) {
+	t.Helper()
+	actual, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(readFixture(t, name+".request.json")), string(actual))
+}
+
+func seriesValues(series *tsdb.TimeSeries) []null.Float {
+	values := make([]null.Float, 0, len(series.Points))
+	for _, p := range series.Points {
+		values = append(values, p[0])
+	}
+	return values
+}
+
+func TestAggregationFixtures(t *testing.T) {
+	t.Run("top_metrics", func(t *testing.T) {
+		requests, res := executeFixture(t, "top_metrics", "top_metrics.response.json")
+		require.Len(t, requests, 1)
+		assertRequestFixture(t, "top_metrics", requests[0])
+
+		require.NoError(t, res.Error)
+		require.Len(t, res.Series, 2)
+		assert.Equal(t, "Top Metrics cpu", res.Series[0].Name)
+		assert.Equal(t, []null.Float{null.FloatFrom(10), null.FloatFrom(11)}, seriesValues(res.Series[0]))
+		assert.Equal(t, "Top Metrics mem", res.Series[1].Name)
+		assert.Equal(t, []null.Float{null.FloatFrom(20), null.FloatFrom(21)}, seriesValues(res.Series[1]))
+	})
+
+	t.Run("rate and serial_diff", func(t *testing.T) {
+		requests, res := executeFixture(t, "rate_serial_diff", "rate_serial_diff.response.json")
+		require.Len(t, requests, 1)
+		assertRequestFixture(t, "rate_serial_diff", requests[0])
+
+		require.NoError(t, res.Error)
+		require.Len(t, res.Series, 2)
+		assert.Equal(t, "Rate bytes", res.Series[0].Name)
+		assert.Equal(t, []null.Float{null.FloatFrom(100), null.FloatFrom(130), null.FloatFrom(110)}, seriesValues(res.Series[0]))
+		assert.Equal(t, "Serial Difference Rate 1", res.Series[1].Name)
+		assert.Equal(t, []null.Float{null.FloatFrom(30), null.FloatFrom(-20)}, seriesValues(res.Series[1]))
+	})
+
+	t.Run("min, max and avg bucket pipelines", func(t *testing.T) {
+		requests, res := executeFixture(t, "bucket_pipelines", "bucket_pipelines.response.json")
+		require.Len(t, requests, 1)
+		assertRequestFixture(t, "bucket_pipelines", requests[0])
+
+		require.NoError(t, res.Error)
+		require.Len(t, res.Series, 4)
+		assert.Equal(t, "server-1 Average cpu", res.Series[0].Name)
+		assert.Equal(t, []null.Float{null.FloatFrom(10), null.FloatFrom(30)}, seriesValues(res.Series[0]))
+		assert.Equal(t, "server-1 Max Bucket Average 1", res.Series[1].Name)
+		assert.Equal(t, []null.Float{null.FloatFrom(30), null.FloatFrom(30)}, seriesValues(res.Series[1]))
+		assert.Equal(t, "server-1 Min Bucket Average 1", res.Series[2].Name)
+		assert.Equal(t, []null.Float{null.FloatFrom(10), null.FloatFrom(10)}, seriesValues(res.Series[2]))
+		assert.Equal(t, "server-1 Average Bucket Count", res.Series[3].Name)
+		assert.Equal(t, []null.Float{null.FloatFrom(2), null.FloatFrom(2)}, seriesValues(res.Series[3]))
+	})
+
+	t.Run("bucket pipelines of a terms table", func(t *testing.T) {
+		requests, res := executeFixture(t, "terms_bucket_pipelines", "terms_bucket_pipelines.response.json")
+		require.Len(t, requests, 1)
+		assertRequestFixture(t, "terms_bucket_pipelines", requests[0])
+
+		require.NoError(t, res.Error)
+		require.Len(t, res.Tables, 1)
+		table := res.Tables[0]
+		require.Len(t, table.Columns, 3)
+		assert.Equal(t, "host", table.Columns[0].Text)
+		assert.Equal(t, "Average", table.Columns[1].Text)
+		assert.Equal(t, "Max Bucket", table.Columns[2].Text)
+		assert.Equal(t, []tsdb.RowValues{
+			{"server-1", null.FloatFrom(10), null.FloatFrom(30)},
+			{"server-2", null.FloatFrom(30), null.FloatFrom(30)},
+		}, table.Rows)
+	})
+
+	t.Run("date_range", func(t *testing.T) {
+		requests, res := executeFixture(t, "date_range", "date_range.response.json")
+		require.Len(t, requests, 1)
+		assertRequestFixture(t, "date_range", requests[0])
+
+		require.NoError(t, res.Error)
+		require.Len(t, res.Tables, 1)
+		table := res.Tables[0]
+		require.Len(t, table.Columns, 3)
+		assert.Equal(t, "@timestamp", table.Columns[0].Text)
+		assert.Equal(t, "Count", table.Columns[1].Text)
+		assert.Equal(t, "Average", table.Columns[2].Text)
+		require.Len(t, table.Rows, 2)
+		assert.Equal(t, tsdb.RowValues{"older", null.FloatFrom(5), null.FloatFrom(1.5)}, table.Rows[0])
+		assert.Equal(t, tsdb.RowValues{"last hour", null.FloatFrom(3), null.FloatFrom(2)}, table.Rows[1])
+	})
+
+	t.Run("composite with paging", func(t *testing.T) {
+		requests, res := executeFixture(t, "composite", "composite.response.json", "composite.page2.response.json")
+		require.Len(t, requests, 2)
+		assertRequestFixture(t, "composite", requests[0])
+
+		// the second page starts after the key of the last bucket of the first page
+		composite := requests[1].Aggs[0].Aggregation.Aggregation.(*es.CompositeAggregation)
+		assert.Equal(t, map[string]interface{}{"host": "server-2", "dc": "eu"}, composite.After)
+
+		require.NoError(t, res.Error)
+		require.Len(t, res.Tables, 1)
+		table := res.Tables[0]
+		require.Len(t, table.Columns, 3)
+		assert.Equal(t, "host", table.Columns[0].Text)
+		assert.Equal(t, "dc", table.Columns[1].Text)
+		assert.Equal(t, "Count", table.Columns[2].Text)
+		assert.Equal(t, []tsdb.RowValues{
+			{"server-1", "eu", null.FloatFrom(3)},
+			{"server-2", "eu", null.FloatFrom(4)},
+			{"server-3", "us", null.FloatFrom(5)},
+		}, table.Rows)
+	})
+
+	t.Run("composite paging is limited", func(t *testing.T) {
+		c := &fixtureClient{fakeClient: newFakeClient(70)}
+		for i := 0; i < maxCompositePages+1; i++ {
+			var res es.MultiSearchResponse
+			require.NoError(t, json.Unmarshal(readFixture(t, "composite.response.json"), &res))
+			c.responses = append(c.responses, &res)
+		}
+
+		from := time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC)
+		to := time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC)
+		_, err := executeTsdbQuery(c, `{
+			"timeField": "@timestamp",
+			"bucketAggs": [{
+				"id": "2",
+				"type": "composite",
+				"settings": {"size": "2", "maxPages": "1000000", "sources": [{ "name": "host", "field": "host.keyword" }]}
+			}],
+			"metrics": [{ "id": "1", "type": "count" }]
+		}`, from, to, 15*time.Second)
+		require.NoError(t, err)
+		assert.Len(t, c.multisearchRequests, maxCompositePages)
+	})
+}
@@ -35,12 +35,20 @@ var newDatasourceHttpClient = func(ds *models.DataSource) (*http.Client, error)
 type Client interface {
 	GetVersion() int
 	GetTimeField() string
+	GetConfiguredFields() ConfiguredFields
 	GetMinInterval(queryInterval string) (time.Duration, error)
 	ExecuteMultisearch(r *MultiSearchRequest) (*MultiSearchResponse, error)
 	MultiSearch() *MultiSearchRequestBuilder
 	EnableDebug()
 }
 
+// ConfiguredFields holds the fields configured for the datasource
+type ConfiguredFields struct {
+	TimeField       string
+	LogMessageField string
+	LogLevelField   string
+}
+
 // NewClient creates a new elasticsearch client
 var NewClient = func(ctx context.Context, ds *models.DataSource, timeRange *tsdb.TimeRange) (Client, error) {
 	version, err := ds.JsonData.Get("esVersion").Int()
@@ -99,6 +107,14 @@ func (c *baseClientImpl) GetTimeField() string {
 	return c.timeField
 }
 
+func (c *baseClientImpl) GetConfiguredFields() ConfiguredFields {
+	return ConfiguredFields{
+		TimeField:       c.timeField,
+		LogMessageField: c.ds.JsonData.Get("logMessageField").MustString(),
+		LogLevelField:   c.ds.JsonData.Get("logLevelField").MustString(),
+	}
+}
+
 func (c *baseClientImpl) GetMinInterval(queryInterval string) (time.Duration, error) {
 	return tsdb.GetIntervalFrom(c.ds, simplejson.NewFromAny(map[string]interface{}{
 		"interval": queryInterval,
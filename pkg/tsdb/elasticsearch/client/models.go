@@ -35,7 +35,7 @@ type SearchRequest struct {
 	Index       string
 	Interval    tsdb.Interval
 	Size        int
-	Sort        map[string]interface{}
+	Sort        []map[string]interface{}
 	Query       *Query
 	Aggs        AggArray
 	CustomProps map[string]interface{}
@@ -65,7 +65,22 @@ func (r *SearchRequest) MarshalJSON() ([]byte, error) {
 
 // SearchResponseHits represents search response hits
 type SearchResponseHits struct {
-	Hits []map[string]interface{}
+	Hits  []map[string]interface{}
+	Total interface{} `json:"total"`
+}
+
+// GetTotal returns the total number of hits, which is a number before
+// version 7 and an object holding the number since.
+func (h *SearchResponseHits) GetTotal() int64 {
+	switch total := h.Total.(type) {
+	case float64:
+		return int64(total)
+	case map[string]interface{}:
+		if value, ok := total["value"].(float64); ok {
+			return int64(value)
+		}
+	}
+	return 0
 }
 
 // SearchResponse represents a search response
@@ -150,6 +165,18 @@ type RangeFilter struct {
 // DateFormatEpochMS represents a date format of epoch milliseconds (epoch_millis)
 const DateFormatEpochMS = "epoch_millis"
 
+// Sort orders
+const (
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+// Tags wrapping highlighted matches in documents
+const (
+	HighlightPreTagsString  = "@HIGHLIGHT@"
+	HighlightPostTagsString = "@/HIGHLIGHT@"
+)
+
 // MarshalJSON returns the JSON encoding of the query string filter.
 func (f *RangeFilter) MarshalJSON() ([]byte, error) {
 	root := map[string]map[string]map[string]interface{}{
@@ -272,6 +299,21 @@ type ExtendedBounds struct {
 	Max string `json:"max"`
 }
 
+// DateRangeAggregation represents a date range aggregation
+type DateRangeAggregation struct {
+	Field  string                   `json:"field"`
+	Format string                   `json:"format,omitempty"`
+	Ranges []map[string]interface{} `json:"ranges"`
+}
+
+// CompositeAggregation represents a composite aggregation. After is the key
+// of the last bucket of the previous page when paging through the buckets.
+type CompositeAggregation struct {
+	Size    int                      `json:"size"`
+	Sources []map[string]interface{} `json:"sources"`
+	After   map[string]interface{}   `json:"after,omitempty"`
+}
+
 // GeoHashGridAggregation represents a geo hash grid aggregation
 type GeoHashGridAggregation struct {
 	Field     string `json:"field"`
@@ -286,8 +328,11 @@ type MetricAggregation struct {
 
 // MarshalJSON returns the JSON encoding of the metric aggregation
 func (a *MetricAggregation) MarshalJSON() ([]byte, error) {
-	root := map[string]interface{}{
-		"field": a.Field,
+	root := map[string]interface{}{}
+
+	// aggregations such as top_metrics have no field
+	if a.Field != "" {
+		root["field"] = a.Field
 	}
 
 	for k, v := range a.Settings {
@@ -57,7 +57,7 @@ func TestSearchRequest(t *testing.T) {
 					})
 
 					Convey("Should have correct sorting", func() {
-						sort, ok := sr.Sort[timeField].(map[string]string)
+						sort, ok := sr.Sort[0][timeField].(map[string]string)
 						So(ok, ShouldBeTrue)
 						So(sort["order"], ShouldEqual, "desc")
 						So(sort["unmapped_type"], ShouldEqual, "boolean")
@@ -85,7 +85,7 @@ func TestSearchRequest(t *testing.T) {
 						So(err, ShouldBeNil)
 						So(json.Get("size").MustInt(0), ShouldEqual, 200)
 
-						sort := json.GetPath("sort", timeField)
+						sort := json.Get("sort").GetIndex(0).Get(timeField)
 						So(sort.Get("order").MustString(), ShouldEqual, "desc")
 						So(sort.Get("unmapped_type").MustString(), ShouldEqual, "boolean")
 
@@ -101,6 +101,33 @@ func TestSearchRequest(t *testing.T) {
 				})
 			})
 
+			Convey("When adding sorts, search after and highlight", func() {
+				b.Sort("asc", timeField, "boolean")
+				b.Sort("asc", "_doc", "")
+				b.SearchAfter([]interface{}{1526406600000, 42})
+				b.AddHighlight()
+
+				Convey("When marshal to JSON should generate correct json", func() {
+					sr, err := b.Build()
+					So(err, ShouldBeNil)
+					body, err := json.Marshal(sr)
+					So(err, ShouldBeNil)
+					json, err := simplejson.NewJson(body)
+					So(err, ShouldBeNil)
+
+					sort := json.Get("sort").MustArray()
+					So(sort, ShouldHaveLength, 2)
+					So(json.Get("sort").GetIndex(0).GetPath(timeField, "order").MustString(), ShouldEqual, "asc")
+					So(json.Get("sort").GetIndex(1).GetPath("_doc", "order").MustString(), ShouldEqual, "asc")
+					So(json.Get("sort").GetIndex(1).GetPath("_doc", "unmapped_type").Interface(), ShouldBeNil)
+
+					So(json.Get("search_after").MustArray(), ShouldHaveLength, 2)
+					So(json.GetPath("highlight", "pre_tags").MustStringArray(), ShouldResemble, []string{HighlightPreTagsString})
+					So(json.GetPath("highlight", "post_tags").MustStringArray(), ShouldResemble, []string{HighlightPostTagsString})
+					So(json.GetPath("highlight", "fields").Get("*").Interface(), ShouldNotBeNil)
+				})
+			})
+
 			Convey("When adding doc value field", func() {
 				b.AddDocValueField(timeField)
 
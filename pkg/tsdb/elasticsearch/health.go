@@ -0,0 +1,50 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+// CheckHealth counts the documents of the last 5 minutes, which fails if the
+// index or the time field don't exist.
+func (e *ElasticsearchExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	timeRange := tsdb.NewTimeRange("now-5m", "now")
+	client, err := es.NewClient(ctx, dsInfo, timeRange)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+
+	from := strconv.FormatInt(timeRange.GetFromAsMsEpoch(), 10)
+	to := strconv.FormatInt(timeRange.GetToAsMsEpoch(), 10)
+
+	ms := client.MultiSearch()
+	b := ms.Search(tsdb.Interval{Text: "1m", Value: time.Minute})
+	b.Size(0)
+	b.Query().Bool().Filter().AddDateRangeFilter(client.GetTimeField(), to, from, es.DateFormatEpochMS)
+
+	req, err := ms.Build()
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+
+	res, err := client.ExecuteMultisearch(req)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	if res.Status/100 != 2 {
+		return tsdb.HealthCheckError(fmt.Errorf("Elasticsearch returned invalid status code: %d", res.Status))
+	}
+	for _, r := range res.Responses {
+		if r.Error != nil {
+			return tsdb.HealthCheckError(fmt.Errorf("%s", getErrorFromElasticResponse(r).ErrorString))
+		}
+	}
+
+	return tsdb.HealthCheckOK("Index OK. Time field name OK.")
+}
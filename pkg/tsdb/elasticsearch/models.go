@@ -6,13 +6,19 @@ import (
 
 // Query represents the time series query model of the datasource
 type Query struct {
-	TimeField  string       `json:"timeField"`
-	RawQuery   string       `json:"query"`
-	BucketAggs []*BucketAgg `json:"bucketAggs"`
-	Metrics    []*MetricAgg `json:"metrics"`
-	Alias      string       `json:"alias"`
-	Interval   string
-	RefID      string
+	TimeField   string        `json:"timeField"`
+	RawQuery    string        `json:"query"`
+	BucketAggs  []*BucketAgg  `json:"bucketAggs"`
+	Metrics     []*MetricAgg  `json:"metrics"`
+	Alias       string        `json:"alias"`
+	SearchAfter []interface{} `json:"searchAfter"`
+	Interval    string
+	RefID       string
+
+	// LogMessageField and LogLevelField are the fields configured for logs
+	// in the datasource settings
+	LogMessageField string
+	LogLevelField   string
 }
 
 // BucketAgg represents a bucket aggregation of the time series query model of the datasource
@@ -49,6 +55,14 @@ var metricAggType = map[string]string{
 	"derivative":     "Derivative",
 	"bucket_script":  "Bucket Script",
 	"raw_document":   "Raw Document",
+	"raw_data":       "Raw Data",
+	"logs":           "Logs",
+	"top_metrics":    "Top Metrics",
+	"rate":           "Rate",
+	"serial_diff":    "Serial Difference",
+	"min_bucket":     "Min Bucket",
+	"max_bucket":     "Max Bucket",
+	"avg_bucket":     "Average Bucket",
 }
 
 var extendedStats = map[string]string{
@@ -67,12 +81,37 @@ var pipelineAggType = map[string]string{
 	"cumulative_sum": "cumulative_sum",
 	"derivative":     "derivative",
 	"bucket_script":  "bucket_script",
+	"serial_diff":    "serial_diff",
+	"min_bucket":     "min_bucket",
+	"max_bucket":     "max_bucket",
+	"avg_bucket":     "avg_bucket",
+}
+
+// siblingPipelineAggType are the pipeline aggregations computing a single
+// value from the buckets of a sibling aggregation
+var siblingPipelineAggType = map[string]string{
+	"min_bucket": "min_bucket",
+	"max_bucket": "max_bucket",
+	"avg_bucket": "avg_bucket",
 }
 
 var pipelineAggWithMultipleBucketPathsType = map[string]string{
 	"bucket_script": "bucket_script",
 }
 
+// isDocumentQuery reports whether the query returns the matching documents
+// rather than aggregations.
+func isDocumentQuery(q *Query) bool {
+	if len(q.Metrics) == 0 {
+		return false
+	}
+	switch q.Metrics[0].Type {
+	case rawDocumentType, rawDataType, logsType:
+		return true
+	}
+	return false
+}
+
 func isPipelineAgg(metricType string) bool {
 	if _, ok := pipelineAggType[metricType]; ok {
 		return true
@@ -87,6 +126,46 @@ func isPipelineAggWithMultipleBucketPaths(metricType string) bool {
 	return false
 }
 
+func isSiblingPipelineAgg(metricType string) bool {
+	if _, ok := siblingPipelineAggType[metricType]; ok {
+		return true
+	}
+	return false
+}
+
+// compositeSource is a values source of a composite aggregation
+type compositeSource struct {
+	name       string
+	sourceType string
+	field      string
+	interval   string
+}
+
+// compositeSources returns the configured sources of a composite aggregation,
+// or a single terms source on the aggregation field.
+func compositeSources(bucketAgg *BucketAgg) []compositeSource {
+	sources := make([]compositeSource, 0)
+	for _, s := range bucketAgg.Settings.Get("sources").MustArray() {
+		sourceJSON := simplejson.NewFromAny(s)
+		field := sourceJSON.Get("field").MustString()
+		if field == "" {
+			continue
+		}
+		sources = append(sources, compositeSource{
+			name:       sourceJSON.Get("name").MustString(field),
+			sourceType: sourceJSON.Get("type").MustString(termsType),
+			field:      field,
+			interval:   sourceJSON.Get("interval").MustString(),
+		})
+	}
+
+	if len(sources) == 0 && bucketAgg.Field != "" {
+		sources = append(sources, compositeSource{name: bucketAgg.Field, sourceType: termsType, field: bucketAgg.Field})
+	}
+
+	return sources
+}
+
 func describeMetric(metricType, field string) string {
 	text := metricAggType[metricType]
 	if metricType == countType {
@@ -1,12 +1,16 @@
 package elasticsearch
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/null"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/tsdb"
@@ -18,12 +22,18 @@ const (
 	countType         = "count"
 	percentilesType   = "percentiles"
 	extendedStatsType = "extended_stats"
+	rawDocumentType   = "raw_document"
+	rawDataType       = "raw_data"
+	logsType          = "logs"
+	topMetricsType    = "top_metrics"
 	// Bucket types
 	dateHistType    = "date_histogram"
 	histogramType   = "histogram"
 	filtersType     = "filters"
 	termsType       = "terms"
 	geohashGridType = "geohash_grid"
+	dateRangeType   = "date_range"
+	compositeType   = "composite"
 )
 
 type responseParser struct {
@@ -64,6 +74,14 @@ func (rp *responseParser) getTimeSeries() (*tsdb.Response, error) {
 
 		queryRes := tsdb.NewQueryResult()
 		queryRes.Meta = debugInfo
+
+		if isDocumentQuery(target) {
+			frame := rp.processDocuments(res, target)
+			queryRes.Dataframes = tsdb.NewDecodedDataFrames(data.Frames{frame})
+			result.Results[target.RefID] = queryRes
+			continue
+		}
+
 		props := make(map[string]string)
 		table := tsdb.Table{
 			Columns: make([]tsdb.TableColumn, 0),
@@ -85,6 +103,230 @@ func (rp *responseParser) getTimeSeries() (*tsdb.Response, error) {
 	return result, nil
 }
 
+// processDocuments converts the hits of a document query into a frame with a
+// row per document. Nested objects of the document source are flattened into
+// dot separated field names. The total number of hits, the sort values of the
+// last document for fetching the next page and the highlighted search words
+// are returned as custom frame meta data.
+func (rp *responseParser) processDocuments(res *es.SearchResponse, target *Query) *data.Frame {
+	var hits []map[string]interface{}
+	var total int64
+	if res.Hits != nil {
+		hits = res.Hits.Hits
+		total = res.Hits.GetTotal()
+	}
+	isLogs := target.Metrics[0].Type == logsType
+
+	times := make([]*time.Time, len(hits))
+	docs := make([]map[string]interface{}, len(hits))
+	propNames := make(map[string]bool)
+	searchWords := make(map[string]bool)
+
+	for i, hit := range hits {
+		doc := map[string]interface{}{
+			"_id":    hit["_id"],
+			"_type":  hit["_type"],
+			"_index": hit["_index"],
+		}
+
+		source, _ := hit["_source"].(map[string]interface{})
+		flattenDocument("", source, doc)
+		if isLogs {
+			doc["_source"] = source
+		}
+
+		if highlight, ok := hit["highlight"].(map[string]interface{}); ok && len(highlight) > 0 {
+			doc["highlight"] = highlight
+			for _, fragments := range highlight {
+				list, _ := fragments.([]interface{})
+				for _, fragment := range list {
+					text, _ := fragment.(string)
+					for _, match := range highlightPattern.FindAllStringSubmatch(text, -1) {
+						searchWords[match[1]] = true
+					}
+				}
+			}
+		}
+
+		// the level field of the document, if any, is kept
+		if _, ok := doc["level"]; isLogs && target.LogLevelField != "" && !ok {
+			doc["level"] = doc[target.LogLevelField]
+		}
+
+		times[i] = documentTime(hit, doc, target.TimeField)
+		for name := range doc {
+			propNames[name] = true
+		}
+		docs[i] = doc
+	}
+
+	// the configured log fields come first, so that they are used as the log
+	// message and level
+	names := make([]string, 0, len(propNames))
+	if isLogs && target.LogMessageField != "" {
+		names = append(names, target.LogMessageField)
+	}
+	if isLogs && target.LogLevelField != "" {
+		names = append(names, "level")
+	}
+	sortedNames := make([]string, 0, len(propNames))
+	for name := range propNames {
+		if name != target.TimeField && name != target.LogMessageField && name != "level" {
+			sortedNames = append(sortedNames, name)
+		}
+	}
+	sort.Strings(sortedNames)
+	names = append(names, sortedNames...)
+
+	fields := data.Fields{data.NewField(target.TimeField, nil, times)}
+	for _, name := range names {
+		values := make([]interface{}, len(docs))
+		for i, doc := range docs {
+			values[i] = doc[name]
+		}
+		fields = append(fields, newDocumentField(name, values))
+	}
+
+	frame := data.NewFrame("", fields...)
+	frame.RefID = target.RefID
+
+	custom := map[string]interface{}{
+		"total": total,
+	}
+	if len(hits) > 0 {
+		if sortValues, ok := hits[len(hits)-1]["sort"].([]interface{}); ok {
+			custom["searchAfter"] = sortValues
+		}
+	}
+	if len(searchWords) > 0 {
+		words := make([]string, 0, len(searchWords))
+		for word := range searchWords {
+			words = append(words, word)
+		}
+		sort.Strings(words)
+		custom["searchWords"] = words
+	}
+	frame.Meta = &data.FrameMeta{Custom: custom}
+	if isLogs {
+		frame.Meta.PreferredVisualization = data.VisTypeLogs
+	}
+
+	return frame
+}
+
+var highlightPattern = regexp.MustCompile(regexp.QuoteMeta(es.HighlightPreTagsString) + "(.*?)" + regexp.QuoteMeta(es.HighlightPostTagsString))
+
+// flattenDocument adds the values of a document source to doc, with nested
+// objects flattened into dot separated names.
+func flattenDocument(prefix string, source map[string]interface{}, doc map[string]interface{}) {
+	for k, v := range source {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+
+		if nested, ok := v.(map[string]interface{}); ok {
+			flattenDocument(name, nested, doc)
+			continue
+		}
+		doc[name] = v
+	}
+}
+
+// documentTime returns the time of a document from the requested doc value
+// field, or else from the document source.
+func documentTime(hit map[string]interface{}, doc map[string]interface{}, timeField string) *time.Time {
+	var value interface{}
+	if fields, ok := hit["fields"].(map[string]interface{}); ok {
+		if values, ok := fields[timeField].([]interface{}); ok && len(values) > 0 {
+			value = values[0]
+		}
+	}
+	if value == nil {
+		value = doc[timeField]
+	}
+
+	switch v := value.(type) {
+	case float64:
+		t := time.Unix(0, int64(v*float64(time.Millisecond))).UTC()
+		return &t
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"} {
+			if t, err := time.ParseInLocation(layout, v, time.UTC); err == nil {
+				t = t.UTC()
+				return &t
+			}
+		}
+		if ms, err := strconv.ParseFloat(v, 64); err == nil {
+			t := time.Unix(0, int64(ms*float64(time.Millisecond))).UTC()
+			return &t
+		}
+	}
+
+	return nil
+}
+
+// newDocumentField creates a nullable float64 or bool field if all values are
+// numbers or booleans, or else a string field with objects and arrays encoded
+// as JSON.
+func newDocumentField(name string, values []interface{}) *data.Field {
+	allOfType := func(check func(v interface{}) bool) bool {
+		found := false
+		for _, v := range values {
+			if v == nil {
+				continue
+			}
+			if !check(v) {
+				return false
+			}
+			found = true
+		}
+		return found
+	}
+
+	switch {
+	case allOfType(func(v interface{}) bool { _, ok := v.(float64); return ok }):
+		field := make([]*float64, len(values))
+		for i, v := range values {
+			if f, ok := v.(float64); ok {
+				field[i] = &f
+			}
+		}
+		return data.NewField(name, nil, field)
+	case allOfType(func(v interface{}) bool { _, ok := v.(bool); return ok }):
+		field := make([]*bool, len(values))
+		for i, v := range values {
+			if b, ok := v.(bool); ok {
+				field[i] = &b
+			}
+		}
+		return data.NewField(name, nil, field)
+	}
+
+	field := make([]*string, len(values))
+	for i, v := range values {
+		var str string
+		switch value := v.(type) {
+		case nil:
+			continue
+		case string:
+			str = value
+		case float64:
+			str = strconv.FormatFloat(value, 'f', -1, 64)
+		case map[string]interface{}, []interface{}:
+			b, err := json.Marshal(value)
+			if err != nil {
+				continue
+			}
+			str = string(b)
+		default:
+			str = fmt.Sprintf("%v", value)
+		}
+		field[i] = &str
+	}
+	return data.NewField(name, nil, field)
+}
+
 func (rp *responseParser) processBuckets(aggs map[string]interface{}, target *Query, series *tsdb.TimeSeriesSlice, table *tsdb.Table, props map[string]string, depth int) error {
 	var err error
 	maxDepth := len(target.BucketAggs) - 1
@@ -105,8 +347,11 @@ func (rp *responseParser) processBuckets(aggs map[string]interface{}, target *Qu
 		if depth == maxDepth {
 			if aggDef.Type == dateHistType {
 				err = rp.processMetrics(esAgg, target, series, props)
+				if err == nil {
+					rp.processSiblingPipelineMetrics(aggs, esAgg, target, series, props)
+				}
 			} else {
-				err = rp.processAggregationDocs(esAgg, aggDef, target, table, props)
+				err = rp.processAggregationDocs(aggs, esAgg, aggDef, target, table, props)
 			}
 			if err != nil {
 				return err
@@ -129,6 +374,11 @@ func (rp *responseParser) processBuckets(aggs map[string]interface{}, target *Qu
 				if key, err := bucket.Get("key_as_string").String(); err == nil {
 					newProps[aggDef.Field] = key
 				}
+				if aggDef.Type == compositeType {
+					for name, value := range compositeKey(bucket) {
+						newProps[name] = value
+					}
+				}
 				err = rp.processBuckets(bucket.MustMap(), target, series, table, newProps, depth+1)
 				if err != nil {
 					return err
@@ -168,7 +418,30 @@ func (rp *responseParser) processMetrics(esAgg *simplejson.Json, target *Query,
 			continue
 		}
 
+		if isSiblingPipelineAgg(metric.Type) {
+			continue
+		}
+
 		switch metric.Type {
+		case topMetricsType:
+			for _, field := range metric.Settings.Get("metrics").MustStringArray() {
+				newSeries := tsdb.TimeSeries{
+					Tags: make(map[string]string),
+				}
+				for k, v := range props {
+					newSeries.Tags[k] = v
+				}
+				newSeries.Tags["metric"] = topMetricsType
+				newSeries.Tags["field"] = field
+
+				for _, v := range esAgg.Get("buckets").MustArray() {
+					bucket := simplejson.NewFromAny(v)
+					key := castToNullFloat(bucket.Get("key"))
+					value := castToNullFloat(bucket.GetPath(metric.ID, "top").GetIndex(0).GetPath("metrics", field))
+					newSeries.Points = append(newSeries.Points, tsdb.TimePoint{value, key})
+				}
+				*series = append(*series, &newSeries)
+			}
 		case countType:
 			newSeries := tsdb.TimeSeries{
 				Tags: make(map[string]string),
@@ -290,18 +563,82 @@ func (rp *responseParser) processMetrics(esAgg *simplejson.Json, target *Query,
 	return nil
 }
 
-func (rp *responseParser) processAggregationDocs(esAgg *simplejson.Json, aggDef *BucketAgg, target *Query, table *tsdb.Table, props map[string]string) error {
+// processSiblingPipelineMetrics adds a series for each sibling pipeline
+// aggregation of a date histogram. The single value of the aggregation is
+// repeated for every bucket of the histogram.
+func (rp *responseParser) processSiblingPipelineMetrics(aggs map[string]interface{}, esAgg *simplejson.Json, target *Query, series *tsdb.TimeSeriesSlice, props map[string]string) {
+	for _, metric := range target.Metrics {
+		if metric.Hide || !isSiblingPipelineAgg(metric.Type) {
+			continue
+		}
+
+		newSeries := tsdb.TimeSeries{
+			Tags: make(map[string]string),
+		}
+		for k, v := range props {
+			newSeries.Tags[k] = v
+		}
+		newSeries.Tags["metric"] = metric.Type
+		newSeries.Tags["field"] = metric.Field
+		newSeries.Tags["metricId"] = metric.ID
+
+		value := castToNullFloat(simplejson.NewFromAny(aggs[metric.ID]).Get("value"))
+		for _, v := range esAgg.Get("buckets").MustArray() {
+			bucket := simplejson.NewFromAny(v)
+			key := castToNullFloat(bucket.Get("key"))
+			newSeries.Points = append(newSeries.Points, tsdb.TimePoint{value, key})
+		}
+		*series = append(*series, &newSeries)
+	}
+}
+
+// compositeKey returns the values of the sources of a composite aggregation
+// bucket by source name.
+func compositeKey(bucket *simplejson.Json) map[string]string {
+	key := make(map[string]string)
+	for name, v := range bucket.Get("key").MustMap() {
+		switch value := v.(type) {
+		case string:
+			key[name] = value
+		case json.Number:
+			key[name] = value.String()
+		case float64:
+			key[name] = strconv.FormatFloat(value, 'f', -1, 64)
+		case nil:
+			key[name] = ""
+		default:
+			key[name] = fmt.Sprintf("%v", value)
+		}
+	}
+	return key
+}
+
+// processAggregationDocs adds a row for each bucket of the last bucket
+// aggregation to the table. The single value of a sibling pipeline
+// aggregation is repeated on every row.
+func (rp *responseParser) processAggregationDocs(aggs map[string]interface{}, esAgg *simplejson.Json, aggDef *BucketAgg, target *Query, table *tsdb.Table, props map[string]string) error {
 	propKeys := make([]string, 0)
 	for k := range props {
 		propKeys = append(propKeys, k)
 	}
 	sort.Strings(propKeys)
 
+	// the buckets of a composite aggregation have a key column per source
+	keyColumns := []string{aggDef.Field}
+	if aggDef.Type == compositeType {
+		keyColumns = keyColumns[:0]
+		for _, source := range compositeSources(aggDef) {
+			keyColumns = append(keyColumns, source.name)
+		}
+	}
+
 	if len(table.Columns) == 0 {
 		for _, propKey := range propKeys {
 			table.Columns = append(table.Columns, tsdb.TableColumn{Text: propKey})
 		}
-		table.Columns = append(table.Columns, tsdb.TableColumn{Text: aggDef.Field})
+		for _, keyColumn := range keyColumns {
+			table.Columns = append(table.Columns, tsdb.TableColumn{Text: keyColumn})
+		}
 	}
 
 	addMetricValue := func(values *tsdb.RowValues, metricName string, value null.Float) {
@@ -326,7 +663,12 @@ func (rp *responseParser) processAggregationDocs(esAgg *simplejson.Json, aggDef
 			values = append(values, props[propKey])
 		}
 
-		if key, err := bucket.Get("key").String(); err == nil {
+		if aggDef.Type == compositeType {
+			key := compositeKey(bucket)
+			for _, keyColumn := range keyColumns {
+				values = append(values, key[keyColumn])
+			}
+		} else if key, err := bucket.Get("key").String(); err == nil {
 			values = append(values, key)
 		} else {
 			values = append(values, castToNullFloat(bucket.Get("key")))
@@ -334,6 +676,11 @@ func (rp *responseParser) processAggregationDocs(esAgg *simplejson.Json, aggDef
 
 		for _, metric := range target.Metrics {
 			switch metric.Type {
+			case topMetricsType:
+				for _, field := range metric.Settings.Get("metrics").MustStringArray() {
+					value := castToNullFloat(bucket.GetPath(metric.ID, "top").GetIndex(0).GetPath("metrics", field))
+					addMetricValue(&values, rp.getMetricName(metric.Type)+" "+field, value)
+				}
 			case countType:
 				addMetricValue(&values, rp.getMetricName(metric.Type), castToNullFloat(bucket.Get("doc_count")))
 			case extendedStatsType:
@@ -380,7 +727,11 @@ func (rp *responseParser) processAggregationDocs(esAgg *simplejson.Json, aggDef
 					}
 				}
 
-				addMetricValue(&values, metricName, castToNullFloat(bucket.GetPath(metric.ID, "value")))
+				value := castToNullFloat(bucket.GetPath(metric.ID, "value"))
+				if isSiblingPipelineAgg(metric.Type) {
+					value = castToNullFloat(simplejson.NewFromAny(aggs[metric.ID]).Get("value"))
+				}
+				addMetricValue(&values, metricName, value)
 			}
 		}
 
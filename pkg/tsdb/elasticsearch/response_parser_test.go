@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/null"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
@@ -939,6 +940,166 @@ func TestResponseParser(t *testing.T) {
 			So(queryRes.Tables[0].Rows[1][3].(null.Float).Float64, ShouldEqual, 12)
 			So(queryRes.Tables[0].Rows[1][4].(null.Float).Float64, ShouldEqual, 48)
 		})
+		Convey("Raw data query", func() {
+			targets := map[string]string{
+				"A": `{
+					"timeField": "@timestamp",
+					"metrics": [{ "type": "raw_data", "id": "1" }]
+				}`,
+			}
+			response := `{
+				"responses": [
+					{
+						"hits": {
+							"total": { "value": 109, "relation": "eq" },
+							"hits": [
+								{
+									"_id": "1",
+									"_type": "_doc",
+									"_index": "index",
+									"_source": { "@timestamp": "2018-05-15T17:53:00.000Z", "host": { "name": "server-1" }, "cpu": 10, "tags": ["a"] },
+									"fields": { "@timestamp": ["2018-05-15T17:53:00.000Z"] },
+									"sort": [1526406780000, 4]
+								},
+								{
+									"_id": "2",
+									"_type": "_doc",
+									"_index": "index",
+									"_source": { "@timestamp": 1526406720000, "host": { "name": "server-2" }, "up": true },
+									"sort": [1526406720000, 3]
+								}
+							]
+						}
+					}
+				]
+			}`
+			rp, err := newResponseParserForTest(targets, response)
+			So(err, ShouldBeNil)
+			result, err := rp.getTimeSeries()
+			So(err, ShouldBeNil)
+			So(result.Results, ShouldHaveLength, 1)
+
+			queryRes := result.Results["A"]
+			So(queryRes, ShouldNotBeNil)
+			frames, err := queryRes.Dataframes.Decoded()
+			So(err, ShouldBeNil)
+			So(frames, ShouldHaveLength, 1)
+
+			frame := frames[0]
+			So(frame.RefID, ShouldEqual, "A")
+			So(frame.Rows(), ShouldEqual, 2)
+
+			names := []string{}
+			for _, f := range frame.Fields {
+				names = append(names, f.Name)
+			}
+			So(names, ShouldResemble, []string{"@timestamp", "_id", "_index", "_type", "cpu", "host.name", "tags", "up"})
+
+			So(*frame.Fields[0].At(0).(*time.Time), ShouldEqual, time.Date(2018, 5, 15, 17, 53, 0, 0, time.UTC))
+			So(*frame.Fields[0].At(1).(*time.Time), ShouldEqual, time.Date(2018, 5, 15, 17, 52, 0, 0, time.UTC))
+			So(*frame.Fields[4].At(0).(*float64), ShouldEqual, 10)
+			So(frame.Fields[4].At(1), ShouldBeNil)
+			So(*frame.Fields[5].At(1).(*string), ShouldEqual, "server-2")
+			So(*frame.Fields[6].At(0).(*string), ShouldEqual, `["a"]`)
+			So(*frame.Fields[7].At(1).(*bool), ShouldBeTrue)
+
+			So(frame.Meta.Custom, ShouldResemble, map[string]interface{}{
+				"total":       int64(109),
+				"searchAfter": []interface{}{float64(1526406720000), float64(3)},
+			})
+		})
+
+		Convey("Logs query", func() {
+			targets := map[string]string{
+				"A": `{
+					"timeField": "@timestamp",
+					"query": "error",
+					"metrics": [{ "type": "logs", "id": "1" }]
+				}`,
+			}
+			response := `{
+				"responses": [
+					{
+						"hits": {
+							"total": 1,
+							"hits": [
+								{
+									"_id": "1",
+									"_type": "_doc",
+									"_index": "index",
+									"_source": { "@timestamp": "2018-05-15T17:53:00.000Z", "msg": "an error occurred", "severity": "error" },
+									"highlight": { "msg": ["an @HIGHLIGHT@error@/HIGHLIGHT@ occurred"] }
+								}
+							]
+						}
+					}
+				]
+			}`
+			rp, err := newResponseParserForTest(targets, response)
+			So(err, ShouldBeNil)
+			rp.Targets[0].LogMessageField = "msg"
+			rp.Targets[0].LogLevelField = "severity"
+			result, err := rp.getTimeSeries()
+			So(err, ShouldBeNil)
+
+			frames, err := result.Results["A"].Dataframes.Decoded()
+			So(err, ShouldBeNil)
+			So(frames, ShouldHaveLength, 1)
+
+			frame := frames[0]
+			So(frame.Meta.PreferredVisualization, ShouldEqual, data.VisTypeLogs)
+			So(frame.Meta.Custom.(map[string]interface{})["searchWords"], ShouldResemble, []string{"error"})
+
+			names := []string{}
+			for _, f := range frame.Fields {
+				names = append(names, f.Name)
+			}
+			So(names, ShouldResemble, []string{"@timestamp", "msg", "level", "_id", "_index", "_source", "_type", "highlight", "severity"})
+			So(*frame.Fields[1].At(0).(*string), ShouldEqual, "an error occurred")
+			So(*frame.Fields[2].At(0).(*string), ShouldEqual, "error")
+			So(*frame.Fields[7].At(0).(*string), ShouldEqual, `{"msg":["an @HIGHLIGHT@error@/HIGHLIGHT@ occurred"]}`)
+		})
+
+		Convey("Logs query with a level field in the documents", func() {
+			targets := map[string]string{
+				"A": `{
+					"timeField": "@timestamp",
+					"metrics": [{ "type": "logs", "id": "1" }]
+				}`,
+			}
+			response := `{
+				"responses": [
+					{
+						"hits": {
+							"total": 1,
+							"hits": [
+								{
+									"_id": "1",
+									"_type": "_doc",
+									"_index": "index",
+									"_source": { "@timestamp": "2018-05-15T17:53:00.000Z", "msg": "disk full", "level": "critical", "severity": "error" }
+								}
+							]
+						}
+					}
+				]
+			}`
+			rp, err := newResponseParserForTest(targets, response)
+			So(err, ShouldBeNil)
+			rp.Targets[0].LogMessageField = "msg"
+			rp.Targets[0].LogLevelField = "severity"
+			result, err := rp.getTimeSeries()
+			So(err, ShouldBeNil)
+
+			frames, err := result.Results["A"].Dataframes.Decoded()
+			So(err, ShouldBeNil)
+			So(frames, ShouldHaveLength, 1)
+
+			levels := frames[0].Fields[2]
+			So(levels.Name, ShouldEqual, "level")
+			So(*levels.At(0).(*string), ShouldEqual, "critical")
+		})
+
 		// Convey("Raw documents query", func() {
 		// 	targets := map[string]string{
 		// 		"A": `{
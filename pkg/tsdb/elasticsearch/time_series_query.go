@@ -9,6 +9,17 @@ import (
 	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
 )
 
+const (
+	defaultDocumentSize  = 500
+	defaultCompositeSize = 500
+	// defaultCompositeMaxPages limits the number of requests made to fetch all
+	// buckets of a composite aggregation
+	defaultCompositeMaxPages = 10
+	// maxCompositePages is the highest maxPages setting of a composite
+	// aggregation
+	maxCompositePages = 100
+)
+
 type timeSeriesQuery struct {
 	client             es.Client
 	tsdbQuery          *tsdb.TsdbQuery
@@ -53,10 +64,81 @@ func (e *timeSeriesQuery) execute() (*tsdb.Response, error) {
 		return nil, err
 	}
 
+	for i, q := range queries {
+		if i < len(res.Responses) {
+			if err := e.fetchCompositePages(q, res.Responses[i], from, to); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	rp := newResponseParser(res.Responses, queries, res.DebugInfo)
 	return rp.getTimeSeries()
 }
 
+// fetchCompositePages fetches the following pages of a composite aggregation
+// until all buckets are fetched or the maxPages setting is reached, and
+// appends their buckets to the response of the first page.
+func (e *timeSeriesQuery) fetchCompositePages(q *Query, res *es.SearchResponse, from, to string) error {
+	if len(q.BucketAggs) == 0 || q.BucketAggs[0].Type != compositeType || res.Error != nil {
+		return nil
+	}
+
+	composite := q.BucketAggs[0]
+	agg, ok := res.Aggregations[composite.ID].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	defer composite.Settings.Del("after")
+
+	size := intSetting(composite.Settings, "size", defaultCompositeSize)
+	maxPages := intSetting(composite.Settings, "maxPages", defaultCompositeMaxPages)
+	if maxPages > maxCompositePages {
+		maxPages = maxCompositePages
+	}
+
+	page := agg
+	for pages := 1; pages < maxPages; pages++ {
+		afterKey, ok := page["after_key"].(map[string]interface{})
+		buckets, _ := page["buckets"].([]interface{})
+		if !ok || len(buckets) < size {
+			break
+		}
+
+		composite.Settings.Set("after", afterKey)
+		ms := e.client.MultiSearch()
+		if err := e.processQuery(q, ms, from, to, &tsdb.Response{Results: make(map[string]*tsdb.QueryResult)}); err != nil {
+			return err
+		}
+		req, err := ms.Build()
+		if err != nil {
+			return err
+		}
+		pageRes, err := e.client.ExecuteMultisearch(req)
+		if err != nil {
+			return err
+		}
+		if len(pageRes.Responses) == 0 {
+			break
+		}
+		if pageRes.Responses[0].Error != nil {
+			res.Error = pageRes.Responses[0].Error
+			return nil
+		}
+
+		page, ok = pageRes.Responses[0].Aggregations[composite.ID].(map[string]interface{})
+		if !ok {
+			break
+		}
+		nextBuckets, _ := page["buckets"].([]interface{})
+		allBuckets, _ := agg["buckets"].([]interface{})
+		agg["buckets"] = append(allBuckets, nextBuckets...)
+		agg["after_key"] = page["after_key"]
+	}
+
+	return nil
+}
+
 func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilder, from, to string,
 	result *tsdb.Response) error {
 	minInterval, err := e.client.GetMinInterval(q.Interval)
@@ -74,26 +156,31 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 		filters.AddQueryStringFilter(q.RawQuery, true)
 	}
 
+	if isDocumentQuery(q) {
+		return e.processDocumentQuery(q, b)
+	}
+
 	if len(q.BucketAggs) == 0 {
-		if len(q.Metrics) == 0 || q.Metrics[0].Type != "raw_document" {
-			result.Results[q.RefID] = &tsdb.QueryResult{
-				RefId:       q.RefID,
-				Error:       fmt.Errorf("invalid query, missing metrics and aggregations"),
-				ErrorString: "invalid query, missing metrics and aggregations",
-			}
-			return nil
+		result.Results[q.RefID] = &tsdb.QueryResult{
+			RefId:       q.RefID,
+			Error:       fmt.Errorf("invalid query, missing metrics and aggregations"),
+			ErrorString: "invalid query, missing metrics and aggregations",
 		}
-		metric := q.Metrics[0]
-		b.Size(metric.Settings.Get("size").MustInt(500))
-		b.SortDesc("@timestamp", "boolean")
-		b.AddDocValueField("@timestamp")
 		return nil
 	}
 
 	aggBuilder := b.Agg()
 
+	// sibling pipeline aggregations are added next to the last bucket
+	// aggregation, so keep track of the builder it was added to
+	parentBuilder := aggBuilder
+	lastBucketAggID := ""
+
 	// iterate backwards to create aggregations bottom-down
 	for _, bucketAgg := range q.BucketAggs {
+		parentBuilder = aggBuilder
+		lastBucketAggID = bucketAgg.ID
+
 		switch bucketAgg.Type {
 		case dateHistType:
 			aggBuilder = addDateHistogramAgg(aggBuilder, bucketAgg, from, to)
@@ -105,6 +192,10 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 			aggBuilder = addTermsAgg(aggBuilder, bucketAgg, q.Metrics)
 		case geohashGridType:
 			aggBuilder = addGeoHashGridAgg(aggBuilder, bucketAgg)
+		case dateRangeType:
+			aggBuilder = addDateRangeAgg(aggBuilder, bucketAgg)
+		case compositeType:
+			aggBuilder = addCompositeAgg(aggBuilder, bucketAgg)
 		}
 	}
 
@@ -158,7 +249,13 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 							bucketPath = "_count"
 						}
 
-						aggBuilder.Pipeline(m.ID, m.Type, bucketPath, func(a *es.PipelineAggregation) {
+						builder := aggBuilder
+						if isSiblingPipelineAgg(m.Type) {
+							bucketPath = lastBucketAggID + ">" + bucketPath
+							builder = parentBuilder
+						}
+
+						builder.Pipeline(m.ID, m.Type, bucketPath, func(a *es.PipelineAggregation) {
 							a.Settings = m.Settings.MustMap()
 						})
 					}
@@ -166,6 +263,8 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 					continue
 				}
 			}
+		} else if m.Type == topMetricsType {
+			addTopMetricsAgg(aggBuilder, m)
 		} else {
 			aggBuilder.Metric(m.ID, m.Type, m.Field, func(a *es.MetricAggregation) {
 				a.Settings = m.Settings.MustMap()
@@ -176,6 +275,64 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 	return nil
 }
 
+// processDocumentQuery builds a search for the documents matching a raw
+// document, raw data or logs query. Documents are sorted by time and then by
+// index order, so that the sort values of the last document can be passed as
+// searchAfter to fetch the next page.
+func (e *timeSeriesQuery) processDocumentQuery(q *Query, b *es.SearchRequestBuilder) error {
+	metric := q.Metrics[0]
+	fields := e.client.GetConfiguredFields()
+	q.TimeField = fields.TimeField
+
+	sizeSetting := "size"
+	if metric.Type == logsType {
+		sizeSetting = "limit"
+	}
+	b.Size(intSetting(metric.Settings, sizeSetting, defaultDocumentSize))
+
+	order := metric.Settings.Get("order").MustString(es.SortOrderDesc)
+	if order != es.SortOrderAsc && order != es.SortOrderDesc {
+		return fmt.Errorf("invalid sort order %q", order)
+	}
+	b.Sort(order, fields.TimeField, "boolean")
+	b.Sort(order, "_doc", "")
+	b.AddDocValueField(fields.TimeField)
+
+	if len(q.SearchAfter) > 0 {
+		b.SearchAfter(q.SearchAfter)
+	}
+
+	if metric.Type == logsType {
+		q.LogMessageField = fields.LogMessageField
+		q.LogLevelField = fields.LogLevelField
+
+		if q.RawQuery != "" {
+			b.AddHighlight()
+		}
+	}
+
+	return nil
+}
+
+// intSetting returns a setting that is either a number or a string holding a
+// number, or defaultValue if it is missing, invalid or zero.
+func intSetting(settings *simplejson.Json, key string, defaultValue int) int {
+	value, err := settings.Get(key).Int()
+	if err != nil {
+		str, err := settings.Get(key).String()
+		if err != nil {
+			return defaultValue
+		}
+		if value, err = strconv.Atoi(str); err != nil {
+			return defaultValue
+		}
+	}
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
+
 func addDateHistogramAgg(aggBuilder es.AggBuilder, bucketAgg *BucketAgg, timeFrom, timeTo string) es.AggBuilder {
 	aggBuilder.DateHistogram(bucketAgg.ID, bucketAgg.Field, func(a *es.DateHistogramAgg, b es.AggBuilder) {
 		a.Interval = bucketAgg.Settings.Get("interval").MustString("auto")
@@ -289,6 +446,74 @@ func addGeoHashGridAgg(aggBuilder es.AggBuilder, bucketAgg *BucketAgg) es.AggBui
 	return aggBuilder
 }
 
+func addDateRangeAgg(aggBuilder es.AggBuilder, bucketAgg *BucketAgg) es.AggBuilder {
+	aggBuilder.DateRange(bucketAgg.ID, bucketAgg.Field, func(a *es.DateRangeAggregation, b es.AggBuilder) {
+		a.Format = bucketAgg.Settings.Get("format").MustString(es.DateFormatEpochMS)
+
+		for _, r := range bucketAgg.Settings.Get("ranges").MustArray() {
+			rangeJSON := simplejson.NewFromAny(r)
+			dateRange := make(map[string]interface{})
+			for _, key := range []string{"key", "from", "to"} {
+				if value, err := rangeJSON.Get(key).String(); err == nil && value != "" {
+					dateRange[key] = value
+				}
+			}
+			a.Ranges = append(a.Ranges, dateRange)
+		}
+
+		aggBuilder = b
+	})
+
+	return aggBuilder
+}
+
+// addCompositeAgg adds a composite aggregation with a source per configured
+// source, or a single terms source on the aggregation field. The "after"
+// setting is set when fetching the following pages of buckets.
+func addCompositeAgg(aggBuilder es.AggBuilder, bucketAgg *BucketAgg) es.AggBuilder {
+	aggBuilder.Composite(bucketAgg.ID, func(a *es.CompositeAggregation, b es.AggBuilder) {
+		a.Size = intSetting(bucketAgg.Settings, "size", defaultCompositeSize)
+
+		for _, source := range compositeSources(bucketAgg) {
+			values := map[string]interface{}{
+				"field": source.field,
+			}
+			if source.interval != "" {
+				values["interval"] = source.interval
+			}
+			a.Sources = append(a.Sources, map[string]interface{}{
+				source.name: map[string]interface{}{source.sourceType: values},
+			})
+		}
+
+		if after, err := bucketAgg.Settings.Get("after").Map(); err == nil {
+			a.After = after
+		}
+
+		aggBuilder = b
+	})
+
+	return aggBuilder
+}
+
+// addTopMetricsAgg adds a top_metrics aggregation returning the values of the
+// configured fields of the first document ordered by the orderBy setting.
+func addTopMetricsAgg(aggBuilder es.AggBuilder, m *MetricAgg) {
+	metrics := make([]map[string]interface{}, 0)
+	for _, field := range m.Settings.Get("metrics").MustStringArray() {
+		metrics = append(metrics, map[string]interface{}{"field": field})
+	}
+	orderBy := m.Settings.Get("orderBy").MustString("@timestamp")
+	order := m.Settings.Get("order").MustString(es.SortOrderDesc)
+
+	aggBuilder.Metric(m.ID, m.Type, "", func(a *es.MetricAggregation) {
+		a.Settings = map[string]interface{}{
+			"metrics": metrics,
+			"sort":    []map[string]interface{}{{orderBy: order}},
+		}
+	})
+}
+
 type timeSeriesQueryParser struct{}
 
 func newTimeSeriesQueryParser() *timeSeriesQueryParser {
@@ -313,16 +538,18 @@ func (p *timeSeriesQueryParser) parse(tsdbQuery *tsdb.TsdbQuery) ([]*Query, erro
 			return nil, err
 		}
 		alias := model.Get("alias").MustString("")
+		searchAfter := model.Get("searchAfter").MustArray()
 		interval := strconv.FormatInt(q.IntervalMs, 10) + "ms"
 
 		queries = append(queries, &Query{
-			TimeField:  timeField,
-			RawQuery:   rawQuery,
-			BucketAggs: bucketAggs,
-			Metrics:    metrics,
-			Alias:      alias,
-			Interval:   interval,
-			RefID:      q.RefId,
+			TimeField:   timeField,
+			RawQuery:    rawQuery,
+			BucketAggs:  bucketAggs,
+			Metrics:     metrics,
+			Alias:       alias,
+			SearchAfter: searchAfter,
+			Interval:    interval,
+			RefID:       q.RefId,
 		})
 	}
 
@@ -1,6 +1,7 @@
 package elasticsearch
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -302,6 +303,53 @@ func TestExecuteTimeSeriesQuery(t *testing.T) {
 			So(sr.Size, ShouldEqual, 1337)
 		})
 
+		Convey("With raw data metric", func() {
+			c := newFakeClient(70)
+			_, err := executeTsdbQuery(c, `{
+				"timeField": "@timestamp",
+				"bucketAggs": [],
+				"searchAfter": [1526406600000, 3],
+				"metrics": [{ "id": "1", "type": "raw_data", "settings": { "size": "100", "order": "asc" } }]
+			}`, from, to, 15*time.Second)
+			So(err, ShouldBeNil)
+			sr := c.multisearchRequests[0].Requests[0]
+
+			So(sr.Size, ShouldEqual, 100)
+			So(sr.Sort, ShouldHaveLength, 2)
+			So(sr.Sort[0]["@timestamp"], ShouldResemble, map[string]string{"order": "asc", "unmapped_type": "boolean"})
+			So(sr.Sort[1]["_doc"], ShouldResemble, map[string]string{"order": "asc"})
+			So(sr.CustomProps["search_after"], ShouldResemble, []interface{}{json.Number("1526406600000"), json.Number("3")})
+			So(sr.CustomProps["docvalue_fields"], ShouldResemble, []string{"@timestamp"})
+			So(sr.CustomProps["highlight"], ShouldBeNil)
+		})
+
+		Convey("With raw data metric and invalid sort order", func() {
+			c := newFakeClient(70)
+			_, err := executeTsdbQuery(c, `{
+				"timeField": "@timestamp",
+				"bucketAggs": [],
+				"metrics": [{ "id": "1", "type": "raw_data", "settings": { "order": "up" } }]
+			}`, from, to, 15*time.Second)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("With logs metric", func() {
+			c := newFakeClient(70)
+			_, err := executeTsdbQuery(c, `{
+				"timeField": "@timestamp",
+				"query": "error",
+				"bucketAggs": [],
+				"metrics": [{ "id": "1", "type": "logs", "settings": { "limit": 10 } }]
+			}`, from, to, 15*time.Second)
+			So(err, ShouldBeNil)
+			sr := c.multisearchRequests[0].Requests[0]
+
+			So(sr.Size, ShouldEqual, 10)
+			So(sr.Sort[0]["@timestamp"], ShouldResemble, map[string]string{"order": "desc", "unmapped_type": "boolean"})
+			So(sr.CustomProps["search_after"], ShouldBeNil)
+			So(sr.CustomProps["highlight"], ShouldNotBeNil)
+		})
+
 		Convey("With date histogram agg", func() {
 			c := newFakeClient(5)
 			_, err := executeTsdbQuery(c, `{
@@ -726,6 +774,8 @@ func TestExecuteTimeSeriesQuery(t *testing.T) {
 type fakeClient struct {
 	version             int
 	timeField           string
+	logMessageField     string
+	logLevelField       string
 	multiSearchResponse *es.MultiSearchResponse
 	multiSearchError    error
 	builder             *es.MultiSearchRequestBuilder
@@ -751,6 +801,14 @@ func (c *fakeClient) GetTimeField() string {
 	return c.timeField
 }
 
+func (c *fakeClient) GetConfiguredFields() es.ConfiguredFields {
+	return es.ConfiguredFields{
+		TimeField:       c.timeField,
+		LogMessageField: c.logMessageField,
+		LogLevelField:   c.logLevelField,
+	}
+}
+
 func (c *fakeClient) GetMinInterval(queryInterval string) (time.Duration, error) {
 	return 15 * time.Second, nil
 }
@@ -0,0 +1,69 @@
+package filedatasource
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const maxCachedFiles = 100
+
+// cachedFile is a parsed file together with the attributes used to detect
+// whether the file changed: modification time and size for local files, the
+// ETag and Last-Modified headers for files served over HTTP.
+type cachedFile struct {
+	modTime      time.Time
+	size         int64
+	etag         string
+	lastModified string
+	frame        *data.Frame
+	lastUsed     time.Time
+}
+
+// fileCache caches parsed files by their path or URL. Cached frames are
+// shared between queries and must not be modified.
+type fileCache struct {
+	sync.Mutex
+	files map[string]*cachedFile
+	now   func() time.Time
+}
+
+func newFileCache() *fileCache {
+	return &fileCache{
+		files: make(map[string]*cachedFile),
+		now:   time.Now,
+	}
+}
+
+func (c *fileCache) get(key string) (*cachedFile, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	file, ok := c.files[key]
+	if ok {
+		file.lastUsed = c.now()
+	}
+	return file, ok
+}
+
+// set adds a file to the cache, evicting the least recently used file when
+// the cache is full.
+func (c *fileCache) set(key string, file *cachedFile) {
+	c.Lock()
+	defer c.Unlock()
+
+	if _, exists := c.files[key]; !exists && len(c.files) >= maxCachedFiles {
+		var oldestKey string
+		var oldest time.Time
+		for k, f := range c.files {
+			if oldestKey == "" || f.lastUsed.Before(oldest) {
+				oldestKey, oldest = k, f.lastUsed
+			}
+		}
+		delete(c.files, oldestKey)
+	}
+
+	file.lastUsed = c.now()
+	c.files[key] = file
+}
@@ -0,0 +1,490 @@
+package filedatasource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/util"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// maxFileSize is the maximum size of a file that is parsed, larger files are rejected.
+const maxFileSize = 64 << 20
+
+var (
+	flog  log.Logger
+	cache = newFileCache()
+
+	errNoPath          = errors.New("query is missing the file path")
+	errFileTooLarge    = fmt.Errorf("file exceeds the maximum size of %d bytes", maxFileSize)
+	errNoAllowedPaths  = errors.New("reading local files is disabled, configure allowed_paths in the [datasources.file] section")
+	errNoTimeColumn    = errors.New("no time column found, use the table format or configure the time column")
+	errUnknownFormat   = errors.New("unknown file format, supported formats are csv, tsv, json and ndjson")
+	errInvalidFileType = errors.New("path is not a regular file")
+)
+
+func init() {
+	flog = log.New("tsdb.file")
+	tsdb.RegisterTsdbQueryEndpoint("file", NewFileExecutor)
+}
+
+// FileExecutor queries CSV, JSON and newline delimited JSON files that are
+// either stored on the Grafana server or served over HTTP.
+//
+// Files are read from the server when the data source has no URL, otherwise
+// the query path is resolved against the data source URL.
+type FileExecutor struct{}
+
+func NewFileExecutor(datasource *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+	return &FileExecutor{}, nil
+}
+
+type fileQuery struct {
+	RefID        string
+	Path         string
+	FileFormat   string
+	Delimiter    rune
+	TimeColumn   string
+	LabelColumns []string
+	Format       string
+}
+
+func parseQuery(query *tsdb.Query) (*fileQuery, error) {
+	model := query.Model
+
+	q := &fileQuery{
+		RefID:      query.RefId,
+		Path:       strings.TrimSpace(model.Get("path").MustString()),
+		FileFormat: strings.ToLower(model.Get("fileFormat").MustString()),
+		TimeColumn: model.Get("timeColumn").MustString(),
+		Format:     model.Get("format").MustString("time_series"),
+	}
+
+	if q.Path == "" {
+		return nil, errNoPath
+	}
+
+	for _, label := range model.Get("labelColumns").MustStringArray() {
+		if label = strings.TrimSpace(label); label != "" {
+			q.LabelColumns = append(q.LabelColumns, label)
+		}
+	}
+
+	if q.FileFormat == "" {
+		q.FileFormat = formatFromExtension(q.Path)
+	}
+
+	q.Delimiter = ','
+	if q.FileFormat == "tsv" {
+		q.Delimiter = '\t'
+	}
+	if delimiter := model.Get("delimiter").MustString(); delimiter != "" {
+		r, size := utf8.DecodeRuneInString(delimiter)
+		if size != len(delimiter) {
+			return nil, fmt.Errorf("delimiter must be a single character, got %q", delimiter)
+		}
+		q.Delimiter = r
+	}
+
+	return q, nil
+}
+
+func formatFromExtension(p string) string {
+	if u, err := url.Parse(p); err == nil {
+		p = u.Path
+	}
+
+	switch strings.ToLower(path.Ext(p)) {
+	case ".json":
+		return "json"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	case ".tsv":
+		return "tsv"
+	default:
+		return "csv"
+	}
+}
+
+func (e *FileExecutor) Query(ctx context.Context, dsInfo *models.DataSource, tsdbQuery *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	result := &tsdb.Response{
+		Results: make(map[string]*tsdb.QueryResult),
+	}
+
+	for _, query := range tsdbQuery.Queries {
+		queryResult := &tsdb.QueryResult{RefId: query.RefId}
+		result.Results[query.RefId] = queryResult
+
+		q, err := parseQuery(query)
+		if err != nil {
+			queryResult.Error = err
+			continue
+		}
+
+		frames, err := e.executeQuery(ctx, dsInfo, q, tsdbQuery.TimeRange)
+		if err != nil {
+			queryResult.Error = err
+			continue
+		}
+
+		queryResult.Dataframes = tsdb.NewDecodedDataFrames(frames)
+	}
+
+	return result, nil
+}
+
+func (e *FileExecutor) executeQuery(ctx context.Context, dsInfo *models.DataSource, q *fileQuery, timeRange *tsdb.TimeRange) (data.Frames, error) {
+	var frame *data.Frame
+	var err error
+	if dsInfo.Url == "" {
+		frame, err = loadLocalFile(dsInfo, q)
+	} else {
+		frame, err = loadHTTPFile(ctx, dsInfo, q)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	timeIndex := -1
+	for i, field := range frame.Fields {
+		if field.Type() == data.FieldTypeNullableTime {
+			timeIndex = i
+			break
+		}
+	}
+
+	if timeIndex >= 0 && timeRange != nil {
+		frame, err = filterTimeRange(frame, timeIndex, timeRange)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if q.Format == "table" {
+		// the frame may be shared with the cache, so set the RefID on a copy
+		table := data.NewFrame("", frame.Fields...)
+		table.RefID = q.RefID
+		return data.Frames{table}, nil
+	}
+
+	if timeIndex < 0 {
+		return nil, errNoTimeColumn
+	}
+
+	frames, err := toTimeSeriesFrames(frame, timeIndex, q.LabelColumns)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range frames {
+		f.RefID = q.RefID
+	}
+
+	return frames, nil
+}
+
+// cacheKey identifies a parsed file in the cache. The data source is part of
+// the key so that files fetched with different credentials are never shared,
+// the parsing options since they change the parsed frame.
+func cacheKey(dsInfo *models.DataSource, location string, q *fileQuery) string {
+	return fmt.Sprintf("%d|%s|%s|%c|%s", dsInfo.Id, location, q.FileFormat, q.Delimiter, q.TimeColumn)
+}
+
+func loadLocalFile(dsInfo *models.DataSource, q *fileQuery) (*data.Frame, error) {
+	if len(setting.FileDataSourceAllowedPaths) == 0 {
+		return nil, errNoAllowedPaths
+	}
+
+	p := q.Path
+	if !filepath.IsAbs(p) {
+		if dir := dsInfo.JsonData.Get("directory").MustString(); dir != "" {
+			p = filepath.Join(dir, p)
+		}
+	}
+
+	resolved, err := util.ResolvePathInDirs(p, setting.FileDataSourceAllowedPaths)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file %q does not exist", q.Path)
+		}
+		return nil, err
+	}
+
+	f, err := os.Open(resolved)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			flog.Warn("Failed to close file", "path", resolved, "err", err)
+		}
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, errInvalidFileType
+	}
+	if info.Size() > maxFileSize {
+		return nil, errFileTooLarge
+	}
+
+	key := cacheKey(dsInfo, resolved, q)
+	if cached, ok := cache.get(key); ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.frame, nil
+	}
+
+	frame, err := parseFile(f, q)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.set(key, &cachedFile{modTime: info.ModTime(), size: info.Size(), frame: frame})
+	return frame, nil
+}
+
+func loadHTTPFile(ctx context.Context, dsInfo *models.DataSource, q *fileQuery) (*data.Frame, error) {
+	u, err := url.Parse(dsInfo.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := url.Parse(q.Path)
+	if err != nil {
+		return nil, err
+	}
+	if ref.IsAbs() || ref.Host != "" {
+		return nil, errors.New("query path must be relative to the data source URL")
+	}
+
+	basePath := strings.TrimSuffix(u.Path, "/")
+	u.Path = path.Join("/", basePath, ref.Path)
+	if basePath != "" && !strings.HasPrefix(u.Path, basePath+"/") {
+		return nil, errors.New("query path must not escape the data source URL")
+	}
+	u.RawQuery = ref.RawQuery
+	location := u.String()
+
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	if dsInfo.BasicAuth {
+		req.SetBasicAuth(dsInfo.BasicAuthUser, dsInfo.DecryptedBasicAuthPassword())
+	}
+
+	key := cacheKey(dsInfo, location, q)
+	cached, isCached := cache.get(key)
+	if isCached {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := ctxhttp.Do(ctx, httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			flog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if res.StatusCode == http.StatusNotModified && isCached {
+		return cached.frame, nil
+	}
+
+	if res.StatusCode/100 != 2 {
+		flog.Info("Request failed", "status", res.Status, "url", location)
+		return nil, fmt.Errorf("request failed, status: %s", res.Status)
+	}
+
+	if res.ContentLength > maxFileSize {
+		return nil, errFileTooLarge
+	}
+
+	frame, err := parseFile(res.Body, q)
+	if err != nil {
+		return nil, err
+	}
+
+	// only responses that can be revalidated are cached
+	etag, lastModified := res.Header.Get("ETag"), res.Header.Get("Last-Modified")
+	if etag != "" || lastModified != "" {
+		cache.set(key, &cachedFile{etag: etag, lastModified: lastModified, frame: frame})
+	}
+
+	return frame, nil
+}
+
+func parseFile(r io.Reader, q *fileQuery) (*data.Frame, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r, maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxFileSize {
+		return nil, errFileTooLarge
+	}
+
+	var table *rawTable
+	switch q.FileFormat {
+	case "csv", "tsv":
+		table, err = parseCSV(bytes.NewReader(body), q.Delimiter)
+	case "json", "ndjson":
+		table, err = parseJSON(bytes.NewReader(body))
+	default:
+		return nil, errUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toFrame(table, q.TimeColumn)
+}
+
+func filterTimeRange(frame *data.Frame, timeIndex int, timeRange *tsdb.TimeRange) (*data.Frame, error) {
+	from, err := timeRange.ParseFrom()
+	if err != nil {
+		return nil, err
+	}
+	to, err := timeRange.ParseTo()
+	if err != nil {
+		return nil, err
+	}
+
+	return frame.FilterRowsByField(timeIndex, func(i interface{}) (bool, error) {
+		t, ok := i.(*time.Time)
+		if !ok || t == nil {
+			return false, nil
+		}
+		return !t.Before(from) && !t.After(to), nil
+	})
+}
+
+// toTimeSeriesFrames splits a frame into one frame per distinct combination of
+// label column values. Label columns become labels on the numeric fields,
+// remaining string and bool fields are dropped. When no label columns are
+// configured all string fields are used as label columns.
+func toTimeSeriesFrames(frame *data.Frame, timeIndex int, labelColumns []string) (data.Frames, error) {
+	var labelIndices, valueIndices []int
+
+	if len(labelColumns) == 0 {
+		for i, field := range frame.Fields {
+			if field.Type() == data.FieldTypeNullableString {
+				labelIndices = append(labelIndices, i)
+			}
+		}
+	} else {
+		for _, name := range labelColumns {
+			idx := -1
+			for i, field := range frame.Fields {
+				if field.Name == name {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				return nil, fmt.Errorf("label column %q not found", name)
+			}
+			labelIndices = append(labelIndices, idx)
+		}
+	}
+
+	for i, field := range frame.Fields {
+		if i != timeIndex && field.Type() == data.FieldTypeNullableFloat64 && !containsInt(labelIndices, i) {
+			valueIndices = append(valueIndices, i)
+		}
+	}
+
+	if len(labelIndices) == 0 {
+		fields := []*data.Field{frame.Fields[timeIndex]}
+		for _, i := range valueIndices {
+			fields = append(fields, frame.Fields[i])
+		}
+		return data.Frames{data.NewFrame("", fields...)}, nil
+	}
+
+	type series struct {
+		labels data.Labels
+		frame  *data.Frame
+	}
+
+	var ordered []*series
+	byKey := map[string]*series{}
+
+	for row := 0; row < frame.Rows(); row++ {
+		labels := data.Labels{}
+		for _, i := range labelIndices {
+			labels[frame.Fields[i].Name] = labelValue(frame.Fields[i], row)
+		}
+
+		key := labels.String()
+		s, ok := byKey[key]
+		if !ok {
+			fields := []*data.Field{data.NewField(frame.Fields[timeIndex].Name, nil, []*time.Time{})}
+			for _, i := range valueIndices {
+				fields = append(fields, data.NewField(frame.Fields[i].Name, labels, []*float64{}))
+			}
+			s = &series{labels: labels, frame: data.NewFrame("", fields...)}
+			byKey[key] = s
+			ordered = append(ordered, s)
+		}
+
+		values := []interface{}{frame.Fields[timeIndex].At(row)}
+		for _, i := range valueIndices {
+			values = append(values, frame.Fields[i].At(row))
+		}
+		s.frame.AppendRow(values...)
+	}
+
+	frames := make(data.Frames, 0, len(ordered))
+	for _, s := range ordered {
+		frames = append(frames, s.frame)
+	}
+
+	return frames, nil
+}
+
+func labelValue(field *data.Field, row int) string {
+	v, ok := field.ConcreteAt(row)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func containsInt(s []int, v int) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
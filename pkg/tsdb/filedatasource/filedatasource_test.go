@@ -0,0 +1,239 @@
+package filedatasource
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCSV = `time,host,dc,cpu
+2020-01-01T00:00:00Z,a,eu,1
+2020-01-01T00:00:00Z,b,us,10
+2020-01-01T00:01:00Z,a,eu,2
+2020-01-01T00:01:00Z,b,us,20
+2020-01-01T00:02:00Z,a,eu,3
+2020-01-01T00:02:00Z,b,us,30
+`
+
+var testStart = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestTimeRange(from, to time.Time) *tsdb.TimeRange {
+	return tsdb.NewTimeRange(strconv.FormatInt(from.UnixNano()/1e6, 10), strconv.FormatInt(to.UnixNano()/1e6, 10))
+}
+
+func executeTestQuery(t *testing.T, ds *models.DataSource, model map[string]interface{}, timeRange *tsdb.TimeRange) (data.Frames, error) {
+	t.Helper()
+
+	executor := &FileExecutor{}
+	resp, err := executor.Query(context.Background(), ds, &tsdb.TsdbQuery{
+		TimeRange: timeRange,
+		Queries: []*tsdb.Query{
+			{RefId: "A", Model: simplejson.NewFromAny(model), DataSource: ds},
+		},
+	})
+	require.NoError(t, err)
+
+	res := resp.Results["A"]
+	require.NotNil(t, res)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	return res.Dataframes.Decoded()
+}
+
+func TestFileExecutorLocalFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grafana-file-ds")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	origAllowedPaths := setting.FileDataSourceAllowedPaths
+	setting.FileDataSourceAllowedPaths = []string{dir}
+	t.Cleanup(func() { setting.FileDataSourceAllowedPaths = origAllowedPaths })
+
+	csvPath := filepath.Join(dir, "cpu.csv")
+	require.NoError(t, ioutil.WriteFile(csvPath, []byte(testCSV), 0600))
+
+	ds := &models.DataSource{Id: 1, JsonData: simplejson.NewFromAny(map[string]interface{}{"directory": dir})}
+	fullRange := newTestTimeRange(testStart, testStart.Add(time.Hour))
+
+	t.Run("time series with label columns", func(t *testing.T) {
+		frames, err := executeTestQuery(t, ds, map[string]interface{}{
+			"path":         "cpu.csv",
+			"labelColumns": []interface{}{"host"},
+		}, fullRange)
+		require.NoError(t, err)
+
+		require.Len(t, frames, 2)
+		assert.Equal(t, "A", frames[0].RefID)
+		require.Len(t, frames[0].Fields, 2)
+		assert.Equal(t, "cpu", frames[0].Fields[1].Name)
+		assert.Equal(t, data.Labels{"host": "a"}, frames[0].Fields[1].Labels)
+		assert.Equal(t, data.Labels{"host": "b"}, frames[1].Fields[1].Labels)
+		assert.Equal(t, 3, frames[1].Rows())
+		assert.Equal(t, 30.0, *frames[1].Fields[1].At(2).(*float64))
+
+		// frames can be used by alerting
+		series, err := tsdb.FrameToSeriesSlice(frames[1])
+		require.NoError(t, err)
+		require.Len(t, series, 1)
+		assert.Equal(t, map[string]string{"host": "b"}, series[0].Tags)
+	})
+
+	t.Run("string columns are labels by default", func(t *testing.T) {
+		frames, err := executeTestQuery(t, ds, map[string]interface{}{"path": csvPath}, fullRange)
+		require.NoError(t, err)
+
+		require.Len(t, frames, 2)
+		assert.Equal(t, data.Labels{"host": "a", "dc": "eu"}, frames[0].Fields[1].Labels)
+	})
+
+	t.Run("time range filtering", func(t *testing.T) {
+		frames, err := executeTestQuery(t, ds, map[string]interface{}{
+			"path":   "cpu.csv",
+			"format": "table",
+		}, newTestTimeRange(testStart.Add(time.Minute), testStart.Add(2*time.Minute)))
+		require.NoError(t, err)
+
+		require.Len(t, frames, 1)
+		require.Len(t, frames[0].Fields, 4)
+		assert.Equal(t, 4, frames[0].Rows())
+		assert.Equal(t, testStart.Add(time.Minute), *frames[0].Fields[0].At(0).(*time.Time))
+	})
+
+	t.Run("newline delimited JSON", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "events.ndjson"), []byte(
+			`{"ts": "2020-01-01T00:00:30Z", "latency": 12.5, "service": {"name": "api"}}
+{"ts": "2020-01-01T00:01:30Z", "latency": 7, "service": {"name": "api"}}
+`), 0600))
+
+		frames, err := executeTestQuery(t, ds, map[string]interface{}{"path": "events.ndjson"}, fullRange)
+		require.NoError(t, err)
+
+		require.Len(t, frames, 1)
+		assert.Equal(t, "ts", frames[0].Fields[0].Name)
+		assert.Equal(t, "latency", frames[0].Fields[1].Name)
+		assert.Equal(t, data.Labels{"service.name": "api"}, frames[0].Fields[1].Labels)
+		assert.Equal(t, 2, frames[0].Rows())
+	})
+
+	t.Run("parsed files are cached until the file changes", func(t *testing.T) {
+		path := filepath.Join(dir, "cached.csv")
+		require.NoError(t, ioutil.WriteFile(path, []byte("time,v\n2020-01-01T00:00:00Z,1\n"), 0600))
+
+		query := map[string]interface{}{"path": "cached.csv", "format": "table"}
+		_, err := executeTestQuery(t, ds, query, fullRange)
+		require.NoError(t, err)
+
+		resolved, err := filepath.EvalSymlinks(path)
+		require.NoError(t, err)
+		q, err := parseQuery(&tsdb.Query{Model: simplejson.NewFromAny(query)})
+		require.NoError(t, err)
+		key := cacheKey(ds, resolved, q)
+		cached, ok := cache.get(key)
+		require.True(t, ok)
+
+		_, err = executeTestQuery(t, ds, query, fullRange)
+		require.NoError(t, err)
+		unchanged, ok := cache.get(key)
+		require.True(t, ok)
+		assert.Same(t, cached, unchanged)
+
+		require.NoError(t, ioutil.WriteFile(path, []byte("time,v\n2020-01-01T00:00:00Z,1\n2020-01-01T00:01:00Z,2\n"), 0600))
+		modTime := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+
+		frames, err := executeTestQuery(t, ds, query, fullRange)
+		require.NoError(t, err)
+		assert.Equal(t, 2, frames[0].Rows())
+		changed, ok := cache.get(key)
+		require.True(t, ok)
+		assert.NotSame(t, cached, changed)
+	})
+
+	t.Run("files outside of the allowed paths are rejected", func(t *testing.T) {
+		_, err := executeTestQuery(t, ds, map[string]interface{}{"path": "../../etc/passwd"}, fullRange)
+		require.Error(t, err)
+	})
+
+	t.Run("time series without time column fails", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "hosts.csv"), []byte("host,cores\na,4\n"), 0600))
+
+		_, err := executeTestQuery(t, ds, map[string]interface{}{"path": "hosts.csv"}, fullRange)
+		require.Equal(t, errNoTimeColumn, err)
+
+		frames, err := executeTestQuery(t, ds, map[string]interface{}{"path": "hosts.csv", "format": "table"}, fullRange)
+		require.NoError(t, err)
+		assert.Equal(t, 1, frames[0].Rows())
+	})
+
+	t.Run("local files are disabled without allowed paths", func(t *testing.T) {
+		setting.FileDataSourceAllowedPaths = nil
+		defer func() { setting.FileDataSourceAllowedPaths = []string{dir} }()
+
+		_, err := executeTestQuery(t, ds, map[string]interface{}{"path": "cpu.csv"}, fullRange)
+		require.Equal(t, errNoAllowedPaths, err)
+	})
+}
+
+func TestFileExecutorHTTPFiles(t *testing.T) {
+	requests, notModified := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.URL.Path != "/files/cpu.csv" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, err := w.Write([]byte(testCSV))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	ds := &models.DataSource{Id: 2, Url: server.URL + "/files/", JsonData: simplejson.New()}
+	fullRange := newTestTimeRange(testStart, testStart.Add(time.Hour))
+
+	t.Run("fetches and revalidates files", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			frames, err := executeTestQuery(t, ds, map[string]interface{}{"path": "cpu.csv", "labelColumns": []interface{}{"host"}}, fullRange)
+			require.NoError(t, err)
+			require.Len(t, frames, 2)
+		}
+		assert.Equal(t, 2, requests)
+		assert.Equal(t, 1, notModified)
+	})
+
+	t.Run("missing files return an error", func(t *testing.T) {
+		_, err := executeTestQuery(t, ds, map[string]interface{}{"path": "missing.csv"}, fullRange)
+		require.Error(t, err)
+	})
+
+	t.Run("paths escaping the data source URL are rejected", func(t *testing.T) {
+		for _, path := range []string{"../secret.csv", "http://example.com/cpu.csv", "//example.com/cpu.csv"} {
+			_, err := executeTestQuery(t, ds, map[string]interface{}{"path": path}, fullRange)
+			require.Error(t, err, path)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package filedatasource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/util"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// CheckHealth checks that the server serving the files is reachable, or
+// that the directory of local files can be read.
+func (e *FileExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	if dsInfo.Url != "" {
+		return checkHTTPHealth(ctx, dsInfo)
+	}
+
+	if len(setting.FileDataSourceAllowedPaths) == 0 {
+		return tsdb.HealthCheckError(errNoAllowedPaths)
+	}
+
+	dir := dsInfo.JsonData.Get("directory").MustString()
+	if dir == "" {
+		return tsdb.HealthCheckOK("Local files can be read")
+	}
+
+	resolved, err := util.ResolvePathInDirs(dir, setting.FileDataSourceAllowedPaths)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tsdb.HealthCheckError(fmt.Errorf("directory %q does not exist", dir))
+		}
+		return tsdb.HealthCheckError(err)
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	if !info.IsDir() {
+		return tsdb.HealthCheckError(fmt.Errorf("%q is not a directory", dir))
+	}
+
+	return tsdb.HealthCheckOK("Local files can be read")
+}
+
+// checkHTTPHealth sends a HEAD request to the data source URL. Any response
+// but a server or authentication error is healthy, since the URL itself
+// often isn't a file.
+func checkHTTPHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	req, err := http.NewRequest(http.MethodHead, dsInfo.Url, nil)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	if dsInfo.BasicAuth {
+		req.SetBasicAuth(dsInfo.BasicAuthUser, dsInfo.DecryptedBasicAuthPassword())
+	}
+
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+
+	res, err := ctxhttp.Do(ctx, httpClient, req)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= 500 || res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		return tsdb.HealthCheckError(fmt.Errorf("file server returned status %s", res.Status))
+	}
+
+	return tsdb.HealthCheckOK("File server is reachable")
+}
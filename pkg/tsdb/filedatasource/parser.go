@@ -0,0 +1,397 @@
+package filedatasource
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// column holds the raw cells of a parsed file column. Cells are strings for CSV
+// files and decoded JSON values for JSON files, empty cells are nil.
+type column struct {
+	name  string
+	cells []interface{}
+}
+
+// rawTable is a parsed file before type inference.
+type rawTable struct {
+	columns []*column
+	rows    int
+}
+
+func (t *rawTable) column(name string) *column {
+	for _, c := range t.columns {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// timeColumnNames are the column names that are treated as the time column
+// when no time column is configured, compared case insensitively.
+var timeColumnNames = []string{"time", "timestamp", "ts", "date", "datetime"}
+
+// timeFormats are the layouts tried, in order, when parsing time strings.
+// Layouts without a zone are interpreted as UTC.
+var timeFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func parseCSV(r io.Reader, delimiter rune) (*rawTable, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return &rawTable{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	table := &rawTable{}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if i == 0 {
+			// strip a UTF-8 byte order mark written by some spreadsheet applications
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		if name == "" {
+			name = fmt.Sprintf("column%d", i+1)
+		}
+		table.columns = append(table.columns, &column{name: name})
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row %d: %w", table.rows+2, err)
+		}
+
+		for i, c := range table.columns {
+			var cell interface{}
+			if i < len(record) {
+				if v := strings.TrimSpace(record[i]); v != "" {
+					cell = v
+				}
+			}
+			c.cells = append(c.cells, cell)
+		}
+		table.rows++
+	}
+
+	return table, nil
+}
+
+// parseJSON parses either a JSON array of objects or newline delimited JSON
+// objects. Nested objects are flattened into dot separated column names and
+// columns are ordered by first appearance.
+func parseJSON(r io.Reader) (*rawTable, error) {
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		return &rawTable{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if first == '[' {
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+
+	table := &rawTable{}
+	index := map[string]*column{}
+
+	for dec.More() {
+		var obj map[string]interface{}
+		if err := dec.Decode(&obj); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON object %d: %w", table.rows+1, err)
+		}
+
+		row := map[string]interface{}{}
+		var names []string
+		flatten("", obj, row, &names)
+
+		for _, name := range names {
+			if _, ok := index[name]; !ok {
+				c := &column{name: name, cells: make([]interface{}, table.rows)}
+				index[name] = c
+				table.columns = append(table.columns, c)
+			}
+		}
+
+		for _, c := range table.columns {
+			c.cells = append(c.cells, row[c.name])
+		}
+		table.rows++
+	}
+
+	return table, nil
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			return b[0], nil
+		}
+		if _, err := br.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// flatten adds the values of obj to row and their names to names. Keys of an
+// object are visited in sorted order, since JSON objects are decoded into maps.
+func flatten(prefix string, obj map[string]interface{}, row map[string]interface{}, names *[]string) {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+
+		switch v := obj[k].(type) {
+		case map[string]interface{}:
+			flatten(name, v, row, names)
+		case []interface{}:
+			b, err := json.Marshal(v)
+			if err == nil {
+				row[name] = string(b)
+				*names = append(*names, name)
+			}
+		default:
+			row[name] = v
+			*names = append(*names, name)
+		}
+	}
+}
+
+// toFrame infers the type of each column and converts the table into a frame,
+// sorted ascending by time when a time column is found.
+func toFrame(table *rawTable, timeColumn string) (*data.Frame, error) {
+	timeCol, err := detectTimeColumn(table, timeColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	frame := data.NewFrame("")
+
+	if timeCol != nil {
+		times := make([]*time.Time, table.rows)
+		for i, cell := range timeCol.cells {
+			if cell == nil {
+				continue
+			}
+			t, ok := parseTime(cell, true)
+			if !ok {
+				return nil, fmt.Errorf("failed to parse value %v of time column %q", cell, timeCol.name)
+			}
+			times[i] = &t
+		}
+
+		// sort rows by time, rows without a time value go last
+		order := make([]int, table.rows)
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			a, b := times[order[i]], times[order[j]]
+			if a == nil || b == nil {
+				return a != nil
+			}
+			return a.Before(*b)
+		})
+
+		sorted := make([]*time.Time, table.rows)
+		for i, idx := range order {
+			sorted[i] = times[idx]
+		}
+		frame.Fields = append(frame.Fields, data.NewField(timeCol.name, nil, sorted))
+
+		for _, c := range table.columns {
+			cells := make([]interface{}, table.rows)
+			for i, idx := range order {
+				cells[i] = c.cells[idx]
+			}
+			c.cells = cells
+		}
+	}
+
+	for _, c := range table.columns {
+		if c == timeCol {
+			continue
+		}
+		frame.Fields = append(frame.Fields, inferField(c))
+	}
+
+	return frame, nil
+}
+
+func detectTimeColumn(table *rawTable, timeColumn string) (*column, error) {
+	if timeColumn != "" {
+		c := table.column(timeColumn)
+		if c == nil {
+			return nil, fmt.Errorf("time column %q not found", timeColumn)
+		}
+		return c, nil
+	}
+
+	for _, name := range timeColumnNames {
+		for _, c := range table.columns {
+			if strings.EqualFold(c.name, name) && allCells(c, func(v interface{}) bool { _, ok := parseTime(v, true); return ok }) {
+				return c, nil
+			}
+		}
+	}
+
+	// fall back to the first column holding time strings only, numbers are
+	// only treated as epoch timestamps in columns named like a time column
+	for _, c := range table.columns {
+		if allCells(c, func(v interface{}) bool { _, ok := parseTime(v, false); return ok }) {
+			return c, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// allCells reports whether fn is true for all non empty cells of a column
+// and the column has at least one non empty cell.
+func allCells(c *column, fn func(v interface{}) bool) bool {
+	found := false
+	for _, v := range c.cells {
+		if v == nil {
+			continue
+		}
+		if !fn(v) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+func parseTime(v interface{}, allowEpoch bool) (time.Time, bool) {
+	switch value := v.(type) {
+	case string:
+		for _, layout := range timeFormats {
+			if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+				return t, true
+			}
+		}
+		if allowEpoch {
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				return epochToTime(f), true
+			}
+		}
+	case float64:
+		if allowEpoch {
+			return epochToTime(value), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func epochToTime(epoch float64) time.Time {
+	ms := tsdb.EpochPrecisionToMs(epoch)
+	return time.Unix(0, int64(ms*float64(time.Millisecond))).UTC()
+}
+
+func parseFloat(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case float64:
+		return value, true
+	case string:
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func parseBool(v interface{}) (bool, bool) {
+	switch value := v.(type) {
+	case bool:
+		return value, true
+	case string:
+		switch strings.ToLower(value) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// inferField converts a column to a nullable float64, bool or string field,
+// whichever is the narrowest type all non empty cells can be converted to.
+func inferField(c *column) *data.Field {
+	switch {
+	case allCells(c, func(v interface{}) bool { _, ok := parseFloat(v); return ok }):
+		values := make([]*float64, len(c.cells))
+		for i, v := range c.cells {
+			if f, ok := parseFloat(v); ok {
+				values[i] = &f
+			}
+		}
+		return data.NewField(c.name, nil, values)
+	case allCells(c, func(v interface{}) bool { _, ok := parseBool(v); return ok }):
+		values := make([]*bool, len(c.cells))
+		for i, v := range c.cells {
+			if b, ok := parseBool(v); ok {
+				values[i] = &b
+			}
+		}
+		return data.NewField(c.name, nil, values)
+	default:
+		values := make([]*string, len(c.cells))
+		for i, v := range c.cells {
+			if v == nil {
+				continue
+			}
+			s := fmt.Sprintf("%v", v)
+			if f, ok := v.(float64); ok {
+				s = strconv.FormatFloat(f, 'f', -1, 64)
+			}
+			values[i] = &s
+		}
+		return data.NewField(c.name, nil, values)
+	}
+}
@@ -0,0 +1,142 @@
+package filedatasource
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	t.Run("header and rows with empty cells", func(t *testing.T) {
+		table, err := parseCSV(strings.NewReader("\ufefftime, host,value\n2020-01-01T00:00:00Z,a,1\n2020-01-01T00:01:00Z,,\n"), ',')
+		require.NoError(t, err)
+
+		require.Len(t, table.columns, 3)
+		assert.Equal(t, "time", table.columns[0].name)
+		assert.Equal(t, "host", table.columns[1].name)
+		assert.Equal(t, 2, table.rows)
+		assert.Equal(t, []interface{}{"a", nil}, table.columns[1].cells)
+		assert.Equal(t, []interface{}{"1", nil}, table.columns[2].cells)
+	})
+
+	t.Run("custom delimiter and comments", func(t *testing.T) {
+		table, err := parseCSV(strings.NewReader("# generated\na;b\n1;2\n"), ';')
+		require.NoError(t, err)
+
+		require.Len(t, table.columns, 2)
+		assert.Equal(t, []interface{}{"2"}, table.columns[1].cells)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		table, err := parseCSV(strings.NewReader(""), ',')
+		require.NoError(t, err)
+		assert.Empty(t, table.columns)
+	})
+}
+
+func TestParseJSON(t *testing.T) {
+	t.Run("array of objects", func(t *testing.T) {
+		table, err := parseJSON(strings.NewReader(`[
+			{"time": "2020-01-01T00:00:00Z", "value": 1, "meta": {"host": "a"}},
+			{"time": "2020-01-01T00:01:00Z", "value": 2, "extra": true, "tags": ["x"]}
+		]`))
+		require.NoError(t, err)
+
+		names := []string{}
+		for _, c := range table.columns {
+			names = append(names, c.name)
+		}
+		assert.Equal(t, []string{"meta.host", "time", "value", "extra", "tags"}, names)
+		assert.Equal(t, 2, table.rows)
+		assert.Equal(t, []interface{}{"a", nil}, table.column("meta.host").cells)
+		assert.Equal(t, []interface{}{nil, true}, table.column("extra").cells)
+		assert.Equal(t, []interface{}{nil, `["x"]`}, table.column("tags").cells)
+	})
+
+	t.Run("newline delimited objects", func(t *testing.T) {
+		table, err := parseJSON(strings.NewReader("{\"a\": 1}\n{\"a\": 2}\n\n{\"a\": null}\n"))
+		require.NoError(t, err)
+
+		require.Len(t, table.columns, 1)
+		assert.Equal(t, []interface{}{float64(1), float64(2), nil}, table.columns[0].cells)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := parseJSON(strings.NewReader("{\"a\": 1}\n{\"a\": "))
+		require.Error(t, err)
+	})
+}
+
+func TestToFrame(t *testing.T) {
+	t.Run("detects the time column and infers types", func(t *testing.T) {
+		table, err := parseCSV(strings.NewReader(`host,when,value,up
+b,2020-01-01 00:01:00,2.5,true
+a,2020-01-01 00:00:00,1,false
+c,2020-01-01 00:02:00,,
+`), ',')
+		require.NoError(t, err)
+
+		frame, err := toFrame(table, "")
+		require.NoError(t, err)
+
+		require.Len(t, frame.Fields, 4)
+		assert.Equal(t, "when", frame.Fields[0].Name)
+		assert.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		assert.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[2].Type())
+		assert.Equal(t, data.FieldTypeNullableBool, frame.Fields[3].Type())
+
+		// rows are sorted by time
+		first := frame.Fields[0].At(0).(*time.Time)
+		assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), *first)
+		assert.Equal(t, "a", *frame.Fields[1].At(0).(*string))
+		assert.Equal(t, 2.5, *frame.Fields[2].At(1).(*float64))
+		assert.Nil(t, frame.Fields[2].At(2))
+	})
+
+	t.Run("numeric epochs are used in columns named like a time column", func(t *testing.T) {
+		table, err := parseJSON(strings.NewReader(`[{"timestamp": 1577836800000, "v": 1}, {"timestamp": 1577836860, "v": 2}]`))
+		require.NoError(t, err)
+
+		frame, err := toFrame(table, "")
+		require.NoError(t, err)
+
+		assert.Equal(t, "timestamp", frame.Fields[0].Name)
+		assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), *frame.Fields[0].At(0).(*time.Time))
+		assert.Equal(t, time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC), *frame.Fields[0].At(1).(*time.Time))
+	})
+
+	t.Run("numeric columns are not detected as time by value", func(t *testing.T) {
+		table, err := parseCSV(strings.NewReader("id,value\n1577836800,1\n"), ',')
+		require.NoError(t, err)
+
+		frame, err := toFrame(table, "")
+		require.NoError(t, err)
+		assert.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[0].Type())
+	})
+
+	t.Run("configured time column", func(t *testing.T) {
+		table, err := parseCSV(strings.NewReader("id,created\n1,1577836800\n"), ',')
+		require.NoError(t, err)
+
+		frame, err := toFrame(table, "created")
+		require.NoError(t, err)
+		assert.Equal(t, "created", frame.Fields[0].Name)
+		assert.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+
+		_, err = toFrame(table, "missing")
+		require.Error(t, err)
+	})
+
+	t.Run("invalid value in configured time column", func(t *testing.T) {
+		table, err := parseCSV(strings.NewReader("id,created\n1,yesterday\n"), ',')
+		require.NoError(t, err)
+
+		_, err = toFrame(table, "created")
+		require.Error(t, err)
+	})
+}
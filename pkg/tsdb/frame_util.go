@@ -40,7 +40,8 @@ func convertTSDBTimePoint(point TimePoint) (t *time.Time, f *float64) {
 }
 
 // FrameToSeriesSlice converts a frame that is a valid time series as per data.TimeSeriesSchema()
-// to a TimeSeriesSlice.
+// to a TimeSeriesSlice. Series are named after the display name of the field config, if any,
+// or else after the field name.
 func FrameToSeriesSlice(frame *data.Frame) (TimeSeriesSlice, error) {
 	tsSchema := frame.TimeSeriesSchema()
 	if tsSchema.Type == data.TimeSeriesTypeNot {
@@ -70,8 +71,12 @@ func FrameToSeriesSlice(frame *data.Frame) (TimeSeriesSlice, error) {
 
 	for _, fieldIdx := range tsSchema.ValueIndices { // create a TimeSeries for each value Field
 		field := frame.Fields[fieldIdx]
+		name := field.Name
+		if field.Config != nil && field.Config.DisplayName != "" {
+			name = field.Config.DisplayName
+		}
 		ts := &TimeSeries{
-			Name:   field.Name,
+			Name:   name,
 			Tags:   field.Labels.Copy(),
 			Points: make(TimeSeriesPoints, field.Len()),
 		}
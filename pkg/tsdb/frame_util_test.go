@@ -77,6 +77,27 @@ func TestFrameToSeriesSlice(t *testing.T) {
 			},
 			Err: require.NoError,
 		},
+		{
+			name: "a series with display name",
+			frame: data.NewFrame("",
+				data.NewField("Time", nil, []time.Time{
+					time.Date(2020, 1, 2, 3, 4, 0, 0, time.UTC),
+				}),
+				data.NewField("Value", data.Labels{"job": "api"}, []float64{
+					1.0,
+				}).SetConfig(&data.FieldConfig{DisplayName: `up{job="api"}`})),
+
+			seriesSlice: TimeSeriesSlice{
+				&TimeSeries{
+					Name: `up{job="api"}`,
+					Tags: map[string]string{"job": "api"},
+					Points: TimeSeriesPoints{
+						TimePoint{null.FloatFrom(1), null.FloatFrom(1577934240000)},
+					},
+				},
+			},
+			Err: require.NoError,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -10,10 +10,15 @@ import (
 	"net/url"
 	"path"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/net/context/ctxhttp"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/null"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
@@ -35,19 +40,31 @@ func init() {
 	tsdb.RegisterTsdbQueryEndpoint("graphite", NewGraphiteExecutor)
 }
 
+// defaultMaxDataPoints is used for queries without a maxDataPoints, such as
+// alert rule queries.
+const defaultMaxDataPoints = 500
+
+// consolidationFuncs are the functions supported by Graphite's consolidateBy.
+var consolidationFuncs = map[string]bool{
+	"average": true,
+	"median":  true,
+	"sum":     true,
+	"min":     true,
+	"max":     true,
+	"first":   true,
+	"last":    true,
+}
+
+// consolidateByPattern matches the function of a consolidateBy call in a target.
+var consolidateByPattern = regexp.MustCompile(`consolidateBy\(.*,\s*['"](\w+)['"]\s*\)`)
+
 func (e *GraphiteExecutor) Query(ctx context.Context, dsInfo *models.DataSource, tsdbQuery *tsdb.TsdbQuery) (*tsdb.Response, error) {
-	result := &tsdb.Response{}
+	result := &tsdb.Response{
+		Results: make(map[string]*tsdb.QueryResult),
+	}
 
 	from := "-" + formatTimeRange(tsdbQuery.TimeRange.From)
 	until := formatTimeRange(tsdbQuery.TimeRange.To)
-	var target string
-
-	formData := url.Values{
-		"from":          []string{from},
-		"until":         []string{until},
-		"format":        []string{"json"},
-		"maxDataPoints": []string{"500"},
-	}
 
 	emptyQueries := make([]string, 0)
 	for _, query := range tsdbQuery.Queries {
@@ -63,15 +80,50 @@ func (e *GraphiteExecutor) Query(ctx context.Context, dsInfo *models.DataSource,
 			emptyQueries = append(emptyQueries, fmt.Sprintf("Query: %v has no target", query.Model))
 			continue
 		}
-		target = fixIntervalFormat(currTarget)
+
+		queryRes := tsdb.NewQueryResult()
+		queryRes.RefId = query.RefId
+
+		frames, err := e.executeQuery(ctx, dsInfo, query, fixIntervalFormat(currTarget), from, until)
+		if err != nil {
+			queryRes.Error = err
+		} else {
+			queryRes.Dataframes = tsdb.NewDecodedDataFrames(frames)
+		}
+		result.Results[query.RefId] = queryRes
 	}
 
-	if target == "" {
+	if len(result.Results) == 0 {
 		glog.Error("No targets in query model", "models without targets", strings.Join(emptyQueries, "\n"))
 		return nil, errors.New("No query target found for the alert rule")
 	}
 
-	formData["target"] = []string{target}
+	return result, nil
+}
+
+func (e *GraphiteExecutor) executeQuery(ctx context.Context, dsInfo *models.DataSource, query *tsdb.Query, target, from, until string) (data.Frames, error) {
+	consolidateBy := query.Model.Get("consolidateBy").MustString("")
+	if consolidateBy != "" && !consolidationFuncs[consolidateBy] {
+		return nil, fmt.Errorf("invalid consolidation function %q", consolidateBy)
+	}
+	if m := consolidateByPattern.FindStringSubmatch(target); m != nil {
+		consolidateBy = m[1]
+	} else if consolidateBy != "" {
+		target = fmt.Sprintf("consolidateBy(%s, '%s')", target, consolidateBy)
+	}
+
+	maxDataPoints := query.MaxDataPoints
+	if maxDataPoints <= 0 {
+		maxDataPoints = defaultMaxDataPoints
+	}
+
+	formData := url.Values{
+		"from":          []string{from},
+		"until":         []string{until},
+		"format":        []string{"json"},
+		"maxDataPoints": []string{strconv.FormatInt(maxDataPoints, 10)},
+		"target":        []string{target},
+	}
 
 	if setting.Env == setting.DEV {
 		glog.Debug("Graphite request", "params", formData)
@@ -108,27 +160,133 @@ func (e *GraphiteExecutor) Query(ctx context.Context, dsInfo *models.DataSource,
 		return nil, err
 	}
 
-	data, err := e.parseResponse(res)
+	series, err := e.parseResponse(res)
 	if err != nil {
 		return nil, err
 	}
 
-	result.Results = make(map[string]*tsdb.QueryResult)
-	queryRes := tsdb.NewQueryResult()
+	frames := make(data.Frames, 0, len(series))
+	for _, s := range series {
+		if setting.Env == setting.DEV {
+			glog.Debug("Graphite response", "target", s.Target, "datapoints", len(s.DataPoints))
+		}
 
-	for _, series := range data {
-		queryRes.Series = append(queryRes.Series, &tsdb.TimeSeries{
-			Name:   series.Target,
-			Points: series.DataPoints,
-		})
+		// Graphite consolidates series to maxDataPoints itself, but not all
+		// Graphite compatible backends support the parameter.
+		s.DataPoints = consolidate(s.DataPoints, int(maxDataPoints), consolidateBy)
 
-		if setting.Env == setting.DEV {
-			glog.Debug("Graphite response", "target", series.Target, "datapoints", len(series.DataPoints))
+		frame := toFrame(s)
+		frame.RefID = query.RefId
+		frame.Meta = &data.FrameMeta{ExecutedQueryString: target}
+		frames = append(frames, frame)
+	}
+
+	return frames, nil
+}
+
+// toFrame converts a Graphite series into a frame with a time and a value
+// field. The tags of the series, as returned for seriesByTag queries and
+// tagged series, become labels of the value field.
+func toFrame(series TargetResponseDTO) *data.Frame {
+	times := make([]time.Time, 0, len(series.DataPoints))
+	values := make([]*float64, 0, len(series.DataPoints))
+	for _, point := range series.DataPoints {
+		if !point[1].Valid {
+			continue
 		}
+		times = append(times, time.Unix(int64(point[1].Float64), 0).UTC())
+
+		var value *float64
+		if point[0].Valid {
+			v := point[0].Float64
+			value = &v
+		}
+		values = append(values, value)
 	}
 
-	result.Results["A"] = queryRes
-	return result, nil
+	var labels data.Labels
+	if len(series.Tags) > 0 {
+		labels = make(data.Labels, len(series.Tags))
+		for k, v := range series.Tags {
+			labels[k] = fmt.Sprint(v)
+		}
+	}
+
+	valueField := data.NewField("Value", labels, values)
+	valueField.SetConfig(&data.FieldConfig{DisplayName: series.Target})
+
+	return data.NewFrame(series.Target,
+		data.NewField("Time", nil, times),
+		valueField,
+	)
+}
+
+// consolidate reduces points to at most maxDataPoints points by combining
+// consecutive points with the given consolidation function, average if empty.
+// Null values are ignored, a bucket of null values consolidates to null.
+func consolidate(points tsdb.TimeSeriesPoints, maxDataPoints int, fn string) tsdb.TimeSeriesPoints {
+	if maxDataPoints <= 0 || len(points) <= maxDataPoints {
+		return points
+	}
+
+	bucketSize := (len(points) + maxDataPoints - 1) / maxDataPoints
+	result := make(tsdb.TimeSeriesPoints, 0, maxDataPoints)
+	for start := 0; start < len(points); start += bucketSize {
+		end := start + bucketSize
+		if end > len(points) {
+			end = len(points)
+		}
+		bucket := points[start:end]
+
+		values := make([]float64, 0, len(bucket))
+		for _, p := range bucket {
+			if p[0].Valid {
+				values = append(values, p[0].Float64)
+			}
+		}
+
+		var value null.Float
+		if len(values) > 0 {
+			value = null.FloatFrom(consolidateValues(values, fn))
+		}
+		result = append(result, tsdb.TimePoint{value, bucket[0][1]})
+	}
+
+	return result
+}
+
+func consolidateValues(values []float64, fn string) float64 {
+	switch fn {
+	case "first":
+		return values[0]
+	case "last":
+		return values[len(values)-1]
+	case "min", "max":
+		res := values[0]
+		for _, v := range values[1:] {
+			if (fn == "min" && v < res) || (fn == "max" && v > res) {
+				res = v
+			}
+		}
+		return res
+	case "median":
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+		mid := len(sorted) / 2
+		if len(sorted)%2 == 0 {
+			return (sorted[mid-1] + sorted[mid]) / 2
+		}
+		return sorted[mid]
+	}
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	if fn == "sum" {
+		return sum
+	}
+	return sum / float64(len(values))
 }
 
 func (e *GraphiteExecutor) parseResponse(res *http.Response) ([]TargetResponseDTO, error) {
@@ -143,14 +301,14 @@ func (e *GraphiteExecutor) parseResponse(res *http.Response) ([]TargetResponseDT
 		return nil, fmt.Errorf("Request failed status: %v", res.Status)
 	}
 
-	var data []TargetResponseDTO
-	err = json.Unmarshal(body, &data)
+	var series []TargetResponseDTO
+	err = json.Unmarshal(body, &series)
 	if err != nil {
 		glog.Info("Failed to unmarshal graphite response", "error", err, "status", res.Status, "body", string(body))
 		return nil, err
 	}
 
-	return data, nil
+	return series, nil
 }
 
 func (e *GraphiteExecutor) createRequest(dsInfo *models.DataSource, data url.Values) (*http.Request, error) {
@@ -1,9 +1,22 @@
 package graphite
 
 import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGraphiteFunctions(t *testing.T) {
@@ -44,3 +57,143 @@ func TestGraphiteFunctions(t *testing.T) {
 		})
 	})
 }
+
+func TestGraphiteQuery(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		form, err = url.ParseQuery(string(body))
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte(`[
+			{"target": "cpu;host=a", "tags": {"name": "cpu", "host": "a"}, "datapoints": [[1, 1577836800], [null, 1577836860], [3, 1577836920]]},
+			{"target": "cpu;host=b", "tags": {"name": "cpu", "host": "b"}, "datapoints": [[10, 1577836800], [20, 1577836860], [30, 1577836920]]}
+		]`))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	executor := &GraphiteExecutor{}
+	ds := &models.DataSource{Url: server.URL, JsonData: simplejson.New()}
+	timeRange := tsdb.NewTimeRange("now-1h", "now")
+
+	t.Run("tags become labels", func(t *testing.T) {
+		resp, err := executor.Query(context.Background(), ds, &tsdb.TsdbQuery{
+			TimeRange: timeRange,
+			Queries: []*tsdb.Query{
+				{RefId: "B", MaxDataPoints: 100, Model: simplejson.NewFromAny(map[string]interface{}{"target": "seriesByTag('name=cpu')"})},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "seriesByTag('name=cpu')", form.Get("target"))
+		assert.Equal(t, "100", form.Get("maxDataPoints"))
+
+		res := resp.Results["B"]
+		require.NotNil(t, res)
+		require.NoError(t, res.Error)
+		frames, err := res.Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 2)
+
+		assert.Equal(t, "B", frames[0].RefID)
+		assert.Equal(t, time.Unix(1577836800, 0).UTC(), frames[0].Fields[0].At(0))
+		assert.Nil(t, frames[0].Fields[1].At(1))
+		assert.Equal(t, data.Labels{"name": "cpu", "host": "b"}, frames[1].Fields[1].Labels)
+
+		// every tagged series is evaluated separately by alerting
+		series, err := tsdb.FrameToSeriesSlice(frames[1])
+		require.NoError(t, err)
+		require.Len(t, series, 1)
+		assert.Equal(t, "cpu;host=b", series[0].Name)
+		assert.Equal(t, map[string]string{"name": "cpu", "host": "b"}, series[0].Tags)
+	})
+
+	t.Run("alert queries use the default max data points and consolidation function", func(t *testing.T) {
+		resp, err := executor.Query(context.Background(), ds, &tsdb.TsdbQuery{
+			TimeRange: timeRange,
+			Queries: []*tsdb.Query{
+				{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{"target": "cpu.*", "consolidateBy": "max"})},
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, resp.Results["A"].Error)
+		assert.Equal(t, "consolidateBy(cpu.*, 'max')", form.Get("target"))
+		assert.Equal(t, "500", form.Get("maxDataPoints"))
+	})
+
+	t.Run("consolidation function of the target is kept", func(t *testing.T) {
+		resp, err := executor.Query(context.Background(), ds, &tsdb.TsdbQuery{
+			TimeRange: timeRange,
+			Queries: []*tsdb.Query{
+				{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{"target": "consolidateBy(cpu.*, 'min')", "consolidateBy": "max"})},
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, resp.Results["A"].Error)
+		assert.Equal(t, "consolidateBy(cpu.*, 'min')", form.Get("target"))
+	})
+
+	t.Run("invalid consolidation function", func(t *testing.T) {
+		resp, err := executor.Query(context.Background(), ds, &tsdb.TsdbQuery{
+			TimeRange: timeRange,
+			Queries: []*tsdb.Query{
+				{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{"target": "cpu.*", "consolidateBy": "mean"})},
+			},
+		})
+		require.NoError(t, err)
+		require.Error(t, resp.Results["A"].Error)
+	})
+
+	t.Run("no targets", func(t *testing.T) {
+		_, err := executor.Query(context.Background(), ds, &tsdb.TsdbQuery{
+			TimeRange: timeRange,
+			Queries:   []*tsdb.Query{{RefId: "A", Model: simplejson.New()}},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestConsolidate(t *testing.T) {
+	points := tsdb.TimeSeriesPoints{
+		{null.FloatFrom(1), null.FloatFrom(10)},
+		{null.FloatFrom(5), null.FloatFrom(20)},
+		{null.FloatFrom(3), null.FloatFrom(30)},
+		{null.FloatFromPtr(nil), null.FloatFrom(40)},
+		{null.FloatFromPtr(nil), null.FloatFrom(50)},
+	}
+
+	t.Run("series within max data points are unchanged", func(t *testing.T) {
+		assert.Equal(t, points, consolidate(points, 5, ""))
+	})
+
+	t.Run("consolidation functions", func(t *testing.T) {
+		for fn, expected := range map[string]float64{"": 3, "sum": 9, "max": 5, "min": 1, "first": 1, "last": 3, "median": 3} {
+			res := consolidate(points, 2, fn)
+			require.Len(t, res, 2, fn)
+			assert.Equal(t, null.FloatFrom(expected), res[0][0], fn)
+			assert.Equal(t, null.FloatFrom(10), res[0][1], fn)
+			assert.False(t, res[1][0].Valid, fn)
+			assert.Equal(t, null.FloatFrom(40), res[1][1], fn)
+		}
+	})
+}
+
+func TestGraphiteCheckHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics/find" || r.URL.Query().Get("query") != "*" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, err := w.Write([]byte(`[{"text": "cpu", "id": "cpu", "leaf": 0}]`))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	executor := &GraphiteExecutor{}
+	result := executor.CheckHealth(context.Background(), &models.DataSource{Url: server.URL, JsonData: simplejson.New()})
+	assert.Equal(t, tsdb.HealthStatusOK, result.Status)
+
+	result = executor.CheckHealth(context.Background(), &models.DataSource{Url: server.URL + "/missing", JsonData: simplejson.New()})
+	assert.Equal(t, tsdb.HealthStatusError, result.Status)
+}
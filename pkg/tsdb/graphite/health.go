@@ -0,0 +1,15 @@
+package graphite
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth finds the top level metrics, which doesn't fetch any datapoints
+func (e *GraphiteExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	params := url.Values{"query": []string{"*"}}
+	return tsdb.CheckHTTPHealth(ctx, dsInfo, "metrics/find", params, "Data source is working")
+}
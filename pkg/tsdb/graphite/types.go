@@ -3,6 +3,7 @@ package graphite
 import "github.com/grafana/grafana/pkg/tsdb"
 
 type TargetResponseDTO struct {
-	Target     string                `json:"target"`
-	DataPoints tsdb.TimeSeriesPoints `json:"datapoints"`
+	Target     string                 `json:"target"`
+	DataPoints tsdb.TimeSeriesPoints  `json:"datapoints"`
+	Tags       map[string]interface{} `json:"tags"`
 }
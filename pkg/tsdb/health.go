@@ -0,0 +1,115 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/grafana/grafana/pkg/models"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// ErrHealthCheckNotSupported is returned by CheckHealth when the query
+// endpoint of the data source doesn't implement HealthChecker.
+var ErrHealthCheckNotSupported = errors.New("health check not supported by data source")
+
+// HealthStatus is the status of a data source health check
+type HealthStatus string
+
+const (
+	HealthStatusOK    HealthStatus = "OK"
+	HealthStatusError HealthStatus = "ERROR"
+)
+
+// HealthCheckResult is the result of a data source health check
+type HealthCheckResult struct {
+	Status  HealthStatus           `json:"status"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthChecker is implemented by the query endpoints able to check that
+// their data source is reachable with a cheap request, instead of a full query.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context, ds *models.DataSource) *HealthCheckResult
+}
+
+// HealthCheckOK returns a successful health check result
+func HealthCheckOK(message string) *HealthCheckResult {
+	return &HealthCheckResult{Status: HealthStatusOK, Message: message}
+}
+
+// HealthCheckError returns a failed health check result
+func HealthCheckError(err error) *HealthCheckResult {
+	return &HealthCheckResult{Status: HealthStatusError, Message: err.Error()}
+}
+
+// CheckHealth checks the health of a data source with the query endpoint of
+// its type. Failing to create the query endpoint is reported as an unhealthy
+// data source.
+func CheckHealth(ctx context.Context, ds *models.DataSource) (*HealthCheckResult, error) {
+	fn, exists := registry[ds.Type]
+	if !exists {
+		return nil, ErrHealthCheckNotSupported
+	}
+
+	endpoint, err := fn(ds)
+	if err != nil {
+		return HealthCheckError(err), nil
+	}
+
+	checker, ok := endpoint.(HealthChecker)
+	if !ok {
+		return nil, ErrHealthCheckNotSupported
+	}
+
+	return checker.CheckHealth(ctx, ds), nil
+}
+
+// CheckHTTPHealth sends a GET request to a path of the data source URL, with
+// the data source HTTP client and basic auth, and reports any non 2xx
+// response as unhealthy.
+func CheckHTTPHealth(ctx context.Context, ds *models.DataSource, urlPath string, params url.Values, message string) *HealthCheckResult {
+	u, err := url.Parse(ds.Url)
+	if err != nil {
+		return HealthCheckError(err)
+	}
+	u.Path = path.Join(u.Path, urlPath)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return HealthCheckError(err)
+	}
+	req.Header.Set("User-Agent", "Grafana")
+	if ds.BasicAuth {
+		req.SetBasicAuth(ds.BasicAuthUser, ds.DecryptedBasicAuthPassword())
+	}
+
+	client, err := ds.GetHttpClient()
+	if err != nil {
+		return HealthCheckError(err)
+	}
+
+	res, err := ctxhttp.Do(ctx, client, req)
+	if err != nil {
+		return HealthCheckError(err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<20))
+
+	if res.StatusCode/100 != 2 {
+		return &HealthCheckResult{
+			Status:  HealthStatusError,
+			Message: fmt.Sprintf("Data source returned status %s", res.Status),
+			Details: map[string]interface{}{"statusCode": res.StatusCode},
+		}
+	}
+
+	return HealthCheckOK(message)
+}
@@ -0,0 +1,87 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHealthChecker struct {
+	FakeExecutor
+	result *HealthCheckResult
+}
+
+func (e *fakeHealthChecker) CheckHealth(ctx context.Context, ds *models.DataSource) *HealthCheckResult {
+	return e.result
+}
+
+func TestCheckHealth(t *testing.T) {
+	RegisterTsdbQueryEndpoint("health-ok", func(*models.DataSource) (TsdbQueryEndpoint, error) {
+		return &fakeHealthChecker{result: HealthCheckOK("working")}, nil
+	})
+	RegisterTsdbQueryEndpoint("health-invalid", func(*models.DataSource) (TsdbQueryEndpoint, error) {
+		return nil, errors.New("invalid settings")
+	})
+	RegisterTsdbQueryEndpoint("health-unsupported", func(ds *models.DataSource) (TsdbQueryEndpoint, error) {
+		return NewFakeExecutor(ds)
+	})
+
+	t.Run("returns the result of the query endpoint", func(t *testing.T) {
+		result, err := CheckHealth(context.Background(), &models.DataSource{Type: "health-ok"})
+		require.NoError(t, err)
+		assert.Equal(t, &HealthCheckResult{Status: HealthStatusOK, Message: "working"}, result)
+	})
+
+	t.Run("reports invalid settings as unhealthy", func(t *testing.T) {
+		result, err := CheckHealth(context.Background(), &models.DataSource{Type: "health-invalid"})
+		require.NoError(t, err)
+		assert.Equal(t, &HealthCheckResult{Status: HealthStatusError, Message: "invalid settings"}, result)
+	})
+
+	t.Run("returns an error if health checks are not supported", func(t *testing.T) {
+		_, err := CheckHealth(context.Background(), &models.DataSource{Type: "health-unsupported"})
+		assert.Equal(t, ErrHealthCheckNotSupported, err)
+
+		_, err = CheckHealth(context.Background(), &models.DataSource{Type: "health-unknown"})
+		assert.Equal(t, ErrHealthCheckNotSupported, err)
+	})
+}
+
+func TestCheckHTTPHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if user != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/base/api/status" || r.URL.Query().Get("q") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	ds := &models.DataSource{
+		Id:                1,
+		Url:               server.URL + "/base",
+		BasicAuth:         true,
+		BasicAuthUser:     "admin",
+		BasicAuthPassword: "secret",
+	}
+
+	result := CheckHTTPHealth(context.Background(), ds, "api/status", url.Values{"q": []string{"1"}}, "working")
+	assert.Equal(t, &HealthCheckResult{Status: HealthStatusOK, Message: "working"}, result)
+
+	result = CheckHTTPHealth(context.Background(), ds, "api/missing", nil, "working")
+	assert.Equal(t, HealthStatusError, result.Status)
+	assert.Equal(t, "Data source returned status 404 Not Found", result.Message)
+	assert.Equal(t, 404, result.Details["statusCode"])
+}
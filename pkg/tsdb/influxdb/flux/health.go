@@ -0,0 +1,37 @@
+package flux
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth lists a bucket of the organization, which fails if the token
+// can't read the organization.
+func CheckHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	r, err := runnerFromDataSource(dsInfo)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	defer r.client.Close()
+
+	res, err := r.runQuery(ctx, "buckets() |> limit(n: 1)")
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	defer res.Close()
+
+	buckets := 0
+	for res.Next() {
+		buckets++
+	}
+	if res.Err() != nil {
+		return tsdb.HealthCheckError(res.Err())
+	}
+	if buckets == 0 {
+		return &tsdb.HealthCheckResult{Status: tsdb.HealthStatusError, Message: "Data source is working, but no buckets were found"}
+	}
+
+	return tsdb.HealthCheckOK("Data source is working")
+}
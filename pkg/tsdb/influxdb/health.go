@@ -0,0 +1,59 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/flux"
+)
+
+// CheckHealth lists a measurement of the database, which fails if the
+// database doesn't exist or the user can't read it.
+func (e *InfluxDBExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	if dsInfo.JsonData.Get("version").MustString("") == "Flux" {
+		return flux.CheckHealth(ctx, dsInfo)
+	}
+
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+
+	req, err := e.createRequest(ctx, dsInfo, "SHOW MEASUREMENTS LIMIT 1")
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	defer resp.Body.Close()
+	var response struct {
+		Error   string `json:"error"`
+		Results []struct {
+			Error string `json:"error"`
+		} `json:"results"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if response.Error != "" {
+		return tsdb.HealthCheckError(errors.New(response.Error))
+	}
+	if resp.StatusCode/100 != 2 {
+		return tsdb.HealthCheckError(fmt.Errorf("InfluxDB returned invalid status code: %v", resp.Status))
+	}
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+	for _, result := range response.Results {
+		if result.Error != "" {
+			return tsdb.HealthCheckError(errors.New(result.Error))
+		}
+	}
+
+	return tsdb.HealthCheckOK("Data source is working")
+}
@@ -10,6 +10,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
@@ -54,12 +55,38 @@ func (e *InfluxDBExecutor) Query(ctx context.Context, dsInfo *models.DataSource,
 	// NOTE: the following path is currently only called from alerting queries
 	// In dashboards, the request runs through proxy and are managed in the frontend
 
-	query, err := e.getQuery(dsInfo, tsdbQuery.Queries, tsdbQuery)
+	if len(tsdbQuery.Queries) == 0 {
+		return nil, fmt.Errorf("query request contains no queries")
+	}
+
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &tsdb.Response{}
+	result.Results = make(map[string]*tsdb.QueryResult)
+	for _, query := range tsdbQuery.Queries {
+		queryRes, err := e.executeQuery(ctx, dsInfo, httpClient, query, tsdbQuery)
+		if err != nil {
+			queryRes = &tsdb.QueryResult{Error: err}
+		}
+		queryRes.RefId = query.RefId
+		result.Results[query.RefId] = queryRes
+	}
+
+	return result, nil
+}
+
+// executeQuery runs the statements of a single query and returns their
+// results as data frames, with the executed query set on the first frame.
+func (e *InfluxDBExecutor) executeQuery(ctx context.Context, dsInfo *models.DataSource, httpClient *http.Client, tsdbQuery *tsdb.Query, queryContext *tsdb.TsdbQuery) (*tsdb.QueryResult, error) {
+	query, err := e.QueryParser.Parse(tsdbQuery.Model, dsInfo)
 	if err != nil {
 		return nil, err
 	}
 
-	rawQuery, err := query.Build(tsdbQuery)
+	rawQuery, err := query.Build(queryContext)
 	if err != nil {
 		return nil, err
 	}
@@ -73,11 +100,6 @@ func (e *InfluxDBExecutor) Query(ctx context.Context, dsInfo *models.DataSource,
 		return nil, err
 	}
 
-	httpClient, err := dsInfo.GetHttpClient()
-	if err != nil {
-		return nil, err
-	}
-
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, err
@@ -98,25 +120,22 @@ func (e *InfluxDBExecutor) Query(ctx context.Context, dsInfo *models.DataSource,
 		return nil, response.Err
 	}
 
-	result := &tsdb.Response{}
-	result.Results = make(map[string]*tsdb.QueryResult)
-	result.Results["A"] = e.ResponseParser.Parse(&response, query)
-
-	return result, nil
-}
-
-func (e *InfluxDBExecutor) getQuery(dsInfo *models.DataSource, queries []*tsdb.Query, context *tsdb.TsdbQuery) (*Query, error) {
-	if len(queries) == 0 {
-		return nil, fmt.Errorf("query request contains no queries")
-	}
+	queryRes := e.ResponseParser.Parse(&response, query)
 
-	// The model supports multiple queries, but right now this is only used from
-	// alerting so we only needed to support batch executing 1 query at a time.
-	query, err := e.QueryParser.Parse(queries[0].Model, dsInfo)
+	frames, err := queryRes.Dataframes.Decoded()
 	if err != nil {
 		return nil, err
 	}
-	return query, nil
+	if len(frames) == 0 {
+		frames = append(frames, data.NewFrame(""))
+	}
+	if frames[0].Meta == nil {
+		frames[0].SetMeta(&data.FrameMeta{})
+	}
+	frames[0].Meta.ExecutedQueryString = rawQuery
+	queryRes.Dataframes = tsdb.NewDecodedDataFrames(frames)
+
+	return queryRes, nil
 }
 
 func (e *InfluxDBExecutor) createRequest(ctx context.Context, dsInfo *models.DataSource, query string) (*http.Request, error) {
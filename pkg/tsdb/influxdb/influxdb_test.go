@@ -3,11 +3,15 @@ package influxdb
 import (
 	"context"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -72,5 +76,52 @@ func TestInfluxDB(t *testing.T) {
 				So(err, ShouldEqual, ErrInvalidHttpMode)
 			})
 		})
+
+		Convey("Query runs every query and returns data frames", func() {
+			var epoch string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				epoch = r.URL.Query().Get("epoch")
+				_, _ = w.Write([]byte(`{"results": [
+					{"statement_id": 0, "series": [{"name": "cpu", "columns": ["time", "mean"], "values": [[1, 1.5]]}]},
+					{"statement_id": 1, "series": [{"name": "measurements", "columns": ["name"], "values": [["cpu"]]}]}
+				]}`))
+			}))
+			defer server.Close()
+			datasource.Url = server.URL
+
+			tsdbQuery := &tsdb.TsdbQuery{
+				TimeRange: tsdb.NewTimeRange("5m", "now"),
+				Queries: []*tsdb.Query{
+					{
+						RefId: "A",
+						Model: simplejson.NewFromAny(map[string]interface{}{
+							"rawQuery":     true,
+							"query":        "SELECT mean(value) FROM cpu; SHOW MEASUREMENTS",
+							"resultFormat": "time_series",
+						}),
+					},
+					{
+						RefId: "B",
+						Model: simplejson.NewFromAny(map[string]interface{}{
+							"rawQuery": true,
+							"query":    "SHOW MEASUREMENTS",
+						}),
+					},
+				},
+			}
+
+			resp, err := e.Query(context.Background(), datasource, tsdbQuery)
+			So(err, ShouldBeNil)
+			So(epoch, ShouldEqual, "s")
+			So(resp.Results["A"].Error, ShouldBeNil)
+			So(resp.Results["B"].Error, ShouldNotBeNil)
+
+			frames, err := resp.Results["A"].Dataframes.Decoded()
+			So(err, ShouldBeNil)
+			So(len(frames), ShouldEqual, 2)
+			So(frames[0].Meta.ExecutedQueryString, ShouldEqual, "SELECT mean(value) FROM cpu; SHOW MEASUREMENTS")
+			So(frames[0].Fields[0].At(0), ShouldEqual, time.Unix(1, 0).UTC())
+			So(frames[1].Fields[0].Name, ShouldEqual, "name")
+		})
 	})
 }
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/null"
 	"github.com/grafana/grafana/pkg/tsdb"
 )
@@ -14,50 +17,234 @@ import (
 type ResponseParser struct{}
 
 var (
-	legendFormat *regexp.Regexp
+	legendFormat  *regexp.Regexp
+	groupByClause *regexp.Regexp
+	groupByTime   *regexp.Regexp
 )
 
 func init() {
 	legendFormat = regexp.MustCompile(`\[\[(\w+)(\.\w+)*\]\]*|\$\s*(\w+?)*`)
+	groupByClause = regexp.MustCompile(`(?is)\bgroup\s+by\b(.*)`)
+	groupByTime = regexp.MustCompile(`(?i)\btime\s*\(`)
 }
 
+// Parse converts the results of all statements of an InfluxQL response into
+// data frames. Rows with a time column become one time series frame per value
+// column, with the row tags as labels. Rows without a time column, such as the
+// results of SHOW statements, results of a query that groups by tags but not by
+// time, for which InfluxDB returns a constant time, as well as queries with the
+// table result format, become a single table frame per statement.
 func (rp *ResponseParser) Parse(response *Response, query *Query) *tsdb.QueryResult {
 	queryRes := tsdb.NewQueryResult()
 
+	frames := data.Frames{}
 	for _, result := range response.Results {
-		queryRes.Series = append(queryRes.Series, rp.transformRows(result.Series, queryRes, query)...)
+		if query.ResultFormat == "table" || !groupsByTime(query) || !hasTimeColumn(result.Series) {
+			if frame := rp.transformRowsToTable(result.Series); frame != nil {
+				frames = append(frames, frame)
+			}
+		} else {
+			frames = append(frames, rp.transformRows(result.Series, query)...)
+		}
 		if result.Err != nil {
 			queryRes.Error = result.Err
 		}
 	}
 
+	queryRes.Dataframes = tsdb.NewDecodedDataFrames(frames)
 	return queryRes
 }
 
-func (rp *ResponseParser) transformRows(rows []Row, queryResult *tsdb.QueryResult, query *Query) tsdb.TimeSeriesSlice {
-	var result tsdb.TimeSeriesSlice
+func (rp *ResponseParser) transformRows(rows []Row, query *Query) data.Frames {
+	var frames data.Frames
 	for _, row := range rows {
+		timeIndex := columnIndex(row.Columns, "time")
 		for columnIndex, column := range row.Columns {
-			if column == "time" {
+			if columnIndex == timeIndex {
 				continue
 			}
 
-			var points tsdb.TimeSeriesPoints
+			times := make([]time.Time, 0, len(row.Values))
+			values := make([]*float64, 0, len(row.Values))
+			for _, valuePair := range row.Values {
+				timestamp, err := rp.parseTimestamp(valuePair[timeIndex])
+				if err != nil {
+					continue
+				}
+				times = append(times, timestamp)
+				values = append(values, rp.parseValue(valuePair[columnIndex]).Ptr())
+			}
+
+			name := rp.formatSeriesName(row, column, query)
+			valueField := data.NewField("Value", data.Labels(row.Tags), values)
+			valueField.SetConfig(&data.FieldConfig{DisplayName: name})
+			frames = append(frames, data.NewFrame(name, data.NewField("Time", nil, times), valueField))
+		}
+	}
+
+	return frames
+}
+
+// transformRowsToTable returns a frame with the tag keys of the rows followed
+// by their columns, or nil if there are no rows.
+func (rp *ResponseParser) transformRowsToTable(rows []Row) *data.Frame {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var tagKeys []string
+	var columns []string
+	for _, row := range rows {
+		for key := range row.Tags {
+			if !containsString(tagKeys, key) {
+				tagKeys = append(tagKeys, key)
+			}
+		}
+		for _, column := range row.Columns {
+			if !containsString(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	sort.Strings(tagKeys)
+
+	frame := data.NewFrame(rows[0].Name)
+	for _, key := range tagKeys {
+		values := make([]string, 0)
+		for _, row := range rows {
+			for range row.Values {
+				values = append(values, row.Tags[key])
+			}
+		}
+		frame.Fields = append(frame.Fields, data.NewField(key, nil, values))
+	}
+
+	for _, column := range columns {
+		var cells []interface{}
+		for _, row := range rows {
+			index := columnIndex(row.Columns, column)
 			for _, valuePair := range row.Values {
-				point, err := rp.parseTimepoint(valuePair, columnIndex)
-				if err == nil {
-					points = append(points, point)
+				if index < 0 || index >= len(valuePair) {
+					cells = append(cells, nil)
+					continue
 				}
+				cells = append(cells, valuePair[index])
 			}
-			result = append(result, &tsdb.TimeSeries{
-				Name:   rp.formatSeriesName(row, column, query),
-				Points: points,
-				Tags:   row.Tags,
-			})
 		}
+		frame.Fields = append(frame.Fields, rp.newTableField(column, cells))
 	}
 
-	return result
+	return frame
+}
+
+// newTableField returns a field of the type of the non-null cells of a column:
+// time for the time column, then number, boolean or string.
+func (rp *ResponseParser) newTableField(column string, cells []interface{}) *data.Field {
+	if column == "time" {
+		values := make([]*time.Time, len(cells))
+		for i, cell := range cells {
+			if timestamp, err := rp.parseTimestamp(cell); err == nil {
+				values[i] = &timestamp
+			}
+		}
+		return data.NewField(column, nil, values)
+	}
+
+	numbers, bools := true, true
+	for _, cell := range cells {
+		switch cell.(type) {
+		case nil:
+		case json.Number:
+			bools = false
+		case bool:
+			numbers = false
+		default:
+			numbers, bools = false, false
+		}
+	}
+
+	switch {
+	case numbers:
+		values := make([]*float64, len(cells))
+		for i, cell := range cells {
+			values[i] = rp.parseValue(cell).Ptr()
+		}
+		return data.NewField(column, nil, values)
+	case bools:
+		values := make([]*bool, len(cells))
+		for i, cell := range cells {
+			if b, ok := cell.(bool); ok {
+				values[i] = &b
+			}
+		}
+		return data.NewField(column, nil, values)
+	default:
+		values := make([]*string, len(cells))
+		for i, cell := range cells {
+			if cell == nil {
+				continue
+			}
+			s := fmt.Sprint(cell)
+			values[i] = &s
+		}
+		return data.NewField(column, nil, values)
+	}
+}
+
+// hasTimeColumn returns true if all rows have a time column and at least one
+// of them has a time other than zero. InfluxDB returns a zero time for
+// aggregations without a time range and without GROUP BY time.
+func hasTimeColumn(rows []Row) bool {
+	nonZero := false
+	for _, row := range rows {
+		index := columnIndex(row.Columns, "time")
+		if index < 0 {
+			return false
+		}
+		for _, valuePair := range row.Values {
+			if index < len(valuePair) && valuePair[index] != json.Number("0") {
+				nonZero = true
+			}
+		}
+	}
+	return nonZero
+}
+
+// groupsByTime returns false for queries that have a GROUP BY clause without
+// time, whose results hold a single aggregated point per tag set.
+func groupsByTime(query *Query) bool {
+	if query.UseRawQuery {
+		match := groupByClause.FindStringSubmatch(query.RawQuery)
+		return match == nil || groupByTime.MatchString(match[1])
+	}
+
+	if len(query.GroupBy) == 0 {
+		return true
+	}
+	for _, group := range query.GroupBy {
+		if group.Type == "time" {
+			return true
+		}
+	}
+	return false
+}
+
+func columnIndex(columns []string, name string) int {
+	for i, column := range columns {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 func (rp *ResponseParser) formatSeriesName(row Row, column string, query *Query) string {
@@ -115,19 +302,18 @@ func (rp *ResponseParser) buildSeriesNameFromQuery(row Row, column string) strin
 	return fmt.Sprintf("%s.%s%s", row.Name, column, tagText)
 }
 
-func (rp *ResponseParser) parseTimepoint(valuePair []interface{}, valuePosition int) (tsdb.TimePoint, error) {
-	value := rp.parseValue(valuePair[valuePosition])
-
-	timestampNumber, ok := valuePair[0].(json.Number)
+// parseTimestamp parses a timestamp returned with second epoch precision.
+func (rp *ResponseParser) parseTimestamp(value interface{}) (time.Time, error) {
+	timestampNumber, ok := value.(json.Number)
 	if !ok {
-		return tsdb.TimePoint{}, fmt.Errorf("valuePair[0] has invalid type: %#v", valuePair[0])
+		return time.Time{}, fmt.Errorf("timestamp has invalid type: %#v", value)
 	}
-	timestamp, err := timestampNumber.Float64()
+	timestamp, err := timestampNumber.Int64()
 	if err != nil {
-		return tsdb.TimePoint{}, err
+		return time.Time{}, err
 	}
 
-	return tsdb.NewTimePoint(value, timestamp), nil
+	return time.Unix(timestamp, 0).UTC(), nil
 }
 
 func (rp *ResponseParser) parseValue(value interface{}) null.Float {
@@ -1,11 +1,16 @@
 package influxdb
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -42,28 +47,37 @@ func TestInfluxdbResponseParser(t *testing.T) {
 			query := &Query{}
 
 			result := parser.Parse(response, query)
+			frames := decodedFrames(result)
 
 			Convey("can parse all series", func() {
-				So(len(result.Series), ShouldEqual, 2)
+				So(len(frames), ShouldEqual, 2)
 			})
 
 			Convey("can parse all points", func() {
-				So(len(result.Series[0].Points), ShouldEqual, 3)
-				So(len(result.Series[1].Points), ShouldEqual, 3)
+				So(frames[0].Rows(), ShouldEqual, 3)
+				So(frames[1].Rows(), ShouldEqual, 3)
 			})
 
 			Convey("can parse multi row result", func() {
-				So(result.Series[0].Points[1][0].Float64, ShouldEqual, float64(222))
-				So(result.Series[1].Points[1][0].Float64, ShouldEqual, float64(333))
+				So(*frames[0].Fields[1].At(1).(*float64), ShouldEqual, float64(222))
+				So(*frames[1].Fields[1].At(1).(*float64), ShouldEqual, float64(333))
 			})
 
 			Convey("can parse null points", func() {
-				So(result.Series[0].Points[2][0].Valid, ShouldBeFalse)
+				So(frames[0].Fields[1].At(2), ShouldBeNil)
 			})
 
 			Convey("can format series names", func() {
-				So(result.Series[0].Name, ShouldEqual, "cpu.mean { datacenter: America }")
-				So(result.Series[1].Name, ShouldEqual, "cpu.sum { datacenter: America }")
+				So(frames[0].Fields[1].Config.DisplayName, ShouldEqual, "cpu.mean { datacenter: America }")
+				So(frames[1].Fields[1].Config.DisplayName, ShouldEqual, "cpu.sum { datacenter: America }")
+			})
+
+			Convey("can parse tags as labels", func() {
+				So(frames[0].Fields[1].Labels, ShouldResemble, data.Labels{"datacenter": "America"})
+			})
+
+			Convey("can parse timestamps", func() {
+				So(frames[0].Fields[0].At(0), ShouldEqual, time.Unix(111, 0).UTC())
 			})
 		})
 
@@ -93,83 +107,83 @@ func TestInfluxdbResponseParser(t *testing.T) {
 			Convey("$ alias", func() {
 				Convey("simple alias", func() {
 					query := &Query{Alias: "series alias"}
-					result := parser.Parse(response, query)
+					frames := decodedFrames(parser.Parse(response, query))
 
-					So(result.Series[0].Name, ShouldEqual, "series alias")
+					So(frames[0].Name, ShouldEqual, "series alias")
 				})
 
 				Convey("measurement alias", func() {
 					query := &Query{Alias: "alias $m $measurement", Measurement: "10m"}
-					result := parser.Parse(response, query)
+					frames := decodedFrames(parser.Parse(response, query))
 
-					So(result.Series[0].Name, ShouldEqual, "alias 10m 10m")
+					So(frames[0].Name, ShouldEqual, "alias 10m 10m")
 				})
 
 				Convey("column alias", func() {
 					query := &Query{Alias: "alias $col", Measurement: "10m"}
-					result := parser.Parse(response, query)
+					frames := decodedFrames(parser.Parse(response, query))
 
-					So(result.Series[0].Name, ShouldEqual, "alias mean")
-					So(result.Series[1].Name, ShouldEqual, "alias sum")
+					So(frames[0].Name, ShouldEqual, "alias mean")
+					So(frames[1].Name, ShouldEqual, "alias sum")
 				})
 
 				Convey("tag alias", func() {
 					query := &Query{Alias: "alias $tag_datacenter"}
-					result := parser.Parse(response, query)
+					frames := decodedFrames(parser.Parse(response, query))
 
-					So(result.Series[0].Name, ShouldEqual, "alias America")
+					So(frames[0].Name, ShouldEqual, "alias America")
 				})
 
 				Convey("segment alias", func() {
 					query := &Query{Alias: "alias $1"}
-					result := parser.Parse(response, query)
+					frames := decodedFrames(parser.Parse(response, query))
 
-					So(result.Series[0].Name, ShouldEqual, "alias upc")
+					So(frames[0].Name, ShouldEqual, "alias upc")
 				})
 
 				Convey("segment position out of bound", func() {
 					query := &Query{Alias: "alias $5"}
-					result := parser.Parse(response, query)
+					frames := decodedFrames(parser.Parse(response, query))
 
-					So(result.Series[0].Name, ShouldEqual, "alias $5")
+					So(frames[0].Name, ShouldEqual, "alias $5")
 				})
 			})
 
 			Convey("[[]] alias", func() {
 				Convey("simple alias", func() {
 					query := &Query{Alias: "series alias"}
-					result := parser.Parse(response, query)
+					frames := decodedFrames(parser.Parse(response, query))
 
-					So(result.Series[0].Name, ShouldEqual, "series alias")
+					So(frames[0].Name, ShouldEqual, "series alias")
 				})
 
 				Convey("measurement alias", func() {
 					query := &Query{Alias: "alias [[m]] [[measurement]]", Measurement: "10m"}
-					result := parser.Parse(response, query)
+					frames := decodedFrames(parser.Parse(response, query))
 
-					So(result.Series[0].Name, ShouldEqual, "alias 10m 10m")
+					So(frames[0].Name, ShouldEqual, "alias 10m 10m")
 				})
 
 				Convey("column alias", func() {
 					query := &Query{Alias: "alias [[col]]", Measurement: "10m"}
-					result := parser.Parse(response, query)
+					frames := decodedFrames(parser.Parse(response, query))
 
-					So(result.Series[0].Name, ShouldEqual, "alias mean")
-					So(result.Series[1].Name, ShouldEqual, "alias sum")
+					So(frames[0].Name, ShouldEqual, "alias mean")
+					So(frames[1].Name, ShouldEqual, "alias sum")
 				})
 
 				Convey("tag alias", func() {
 					query := &Query{Alias: "alias [[tag_datacenter]]"}
-					result := parser.Parse(response, query)
+					frames := decodedFrames(parser.Parse(response, query))
 
-					So(result.Series[0].Name, ShouldEqual, "alias America")
+					So(frames[0].Name, ShouldEqual, "alias America")
 				})
 
 				Convey("tag alias with periods", func() {
 					query := &Query{Alias: "alias [[tag_dc.region.name]]"}
-					result := parser.Parse(response, query)
+					frames := decodedFrames(parser.Parse(response, query))
 
-					So(result.Series[0].Name, ShouldEqual, "alias Northeast")
+					So(frames[0].Name, ShouldEqual, "alias Northeast")
 				})
 			})
 		})
@@ -208,14 +222,15 @@ func TestInfluxdbResponseParser(t *testing.T) {
 			query := &Query{}
 
 			result := parser.Parse(response, query)
+			frames := decodedFrames(result)
 
 			Convey("can parse all series", func() {
-				So(len(result.Series), ShouldEqual, 2)
+				So(len(frames), ShouldEqual, 2)
 			})
 
 			Convey("can parse all points", func() {
-				So(len(result.Series[0].Points), ShouldEqual, 3)
-				So(len(result.Series[1].Points), ShouldEqual, 3)
+				So(frames[0].Rows(), ShouldEqual, 3)
+				So(frames[1].Rows(), ShouldEqual, 3)
 			})
 
 			Convey("can parse errors ", func() {
@@ -223,5 +238,159 @@ func TestInfluxdbResponseParser(t *testing.T) {
 				So(result.Error.Error(), ShouldEqual, "query-timeout limit exceeded")
 			})
 		})
+
+		Convey("Response parser with table results", func() {
+			parser := &ResponseParser{}
+
+			Convey("SHOW statements return a table", func() {
+				response := &Response{
+					Results: []Result{
+						{
+							Series: []Row{
+								{
+									Name:    "measurements",
+									Columns: []string{"name"},
+									Values: [][]interface{}{
+										{"cpu"},
+										{"mem"},
+									},
+								},
+							},
+						},
+					},
+				}
+
+				frames := decodedFrames(parser.Parse(response, &Query{}))
+
+				So(len(frames), ShouldEqual, 1)
+				So(frames[0].Name, ShouldEqual, "measurements")
+				So(len(frames[0].Fields), ShouldEqual, 1)
+				So(frames[0].Fields[0].Name, ShouldEqual, "name")
+				So(*frames[0].Fields[0].At(1).(*string), ShouldEqual, "mem")
+			})
+
+			Convey("GROUP BY without time returns tags as columns", func() {
+				response := &Response{
+					Results: []Result{
+						{
+							Series: []Row{
+								{
+									Name:    "cpu",
+									Columns: []string{"time", "mean"},
+									Tags:    map[string]string{"datacenter": "America"},
+									Values: [][]interface{}{
+										{json.Number("0"), json.Number("1.5")},
+									},
+								},
+								{
+									Name:    "cpu",
+									Columns: []string{"time", "mean"},
+									Tags:    map[string]string{"datacenter": "Europe"},
+									Values: [][]interface{}{
+										{json.Number("0"), nil},
+									},
+								},
+							},
+						},
+					},
+				}
+
+				frames := decodedFrames(parser.Parse(response, &Query{ResultFormat: "table"}))
+
+				So(len(frames), ShouldEqual, 1)
+				So(len(frames[0].Fields), ShouldEqual, 3)
+				So(frames[0].Fields[0].Name, ShouldEqual, "datacenter")
+				So(frames[0].Fields[0].At(1), ShouldEqual, "Europe")
+				So(frames[0].Fields[1].Name, ShouldEqual, "time")
+				So(frames[0].Fields[2].Name, ShouldEqual, "mean")
+				So(*frames[0].Fields[2].At(0).(*float64), ShouldEqual, 1.5)
+				So(frames[0].Fields[2].At(1), ShouldBeNil)
+			})
+
+			Convey("multiple statements return the frames of each statement", func() {
+				response := &Response{
+					Results: []Result{
+						{
+							Series: []Row{
+								{
+									Name:    "cpu",
+									Columns: []string{"time", "mean"},
+									Values: [][]interface{}{
+										{json.Number("1000"), json.Number("1")},
+									},
+								},
+							},
+						},
+						{
+							Series: []Row{
+								{
+									Name:    "tag values",
+									Columns: []string{"key", "value"},
+									Values: [][]interface{}{
+										{"host", "server1"},
+									},
+								},
+							},
+						},
+					},
+				}
+
+				frames := decodedFrames(parser.Parse(response, &Query{}))
+
+				So(len(frames), ShouldEqual, 2)
+				So(frames[0].Fields[1].Config.DisplayName, ShouldEqual, "cpu.mean")
+				So(frames[1].Name, ShouldEqual, "tag values")
+				So(*frames[1].Fields[1].At(0).(*string), ShouldEqual, "server1")
+			})
+		})
+
+		Convey("Response parser with an aggregation grouped by tags only", func() {
+			parser := &ResponseParser{}
+
+			body, err := ioutil.ReadFile("testdata/group_by_tags.json")
+			So(err, ShouldBeNil)
+			var response Response
+			dec := json.NewDecoder(bytes.NewReader(body))
+			dec.UseNumber()
+			So(dec.Decode(&response), ShouldBeNil)
+
+			Convey("raw query returns table frames", func() {
+				query := &Query{
+					UseRawQuery: true,
+					RawQuery:    `SELECT mean("usage_idle") FROM "cpu" WHERE time >= 1602979200s GROUP BY "host"; SELECT count("usage_idle") FROM "cpu"`,
+				}
+				frames := decodedFrames(parser.Parse(&response, query))
+
+				So(len(frames), ShouldEqual, 2)
+				So(frames[0].Fields[0].Name, ShouldEqual, "host")
+				So(frames[0].Rows(), ShouldEqual, 2)
+				So(frames[0].Fields[0].At(1), ShouldEqual, "server02")
+				So(*frames[0].Fields[2].At(1).(*float64), ShouldEqual, 87.26315789473684)
+				So(frames[1].Fields[1].Name, ShouldEqual, "count")
+				So(*frames[1].Fields[1].At(0).(*float64), ShouldEqual, float64(266))
+			})
+
+			Convey("query grouped by tags and fill only returns table frames", func() {
+				query := &Query{GroupBy: []*QueryPart{{Type: "tag", Params: []string{"host"}}, {Type: "fill", Params: []string{"null"}}}}
+				frames := decodedFrames(parser.Parse(&response, query))
+
+				So(frames[0].Fields[0].Name, ShouldEqual, "host")
+				So(frames[0].Rows(), ShouldEqual, 2)
+			})
+
+			Convey("query grouped by time returns time series", func() {
+				query := &Query{GroupBy: []*QueryPart{{Type: "time", Params: []string{"$__interval"}}, {Type: "tag", Params: []string{"host"}}}}
+				frames := decodedFrames(parser.Parse(&response, query))
+
+				So(frames[0].Fields[0].At(0), ShouldEqual, time.Unix(1602979200, 0).UTC())
+				So(frames[0].Fields[1].Labels, ShouldResemble, data.Labels{"host": "server01"})
+			})
+		})
 	})
 }
+
+func decodedFrames(result *tsdb.QueryResult) data.Frames {
+	frames, err := result.Dataframes.Decoded()
+	So(err, ShouldBeNil)
+	return frames
+}
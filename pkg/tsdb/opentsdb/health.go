@@ -0,0 +1,15 @@
+package opentsdb
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth suggests a single metric name, which doesn't fetch any datapoints
+func (e *OpenTsdbExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	params := url.Values{"type": []string{"metrics"}, "max": []string{"1"}}
+	return tsdb.CheckHTTPHealth(ctx, dsInfo, "api/suggest", params, "Data source is working")
+}
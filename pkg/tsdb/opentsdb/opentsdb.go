@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/net/context/ctxhttp"
 
@@ -14,7 +16,8 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
@@ -37,17 +40,55 @@ func init() {
 	tsdb.RegisterTsdbQueryEndpoint("opentsdb", NewOpenTsdbExecutor)
 }
 
-func (e *OpenTsdbExecutor) Query(ctx context.Context, dsInfo *models.DataSource, queryContext *tsdb.TsdbQuery) (*tsdb.Response, error) {
-	result := &tsdb.Response{}
+var fillPolicies = []string{"none", "nan", "null", "zero"}
+
+var filterTypes = []string{
+	"literal_or",
+	"iliteral_or",
+	"not_literal_or",
+	"not_iliteral_or",
+	"wildcard",
+	"iwildcard",
+	"regexp",
+}
 
-	var tsdbQuery OpenTsdbQuery
+// Query runs one OpenTSDB request per query, so that the series and
+// annotations returned can be matched to the query that requested them.
+func (e *OpenTsdbExecutor) Query(ctx context.Context, dsInfo *models.DataSource, queryContext *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	result := &tsdb.Response{
+		Results: make(map[string]*tsdb.QueryResult),
+	}
 
-	tsdbQuery.Start = queryContext.TimeRange.GetFromAsMsEpoch()
-	tsdbQuery.End = queryContext.TimeRange.GetToAsMsEpoch()
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return nil, err
+	}
 
 	for _, query := range queryContext.Queries {
-		metric := e.buildMetric(query)
-		tsdbQuery.Queries = append(tsdbQuery.Queries, metric)
+		queryRes, err := e.executeQuery(ctx, dsInfo, httpClient, query, queryContext)
+		if err != nil {
+			queryRes = &tsdb.QueryResult{Error: err}
+		}
+		queryRes.RefId = query.RefId
+		result.Results[query.RefId] = queryRes
+	}
+
+	return result, nil
+}
+
+func (e *OpenTsdbExecutor) executeQuery(ctx context.Context, dsInfo *models.DataSource, httpClient *http.Client, query *tsdb.Query, queryContext *tsdb.TsdbQuery) (*tsdb.QueryResult, error) {
+	if err := validateQuery(query); err != nil {
+		return nil, err
+	}
+
+	tsdbQuery := OpenTsdbQuery{
+		Start:             queryContext.TimeRange.GetFromAsMsEpoch(),
+		End:               queryContext.TimeRange.GetToAsMsEpoch(),
+		Queries:           []map[string]interface{}{e.buildMetric(query)},
+		MsResolution:      dsInfo.JsonData.Get("tsdbResolution").MustInt(1) == 2,
+		ShowTSUIDs:        query.Model.Get("showTSUIDs").MustBool(),
+		GlobalAnnotations: query.Model.Get("globalAnnotations").MustBool(),
+		annotations:       query.Model.Get("annotations").MustBool(),
 	}
 
 	if setting.Env == setting.DEV {
@@ -59,33 +100,59 @@ func (e *OpenTsdbExecutor) Query(ctx context.Context, dsInfo *models.DataSource,
 		return nil, err
 	}
 
-	httpClient, err := dsInfo.GetHttpClient()
+	res, err := ctxhttp.Do(ctx, httpClient, req)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := ctxhttp.Do(ctx, httpClient, req)
-	if err != nil {
-		return nil, err
+	return e.parseResponse(tsdbQuery, res)
+}
+
+// validateQuery checks the fill policy and filter types of a query, which
+// OpenTSDB would otherwise reject with an error for the whole request.
+func validateQuery(query *tsdb.Query) error {
+	if query.Model.Get("metric").MustString() == "" && len(query.Model.Get("tsuids").MustStringArray()) == 0 {
+		return fmt.Errorf("query has no metric or tsuids")
 	}
 
-	queryResult, err := e.parseResponse(tsdbQuery, res)
-	if err != nil {
-		return nil, err
+	if !query.Model.Get("disableDownsampling").MustBool() {
+		fillPolicy := downsampleFillPolicy(query)
+		if !containsString(fillPolicies, fillPolicy) {
+			return fmt.Errorf("invalid downsample fill policy %q", fillPolicy)
+		}
 	}
 
-	result.Results = queryResult
-	return result, nil
+	for _, f := range query.Model.Get("filters").MustArray() {
+		filter := simplejson.NewFromAny(f)
+		filterType := filter.Get("type").MustString()
+		if !containsString(filterTypes, filterType) {
+			return fmt.Errorf("invalid filter type %q", filterType)
+		}
+		if filter.Get("tagk").MustString() == "" {
+			return fmt.Errorf("filter of type %q has no tag key", filterType)
+		}
+	}
+
+	return nil
 }
 
-func (e *OpenTsdbExecutor) createRequest(dsInfo *models.DataSource, data OpenTsdbQuery) (*http.Request, error) {
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *OpenTsdbExecutor) createRequest(dsInfo *models.DataSource, query OpenTsdbQuery) (*http.Request, error) {
 	u, err := url.Parse(dsInfo.Url)
 	if err != nil {
 		return nil, err
 	}
 	u.Path = path.Join(u.Path, "api/query")
 
-	postData, err := json.Marshal(data)
+	postData, err := json.Marshal(query)
 	if err != nil {
 		plog.Info("Failed marshaling data", "error", err)
 		return nil, fmt.Errorf("Failed to create request. error: %v", err)
@@ -105,8 +172,7 @@ func (e *OpenTsdbExecutor) createRequest(dsInfo *models.DataSource, data OpenTsd
 	return req, err
 }
 
-func (e *OpenTsdbExecutor) parseResponse(query OpenTsdbQuery, res *http.Response) (map[string]*tsdb.QueryResult, error) {
-	queryResults := make(map[string]*tsdb.QueryResult)
+func (e *OpenTsdbExecutor) parseResponse(query OpenTsdbQuery, res *http.Response) (*tsdb.QueryResult, error) {
 	queryRes := tsdb.NewQueryResult()
 
 	body, err := ioutil.ReadAll(res.Body)
@@ -117,42 +183,142 @@ func (e *OpenTsdbExecutor) parseResponse(query OpenTsdbQuery, res *http.Response
 
 	if res.StatusCode/100 != 2 {
 		plog.Info("Request failed", "status", res.Status, "body", string(body))
+		var errorResponse OpenTsdbErrorResponse
+		if err := json.Unmarshal(body, &errorResponse); err == nil && errorResponse.Error.Message != "" {
+			return nil, fmt.Errorf("Request failed status: %v, error: %v", res.Status, errorResponse.Error.Message)
+		}
 		return nil, fmt.Errorf("Request failed status: %v", res.Status)
 	}
 
-	var data []OpenTsdbResponse
-	err = json.Unmarshal(body, &data)
+	var response []OpenTsdbResponse
+	err = json.Unmarshal(body, &response)
 	if err != nil {
 		plog.Info("Failed to unmarshal opentsdb response", "error", err, "status", res.Status, "body", string(body))
 		return nil, err
 	}
 
-	for _, val := range data {
-		series := tsdb.TimeSeries{
-			Name: val.Metric,
+	frames := data.Frames{}
+	var annotations []OpenTsdbAnnotation
+	for _, val := range response {
+		frame, err := e.parseSeries(val, query.MsResolution)
+		if err != nil {
+			return nil, err
 		}
+		frames = append(frames, frame)
+		if query.annotations {
+			annotations = append(annotations, val.Annotations...)
+		}
+		if query.GlobalAnnotations {
+			annotations = append(annotations, val.GlobalAnnotations...)
+		}
+	}
 
-		for timeString, value := range val.DataPoints {
-			timestamp, err := strconv.ParseFloat(timeString, 64)
-			if err != nil {
-				plog.Info("Failed to unmarshal opentsdb timestamp", "timestamp", timeString)
-				return nil, err
-			}
-			series.Points = append(series.Points, tsdb.NewTimePoint(null.FloatFrom(value), timestamp))
+	if len(annotations) > 0 {
+		frames = append(frames, annotationsToFrame(annotations))
+	}
+
+	queryRes.Dataframes = tsdb.NewDecodedDataFrames(frames)
+	return queryRes, nil
+}
+
+// parseSeries converts a series into a frame with its tags as labels. The
+// timestamps of the data points are in seconds, or in milliseconds when the
+// request asked for millisecond resolution.
+func (e *OpenTsdbExecutor) parseSeries(val OpenTsdbResponse, msResolution bool) (*data.Frame, error) {
+	timestamps := make([]int64, 0, len(val.DataPoints))
+	for timeString := range val.DataPoints {
+		timestamp, err := strconv.ParseInt(timeString, 10, 64)
+		if err != nil {
+			plog.Info("Failed to unmarshal opentsdb timestamp", "timestamp", timeString)
+			return nil, err
 		}
+		timestamps = append(timestamps, timestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
 
-		queryRes.Series = append(queryRes.Series, &series)
+	times := make([]time.Time, len(timestamps))
+	values := make([]*float64, len(timestamps))
+	for i, timestamp := range timestamps {
+		if msResolution {
+			times[i] = time.Unix(0, timestamp*int64(time.Millisecond)).UTC()
+		} else {
+			times[i] = time.Unix(timestamp, 0).UTC()
+		}
+		values[i] = val.DataPoints[strconv.FormatInt(timestamp, 10)]
 	}
 
-	queryResults["A"] = queryRes
-	return queryResults, nil
+	valueField := data.NewField("Value", data.Labels(val.Tags), values)
+	valueField.SetConfig(&data.FieldConfig{DisplayName: val.Metric})
+	frame := data.NewFrame(val.Metric, data.NewField("Time", nil, times), valueField)
+
+	custom := map[string]interface{}{}
+	if len(val.AggregateTags) > 0 {
+		custom["aggregateTags"] = val.AggregateTags
+	}
+	if len(val.Tsuids) > 0 {
+		custom["tsuids"] = val.Tsuids
+	}
+	if len(custom) > 0 {
+		frame.SetMeta(&data.FrameMeta{Custom: custom})
+	}
+
+	return frame, nil
+}
+
+// annotationsToFrame returns a frame with the time, end time and text of the
+// annotations, leaving out the duplicates of global annotations that are
+// returned with every series.
+func annotationsToFrame(annotations []OpenTsdbAnnotation) *data.Frame {
+	seen := make(map[OpenTsdbAnnotation]bool)
+	times := make([]time.Time, 0, len(annotations))
+	timeEnds := make([]*time.Time, 0, len(annotations))
+	texts := make([]string, 0, len(annotations))
+	tsuids := make([]string, 0, len(annotations))
+
+	for _, annotation := range annotations {
+		if seen[annotation] {
+			continue
+		}
+		seen[annotation] = true
+
+		var timeEnd *time.Time
+		if annotation.EndTime > 0 {
+			t := time.Unix(annotation.EndTime, 0).UTC()
+			timeEnd = &t
+		}
+		times = append(times, time.Unix(annotation.StartTime, 0).UTC())
+		timeEnds = append(timeEnds, timeEnd)
+		texts = append(texts, annotation.Description)
+		tsuids = append(tsuids, annotation.Tsuid)
+	}
+
+	return data.NewFrame("annotations",
+		data.NewField("time", nil, times),
+		data.NewField("timeEnd", nil, timeEnds),
+		data.NewField("text", nil, texts),
+		data.NewField("tsuid", nil, tsuids),
+	)
+}
+
+// downsampleFillPolicy returns the fill policy of the query, "none" when
+// it's not set.
+func downsampleFillPolicy(query *tsdb.Query) string {
+	if fillPolicy := query.Model.Get("downsampleFillPolicy").MustString("none"); fillPolicy != "" {
+		return fillPolicy
+	}
+	return "none"
 }
 
 func (e *OpenTsdbExecutor) buildMetric(query *tsdb.Query) map[string]interface{} {
 	metric := make(map[string]interface{})
 
-	// Setting metric and aggregator
-	metric["metric"] = query.Model.Get("metric").MustString()
+	// Setting metric or tsuids and aggregator
+	tsuids := query.Model.Get("tsuids").MustStringArray()
+	if len(tsuids) > 0 {
+		metric["tsuids"] = tsuids
+	} else {
+		metric["metric"] = query.Model.Get("metric").MustString()
+	}
 	metric["aggregator"] = query.Model.Get("aggregator").MustString()
 
 	// Setting downsampling options
@@ -163,8 +329,8 @@ func (e *OpenTsdbExecutor) buildMetric(query *tsdb.Query) map[string]interface{}
 			downsampleInterval = "1m" //default value for blank
 		}
 		downsample := downsampleInterval + "-" + query.Model.Get("downsampleAggregator").MustString()
-		if query.Model.Get("downsampleFillPolicy").MustString() != "none" {
-			metric["downsample"] = downsample + "-" + query.Model.Get("downsampleFillPolicy").MustString()
+		if fillPolicy := downsampleFillPolicy(query); fillPolicy != "none" {
+			metric["downsample"] = downsample + "-" + fillPolicy
 		} else {
 			metric["downsample"] = downsample
 		}
@@ -205,5 +371,10 @@ func (e *OpenTsdbExecutor) buildMetric(query *tsdb.Query) map[string]interface{}
 		metric["filters"] = filters.MustArray()
 	}
 
+	// Only return series with exactly the tags of the filters (OpenTSDB 2.3+)
+	if query.Model.Get("explicitTags").MustBool() {
+		metric["explicitTags"] = true
+	}
+
 	return metric
 }
@@ -1,9 +1,16 @@
 package opentsdb
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/tsdb"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -32,6 +39,23 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			So(metric["downsample"], ShouldEqual, "1m-avg")
 		})
 
+		Convey("Build metric with downsampling enabled without fill policy", func() {
+			query := &tsdb.Query{
+				Model: simplejson.New(),
+			}
+
+			query.Model.Set("metric", "cpu.average.percent")
+			query.Model.Set("aggregator", "avg")
+			query.Model.Set("downsampleInterval", "5m")
+			query.Model.Set("downsampleAggregator", "sum")
+
+			So(exec.buildMetric(query)["downsample"], ShouldEqual, "5m-sum")
+
+			query.Model.Set("downsampleFillPolicy", "")
+			So(exec.buildMetric(query)["downsample"], ShouldEqual, "5m-sum")
+			So(validateQuery(query), ShouldBeNil)
+		})
+
 		Convey("Build metric with downsampling disabled", func() {
 			query := &tsdb.Query{
 				Model: simplejson.New(),
@@ -162,5 +186,168 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			So(metric["rateOptions"].(map[string]interface{})["counterMax"], ShouldEqual, 45)
 			So(metric["rateOptions"].(map[string]interface{})["resetValue"], ShouldEqual, 60)
 		})
+
+		Convey("Build metric with explicit tags and tsuids", func() {
+			query := &tsdb.Query{
+				Model: simplejson.New(),
+			}
+
+			query.Model.Set("tsuids", []interface{}{"000001000001000001"})
+			query.Model.Set("aggregator", "sum")
+			query.Model.Set("disableDownsampling", true)
+			query.Model.Set("explicitTags", true)
+
+			metric := exec.buildMetric(query)
+
+			So(len(metric), ShouldEqual, 3)
+			So(metric["metric"], ShouldBeNil)
+			So(metric["tsuids"], ShouldResemble, []string{"000001000001000001"})
+			So(metric["explicitTags"], ShouldEqual, true)
+		})
+
+		Convey("Validate query", func() {
+			query := &tsdb.Query{
+				Model: simplejson.New(),
+			}
+			query.Model.Set("metric", "cpu.average.percent")
+			query.Model.Set("downsampleFillPolicy", "zero")
+
+			Convey("with valid filters", func() {
+				query.Model.Set("filters", []interface{}{
+					map[string]interface{}{"type": "literal_or", "tagk": "env", "filter": "prod|dev", "groupBy": true},
+					map[string]interface{}{"type": "wildcard", "tagk": "host", "filter": "web*", "groupBy": false},
+					map[string]interface{}{"type": "regexp", "tagk": "dc", "filter": "eu-.*", "groupBy": false},
+				})
+				So(validateQuery(query), ShouldBeNil)
+			})
+
+			Convey("with unknown filter type", func() {
+				query.Model.Set("filters", []interface{}{
+					map[string]interface{}{"type": "prefix", "tagk": "env", "filter": "prod"},
+				})
+				So(validateQuery(query), ShouldNotBeNil)
+			})
+
+			Convey("with unknown fill policy", func() {
+				query.Model.Set("downsampleFillPolicy", "previous")
+				So(validateQuery(query), ShouldNotBeNil)
+			})
+
+			Convey("without metric", func() {
+				query.Model.Del("metric")
+				So(validateQuery(query), ShouldNotBeNil)
+			})
+		})
+
+		Convey("Query against a stub server", func() {
+			var requests []map[string]interface{}
+			response := `[{
+				"metric": "cpu.average.percent",
+				"tags": {"env": "prod"},
+				"aggregateTags": ["host"],
+				"dps": {"2000": 2, "1000": 1, "3000": null},
+				"annotations": [{"tsuid": "000001", "description": "deploy", "startTime": 1, "endTime": 2}],
+				"globalAnnotations": [{"description": "outage", "startTime": 5}]
+			}, {
+				"metric": "cpu.average.percent",
+				"tags": {"env": "dev"},
+				"dps": {"1000": 4},
+				"globalAnnotations": [{"description": "outage", "startTime": 5}]
+			}]`
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]interface{}
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				requests = append(requests, body)
+				metric := body["queries"].([]interface{})[0].(map[string]interface{})
+				if metric["metric"] == "missing" {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"error": {"code": 400, "message": "No such name for 'metrics': 'missing'"}}`))
+					return
+				}
+				_, _ = w.Write([]byte(response))
+			}))
+			defer server.Close()
+
+			dsInfo := &models.DataSource{
+				Url:      server.URL,
+				JsonData: simplejson.NewFromAny(map[string]interface{}{"tsdbResolution": 2}),
+			}
+			queryContext := &tsdb.TsdbQuery{
+				TimeRange: tsdb.NewTimeRange("5m", "now"),
+				Queries: []*tsdb.Query{
+					{
+						RefId: "A",
+						Model: simplejson.NewFromAny(map[string]interface{}{
+							"metric":               "cpu.average.percent",
+							"aggregator":           "sum",
+							"downsampleInterval":   "1m",
+							"downsampleAggregator": "avg",
+							"downsampleFillPolicy": "null",
+							"filters": []interface{}{
+								map[string]interface{}{"type": "literal_or", "tagk": "env", "filter": "prod|dev", "groupBy": true},
+							},
+							"annotations":       true,
+							"globalAnnotations": true,
+						}),
+					},
+					{
+						RefId: "B",
+						Model: simplejson.NewFromAny(map[string]interface{}{
+							"metric":     "missing",
+							"aggregator": "sum",
+						}),
+					},
+					{
+						RefId: "C",
+						Model: simplejson.NewFromAny(map[string]interface{}{
+							"metric":  "cpu.average.percent",
+							"filters": []interface{}{map[string]interface{}{"type": "prefix", "tagk": "env"}},
+						}),
+					},
+				},
+			}
+
+			res, err := exec.Query(context.Background(), dsInfo, queryContext)
+			So(err, ShouldBeNil)
+			So(len(requests), ShouldEqual, 2)
+
+			Convey("sends one request per query", func() {
+				So(requests[0]["msResolution"], ShouldEqual, true)
+				So(requests[0]["globalAnnotations"], ShouldEqual, true)
+				metric := requests[0]["queries"].([]interface{})[0].(map[string]interface{})
+				So(metric["downsample"], ShouldEqual, "1m-avg-null")
+				So(len(metric["filters"].([]interface{})), ShouldEqual, 1)
+			})
+
+			Convey("returns a frame per series with tags as labels", func() {
+				frames, err := res.Results["A"].Dataframes.Decoded()
+				So(err, ShouldBeNil)
+				So(len(frames), ShouldEqual, 3)
+				So(frames[0].Rows(), ShouldEqual, 3)
+				So(frames[0].Fields[0].At(0), ShouldEqual, time.Unix(1, 0).UTC())
+				So(*frames[0].Fields[1].At(1).(*float64), ShouldEqual, 2)
+				So(frames[0].Fields[1].At(2), ShouldBeNil)
+				So(frames[0].Fields[1].Labels, ShouldResemble, data.Labels{"env": "prod"})
+				So(frames[0].Fields[1].Config.DisplayName, ShouldEqual, "cpu.average.percent")
+				So(frames[0].Meta.Custom, ShouldResemble, map[string]interface{}{"aggregateTags": []string{"host"}})
+			})
+
+			Convey("returns the annotations without duplicates", func() {
+				frames, err := res.Results["A"].Dataframes.Decoded()
+				So(err, ShouldBeNil)
+				annotations := frames[2]
+				So(annotations.Name, ShouldEqual, "annotations")
+				So(annotations.Rows(), ShouldEqual, 2)
+				So(annotations.Fields[2].At(0), ShouldEqual, "deploy")
+				So(*annotations.Fields[1].At(0).(*time.Time), ShouldEqual, time.Unix(2, 0).UTC())
+				So(annotations.Fields[2].At(1), ShouldEqual, "outage")
+				So(annotations.Fields[1].At(1), ShouldBeNil)
+			})
+
+			Convey("returns an error per query", func() {
+				So(res.Results["B"].Error.Error(), ShouldContainSubstring, "No such name for 'metrics'")
+				So(res.Results["C"].Error.Error(), ShouldEqual, `invalid filter type "prefix"`)
+			})
+		})
 	})
 }
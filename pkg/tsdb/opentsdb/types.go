@@ -1,12 +1,40 @@
 package opentsdb
 
 type OpenTsdbQuery struct {
-	Start   int64                    `json:"start"`
-	End     int64                    `json:"end"`
-	Queries []map[string]interface{} `json:"queries"`
+	Start             int64                    `json:"start"`
+	End               int64                    `json:"end"`
+	Queries           []map[string]interface{} `json:"queries"`
+	MsResolution      bool                     `json:"msResolution,omitempty"`
+	ShowTSUIDs        bool                     `json:"showTSUIDs,omitempty"`
+	GlobalAnnotations bool                     `json:"globalAnnotations,omitempty"`
+
+	// annotations is set when the annotations of the series are requested
+	annotations bool
 }
 
 type OpenTsdbResponse struct {
-	Metric     string             `json:"metric"`
-	DataPoints map[string]float64 `json:"dps"`
+	Metric            string               `json:"metric"`
+	Tags              map[string]string    `json:"tags"`
+	AggregateTags     []string             `json:"aggregateTags"`
+	Tsuids            []string             `json:"tsuids"`
+	Annotations       []OpenTsdbAnnotation `json:"annotations"`
+	GlobalAnnotations []OpenTsdbAnnotation `json:"globalAnnotations"`
+	DataPoints        map[string]*float64  `json:"dps"`
+}
+
+// OpenTsdbAnnotation is an annotation of a time series, or a global
+// annotation if it has no tsuid. Start and end times are in seconds.
+type OpenTsdbAnnotation struct {
+	Tsuid       string `json:"tsuid"`
+	Description string `json:"description"`
+	Notes       string `json:"notes"`
+	StartTime   int64  `json:"startTime"`
+	EndTime     int64  `json:"endTime"`
+}
+
+type OpenTsdbErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
 }
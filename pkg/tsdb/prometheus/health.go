@@ -0,0 +1,23 @@
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth evaluates a constant expression, which doesn't read any series
+func (e *PrometheusExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	client, err := e.getClient(dsInfo)
+	if err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+
+	if _, _, err := client.Query(ctx, "1+1", time.Now()); err != nil {
+		return tsdb.HealthCheckError(err)
+	}
+
+	return tsdb.HealthCheckOK("Data source is working")
+}
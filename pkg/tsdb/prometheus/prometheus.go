@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,7 +13,7 @@ import (
 
 	"net/http"
 
-	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/tsdb"
@@ -98,36 +100,52 @@ func (e *PrometheusExecutor) Query(ctx context.Context, dsInfo *models.DataSourc
 	}
 
 	for _, query := range queries {
-		timeRange := apiv1.Range{
-			Start: query.Start,
-			End:   query.End,
-			Step:  query.Step,
-		}
-
-		plog.Debug("Sending query", "start", timeRange.Start, "end", timeRange.End, "step", timeRange.Step, "query", query.Expr)
-
-		span, ctx := opentracing.StartSpanFromContext(ctx, "alerting.prometheus")
-		span.SetTag("expr", query.Expr)
-		span.SetTag("start_unixnano", query.Start.UnixNano())
-		span.SetTag("stop_unixnano", query.End.UnixNano())
-		defer span.Finish()
-
-		value, _, err := client.QueryRange(ctx, query.Expr, timeRange)
+		queryResult := &tsdb.QueryResult{RefId: query.RefId}
+		result.Results[query.RefId] = queryResult
 
+		value, warnings, err := e.executeQuery(ctx, client, query)
 		if err != nil {
-			return nil, err
+			queryResult.Error = err
+			continue
+		}
+
+		for _, warning := range warnings {
+			plog.Warn("Query returned warning", "query", query.Expr, "warning", warning)
 		}
 
-		queryResult, err := parseResponse(value, query)
+		frames, err := parseResponse(value, warnings, query)
 		if err != nil {
-			return nil, err
+			queryResult.Error = err
+			continue
 		}
-		result.Results[query.RefId] = queryResult
+		queryResult.Dataframes = tsdb.NewDecodedDataFrames(frames)
 	}
 
 	return result, nil
 }
 
+func (e *PrometheusExecutor) executeQuery(ctx context.Context, client apiv1.API, query *PrometheusQuery) (model.Value, apiv1.Warnings, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "alerting.prometheus")
+	span.SetTag("expr", query.Expr)
+	span.SetTag("start_unixnano", query.Start.UnixNano())
+	span.SetTag("stop_unixnano", query.End.UnixNano())
+	defer span.Finish()
+
+	if query.Instant {
+		plog.Debug("Sending instant query", "time", query.End, "query", query.Expr)
+		return client.Query(ctx, query.Expr, query.End)
+	}
+
+	timeRange := apiv1.Range{
+		Start: query.Start,
+		End:   query.End,
+		Step:  query.Step,
+	}
+
+	plog.Debug("Sending query", "start", timeRange.Start, "end", timeRange.End, "step", timeRange.Step, "query", query.Expr)
+	return client.QueryRange(ctx, query.Expr, timeRange)
+}
+
 func formatLegend(metric model.Metric, query *PrometheusQuery) string {
 	if query.LegendFormat == "" {
 		return metric.String()
@@ -175,44 +193,220 @@ func parseQuery(dsInfo *models.DataSource, queries []*tsdb.Query, queryContext *
 		interval := intervalCalculator.Calculate(queryContext.TimeRange, dsInterval)
 		step := time.Duration(int64(interval.Value) * intervalFactor)
 
+		timeRange := end.Sub(start)
+		instant := queryModel.Model.Get("instant").MustBool(false)
+		if !instant {
+			// align the range to the step, so the same samples are returned for
+			// overlapping ranges and responses can be reused by caches
+			start, end = alignTimeRange(start, end, step)
+		}
+
 		qs = append(qs, &PrometheusQuery{
-			Expr:         expr,
+			Expr:         interpolateVariables(expr, interval.Value, step, timeRange, dsInterval),
 			Step:         step,
 			LegendFormat: format,
 			Start:        start,
 			End:          end,
 			RefId:        queryModel.RefId,
+			Instant:      instant,
+			Format:       queryModel.Model.Get("format").MustString("time_series"),
 		})
 	}
 
 	return qs, nil
 }
 
-func parseResponse(value model.Value, query *PrometheusQuery) (*tsdb.QueryResult, error) {
-	queryRes := tsdb.NewQueryResult()
+// alignTimeRange aligns start down and end up to a multiple of step since the Unix epoch.
+func alignTimeRange(start, end time.Time, step time.Duration) (time.Time, time.Time) {
+	if step <= 0 {
+		return start, end
+	}
 
-	data, ok := value.(model.Matrix)
-	if !ok {
-		return queryRes, fmt.Errorf("Unsupported result format: %s", value.Type().String())
+	s := int64(step)
+	alignedStart := start.UnixNano() / s * s
+	alignedEnd := end.UnixNano() / s * s
+	if alignedEnd < end.UnixNano() {
+		alignedEnd += s
 	}
 
-	for _, v := range data {
-		series := tsdb.TimeSeries{
-			Name:   formatLegend(v.Metric, query),
-			Tags:   make(map[string]string, len(v.Metric)),
-			Points: make([]tsdb.TimePoint, 0, len(v.Values)),
+	return time.Unix(0, alignedStart).UTC(), time.Unix(0, alignedEnd).UTC()
+}
+
+// interpolateVariables replaces the global interval and range variables in
+// expr. Longer variable names are replaced first, since they share prefixes.
+//
+// $__rate_interval is the interval guaranteed to contain at least four
+// samples for the scrape interval, and to not miss any sample between steps.
+func interpolateVariables(expr string, interval time.Duration, step time.Duration, timeRange time.Duration, scrapeInterval time.Duration) string {
+	rateInterval := step + scrapeInterval
+	if rateInterval < 4*scrapeInterval {
+		rateInterval = 4 * scrapeInterval
+	}
+
+	rangeSeconds := int64(timeRange.Seconds())
+
+	expr = strings.ReplaceAll(expr, "$__interval_ms", strconv.FormatInt(interval.Milliseconds(), 10))
+	expr = strings.ReplaceAll(expr, "$__interval", model.Duration(interval).String())
+	expr = strings.ReplaceAll(expr, "$__rate_interval", model.Duration(rateInterval).String())
+	expr = strings.ReplaceAll(expr, "$__range_ms", strconv.FormatInt(timeRange.Milliseconds(), 10))
+	expr = strings.ReplaceAll(expr, "$__range_s", strconv.FormatInt(rangeSeconds, 10))
+	expr = strings.ReplaceAll(expr, "$__range", strconv.FormatInt(rangeSeconds, 10)+"s")
+
+	return expr
+}
+
+// frameMeta is stored in the custom frame metadata.
+type frameMeta struct {
+	ResultType string `json:"resultType"`
+	Instant    bool   `json:"instant"`
+	StepMs     int64  `json:"stepMs,omitempty"`
+}
+
+func parseResponse(value model.Value, warnings apiv1.Warnings, query *PrometheusQuery) (data.Frames, error) {
+	var frames data.Frames
+
+	switch v := value.(type) {
+	case model.Matrix:
+		if query.Format == "table" {
+			frames = data.Frames{matrixToTable(v)}
+			break
+		}
+		for _, stream := range v {
+			times := make([]time.Time, 0, len(stream.Values))
+			values := make([]float64, 0, len(stream.Values))
+			for _, pair := range stream.Values {
+				times = append(times, pair.Timestamp.Time().UTC())
+				values = append(values, float64(pair.Value))
+			}
+			frames = append(frames, newSeriesFrame(stream.Metric, times, values, query))
+		}
+	case model.Vector:
+		if query.Format == "table" {
+			frames = data.Frames{vectorToTable(v)}
+			break
+		}
+		for _, sample := range v {
+			frames = append(frames, newSeriesFrame(sample.Metric, []time.Time{sample.Timestamp.Time().UTC()}, []float64{float64(sample.Value)}, query))
 		}
+	case *model.Scalar:
+		frames = append(frames, newSeriesFrame(model.Metric{}, []time.Time{v.Timestamp.Time().UTC()}, []float64{float64(v.Value)}, query))
+	case *model.String:
+		frames = append(frames, data.NewFrame("",
+			data.NewField("Time", nil, []time.Time{v.Timestamp.Time().UTC()}),
+			data.NewField("Value", nil, []string{v.Value}),
+		))
+	default:
+		return nil, fmt.Errorf("Unsupported result format: %s", value.Type().String())
+	}
+
+	// return an empty frame, so the query metadata and warnings are returned
+	if len(frames) == 0 {
+		frames = data.Frames{data.NewFrame("")}
+	}
+
+	meta := frameMeta{ResultType: value.Type().String(), Instant: query.Instant}
+	if !query.Instant {
+		meta.StepMs = query.Step.Milliseconds()
+	}
 
-		for k, v := range v.Metric {
-			series.Tags[string(k)] = string(v)
+	for _, frame := range frames {
+		frame.RefID = query.RefId
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
 		}
+		frame.Meta.ExecutedQueryString = query.Expr
+		frame.Meta.Custom = meta
+		for _, warning := range warnings {
+			frame.AppendNotices(data.Notice{Severity: data.NoticeSeverityWarning, Text: warning})
+		}
+	}
+
+	return frames, nil
+}
 
-		for _, k := range v.Values {
-			series.Points = append(series.Points, tsdb.NewTimePoint(null.FloatFrom(float64(k.Value)), float64(k.Timestamp.Unix()*1000)))
+func newSeriesFrame(metric model.Metric, times []time.Time, values []float64, query *PrometheusQuery) *data.Frame {
+	name := formatLegend(metric, query)
+	labels := make(data.Labels, len(metric))
+	for k, v := range metric {
+		labels[string(k)] = string(v)
+	}
+
+	valueField := data.NewField("Value", labels, values)
+	valueField.SetConfig(&data.FieldConfig{DisplayName: name})
+
+	return data.NewFrame(name, data.NewField("Time", nil, times), valueField)
+}
+
+// labelNames returns the sorted union of label names of the metrics.
+func labelNames(metrics []model.Metric) []model.LabelName {
+	seen := map[model.LabelName]bool{}
+	var names []model.LabelName
+	for _, metric := range metrics {
+		for name := range metric {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
 		}
+	}
+
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
 
-		queryRes.Series = append(queryRes.Series, &series)
+// newTableFrame returns a frame with a time field, a string field per label and a value field.
+func newTableFrame(names []model.LabelName, rows int) *data.Frame {
+	fields := []*data.Field{data.NewField("Time", nil, make([]time.Time, 0, rows))}
+	for _, name := range names {
+		fields = append(fields, data.NewField(string(name), nil, make([]string, 0, rows)))
+	}
+	fields = append(fields, data.NewField("Value", nil, make([]float64, 0, rows)))
+
+	frame := data.NewFrame("", fields...)
+	frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeTable}
+	return frame
+}
+
+func appendTableRow(frame *data.Frame, names []model.LabelName, metric model.Metric, ts model.Time, value model.SampleValue) {
+	row := make([]interface{}, 0, len(names)+2)
+	row = append(row, ts.Time().UTC())
+	for _, name := range names {
+		row = append(row, string(metric[name]))
+	}
+	row = append(row, float64(value))
+	frame.AppendRow(row...)
+}
+
+func vectorToTable(vector model.Vector) *data.Frame {
+	metrics := make([]model.Metric, 0, len(vector))
+	for _, sample := range vector {
+		metrics = append(metrics, sample.Metric)
+	}
+
+	names := labelNames(metrics)
+	frame := newTableFrame(names, len(vector))
+	for _, sample := range vector {
+		appendTableRow(frame, names, sample.Metric, sample.Timestamp, sample.Value)
+	}
+
+	return frame
+}
+
+func matrixToTable(matrix model.Matrix) *data.Frame {
+	metrics := make([]model.Metric, 0, len(matrix))
+	rows := 0
+	for _, stream := range matrix {
+		metrics = append(metrics, stream.Metric)
+		rows += len(stream.Values)
+	}
+
+	names := labelNames(metrics)
+	frame := newTableFrame(names, rows)
+	for _, stream := range matrix {
+		for _, pair := range stream.Values {
+			appendTableRow(frame, names, stream.Metric, pair.Timestamp, pair.Value)
+		}
 	}
 
-	return queryRes, nil
+	return frame
 }
@@ -1,15 +1,22 @@
 package prometheus
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/tsdb"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	p "github.com/prometheus/common/model"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPrometheus(t *testing.T) {
@@ -157,3 +164,185 @@ func TestPrometheus(t *testing.T) {
 		})
 	})
 }
+
+func TestAlignTimeRange(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 7, 0, time.UTC)
+	end := time.Date(2020, 1, 1, 11, 0, 7, 0, time.UTC)
+
+	alignedStart, alignedEnd := alignTimeRange(start, end, 15*time.Second)
+	assert.Equal(t, time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC), alignedStart)
+	assert.Equal(t, time.Date(2020, 1, 1, 11, 0, 15, 0, time.UTC), alignedEnd)
+
+	alignedStart, alignedEnd = alignTimeRange(alignedStart, alignedEnd, 15*time.Second)
+	assert.Equal(t, time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC), alignedStart)
+	assert.Equal(t, time.Date(2020, 1, 1, 11, 0, 15, 0, time.UTC), alignedEnd)
+}
+
+func TestInterpolateVariables(t *testing.T) {
+	t.Run("interval and range variables", func(t *testing.T) {
+		expr := interpolateVariables("rate(x[$__interval]) / $__interval_ms + $__range_s + $__range_ms + avg_over_time(x[$__range])",
+			30*time.Second, 30*time.Second, time.Hour, 15*time.Second)
+		assert.Equal(t, "rate(x[30s]) / 30000 + 3600 + 3600000 + avg_over_time(x[3600s])", expr)
+	})
+
+	t.Run("rate interval covers at least four scrape intervals", func(t *testing.T) {
+		expr := interpolateVariables("rate(x[$__rate_interval])", 15*time.Second, 15*time.Second, time.Hour, 15*time.Second)
+		assert.Equal(t, "rate(x[1m])", expr)
+	})
+
+	t.Run("rate interval covers step and scrape interval", func(t *testing.T) {
+		expr := interpolateVariables("rate(x[$__rate_interval])", 2*time.Minute, 2*time.Minute, 48*time.Hour, 15*time.Second)
+		assert.Equal(t, "rate(x[135s])", expr)
+	})
+}
+
+func newTestServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) (*httptest.Server, *PrometheusExecutor, *models.DataSource) {
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	t.Cleanup(server.Close)
+
+	dsInfo := &models.DataSource{Url: server.URL, JsonData: simplejson.New()}
+	return server, &PrometheusExecutor{Transport: http.DefaultTransport}, dsInfo
+}
+
+func newTestQuery(t *testing.T, model string) *tsdb.TsdbQuery {
+	jsonModel, err := simplejson.NewJson([]byte(model))
+	require.NoError(t, err)
+
+	return &tsdb.TsdbQuery{
+		TimeRange: tsdb.NewTimeRange("1577872800000", "1577876400000"),
+		Queries:   []*tsdb.Query{{RefId: "A", Model: jsonModel}},
+	}
+}
+
+func TestPrometheusExecutor(t *testing.T) {
+	t.Run("range query returns a frame per series", func(t *testing.T) {
+		var form url.Values
+		_, executor, dsInfo := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v1/query_range", r.URL.Path)
+			require.NoError(t, r.ParseForm())
+			form = r.Form
+			_, err := w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"__name__":"up","job":"api"},"values":[[1577872800,"1"],[1577872815,"0"]]},
+				{"metric":{"__name__":"up","job":"db"},"values":[[1577872800,"1"]]}
+			]}}`))
+			require.NoError(t, err)
+		})
+
+		resp, err := executor.Query(context.Background(), dsInfo, newTestQuery(t, `{"expr": "up", "legendFormat": "{{job}}"}`))
+		require.NoError(t, err)
+
+		res := resp.Results["A"]
+		require.NoError(t, res.Error)
+		assert.Equal(t, "15", form.Get("step"))
+
+		frames, err := res.Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 2)
+		assert.Equal(t, "api", frames[0].Name)
+		assert.Equal(t, "A", frames[0].RefID)
+		assert.Equal(t, data.Labels{"__name__": "up", "job": "api"}, frames[0].Fields[1].Labels)
+		assert.Equal(t, 2, frames[0].Rows())
+		assert.Equal(t, time.Unix(1577872815, 0).UTC(), frames[0].Fields[0].At(1))
+		assert.Equal(t, 0.0, frames[0].Fields[1].At(1))
+		assert.Equal(t, "up", frames[0].Meta.ExecutedQueryString)
+
+		series, err := tsdb.FrameToSeriesSlice(frames[1])
+		require.NoError(t, err)
+		assert.Equal(t, "db", series[0].Name)
+		assert.Equal(t, map[string]string{"__name__": "up", "job": "db"}, series[0].Tags)
+	})
+
+	t.Run("instant query in table format", func(t *testing.T) {
+		var form url.Values
+		_, executor, dsInfo := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v1/query", r.URL.Path)
+			require.NoError(t, r.ParseForm())
+			form = r.Form
+			_, err := w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"job":"api","instance":"a"},"value":[1577876400,"3"]},
+				{"metric":{"job":"db"},"value":[1577876400,"5"]}
+			]}}`))
+			require.NoError(t, err)
+		})
+
+		resp, err := executor.Query(context.Background(), dsInfo, newTestQuery(t, `{"expr": "sum(up) by (job)", "instant": true, "format": "table"}`))
+		require.NoError(t, err)
+
+		res := resp.Results["A"]
+		require.NoError(t, res.Error)
+		assert.Equal(t, "1577876400", form.Get("time"))
+
+		frames, err := res.Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+
+		frame := frames[0]
+		require.Len(t, frame.Fields, 4)
+		assert.Equal(t, "Time", frame.Fields[0].Name)
+		assert.Equal(t, "instance", frame.Fields[1].Name)
+		assert.Equal(t, "job", frame.Fields[2].Name)
+		assert.Equal(t, "Value", frame.Fields[3].Name)
+		assert.Equal(t, 2, frame.Rows())
+		assert.Equal(t, "", frame.Fields[1].At(1))
+		assert.Equal(t, "db", frame.Fields[2].At(1))
+		assert.Equal(t, 5.0, frame.Fields[3].At(1))
+		assert.Equal(t, data.VisTypeTable, string(frame.Meta.PreferredVisualization))
+	})
+
+	t.Run("warnings are returned as frame notices", func(t *testing.T) {
+		_, executor, dsInfo := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			_, err := w.Write([]byte(`{"status":"success","warnings":["partial response"],"data":{"resultType":"matrix","result":[]}}`))
+			require.NoError(t, err)
+		})
+
+		resp, err := executor.Query(context.Background(), dsInfo, newTestQuery(t, `{"expr": "up"}`))
+		require.NoError(t, err)
+
+		frames, err := resp.Results["A"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Len(t, frames[0].Meta.Notices, 1)
+		assert.Equal(t, "partial response", frames[0].Meta.Notices[0].Text)
+		assert.Equal(t, data.NoticeSeverityWarning, frames[0].Meta.Notices[0].Severity)
+	})
+
+	t.Run("errors are returned per query", func(t *testing.T) {
+		_, executor, dsInfo := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, err := w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+			require.NoError(t, err)
+		})
+
+		resp, err := executor.Query(context.Background(), dsInfo, newTestQuery(t, `{"expr": "up{"}`))
+		require.NoError(t, err)
+		require.Error(t, resp.Results["A"].Error)
+		assert.Contains(t, resp.Results["A"].Error.Error(), "parse error")
+	})
+}
+
+func TestPrometheusCheckHealth(t *testing.T) {
+	t.Run("evaluates a constant expression", func(t *testing.T) {
+		var expr string
+		_, executor, dsInfo := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v1/query", r.URL.Path)
+			require.NoError(t, r.ParseForm())
+			expr = r.Form.Get("query")
+			_, err := w.Write([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1577876400,"2"]}}`))
+			require.NoError(t, err)
+		})
+
+		result := executor.CheckHealth(context.Background(), dsInfo)
+		assert.Equal(t, tsdb.HealthStatusOK, result.Status)
+		assert.Equal(t, "1+1", expr)
+	})
+
+	t.Run("reports errors as unhealthy", func(t *testing.T) {
+		_, executor, dsInfo := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+
+		result := executor.CheckHealth(context.Background(), dsInfo)
+		assert.Equal(t, tsdb.HealthStatusError, result.Status)
+		assert.NotEmpty(t, result.Message)
+	})
+}
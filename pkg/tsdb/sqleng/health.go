@@ -0,0 +1,20 @@
+package sqleng
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth checks that a connection to the database can be established
+func (e *sqlQueryEndpoint) CheckHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	session := e.engine.NewSession()
+	defer session.Close()
+
+	if err := session.DB().PingContext(ctx); err != nil {
+		return tsdb.HealthCheckError(e.queryResultTransformer.TransformQueryError(err))
+	}
+
+	return tsdb.HealthCheckOK("Database Connection OK")
+}
@@ -271,6 +271,10 @@ func (e *sqlQueryEndpoint) transformToTable(query *tsdb.Query, rows *core.Rows,
 		table.Rows = append(table.Rows, values)
 	}
 
+	if err := rows.Err(); err != nil {
+		return e.queryResultTransformer.TransformQueryError(err)
+	}
+
 	result.Tables = append(result.Tables, table)
 	result.Meta.Set("rowCount", rowCount)
 	return nil
@@ -364,6 +368,10 @@ func (e *sqlQueryEndpoint) transformToTimeSeries(query *tsdb.Query, rows *core.R
 		}
 	}
 
+	if err := rows.Err(); err != nil {
+		return e.queryResultTransformer.TransformQueryError(err)
+	}
+
 	for elem := cfg.seriesByQueryOrder.Front(); elem != nil; elem = elem.Next() {
 		key := elem.Value.(string)
 		result.Series = append(result.Series, cfg.pointsBySeries[key])
@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacroEngine(t *testing.T) {
+	engine := newSqliteMacroEngine()
+	query := &tsdb.Query{Model: simplejson.New()}
+
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	to := from.Add(5 * time.Minute)
+	timeRange := tsdb.NewFakeTimeRange("5m", "now", to)
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{
+			name:     "__time",
+			sql:      "select $__time(time_column)",
+			expected: "select CAST(strftime('%s', time_column) AS INTEGER) AS time",
+		},
+		{
+			name:     "__timeEpoch",
+			sql:      "select $__timeEpoch(time_column)",
+			expected: "select CAST(strftime('%s', time_column) AS INTEGER) AS time",
+		},
+		{
+			name:     "__timeFilter",
+			sql:      "WHERE $__timeFilter(time_column)",
+			expected: "WHERE CAST(strftime('%s', time_column) AS INTEGER) BETWEEN 1523556000 AND 1523556300",
+		},
+		{
+			name:     "__timeFrom",
+			sql:      "select $__timeFrom()",
+			expected: "select datetime(1523556000, 'unixepoch')",
+		},
+		{
+			name:     "__timeTo",
+			sql:      "select $__timeTo()",
+			expected: "select datetime(1523556300, 'unixepoch')",
+		},
+		{
+			name:     "__timeGroup",
+			sql:      "GROUP BY $__timeGroup(time_column,'5m')",
+			expected: "GROUP BY CAST(strftime('%s', time_column) AS INTEGER) / 300 * 300",
+		},
+		{
+			name:     "__timeGroup with spaces",
+			sql:      "GROUP BY $__timeGroup(time_column , '5m')",
+			expected: "GROUP BY CAST(strftime('%s', time_column) AS INTEGER) / 300 * 300",
+		},
+		{
+			name:     "__timeGroupAlias",
+			sql:      "GROUP BY $__timeGroupAlias(time_column,'5m')",
+			expected: "GROUP BY CAST(strftime('%s', time_column) AS INTEGER) / 300 * 300 AS time",
+		},
+		{
+			name:     "__unixEpochFilter",
+			sql:      "select $__unixEpochFilter(time)",
+			expected: "select time >= 1523556000 AND time <= 1523556300",
+		},
+		{
+			name:     "__unixEpochNanoFilter",
+			sql:      "select $__unixEpochNanoFilter(time)",
+			expected: "select time >= 1523556000000000000 AND time <= 1523556300000000000",
+		},
+		{
+			name:     "__unixEpochGroup",
+			sql:      "SELECT $__unixEpochGroup(time_column,'5m')",
+			expected: "SELECT time_column / 300 * 300",
+		},
+		{
+			name:     "__unixEpochGroupAlias",
+			sql:      "SELECT $__unixEpochGroupAlias(time_column,'5m')",
+			expected: "SELECT time_column / 300 * 300 AS time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, err := engine.Interpolate(query, timeRange, tt.sql)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, sql)
+		})
+	}
+
+	t.Run("__timeGroup with fill sets up fill mode", func(t *testing.T) {
+		query := &tsdb.Query{Model: simplejson.New()}
+		_, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroup(time_column,'5m', previous)")
+		require.NoError(t, err)
+
+		assert.True(t, query.Model.Get("fill").MustBool())
+		assert.Equal(t, "previous", query.Model.Get("fillMode").MustString())
+		assert.Equal(t, float64(300), query.Model.Get("fillInterval").MustFloat64())
+	})
+
+	t.Run("__unixEpochGroup accepts double quoted arguments", func(t *testing.T) {
+		query := &tsdb.Query{Model: simplejson.New()}
+		sql, err := engine.Interpolate(query, timeRange, `GROUP BY $__unixEpochGroup(time_column,"5m", "0")`)
+		require.NoError(t, err)
+		assert.Equal(t, "GROUP BY time_column / 300 * 300", sql)
+
+		assert.Equal(t, "value", query.Model.Get("fillMode").MustString())
+		assert.Equal(t, float64(0), query.Model.Get("fillValue").MustFloat64())
+
+		query = &tsdb.Query{Model: simplejson.New()}
+		_, err = engine.Interpolate(query, timeRange, `GROUP BY $__unixEpochGroup(time_column,'5m', 'NULL')`)
+		require.NoError(t, err)
+		assert.Equal(t, "null", query.Model.Get("fillMode").MustString())
+	})
+
+	t.Run("unknown macro returns an error", func(t *testing.T) {
+		_, err := engine.Interpolate(query, timeRange, "select $__unknown(time_column)")
+		require.Error(t, err)
+	})
+
+	t.Run("__timeGroup without interval returns an error", func(t *testing.T) {
+		_, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroup(time_column)")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/mattn/go-sqlite3"
+	"xorm.io/core"
+)
+
+// driverName is the SQLite driver of the data source, which denies
+// attaching other databases
+const driverName = "sqlite3_datasource"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{ConnectHook: denyAttach})
+	core.RegisterDriver(driverName, core.QueryDriver("sqlite3"))
+	tsdb.RegisterTsdbQueryEndpoint("sqlite", newSqliteQueryEndpoint)
+}
+
+// denyAttach prevents queries from attaching databases, such as the Grafana
+// database, which aren't in the allowed paths. The read-only mode doesn't
+// apply to attached databases.
+func denyAttach(conn *sqlite3.SQLiteConn) error {
+	conn.RegisterAuthorizer(func(op int, arg1, arg2, arg3 string) int {
+		if op == sqlite3.SQLITE_ATTACH {
+			return sqlite3.SQLITE_DENY
+		}
+		return sqlite3.SQLITE_OK
+	})
+	return nil
+}
+
+var (
+	errNoDatabasePath   = errors.New("SQLite data source requires a database file path")
+	errPathNotAllowed   = errors.New("SQLite database file is not located in any of the allowed paths")
+	errSqliteNotEnabled = errors.New("SQLite data source is disabled, configure allowed_paths in the [datasources.sqlite] section")
+)
+
+func newSqliteQueryEndpoint(datasource *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+	logger := log.New("tsdb.sqlite")
+
+	path, err := resolveDatabasePath(datasource.Database, setting.SqliteDataSourceAllowedPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	cnnstr := generateConnectionString(path)
+	if setting.Env == setting.DEV {
+		logger.Debug("getEngine", "connection", cnnstr)
+	}
+
+	config := sqleng.SqlQueryEndpointConfiguration{
+		DriverName:        driverName,
+		ConnectionString:  cnnstr,
+		Datasource:        datasource,
+		MetricColumnTypes: []string{"TEXT", "VARCHAR", "CHAR", "NCHAR", "NVARCHAR", "CLOB", "text", "varchar", "char", "nchar", "nvarchar", "clob"},
+	}
+
+	rowTransformer := sqliteQueryResultTransformer{
+		log: logger,
+	}
+
+	return sqleng.NewSqlQueryEndpoint(&config, &rowTransformer, newSqliteMacroEngine(), logger)
+}
+
+// generateConnectionString opens the database file as a read-only URI with
+// query_only enabled, so neither the file nor its schema can be modified.
+func generateConnectionString(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return "file:" + u.EscapedPath() + "?mode=ro&_query_only=true"
+}
+
+// resolveDatabasePath returns the absolute path of the database file after
+// resolving symlinks, provided that it is located inside one of the allowed directories.
+func resolveDatabasePath(path string, allowedPaths []string) (string, error) {
+	if len(allowedPaths) == 0 {
+		return "", errSqliteNotEnabled
+	}
+
+	if strings.TrimSpace(path) == "" {
+		return "", errNoDatabasePath
+	}
+
+	resolved, err := util.ResolvePathInDirs(path, allowedPaths)
+	if err != nil {
+		if errors.Is(err, util.ErrPathNotAllowed) {
+			return "", errPathNotAllowed
+		}
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("SQLite database file %q does not exist", path)
+		}
+		return "", err
+	}
+
+	return resolved, nil
+}
+
+type sqliteQueryResultTransformer struct {
+	log log.Logger
+}
+
+func (t *sqliteQueryResultTransformer) TransformQueryResult(columnTypes []*sql.ColumnType, rows *core.Rows) (tsdb.RowValues, error) {
+	values := make([]interface{}, len(columnTypes))
+	valuePtrs := make([]interface{}, len(columnTypes))
+
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, err
+	}
+
+	// SQLite is dynamically typed, so the driver returns int64, float64, string,
+	// time.Time (for columns declared as date, datetime or timestamp), []byte or nil.
+	for i := range values {
+		if b, ok := values[i].([]byte); ok {
+			values[i] = string(b)
+		}
+	}
+
+	return values, nil
+}
+
+func (t *sqliteQueryResultTransformer) TransformQueryError(err error) error {
+	return err
+}
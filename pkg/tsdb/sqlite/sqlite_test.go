@@ -0,0 +1,260 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fromStart = time.Date(2018, 3, 15, 13, 0, 0, 0, time.UTC)
+
+// createTestDatabase creates a SQLite database file in dir with a metric table
+// containing a point every minute for an hour for the hosts a and b.
+func createTestDatabase(t *testing.T, dir string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "metrics.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE metric (
+		ts TEXT NOT NULL,
+		epoch INTEGER NOT NULL,
+		host TEXT NOT NULL,
+		value REAL NOT NULL
+	)`)
+	require.NoError(t, err)
+
+	for i := 0; i < 60; i++ {
+		ts := fromStart.Add(time.Duration(i) * time.Minute)
+		for j, host := range []string{"a", "b"} {
+			_, err := db.Exec("INSERT INTO metric (ts, epoch, host, value) VALUES (?, ?, ?, ?)",
+				ts.Format("2006-01-02 15:04:05"), ts.Unix(), host, float64(i*(j+1)))
+			require.NoError(t, err)
+		}
+	}
+
+	return path
+}
+
+func newTestTimeRange(from, to time.Time) *tsdb.TimeRange {
+	return tsdb.NewTimeRange(strconv.FormatInt(from.UnixNano()/1e6, 10), strconv.FormatInt(to.UnixNano()/1e6, 10))
+}
+
+func newTestQuery(rawSQL, format string) *tsdb.Query {
+	return &tsdb.Query{
+		RefId:      "A",
+		DataSource: &models.DataSource{JsonData: simplejson.New()},
+		Model: simplejson.NewFromAny(map[string]interface{}{
+			"rawSql": rawSQL,
+			"format": format,
+		}),
+	}
+}
+
+func TestSQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grafana-sqlite-ds")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	origAllowedPaths := setting.SqliteDataSourceAllowedPaths
+	setting.SqliteDataSourceAllowedPaths = []string{dir}
+	t.Cleanup(func() { setting.SqliteDataSourceAllowedPaths = origAllowedPaths })
+
+	path := createTestDatabase(t, dir)
+
+	endpoint, err := newSqliteQueryEndpoint(&models.DataSource{
+		Id:       1,
+		Database: path,
+		JsonData: simplejson.New(),
+	})
+	require.NoError(t, err)
+
+	query := func(t *testing.T, q *tsdb.Query, timeRange *tsdb.TimeRange) *tsdb.QueryResult {
+		t.Helper()
+
+		resp, err := endpoint.Query(context.Background(), nil, &tsdb.TsdbQuery{
+			TimeRange: timeRange,
+			Queries:   []*tsdb.Query{q},
+		})
+		require.NoError(t, err)
+		require.Contains(t, resp.Results, "A")
+		return resp.Results["A"]
+	}
+
+	t.Run("time series query with $__timeFilter and $__timeGroupAlias", func(t *testing.T) {
+		q := newTestQuery(`SELECT $__timeGroupAlias(ts, '10m'), host AS metric, avg(value) AS value
+			FROM metric WHERE $__timeFilter(ts) GROUP BY 1, 2 ORDER BY 1`, "time_series")
+		res := query(t, q, newTestTimeRange(fromStart, fromStart.Add(29*time.Minute)))
+		require.NoError(t, res.Error)
+
+		require.Len(t, res.Series, 2)
+		assert.Equal(t, "a", res.Series[0].Name)
+		assert.Equal(t, "b", res.Series[1].Name)
+
+		points := res.Series[0].Points
+		require.Len(t, points, 3)
+		assert.Equal(t, float64(fromStart.Unix()*1000), points[0][1].Float64)
+		assert.Equal(t, 4.5, points[0][0].Float64)
+		assert.Equal(t, float64(fromStart.Add(20*time.Minute).Unix()*1000), points[2][1].Float64)
+		assert.Equal(t, 24.5, points[2][0].Float64)
+	})
+
+	t.Run("time series query with $__unixEpochFilter and fill", func(t *testing.T) {
+		q := newTestQuery(`SELECT $__unixEpochGroupAlias(epoch, '5m', 0), sum(value) AS value
+			FROM metric WHERE $__unixEpochFilter(epoch) AND host = 'a' GROUP BY 1 ORDER BY 1`, "time_series")
+		res := query(t, q, newTestTimeRange(fromStart.Add(-10*time.Minute), fromStart.Add(9*time.Minute)))
+		require.NoError(t, res.Error)
+
+		require.Len(t, res.Series, 1)
+		points := res.Series[0].Points
+		require.Len(t, points, 4)
+		assert.Equal(t, float64(fromStart.Add(-10*time.Minute).Unix()*1000), points[0][1].Float64)
+		assert.Equal(t, float64(0), points[0][0].Float64)
+		assert.Equal(t, float64(0+1+2+3+4), points[2][0].Float64)
+		assert.Equal(t, float64(5+6+7+8+9), points[3][0].Float64)
+	})
+
+	t.Run("table query", func(t *testing.T) {
+		q := newTestQuery(`SELECT $__time(ts), host, value FROM metric
+			WHERE $__timeFilter(ts) AND host = 'b' ORDER BY ts LIMIT 2`, "table")
+		res := query(t, q, newTestTimeRange(fromStart, fromStart.Add(time.Hour)))
+		require.NoError(t, res.Error)
+
+		require.Len(t, res.Tables, 1)
+		table := res.Tables[0]
+		require.Len(t, table.Columns, 3)
+		assert.Equal(t, "time", table.Columns[0].Text)
+		require.Len(t, table.Rows, 2)
+		assert.Equal(t, fromStart.Unix()*1000, table.Rows[0][0])
+		assert.Equal(t, "b", table.Rows[0][1])
+		assert.Equal(t, float64(2), table.Rows[1][2])
+		assert.Equal(t, 2, res.Meta.Get("rowCount").MustInt())
+	})
+
+	t.Run("executed query is returned in meta", func(t *testing.T) {
+		q := newTestQuery("SELECT $__timeFrom() AS t", "table")
+		res := query(t, q, newTestTimeRange(fromStart, fromStart.Add(time.Hour)))
+		require.NoError(t, res.Error)
+
+		expected := fmt.Sprintf("SELECT datetime(%d, 'unixepoch') AS t", fromStart.Unix())
+		assert.Equal(t, expected, res.Meta.Get("executedQueryString").MustString())
+		assert.Equal(t, "2018-03-15 13:00:00", res.Tables[0].Rows[0][0])
+	})
+
+	t.Run("database is opened read-only", func(t *testing.T) {
+		for _, rawSQL := range []string{
+			"DELETE FROM metric",
+			"CREATE TABLE evil (id INTEGER)",
+		} {
+			q := newTestQuery(rawSQL, "table")
+			res := query(t, q, newTestTimeRange(fromStart, fromStart.Add(time.Hour)))
+			require.Error(t, res.Error, rawSQL)
+		}
+
+		q := newTestQuery("SELECT count(*) AS c FROM metric", "table")
+		res := query(t, q, newTestTimeRange(fromStart, fromStart.Add(time.Hour)))
+		require.NoError(t, res.Error)
+		assert.Equal(t, int64(120), res.Tables[0].Rows[0][0])
+	})
+
+	t.Run("other databases can't be attached", func(t *testing.T) {
+		outside, err := ioutil.TempDir("", "grafana-sqlite-outside")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(outside) })
+		secret := createTestDatabase(t, outside)
+
+		for _, rawSQL := range []string{
+			fmt.Sprintf("ATTACH DATABASE '%s' AS secret", secret),
+			fmt.Sprintf("ATTACH DATABASE 'file:%s?mode=ro' AS secret; SELECT count(*) FROM secret.metric", secret),
+		} {
+			q := newTestQuery(rawSQL, "table")
+			res := query(t, q, newTestTimeRange(fromStart, fromStart.Add(time.Hour)))
+			require.Error(t, res.Error, rawSQL)
+			assert.Contains(t, res.Error.Error(), "not authorized")
+		}
+	})
+
+	t.Run("health check connects to the database", func(t *testing.T) {
+		checker, ok := endpoint.(tsdb.HealthChecker)
+		require.True(t, ok)
+
+		result := checker.CheckHealth(context.Background(), nil)
+		assert.Equal(t, tsdb.HealthStatusOK, result.Status)
+	})
+}
+
+func TestResolveDatabasePath(t *testing.T) {
+	allowed, err := ioutil.TempDir("", "grafana-sqlite-allowed")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(allowed) })
+
+	other, err := ioutil.TempDir("", "grafana-sqlite-other")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(other) })
+
+	inside := filepath.Join(allowed, "inside.db")
+	require.NoError(t, ioutil.WriteFile(inside, nil, 0600))
+	outside := filepath.Join(other, "outside.db")
+	require.NoError(t, ioutil.WriteFile(outside, nil, 0600))
+
+	t.Run("file inside allowed path is accepted", func(t *testing.T) {
+		path, err := resolveDatabasePath(inside, []string{allowed})
+		require.NoError(t, err)
+		expected, err := filepath.EvalSymlinks(inside)
+		require.NoError(t, err)
+		assert.Equal(t, expected, path)
+	})
+
+	t.Run("file outside allowed paths is rejected", func(t *testing.T) {
+		_, err := resolveDatabasePath(outside, []string{allowed})
+		require.Equal(t, errPathNotAllowed, err)
+	})
+
+	t.Run("relative path escaping allowed path is rejected", func(t *testing.T) {
+		escaping := filepath.Join(allowed, "..", filepath.Base(other), "outside.db")
+		_, err := resolveDatabasePath(escaping, []string{allowed})
+		require.Equal(t, errPathNotAllowed, err)
+	})
+
+	t.Run("symlink pointing outside allowed path is rejected", func(t *testing.T) {
+		link := filepath.Join(allowed, "link.db")
+		require.NoError(t, os.Symlink(outside, link))
+		_, err := resolveDatabasePath(link, []string{allowed})
+		require.Equal(t, errPathNotAllowed, err)
+	})
+
+	t.Run("allowed directory itself is rejected", func(t *testing.T) {
+		_, err := resolveDatabasePath(allowed, []string{allowed})
+		require.Equal(t, errPathNotAllowed, err)
+	})
+
+	t.Run("missing file is rejected", func(t *testing.T) {
+		_, err := resolveDatabasePath(filepath.Join(allowed, "missing.db"), []string{allowed})
+		require.Error(t, err)
+	})
+
+	t.Run("empty path is rejected", func(t *testing.T) {
+		_, err := resolveDatabasePath("", []string{allowed})
+		require.Equal(t, errNoDatabasePath, err)
+	})
+
+	t.Run("no allowed paths disables the data source", func(t *testing.T) {
+		_, err := resolveDatabasePath(inside, nil)
+		require.Equal(t, errSqliteNotEnabled, err)
+	})
+}
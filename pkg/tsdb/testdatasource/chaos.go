@@ -0,0 +1,255 @@
+package testdatasource
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+const (
+	// maxLatency caps the sampled latency of the latency scenario
+	maxLatency = time.Minute
+	// maxHugeFramePoints caps the number of points of all frames of the huge
+	// frame scenario, since any viewer can run it
+	maxHugeFramePoints = 1000000
+	// z99 is the 99th percentile of the standard normal distribution
+	z99 = 2.326
+)
+
+// registerChaosScenarios registers the scenarios modelling real-world failure
+// patterns, used to load test dashboards and the alert engine.
+func registerChaosScenarios() {
+	registerScenario(&Scenario{
+		Id:             "latency_distribution",
+		Name:           "Latency Distribution",
+		Description:    "Random walk returned after a latency sampled from a log-normal distribution with the given p50 and p99.",
+		ContextHandler: getLatencyDistribution,
+	})
+
+	registerScenario(&Scenario{
+		Id:          "partial_errors",
+		Name:        "Intermittent Partial Errors",
+		Description: "Random walks where each series fails with probability errorRate, returning the other series and an error.",
+		Handler:     getPartialErrors,
+	})
+
+	registerScenario(&Scenario{
+		Id:          "growing_cardinality",
+		Name:        "Growing Series Cardinality",
+		Description: "Random walks where a new series starts every growthInterval of the time range, up to maxSeries.",
+		Handler:     getGrowingCardinality,
+	})
+
+	registerScenario(&Scenario{
+		Id:          "irregular_points",
+		Name:        "Gaps, Duplicates and Out-of-Order Points",
+		Description: "Random walk with points dropped, duplicated or swapped with the previous one at the given probabilities.",
+		Handler:     getIrregularPoints,
+	})
+
+	registerScenario(&Scenario{
+		Id:          "huge_frame",
+		Name:        "Huge Frame",
+		Description: "Frames of the given number of points spread evenly over the time range.",
+		Handler:     getHugeFrame,
+	})
+}
+
+// newRand returns a random source seeded with the seed of the query, if any,
+// so that scenarios can be replayed.
+func newRand(query *tsdb.Query) *rand.Rand {
+	seed := query.Model.Get("seed").MustInt64(time.Now().UnixNano())
+	return rand.New(rand.NewSource(seed))
+}
+
+// durationOption returns the duration of a query option given as a duration
+// string or as a number of milliseconds.
+func durationOption(options *simplejson.Json, key string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := options.CheckGet(key)
+	if !ok {
+		return defaultValue, nil
+	}
+	if ms, err := value.Float64(); err == nil {
+		return time.Duration(ms * float64(time.Millisecond)), nil
+	}
+	return time.ParseDuration(value.MustString())
+}
+
+// sampleLatency returns a latency from the log-normal distribution with the
+// given median and 99th percentile.
+func sampleLatency(r *rand.Rand, p50, p99 time.Duration) time.Duration {
+	if p50 <= 0 {
+		return 0
+	}
+	if p99 < p50 {
+		p99 = p50
+	}
+	mu := math.Log(float64(p50))
+	sigma := (math.Log(float64(p99)) - mu) / z99
+	latency := time.Duration(math.Exp(mu + sigma*r.NormFloat64()))
+	if latency > maxLatency {
+		return maxLatency
+	}
+	return latency
+}
+
+func getLatencyDistribution(ctx context.Context, query *tsdb.Query, tsdbQuery *tsdb.TsdbQuery) *tsdb.QueryResult {
+	queryRes := tsdb.NewQueryResult()
+
+	p50, err := durationOption(query.Model, "p50", 100*time.Millisecond)
+	if err != nil {
+		queryRes.Error = fmt.Errorf("failed to parse p50 value '%v' into duration: %v", query.Model.Get("p50"), err)
+		return queryRes
+	}
+	p99, err := durationOption(query.Model, "p99", time.Second)
+	if err != nil {
+		queryRes.Error = fmt.Errorf("failed to parse p99 value '%v' into duration: %v", query.Model.Get("p99"), err)
+		return queryRes
+	}
+
+	latency := sampleLatency(newRand(query), p50, p99)
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		queryRes.Error = ctx.Err()
+		return queryRes
+	}
+
+	queryRes.Series = append(queryRes.Series, getRandomWalk(query, tsdbQuery, 0))
+	queryRes.Meta = simplejson.NewFromAny(map[string]interface{}{"latencyMs": latency.Milliseconds()})
+	return queryRes
+}
+
+func getPartialErrors(query *tsdb.Query, context *tsdb.TsdbQuery) *tsdb.QueryResult {
+	queryRes := tsdb.NewQueryResult()
+
+	r := newRand(query)
+	seriesCount := query.Model.Get("seriesCount").MustInt(1)
+	errorRate := query.Model.Get("errorRate").MustFloat64(0.5)
+
+	failed := 0
+	for i := 0; i < seriesCount; i++ {
+		if r.Float64() < errorRate {
+			failed++
+			continue
+		}
+		queryRes.Series = append(queryRes.Series, getRandomWalk(query, context, i))
+	}
+
+	if failed > 0 {
+		queryRes.ErrorString = fmt.Sprintf("%d of %d series failed", failed, seriesCount)
+	}
+	return queryRes
+}
+
+func getGrowingCardinality(query *tsdb.Query, context *tsdb.TsdbQuery) *tsdb.QueryResult {
+	queryRes := tsdb.NewQueryResult()
+
+	growthInterval, err := durationOption(query.Model, "growthInterval", time.Minute)
+	if err != nil || growthInterval <= 0 {
+		queryRes.Error = fmt.Errorf("failed to parse growthInterval value '%v' into positive duration", query.Model.Get("growthInterval"))
+		return queryRes
+	}
+	maxSeries := query.Model.Get("maxSeries").MustInt(100)
+
+	from := context.TimeRange.GetFromAsMsEpoch()
+	to := context.TimeRange.GetToAsMsEpoch()
+	for i := 0; i < maxSeries; i++ {
+		start := from + int64(i)*growthInterval.Milliseconds()
+		if start >= to {
+			break
+		}
+
+		series := getRandomWalk(query, context, i)
+		points := make(tsdb.TimeSeriesPoints, 0, len(series.Points))
+		for _, point := range series.Points {
+			if int64(point[1].Float64) >= start {
+				points = append(points, point)
+			}
+		}
+		series.Points = points
+		series.Tags["series"] = strconv.Itoa(i)
+
+		queryRes.Series = append(queryRes.Series, series)
+	}
+
+	return queryRes
+}
+
+func getIrregularPoints(query *tsdb.Query, context *tsdb.TsdbQuery) *tsdb.QueryResult {
+	queryRes := tsdb.NewQueryResult()
+
+	r := newRand(query)
+	gapProbability := query.Model.Get("gapProbability").MustFloat64(0.1)
+	duplicateProbability := query.Model.Get("duplicateProbability").MustFloat64(0.1)
+	outOfOrderProbability := query.Model.Get("outOfOrderProbability").MustFloat64(0.1)
+
+	series := getRandomWalk(query, context, 0)
+	points := make(tsdb.TimeSeriesPoints, 0, len(series.Points))
+	for _, point := range series.Points {
+		if r.Float64() < gapProbability {
+			continue
+		}
+		points = append(points, point)
+		if r.Float64() < duplicateProbability {
+			points = append(points, tsdb.TimePoint{null.FloatFrom(point[0].Float64 + r.Float64()), point[1]})
+		}
+		if n := len(points); n > 1 && r.Float64() < outOfOrderProbability {
+			points[n-1], points[n-2] = points[n-2], points[n-1]
+		}
+	}
+	series.Points = points
+
+	queryRes.Series = append(queryRes.Series, series)
+	return queryRes
+}
+
+func getHugeFrame(query *tsdb.Query, context *tsdb.TsdbQuery) *tsdb.QueryResult {
+	queryRes := tsdb.NewQueryResult()
+
+	r := newRand(query)
+	pointCount := query.Model.Get("points").MustInt(1000000)
+	if pointCount < 1 || pointCount > maxHugeFramePoints {
+		queryRes.Error = fmt.Errorf("points must be between 1 and %d", maxHugeFramePoints)
+		return queryRes
+	}
+	seriesCount := query.Model.Get("seriesCount").MustInt(1)
+	if seriesCount < 1 || seriesCount > maxHugeFramePoints/pointCount {
+		queryRes.Error = fmt.Errorf("seriesCount must be between 1 and %d for %d points", maxHugeFramePoints/pointCount, pointCount)
+		return queryRes
+	}
+
+	from := context.TimeRange.MustGetFrom()
+	step := context.TimeRange.MustGetTo().Sub(from) / time.Duration(pointCount)
+
+	frames := make(data.Frames, 0, seriesCount)
+	for i := 0; i < seriesCount; i++ {
+		times := make([]time.Time, pointCount)
+		values := make([]float64, pointCount)
+		walker := r.Float64() * 100
+		for j := 0; j < pointCount; j++ {
+			times[j] = from.Add(time.Duration(j) * step)
+			values[j] = walker
+			walker += r.Float64() - 0.5
+		}
+
+		name := newSeriesForQuery(query, i).Name
+		frames = append(frames, data.NewFrame(name,
+			data.NewField("time", nil, times),
+			data.NewField("value", parseLabels(query), values),
+		))
+	}
+
+	queryRes.Dataframes = tsdb.NewDecodedDataFrames(frames)
+	return queryRes
+}
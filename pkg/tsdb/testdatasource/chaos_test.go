@@ -0,0 +1,196 @@
+package testdatasource
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newChaosQuery(model map[string]interface{}) (*tsdb.Query, *tsdb.TsdbQuery) {
+	req := &tsdb.TsdbQuery{
+		TimeRange: tsdb.NewFakeTimeRange("10m", "now", time.Now()),
+		Queries: []*tsdb.Query{
+			{RefId: "A", IntervalMs: 1000, MaxDataPoints: 600, Model: simplejson.NewFromAny(model)},
+		},
+	}
+	return req.Queries[0], req
+}
+
+func TestChaosScenarios(t *testing.T) {
+	Convey("latency distribution", t, func() {
+		Convey("Should sample latencies around the requested percentiles", func() {
+			r := rand.New(rand.NewSource(1))
+			latencies := make([]time.Duration, 10000)
+			for i := range latencies {
+				latencies[i] = sampleLatency(r, 100*time.Millisecond, time.Second)
+			}
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+			So(latencies[5000], ShouldBeBetween, 90*time.Millisecond, 110*time.Millisecond)
+			So(latencies[9900], ShouldBeBetween, 800*time.Millisecond, 1200*time.Millisecond)
+		})
+
+		Convey("Should return the sampled latency", func() {
+			query, req := newChaosQuery(map[string]interface{}{"p50": "1ms", "p99": 2})
+
+			result := ScenarioRegistry["latency_distribution"].ContextHandler(context.Background(), query, req)
+
+			So(result.Error, ShouldBeNil)
+			So(len(result.Series), ShouldEqual, 1)
+			So(result.Meta.Get("latencyMs").MustInt64(-1), ShouldBeGreaterThanOrEqualTo, 0)
+		})
+
+		Convey("Should fail for invalid durations", func() {
+			query, req := newChaosQuery(map[string]interface{}{"p50": "soon"})
+
+			result := ScenarioRegistry["latency_distribution"].ContextHandler(context.Background(), query, req)
+
+			So(result.Error, ShouldNotBeNil)
+		})
+
+		Convey("Should stop waiting when the request is cancelled", func() {
+			query, req := newChaosQuery(map[string]interface{}{"p50": "1m", "p99": "1m"})
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			result := ScenarioRegistry["latency_distribution"].ContextHandler(ctx, query, req)
+
+			So(result.Error, ShouldEqual, context.Canceled)
+			So(len(result.Series), ShouldEqual, 0)
+		})
+	})
+
+	Convey("partial errors", t, func() {
+		Convey("Should drop failed series and report them", func() {
+			query, req := newChaosQuery(map[string]interface{}{"seriesCount": 10, "errorRate": 0.5, "seed": 42})
+
+			result := ScenarioRegistry["partial_errors"].Handler(query, req)
+
+			So(len(result.Series), ShouldBeLessThan, 10)
+			So(len(result.Series), ShouldBeGreaterThan, 0)
+			So(result.ErrorString, ShouldEndWith, "of 10 series failed")
+		})
+
+		Convey("Should not fail without error rate", func() {
+			query, req := newChaosQuery(map[string]interface{}{"seriesCount": 3, "errorRate": 0})
+
+			result := ScenarioRegistry["partial_errors"].Handler(query, req)
+
+			So(len(result.Series), ShouldEqual, 3)
+			So(result.ErrorString, ShouldBeEmpty)
+		})
+	})
+
+	Convey("growing cardinality", t, func() {
+		Convey("Should start a new series every interval", func() {
+			query, req := newChaosQuery(map[string]interface{}{"growthInterval": "3m", "maxSeries": 10})
+
+			result := ScenarioRegistry["growing_cardinality"].Handler(query, req)
+			from := req.TimeRange.GetFromAsMsEpoch()
+
+			So(len(result.Series), ShouldEqual, 4)
+			for i, series := range result.Series {
+				So(series.Tags["series"], ShouldEqual, strconv.Itoa(i))
+				So(int64(series.Points[0][1].Float64), ShouldBeGreaterThanOrEqualTo, from+int64(i)*3*60*1000)
+			}
+			So(len(result.Series[3].Points), ShouldBeLessThan, len(result.Series[0].Points))
+		})
+
+		Convey("Should stop at the maximum number of series", func() {
+			query, req := newChaosQuery(map[string]interface{}{"growthInterval": "1s", "maxSeries": 5})
+
+			result := ScenarioRegistry["growing_cardinality"].Handler(query, req)
+
+			So(len(result.Series), ShouldEqual, 5)
+		})
+	})
+
+	Convey("irregular points", t, func() {
+		Convey("Should drop every point", func() {
+			query, req := newChaosQuery(map[string]interface{}{"gapProbability": 1})
+
+			result := ScenarioRegistry["irregular_points"].Handler(query, req)
+
+			So(len(result.Series[0].Points), ShouldEqual, 0)
+		})
+
+		Convey("Should duplicate every point", func() {
+			query, req := newChaosQuery(map[string]interface{}{
+				"gapProbability":        0,
+				"duplicateProbability":  1,
+				"outOfOrderProbability": 0,
+			})
+
+			result := ScenarioRegistry["irregular_points"].Handler(query, req)
+			points := result.Series[0].Points
+
+			So(len(points), ShouldEqual, 1200)
+			So(points[0][1], ShouldResemble, points[1][1])
+		})
+
+		Convey("Should return points out of order", func() {
+			query, req := newChaosQuery(map[string]interface{}{
+				"gapProbability":        0,
+				"duplicateProbability":  0,
+				"outOfOrderProbability": 0.5,
+				"seed":                  7,
+			})
+
+			result := ScenarioRegistry["irregular_points"].Handler(query, req)
+			points := result.Series[0].Points
+
+			outOfOrder := false
+			for i := 1; i < len(points); i++ {
+				if points[i][1].Float64 < points[i-1][1].Float64 {
+					outOfOrder = true
+				}
+			}
+			So(outOfOrder, ShouldBeTrue)
+		})
+	})
+
+	Convey("huge frame", t, func() {
+		Convey("Should return frames with the requested number of points", func() {
+			query, req := newChaosQuery(map[string]interface{}{"points": 100000, "seriesCount": 2})
+
+			result := ScenarioRegistry["huge_frame"].Handler(query, req)
+			frames, err := result.Dataframes.Decoded()
+
+			So(err, ShouldBeNil)
+			So(len(frames), ShouldEqual, 2)
+			So(frames[0].Rows(), ShouldEqual, 100000)
+			So(frames[1].Name, ShouldEqual, "A-series1")
+		})
+
+		Convey("Should refuse too many points", func() {
+			query, req := newChaosQuery(map[string]interface{}{"points": maxHugeFramePoints + 1})
+
+			result := ScenarioRegistry["huge_frame"].Handler(query, req)
+
+			So(result.Error, ShouldNotBeNil)
+		})
+
+		Convey("Should refuse too many points over all series", func() {
+			query, req := newChaosQuery(map[string]interface{}{"points": maxHugeFramePoints / 2, "seriesCount": 3})
+
+			result := ScenarioRegistry["huge_frame"].Handler(query, req)
+
+			So(result.Error, ShouldNotBeNil)
+		})
+
+		Convey("Should refuse a series count below one", func() {
+			query, req := newChaosQuery(map[string]interface{}{"points": 10, "seriesCount": -1})
+
+			result := ScenarioRegistry["huge_frame"].Handler(query, req)
+
+			So(result.Error, ShouldNotBeNil)
+		})
+	})
+}
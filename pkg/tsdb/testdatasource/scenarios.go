@@ -1,6 +1,7 @@
 package testdatasource
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -19,12 +20,17 @@ import (
 
 type ScenarioHandler func(query *tsdb.Query, context *tsdb.TsdbQuery) *tsdb.QueryResult
 
+// ScenarioContextHandler is a scenario handler that stops when the request is
+// cancelled.
+type ScenarioContextHandler func(ctx context.Context, query *tsdb.Query, tsdbQuery *tsdb.TsdbQuery) *tsdb.QueryResult
+
 type Scenario struct {
-	Id          string          `json:"id"`
-	Name        string          `json:"name"`
-	StringInput string          `json:"stringOption"`
-	Description string          `json:"description"`
-	Handler     ScenarioHandler `json:"-"`
+	Id             string                 `json:"id"`
+	Name           string                 `json:"name"`
+	StringInput    string                 `json:"stringOption"`
+	Description    string                 `json:"description"`
+	Handler        ScenarioHandler        `json:"-"`
+	ContextHandler ScenarioContextHandler `json:"-"`
 }
 
 var ScenarioRegistry map[string]*Scenario
@@ -126,6 +132,13 @@ func init() {
 		Handler: getPredictableCSVWave,
 	})
 
+	registerScenario(&Scenario{
+		Id:          "simulation",
+		Name:        "Simulation",
+		Handler:     getSimulation,
+		Description: SimulationDesc,
+	})
+
 	registerScenario(&Scenario{
 		Id:      "random_walk_table",
 		Name:    "Random Walk Table",
@@ -403,6 +416,8 @@ func init() {
 			return queryRes
 		},
 	})
+
+	registerChaosScenarios()
 }
 
 // PredictablePulseDesc is the description for the Predictable Pulse scenerio.
@@ -592,9 +607,12 @@ func getRandomWalk(query *tsdb.Query, tsdbQuery *tsdb.TsdbQuery, index int) *tsd
  * '{job="foo", instance="bar"} => {job: "foo", instance: "bar"}`
  */
 func parseLabels(query *tsdb.Query) map[string]string {
+	return parseLabelText(query.Model.Get("labels").MustString(""))
+}
+
+func parseLabelText(labelText string) map[string]string {
 	tags := map[string]string{}
 
-	labelText := query.Model.Get("labels").MustString("")
 	if labelText == "" {
 		return map[string]string{}
 	}
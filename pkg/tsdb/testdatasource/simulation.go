@@ -0,0 +1,255 @@
+package testdatasource
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// SimulationDesc is the description for the Simulation scenario.
+const SimulationDesc = `Simulation returns series following a scripted signal: a baseline plus steps, ramps, sines and spikes.
+There is a datapoint every timeStep seconds, aligned on absolute time like Predictable Pulse.
+With absolute signal times, the value at a given time only depends on the script and on the seed of the noise, so any time range returns the same values.
+Signal times are epoch milliseconds, RFC3339 timestamps, or relative to the end of the time range such as "now-5m". Relative times move with the time range, so the values then depend on it.`
+
+const (
+	signalStep  = "step"
+	signalRamp  = "ramp"
+	signalSine  = "sine"
+	signalSpike = "spike"
+)
+
+// simulationSignal is a scripted change of the value of a simulated series.
+// Times and durations are in milliseconds.
+type simulationSignal struct {
+	signalType string
+	at         int64
+	duration   int64
+	period     int64
+	value      float64
+}
+
+// valueAt returns the contribution of the signal to the value at time t.
+func (s simulationSignal) valueAt(t int64) float64 {
+	switch s.signalType {
+	case signalStep:
+		if t >= s.at {
+			return s.value
+		}
+	case signalRamp:
+		if t >= s.at+s.duration {
+			return s.value
+		}
+		if t >= s.at {
+			return s.value * float64(t-s.at) / float64(s.duration)
+		}
+	case signalSine:
+		if t >= s.at {
+			phase := (t - s.at) % s.period // Keep the argument small for precision
+			return s.value * math.Sin(2*math.Pi*float64(phase)/float64(s.period))
+		}
+	case signalSpike:
+		if t >= s.at && t < s.at+s.duration {
+			return s.value
+		}
+	}
+	return 0
+}
+
+// simulationSeries is a simulated series with its labels.
+type simulationSeries struct {
+	alias    string
+	labels   map[string]string
+	baseline float64
+	noise    float64
+	signals  []simulationSignal
+}
+
+// valueAt returns the value of the series at time t, with the noise derived
+// from the seed, the index of the series and the time.
+func (s simulationSeries) valueAt(seed int64, index int, t int64) float64 {
+	value := s.baseline
+	for _, signal := range s.signals {
+		value += signal.valueAt(t)
+	}
+	if s.noise != 0 {
+		value += (deterministicRandom(seed, int64(index), t)*2 - 1) * s.noise
+	}
+	return value
+}
+
+func getSimulation(query *tsdb.Query, context *tsdb.TsdbQuery) *tsdb.QueryResult {
+	queryRes := tsdb.NewQueryResult()
+
+	options := query.Model.Get("simulation")
+	timeStep := options.Get("timeStep").MustInt64(60) * 1000 // Seconds to Milliseconds
+	if timeStep <= 0 {
+		queryRes.Error = fmt.Errorf("timeStep must be a positive number of seconds")
+		return queryRes
+	}
+	seed := options.Get("seed").MustInt64(0)
+
+	seriesOptions := options.Get("series").MustArray()
+	if len(seriesOptions) == 0 {
+		seriesOptions = []interface{}{map[string]interface{}{}}
+	}
+
+	now := context.TimeRange.GetToAsMsEpoch()
+	for index, o := range seriesOptions {
+		series, err := parseSimulationSeries(simplejson.NewFromAny(o), now, timeStep)
+		if err != nil {
+			queryRes.Error = fmt.Errorf("series %d: %v", index, err)
+			return queryRes
+		}
+
+		ts := newSeriesForQuery(query, index)
+		if series.alias != "" {
+			ts.Name = series.alias
+		}
+		ts.Tags = series.labels
+		ts.Points = simulationPoints(context.TimeRange, timeStep, func(t int64) float64 {
+			return series.valueAt(seed, index, t)
+		})
+
+		queryRes.Series = append(queryRes.Series, ts)
+	}
+
+	return queryRes
+}
+
+// simulationPoints returns the points of the time range, aligned on multiples
+// of timeStep since the epoch.
+func simulationPoints(timeRange *tsdb.TimeRange, timeStep int64, getValue func(t int64) float64) tsdb.TimeSeriesPoints {
+	points := make(tsdb.TimeSeriesPoints, 0)
+
+	from := timeRange.GetFromAsMsEpoch()
+	to := timeRange.GetToAsMsEpoch()
+
+	timeCursor := from - (from % timeStep) // Truncate Start
+	if timeCursor < from {
+		timeCursor += timeStep
+	}
+	maxPoints := 10000 // Don't return too many points
+
+	for i := 0; i < maxPoints && timeCursor <= to; i++ {
+		points = append(points, tsdb.NewTimePoint(null.FloatFrom(getValue(timeCursor)), float64(timeCursor)))
+		timeCursor += timeStep
+	}
+	return points
+}
+
+func parseSimulationSeries(options *simplejson.Json, now int64, timeStep int64) (simulationSeries, error) {
+	series := simulationSeries{
+		alias:    options.Get("alias").MustString(),
+		labels:   parseLabelText(options.Get("labels").MustString()),
+		baseline: options.Get("baseline").MustFloat64(0),
+		noise:    options.Get("noise").MustFloat64(0),
+	}
+
+	for i, o := range options.Get("signals").MustArray() {
+		signal, err := parseSimulationSignal(simplejson.NewFromAny(o), now, timeStep)
+		if err != nil {
+			return series, fmt.Errorf("signal %d: %v", i, err)
+		}
+		series.signals = append(series.signals, signal)
+	}
+
+	return series, nil
+}
+
+func parseSimulationSignal(options *simplejson.Json, now int64, timeStep int64) (simulationSignal, error) {
+	signal := simulationSignal{
+		signalType: options.Get("type").MustString(),
+		value:      options.Get("value").MustFloat64(0),
+	}
+
+	var err error
+	if signal.at, err = parseSimulationTime(options.Get("at"), now); err != nil {
+		return signal, fmt.Errorf("failed to parse at value '%v': %v", options.Get("at").Interface(), err)
+	}
+	if signal.duration, err = durationOptionMs(options, "duration", timeStep); err != nil {
+		return signal, err
+	}
+	if signal.period, err = durationOptionMs(options, "period", time.Hour.Milliseconds()); err != nil {
+		return signal, err
+	}
+
+	switch signal.signalType {
+	case signalStep, signalSpike:
+	case signalRamp:
+		if signal.duration <= 0 {
+			return signal, fmt.Errorf("ramp duration must be positive")
+		}
+	case signalSine:
+		if signal.period <= 0 {
+			return signal, fmt.Errorf("sine period must be positive")
+		}
+	default:
+		return signal, fmt.Errorf("unknown signal type '%s'", signal.signalType)
+	}
+
+	return signal, nil
+}
+
+func durationOptionMs(options *simplejson.Json, key string, defaultValue int64) (int64, error) {
+	d, err := durationOption(options, key, time.Duration(defaultValue)*time.Millisecond)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s value '%v' into duration: %v", key, options.Get(key).Interface(), err)
+	}
+	return d.Milliseconds(), nil
+}
+
+// parseSimulationTime parses a time given in epoch milliseconds, as a RFC3339
+// timestamp, or relative to now such as "now-5m". An absent time is the epoch.
+func parseSimulationTime(value *simplejson.Json, now int64) (int64, error) {
+	switch v := value.Interface().(type) {
+	case nil:
+		return 0, nil
+	case json.Number:
+		return v.Int64()
+	case float64:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case string:
+		if strings.HasPrefix(v, "now") {
+			offset := strings.TrimPrefix(v, "now")
+			if offset == "" {
+				return now, nil
+			}
+			d, err := time.ParseDuration(strings.TrimPrefix(offset, "+"))
+			if err != nil {
+				return 0, err
+			}
+			return now + d.Milliseconds(), nil
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return 0, err
+		}
+		return t.UnixNano() / int64(time.Millisecond), nil
+	default:
+		return 0, fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// deterministicRandom returns a number in [0, 1) derived from its arguments
+// with the SplitMix64 mixing function.
+func deterministicRandom(values ...int64) float64 {
+	var h uint64
+	for _, v := range values {
+		h += uint64(v) + 0x9e3779b97f4a7c15
+		h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+		h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+		h ^= h >> 31
+	}
+	return float64(h>>11) / float64(1<<53)
+}
@@ -0,0 +1,152 @@
+package testdatasource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func runSimulation(simulation map[string]interface{}, from, to string, now time.Time) *tsdb.QueryResult {
+	req := &tsdb.TsdbQuery{
+		TimeRange: tsdb.NewFakeTimeRange(from, to, now),
+		Queries: []*tsdb.Query{
+			{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{"simulation": simulation})},
+		},
+	}
+	return ScenarioRegistry["simulation"].Handler(req.Queries[0], req)
+}
+
+func TestSimulationScenario(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	nowMs := now.UnixNano() / int64(time.Millisecond)
+
+	Convey("simulation", t, func() {
+		Convey("Should return a baseline aligned on the time step", func() {
+			result := runSimulation(map[string]interface{}{
+				"timeStep": 60,
+				"series":   []interface{}{map[string]interface{}{"baseline": 5}},
+			}, "10m", "now", now)
+
+			So(result.Error, ShouldBeNil)
+			So(len(result.Series), ShouldEqual, 1)
+			So(result.Series[0].Name, ShouldEqual, "A-series")
+			So(len(result.Series[0].Points), ShouldEqual, 11)
+			for _, point := range result.Series[0].Points {
+				So(int64(point[1].Float64)%60000, ShouldEqual, 0)
+				So(point[0].Float64, ShouldEqual, 5)
+			}
+		})
+
+		Convey("Should apply step, ramp, sine and spike signals", func() {
+			result := runSimulation(map[string]interface{}{
+				"timeStep": 60,
+				"series": []interface{}{
+					map[string]interface{}{
+						"alias":  "scripted",
+						"labels": `{host="a"}`,
+						"signals": []interface{}{
+							map[string]interface{}{"type": "step", "at": "now-5m", "value": 10},
+							map[string]interface{}{"type": "ramp", "at": "now-4m", "duration": "2m", "value": 4},
+							map[string]interface{}{"type": "spike", "at": "now-1m", "duration": "1m", "value": 100},
+						},
+					},
+					map[string]interface{}{
+						"labels": `{host="b"}`,
+						"signals": []interface{}{
+							map[string]interface{}{"type": "sine", "at": 0, "period": "4m", "value": 2},
+						},
+					},
+				},
+			}, "10m", "now", now)
+
+			So(result.Error, ShouldBeNil)
+			So(len(result.Series), ShouldEqual, 2)
+
+			scripted := result.Series[0]
+			So(scripted.Name, ShouldEqual, "scripted")
+			So(scripted.Tags, ShouldResemble, map[string]string{"host": "a"})
+
+			values := map[int64]float64{}
+			for _, point := range scripted.Points {
+				values[int64(point[1].Float64)] = point[0].Float64
+			}
+			minute := int64(60000)
+			So(values[nowMs-6*minute], ShouldEqual, 0)
+			So(values[nowMs-5*minute], ShouldEqual, 10)
+			So(values[nowMs-3*minute], ShouldEqual, 12)
+			So(values[nowMs-1*minute], ShouldEqual, 114)
+			So(values[nowMs], ShouldEqual, 14)
+
+			sine := result.Series[1]
+			So(sine.Name, ShouldEqual, "A-series1")
+			// the time range starts two minutes into a period of the sine
+			So(sine.Points[0][0].Float64, ShouldAlmostEqual, 0, 1e-9)
+			So(sine.Points[1][0].Float64, ShouldAlmostEqual, -2, 1e-9)
+			So(sine.Points[3][0].Float64, ShouldAlmostEqual, 2, 1e-9)
+		})
+
+		Convey("Should not apply a sine before its start", func() {
+			result := runSimulation(map[string]interface{}{
+				"timeStep": 60,
+				"series": []interface{}{
+					map[string]interface{}{
+						"signals": []interface{}{
+							map[string]interface{}{"type": "sine", "at": "now-2m", "period": "4m", "value": 2},
+						},
+					},
+				},
+			}, "10m", "now", now)
+
+			So(result.Error, ShouldBeNil)
+			values := map[int64]float64{}
+			for _, point := range result.Series[0].Points {
+				values[int64(point[1].Float64)] = point[0].Float64
+			}
+			minute := int64(60000)
+			So(values[nowMs-5*minute], ShouldEqual, 0)
+			So(values[nowMs-3*minute], ShouldEqual, 0)
+			So(values[nowMs-1*minute], ShouldAlmostEqual, 2, 1e-9)
+		})
+
+		Convey("Should return the same values for any time range and seed", func() {
+			simulation := map[string]interface{}{
+				"seed":   42,
+				"series": []interface{}{map[string]interface{}{"baseline": 50, "noise": 10}},
+			}
+
+			wide := runSimulation(simulation, "1h", "now", now)
+			narrow := runSimulation(simulation, "20m", "now-10m", now)
+			again := runSimulation(simulation, "1h", "now", now)
+
+			So(again.Series[0].Points, ShouldResemble, wide.Series[0].Points)
+
+			values := map[float64]float64{}
+			for _, point := range wide.Series[0].Points {
+				values[point[1].Float64] = point[0].Float64
+				So(point[0].Float64, ShouldBeBetween, 40, 60)
+			}
+			So(len(narrow.Series[0].Points), ShouldEqual, 11)
+			for _, point := range narrow.Series[0].Points {
+				So(point[0].Float64, ShouldEqual, values[point[1].Float64])
+			}
+
+			simulation["seed"] = 43
+			other := runSimulation(simulation, "1h", "now", now)
+			So(other.Series[0].Points, ShouldNotResemble, wide.Series[0].Points)
+		})
+
+		Convey("Should fail for unknown signals", func() {
+			result := runSimulation(map[string]interface{}{
+				"series": []interface{}{
+					map[string]interface{}{"signals": []interface{}{map[string]interface{}{"type": "square"}}},
+				},
+			}, "10m", "now", now)
+
+			So(result.Error, ShouldNotBeNil)
+			So(result.Error.Error(), ShouldEqual, "series 0: signal 0: unknown signal type 'square'")
+		})
+	})
+}
@@ -31,7 +31,11 @@ func (e *TestDataExecutor) Query(ctx context.Context, dsInfo *models.DataSource,
 	for _, query := range tsdbQuery.Queries {
 		scenarioId := query.Model.Get("scenarioId").MustString("random_walk")
 		if scenario, exist := ScenarioRegistry[scenarioId]; exist {
-			result.Results[query.RefId] = scenario.Handler(query, tsdbQuery)
+			if scenario.ContextHandler != nil {
+				result.Results[query.RefId] = scenario.ContextHandler(ctx, query, tsdbQuery)
+			} else {
+				result.Results[query.RefId] = scenario.Handler(query, tsdbQuery)
+			}
 			result.Results[query.RefId].RefId = query.RefId
 		} else {
 			e.log.Error("Scenario not found", "scenarioId", scenarioId)
@@ -40,3 +44,8 @@ func (e *TestDataExecutor) Query(ctx context.Context, dsInfo *models.DataSource,
 
 	return result, nil
 }
+
+// CheckHealth always succeeds, as test data is generated by Grafana itself
+func (e *TestDataExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) *tsdb.HealthCheckResult {
+	return tsdb.HealthCheckOK("Data source is working")
+}
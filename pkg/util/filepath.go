@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 //ErrWalkSkipDir is the Error returned when we want to skip descending into a directory
@@ -129,3 +130,44 @@ func containsDistFolder(subFiles []subFile) bool {
 
 	return false
 }
+
+//ErrPathNotAllowed is returned by ResolvePathInDirs when a path is not located in any of the allowed directories
+var ErrPathNotAllowed = errors.New("path is not located in any of the allowed directories")
+
+//ResolvePathInDirs returns the absolute path of an existing file after resolving
+//symbolic links, provided that it is located inside one of dirs. Relative
+//paths and symbolic links are resolved before the check, so neither can be
+//used to escape the allowed directories.
+func ResolvePathInDirs(path string, dirs []string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, dir := range dirs {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			continue
+		}
+
+		if rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return path, nil
+		}
+	}
+
+	return "", ErrPathNotAllowed
+}
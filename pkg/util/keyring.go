@@ -0,0 +1,179 @@
+package util
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LegacyKeyID is the ID of the key of payloads encrypted without a key ID,
+// as done by Encrypt.
+const LegacyKeyID = "legacy"
+
+const (
+	keyIDPrefix         = '#'
+	keyIDSuffix         = '#'
+	envelopeKeyIDSuffix = '$'
+	dataKeyLength       = 32
+)
+
+// Keyring encrypts payloads with its current key, and decrypts payloads
+// encrypted with any of its keys. The ID of the key is embedded at the start
+// of the payload as "#<id>#", except for the legacy key which keeps the
+// format of Encrypt.
+//
+// With envelope encryption, the payload is encrypted with a random data
+// encryption key using AES-GCM, and the data encryption key is itself
+// encrypted with the key of the keyring, as "#<id>$<data key length><data key><payload>".
+type Keyring struct {
+	CurrentKeyID string
+	Keys         map[string]string
+	Envelope     bool
+}
+
+// ValidateKeyID returns an error if id can't be embedded in a payload
+func ValidateKeyID(id string) error {
+	if id == "" || strings.ContainsAny(id, "#$") {
+		return fmt.Errorf("invalid encryption key id %q, should not be empty nor contain # or $", id)
+	}
+	return nil
+}
+
+// Encrypt encrypts a payload with the current key of the keyring.
+func (k *Keyring) Encrypt(payload []byte) ([]byte, error) {
+	secret, ok := k.Keys[k.CurrentKeyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key id %q", k.CurrentKeyID)
+	}
+
+	if k.Envelope {
+		return envelopeEncrypt(payload, k.CurrentKeyID, secret)
+	}
+
+	encrypted, err := Encrypt(payload, secret)
+	if err != nil || k.CurrentKeyID == LegacyKeyID {
+		return encrypted, err
+	}
+
+	prefix := string(keyIDPrefix) + k.CurrentKeyID + string(keyIDSuffix)
+	return append([]byte(prefix), encrypted...), nil
+}
+
+// Decrypt decrypts a payload encrypted with any of the keys of the keyring.
+func (k *Keyring) Decrypt(payload []byte) ([]byte, error) {
+	keyID, envelope, body, err := splitPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, ok := k.Keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key id %q", keyID)
+	}
+
+	if envelope {
+		return envelopeDecrypt(body, secret)
+	}
+	return Decrypt(body, secret)
+}
+
+// NeedsRotation returns true if the payload is not encrypted the way the
+// keyring encrypts new payloads.
+func (k *Keyring) NeedsRotation(payload []byte) bool {
+	keyID, envelope, _, err := splitPayload(payload)
+	return err != nil || keyID != k.CurrentKeyID || envelope != k.Envelope
+}
+
+// splitPayload returns the key ID of an encrypted payload, whether it uses
+// envelope encryption, and the payload without its key ID.
+func splitPayload(payload []byte) (string, bool, []byte, error) {
+	if len(payload) == 0 || payload[0] != keyIDPrefix {
+		return LegacyKeyID, false, payload, nil
+	}
+
+	end := bytes.IndexAny(payload[1:], string([]byte{keyIDSuffix, envelopeKeyIDSuffix}))
+	if end < 0 {
+		return "", false, nil, errors.New("invalid encrypted payload, key id is not terminated")
+	}
+	end++
+
+	return string(payload[1:end]), payload[end] == envelopeKeyIDSuffix, payload[end+1:], nil
+}
+
+func envelopeEncrypt(payload []byte, keyID, secret string) ([]byte, error) {
+	dataKey := make([]byte, dataKeyLength)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := Encrypt(dataKey, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	buf.WriteByte(keyIDPrefix)
+	buf.WriteString(keyID)
+	buf.WriteByte(envelopeKeyIDSuffix)
+	if err := binary.Write(buf, binary.BigEndian, uint16(len(encryptedKey))); err != nil {
+		return nil, err
+	}
+	buf.Write(encryptedKey)
+	buf.Write(nonce)
+	buf.Write(gcm.Seal(nil, nonce, payload, nil))
+	return buf.Bytes(), nil
+}
+
+func envelopeDecrypt(body []byte, secret string) ([]byte, error) {
+	if len(body) < 2 {
+		return nil, errors.New("payload too short")
+	}
+	keyLength := int(binary.BigEndian.Uint16(body))
+	body = body[2:]
+	if len(body) < keyLength || keyLength < saltLength+aes.BlockSize {
+		return nil, errors.New("payload too short")
+	}
+
+	dataKey, err := Decrypt(body[:keyLength], secret)
+	if err != nil {
+		return nil, err
+	}
+	body = body[keyLength:]
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < gcm.NonceSize() {
+		return nil, errors.New("payload too short")
+	}
+
+	payload, err := gcm.Open(nil, body[:gcm.NonceSize()], body[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt payload, wrong encryption key")
+	}
+	return payload, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
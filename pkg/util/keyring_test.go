@@ -0,0 +1,79 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyring(t *testing.T) {
+	keys := map[string]string{LegacyKeyID: "old secret", "2020-06": "new secret"}
+
+	t.Run("legacy key keeps the format of Encrypt", func(t *testing.T) {
+		keyring := &Keyring{CurrentKeyID: LegacyKeyID, Keys: keys}
+
+		encrypted, err := keyring.Encrypt([]byte("grafana"))
+		require.NoError(t, err)
+		decrypted, err := Decrypt(encrypted, "old secret")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("grafana"), decrypted)
+	})
+
+	t.Run("embeds the key id and decrypts with any key", func(t *testing.T) {
+		keyring := &Keyring{CurrentKeyID: "2020-06", Keys: keys}
+
+		encrypted, err := keyring.Encrypt([]byte("grafana"))
+		require.NoError(t, err)
+		assert.Equal(t, "#2020-06#", string(encrypted[:9]))
+
+		decrypted, err := keyring.Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("grafana"), decrypted)
+
+		legacy, err := Encrypt([]byte("legacy"), "old secret")
+		require.NoError(t, err)
+		decrypted, err = keyring.Decrypt(legacy)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("legacy"), decrypted)
+
+		assert.True(t, keyring.NeedsRotation(legacy))
+		assert.False(t, keyring.NeedsRotation(encrypted))
+	})
+
+	t.Run("envelope encryption", func(t *testing.T) {
+		keyring := &Keyring{CurrentKeyID: "2020-06", Keys: keys, Envelope: true}
+
+		encrypted, err := keyring.Encrypt([]byte("grafana"))
+		require.NoError(t, err)
+		assert.Equal(t, "#2020-06$", string(encrypted[:9]))
+
+		decrypted, err := keyring.Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("grafana"), decrypted)
+		assert.False(t, keyring.NeedsRotation(encrypted))
+		assert.True(t, (&Keyring{CurrentKeyID: "2020-06", Keys: keys}).NeedsRotation(encrypted))
+
+		wrongKey := &Keyring{CurrentKeyID: "2020-06", Keys: map[string]string{"2020-06": "wrong secret"}}
+		_, err = wrongKey.Decrypt(encrypted)
+		assert.Error(t, err)
+	})
+
+	t.Run("fails for unknown key ids", func(t *testing.T) {
+		keyring := &Keyring{CurrentKeyID: "2020-06", Keys: map[string]string{"2020-06": "new secret"}}
+
+		_, err := keyring.Decrypt([]byte("#2019-01#payload"))
+		assert.EqualError(t, err, `unknown encryption key id "2019-01"`)
+		_, err = keyring.Decrypt([]byte("#2019-01"))
+		assert.Error(t, err)
+		_, err = (&Keyring{CurrentKeyID: "2021-01", Keys: keys}).Encrypt([]byte("grafana"))
+		assert.Error(t, err)
+	})
+
+	t.Run("validates key ids", func(t *testing.T) {
+		assert.NoError(t, ValidateKeyID("2020-06"))
+		assert.Error(t, ValidateKeyID(""))
+		assert.Error(t, ValidateKeyID("a#b"))
+		assert.Error(t, ValidateKeyID("a$b"))
+	})
+}
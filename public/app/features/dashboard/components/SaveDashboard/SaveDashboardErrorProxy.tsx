@@ -38,7 +38,17 @@ export const SaveDashboardErrorProxy: React.FC<SaveDashboardErrorProxyProps> = (
           title="Conflict"
           body={
             <div>
-              Someone else has updated this dashboard <br /> <small>Would you still like to save this dashboard?</small>
+              Someone else has updated this dashboard <br />
+              {error.data.conflicts && (
+                <ul>
+                  {error.data.conflicts.map((conflict: any) => (
+                    <li key={conflict.path}>
+                      <small>{conflict.description}</small>
+                    </li>
+                  ))}
+                </ul>
+              )}
+              <small>Would you still like to save this dashboard?</small>
             </div>
           }
           confirmText="Save & Overwrite"
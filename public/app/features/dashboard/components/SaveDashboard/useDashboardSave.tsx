@@ -1,6 +1,7 @@
 import { useEffect } from 'react';
 import useAsyncFn from 'react-use/lib/useAsyncFn';
 import { AppEvents, locationUtil } from '@grafana/data';
+import { getLegacyAngularInjector } from '@grafana/runtime';
 import { useDispatch, useSelector } from 'react-redux';
 import { SaveDashboardOptions } from './types';
 import { CoreEvents, StoreState } from 'app/types';
@@ -32,6 +33,18 @@ export const useDashboardSave = (dashboard: DashboardModel) => {
 
       // important that these happen before location redirect below
       appEvents.emit(CoreEvents.dashboardSaved, dashboard);
+
+      if (state.value.merged) {
+        // the dashboard was merged with changes saved by someone else, reload it to show them
+        appEvents.emit(AppEvents.alertSuccess, ['Dashboard saved', 'Merged with changes saved by someone else']);
+        getLegacyAngularInjector().invoke([
+          '$route',
+          '$rootScope',
+          ($route: any, $rootScope: any) => $rootScope.$evalAsync(() => $route.reload()),
+        ]);
+        return;
+      }
+
       appEvents.emit(AppEvents.alertSuccess, ['Dashboard saved']);
 
       const newUrl = locationUtil.stripBaseFromUrl(state.value.url);